@@ -91,6 +91,9 @@ func BuildCLIGraph(cli *CLI, root *Sous, in io.Reader, out, err io.Writer) *grap
 	g.Add(func(c *CLI) graph.ErrOut {
 		return graph.ErrOut{Output: c.Err}
 	})
+	g.Add(func(s *Sous) graph.Version {
+		return graph.Version{Version: s.Version}
+	})
 	cli.SousGraph = &graph.SousGraph{Psyringe: g} //Ugh, weird state.
 
 	return cli.SousGraph
@@ -26,6 +26,9 @@ const sousBuildHelp = `build your project
 build builds the project in your current directory by default. If you pass it a
 path, it will instead build the project at that path.
 
+Projects built from a Dockerfile may also build flavored artifacts, each from
+its own Dockerfile.<flavor>; list the flavors to build with -flavors.
+
 args: [path]
 `
 
@@ -33,6 +36,7 @@ args: [path]
 func (sb *SousBuild) AddFlags(fs *flag.FlagSet) {
 	MustAddFlags(fs, &sb.DeployFilterFlags, SourceFlagsHelp)
 	fs.BoolVar(&sb.PolicyFlags.Strict, "strict", false, "require that the build be pristine")
+	fs.StringVar(&sb.PolicyFlags.Flavors, "flavors", "", "comma-separated flavors to also build, each from Dockerfile.<flavor>")
 	//fs.BoolVar(&sb.PolicyFlags.ForceClone, "force-clone", false, "force a shallow clone of the codebase before build")
 	// above is commented prior to impl.
 }
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/ext/singularity"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousPlumbingDelete is the `sous plumbing delete` command.
+type SousPlumbingDelete struct {
+	DeployFilterFlags config.DeployFilterFlags
+	State             *sous.State
+	GDM               graph.CurrentGDM
+	Client            sous.RectificationClient
+
+	flags struct {
+		confirm bool
+		dryrun  string
+		format  cmdr.OutputFormat
+	}
+}
+
+// deletedRequestRecord is a request deleted by `sous plumbing delete`, as
+// written with -format. Fields may be added, but never renamed or removed.
+type deletedRequestRecord struct {
+	Cluster   string `json:"cluster" yaml:"cluster"`
+	URL       string `json:"url" yaml:"url"`
+	RequestID string `json:"requestID" yaml:"requestID"`
+}
+
+func init() { PlumbingSubcommands["delete"] = &SousPlumbingDelete{} }
+
+const sousPlumbingDeleteHelp = `deletes the scheduler request of an orphaned deployment
+
+usage: sous plumbing delete -repo <repo> [-offset <offset>] [-flavor <flavor>] -cluster <cluster> -confirm
+
+An orphaned deployment is one which is still running in a cluster, but no
+longer has a manifest. In clusters with EnableOrphanDeletion set, Sous scales
+orphans down to zero instances, and deletes them once the cluster's
+OrphanGracePeriod has passed. This command deletes an orphan straight away.
+
+Deployments which still have a manifest, and deployments in clusters without
+EnableOrphanDeletion set, are never deleted. Without -confirm, the request
+that would be deleted is reported, but nothing is changed.
+`
+
+// Help implements Command on SousPlumbingDelete.
+func (*SousPlumbingDelete) Help() string { return sousPlumbingDeleteHelp }
+
+// AddFlags implements cmdr.AddFlags on SousPlumbingDelete.
+func (spd *SousPlumbingDelete) AddFlags(fs *flag.FlagSet) {
+	MustAddFlags(fs, &spd.DeployFilterFlags, repoFlagHelp+offsetFlagHelp+flavorFlagHelp+clusterFlagHelp)
+	fs.BoolVar(&spd.flags.confirm, "confirm", false, "actually delete the request")
+	fs.StringVar(&spd.flags.dryrun, "dry-run", "none",
+		"prevent delete from actually changing things - "+
+			"values are none,scheduler,registry,both")
+	fs.Var(&spd.flags.format, "format", cmdr.OutputFormatHelp)
+}
+
+// RegisterOn implements Registrant on SousPlumbingDelete.
+func (spd *SousPlumbingDelete) RegisterOn(psy Addable) {
+	psy.Add(&spd.DeployFilterFlags)
+	psy.Add(graph.DryrunOption(spd.flags.dryrun))
+}
+
+// Execute implements cmdr.Executor on SousPlumbingDelete.
+func (spd *SousPlumbingDelete) Execute(args []string) cmdr.Result {
+	ff := spd.DeployFilterFlags
+	if ff.Repo == "" {
+		return cmdr.UsageErrorf("-repo is required")
+	}
+	if ff.Cluster == "" {
+		return cmdr.UsageErrorf("-cluster is required")
+	}
+	cluster, ok := spd.State.Defs.Clusters[ff.Cluster]
+	if !ok {
+		return cmdr.UsageErrorf("cluster %q not defined", ff.Cluster)
+	}
+	if !cluster.EnableOrphanDeletion {
+		return cmdr.UsageErrorf("orphan deletion is not enabled in cluster %q", ff.Cluster)
+	}
+
+	did := sous.DeploymentID{
+		ManifestID: sous.ManifestID{
+			Source: sous.SourceLocation{Repo: ff.Repo, Dir: ff.Offset},
+			Flavor: ff.Flavor,
+		},
+		Cluster: ff.Cluster,
+	}
+	if _, ok := spd.GDM.Get(did); ok {
+		return cmdr.UsageErrorf("%v still has a manifest; remove it from the manifest before deleting", did)
+	}
+
+	reqID, err := singularity.MakeRequestID(did)
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+	if !spd.flags.confirm {
+		return cmdr.UsageErrorf("would delete request %q at %s; re-run with -confirm to delete it", reqID, cluster.BaseURL)
+	}
+	if err := spd.Client.DeleteRequest(cluster.BaseURL, reqID, "deleting request for removed manifest with `sous plumbing delete`"); err != nil {
+		return EnsureErrorResult(err)
+	}
+	if spd.flags.format.Kind != "" {
+		return cmdr.SuccessFormatted(spd.flags.format, deletedRequestRecord{
+			Cluster:   ff.Cluster,
+			URL:       cluster.BaseURL,
+			RequestID: reqID,
+		})
+	}
+	return cmdr.Successf("deleted request %q at %s", reqID, cluster.BaseURL)
+}
+
+// TableHeaders implements cmdr.Table on deletedRequestRecord.
+func (r deletedRequestRecord) TableHeaders() []string {
+	return []string{"Cluster", "URL", "RequestID"}
+}
+
+// TableRows implements cmdr.Table on deletedRequestRecord.
+func (r deletedRequestRecord) TableRows() [][]string {
+	return [][]string{{r.Cluster, r.URL, r.RequestID}}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/cmdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSousPlumbingDelete(t *testing.T, format string) (*SousPlumbingDelete, *sous.DummyRectificationClient) {
+	state := makeTestState()
+	state.Defs.Clusters["cluster-1"].EnableOrphanDeletion = true
+	deps, err := state.Deployments()
+	require.NoError(t, err)
+	client := sous.NewDummyRectificationClient()
+	spd := &SousPlumbingDelete{
+		State:  state,
+		GDM:    graph.CurrentGDM{Deployments: deps},
+		Client: client,
+	}
+	spd.DeployFilterFlags.Repo = "github.com/user/orphan"
+	spd.DeployFilterFlags.Cluster = "cluster-1"
+	spd.flags.confirm = true
+	require.NoError(t, spd.flags.format.Set(format))
+	return spd, client
+}
+
+func TestSousPlumbingDelete(t *testing.T) {
+	spd, client := newTestSousPlumbingDelete(t, "")
+	res := spd.Execute(nil)
+	assert.Equal(t, 0, res.ExitCode())
+	assert.Regexp(t, `deleted request ".*orphan.*" at http://nothing.here.one`, fmt.Sprint(res))
+	assert.Len(t, client.Deleted, 1)
+}
+
+func TestSousPlumbingDelete_Format(t *testing.T) {
+	spd, _ := newTestSousPlumbingDelete(t, "json")
+	res := spd.Execute(nil)
+	require.Equal(t, 0, res.ExitCode(), "%v", res)
+
+	var r deletedRequestRecord
+	require.NoError(t, json.Unmarshal(res.(cmdr.SuccessResult).Data, &r))
+	assert.Equal(t, "cluster-1", r.Cluster)
+	assert.Equal(t, "http://nothing.here.one", r.URL)
+	assert.Contains(t, r.RequestID, "orphan")
+
+	spd, _ = newTestSousPlumbingDelete(t, "{{.Cluster}} {{.URL}}")
+	res = spd.Execute(nil)
+	assert.Equal(t, "cluster-1 http://nothing.here.one\n", fmt.Sprint(res))
+}
+
+func TestSousPlumbingDelete_NotEnabled(t *testing.T) {
+	spd, client := newTestSousPlumbingDelete(t, "json")
+	spd.DeployFilterFlags.Cluster = "cluster-2"
+	res := spd.Execute(nil)
+	assert.NotEqual(t, 0, res.ExitCode())
+	assert.Empty(t, client.Deleted)
+}
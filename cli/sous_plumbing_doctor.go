@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousPlumbingDoctor is the `sous plumbing doctor` command.
+type SousPlumbingDoctor struct {
+	ServerDoctor *sous.ServerDoctor
+	graph.OutWriter
+	flags struct {
+		format cmdr.OutputFormat
+	}
+}
+
+func init() { PlumbingSubcommands["doctor"] = &SousPlumbingDoctor{} }
+
+const sousPlumbingDoctorHelp = `checks the health of every Sous server
+
+usage: sous plumbing doctor
+
+Lists the servers known to the configured server, and reports whether each is
+healthy and ready, along with its version, GDM revision, when it last resolved
+its cluster, and any problems found. Exits non-zero if any server is unhealthy
+or not ready.
+`
+
+// Help implements cmdr.Command on SousPlumbingDoctor.
+func (*SousPlumbingDoctor) Help() string { return sousPlumbingDoctorHelp }
+
+// AddFlags implements cmdr.AddsFlags on SousPlumbingDoctor.
+func (spd *SousPlumbingDoctor) AddFlags(fs *flag.FlagSet) {
+	fs.Var(&spd.flags.format, "format", cmdr.OutputFormatHelp)
+}
+
+// Execute implements cmdr.Executor on SousPlumbingDoctor.
+func (spd *SousPlumbingDoctor) Execute(args []string) cmdr.Result {
+	if spd.ServerDoctor == nil {
+		return cmdr.UsageErrorf("Please configure a server using 'sous config Server <url>'")
+	}
+	rs, err := spd.ServerDoctor.Examine()
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+	out := cmdr.NewOutput(spd.OutWriter)
+	if err := out.WriteFormatted(spd.flags.format, rs); err != nil {
+		return cmdr.UsageErrorf("unable to write checkup: %s", err)
+	}
+	if !rs.Healthy() {
+		return cmdr.UnknownErrorf("some servers are unhealthy or not ready")
+	}
+	return cmdr.Success()
+}
@@ -0,0 +1,37 @@
+package cli
+
+import "github.com/opentable/sous/util/cmdr"
+
+// SousPlumbingNamecache is the `sous plumbing namecache` command.
+type SousPlumbingNamecache struct{}
+
+// NamecacheSubcommands collects the subcommands of `sous plumbing namecache`.
+var NamecacheSubcommands = cmdr.Commands{}
+
+func init() { PlumbingSubcommands["namecache"] = &SousPlumbingNamecache{} }
+
+const sousPlumbingNamecacheHelp = `moves the name cache between servers
+
+usage: sous plumbing namecache export > cache.json
+       sous plumbing namecache import < cache.json
+
+The name cache maps Docker image names to the source IDs they were built
+from. Images inserted directly (e.g. by sous build, or via /artifact) can't be
+rediscovered from the registry, so when moving a server, export its cache and
+import it on the new server.
+`
+
+// Subcommands implements cmdr.Subcommander on SousPlumbingNamecache.
+func (SousPlumbingNamecache) Subcommands() cmdr.Commands {
+	return NamecacheSubcommands
+}
+
+// Help implements cmdr.Command on SousPlumbingNamecache.
+func (*SousPlumbingNamecache) Help() string { return sousPlumbingNamecacheHelp }
+
+// Execute implements cmdr.Executor on SousPlumbingNamecache.
+func (*SousPlumbingNamecache) Execute(args []string) cmdr.Result {
+	err := cmdr.UsageErrorf("usage: sous plumbing namecache <export|import>")
+	err.Tip = "try `sous plumbing namecache help` for details"
+	return err
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousPlumbingNamecacheExport is the `sous plumbing namecache export` command.
+type SousPlumbingNamecacheExport struct {
+	NameCache *docker.NameCache
+	graph.OutWriter
+	flags struct {
+		format cmdr.OutputFormat
+	}
+}
+
+func init() { NamecacheSubcommands["export"] = &SousPlumbingNamecacheExport{} }
+
+const sousPlumbingNamecacheExportHelp = `writes the local name cache as JSON
+
+usage: sous plumbing namecache export > cache.json
+
+Only JSON can be imported again, but -format can write the cache in other
+formats for inspection.
+`
+
+// Help implements cmdr.Command on SousPlumbingNamecacheExport.
+func (*SousPlumbingNamecacheExport) Help() string { return sousPlumbingNamecacheExportHelp }
+
+// AddFlags implements cmdr.AddsFlags on SousPlumbingNamecacheExport.
+func (spe *SousPlumbingNamecacheExport) AddFlags(fs *flag.FlagSet) {
+	fs.Var(&spe.flags.format, "format", cmdr.OutputFormatHelp+" (default json)")
+}
+
+// Execute implements cmdr.Executor on SousPlumbingNamecacheExport.
+func (spe *SousPlumbingNamecacheExport) Execute(args []string) cmdr.Result {
+	ex, err := spe.NameCache.Export()
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+	format := spe.flags.format
+	if format.Kind == "" {
+		format.Kind = cmdr.FormatJSON
+	}
+	out := cmdr.NewOutput(spe.OutWriter)
+	if err := out.WriteFormatted(format, ex); err != nil {
+		return cmdr.UsageErrorf("unable to write name cache: %s", err)
+	}
+	return cmdr.Success()
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/util/cmdr"
+	"github.com/pkg/errors"
+)
+
+// SousPlumbingNamecacheImport is the `sous plumbing namecache import` command.
+type SousPlumbingNamecacheImport struct {
+	NameCache *docker.NameCache
+	graph.InReader
+}
+
+func init() { NamecacheSubcommands["import"] = &SousPlumbingNamecacheImport{} }
+
+const sousPlumbingNamecacheImportHelp = `adds a name cache exported as JSON to the local name cache
+
+usage: sous plumbing namecache import < cache.json
+`
+
+// Help implements cmdr.Command on SousPlumbingNamecacheImport.
+func (*SousPlumbingNamecacheImport) Help() string { return sousPlumbingNamecacheImportHelp }
+
+// Execute implements cmdr.Executor on SousPlumbingNamecacheImport.
+func (spi *SousPlumbingNamecacheImport) Execute(args []string) cmdr.Result {
+	ex := &docker.NameCacheExport{}
+	if err := json.NewDecoder(spi.InReader).Decode(ex); err != nil {
+		return EnsureErrorResult(errors.Wrap(err, "reading name cache export"))
+	}
+	if err := spi.NameCache.Import(ex); err != nil {
+		return EnsureErrorResult(err)
+	}
+	return cmdr.Successf("imported %d images", len(ex.Images))
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/graph"
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/cmdr"
 )
@@ -14,18 +15,38 @@ import (
 type SousPlumbingStatus struct {
 	DeployFilterFlags config.DeployFilterFlags
 	StatusPoller      *sous.StatusPoller
+	graph.OutWriter
+
+	flags struct {
+		format cmdr.OutputFormat
+	}
+}
+
+// resolveStateRecord is the outcome of `sous plumbing status`, as written
+// with -format. Fields may be added, but never renamed or removed.
+type resolveStateRecord struct {
+	Repo    string `json:"repo" yaml:"repo"`
+	Offset  string `json:"offset" yaml:"offset"`
+	Flavor  string `json:"flavor" yaml:"flavor"`
+	Cluster string `json:"cluster" yaml:"cluster"`
+	Tag     string `json:"tag" yaml:"tag"`
+	State   string `json:"state" yaml:"state"`
 }
 
 func init() { PlumbingSubcommands["status"] = &SousPlumbingStatus{} }
 
 // Help implements Command on SousPlumbingStatus.
 func (*SousPlumbingStatus) Help() string {
-	return `reports the status of a given deployment`
+	return `reports the status of a given deployment
+
+With -format, the state the deployment reached is written out, whether or not
+it was resolved successfully.`
 }
 
 // AddFlags implements cmdr.AddFlags on SousPlumbingStatus.
 func (sps *SousPlumbingStatus) AddFlags(fs *flag.FlagSet) {
 	MustAddFlags(fs, &sps.DeployFilterFlags, DeployFilterFlagsHelp)
+	fs.Var(&sps.flags.format, "format", cmdr.OutputFormatHelp)
 }
 
 // RegisterOn implements Registrant on SousPlumbingStatus.
@@ -46,9 +67,33 @@ func (sps *SousPlumbingStatus) Execute(args []string) cmdr.Result {
 	if err != nil {
 		return cmdr.EnsureErrorResult(err)
 	}
+	if sps.flags.format.Kind != "" {
+		ff := sps.DeployFilterFlags
+		r := resolveStateRecord{
+			Repo:    ff.Repo,
+			Offset:  ff.Offset,
+			Flavor:  ff.Flavor,
+			Cluster: ff.Cluster,
+			Tag:     ff.Tag,
+			State:   state.String(),
+		}
+		if err := cmdr.NewOutput(sps.OutWriter).WriteFormatted(sps.flags.format, r); err != nil {
+			return cmdr.UsageErrorf("unable to write status: %s", err)
+		}
+	}
 	if state != sous.ResolveComplete {
 		return cmdr.UsageErrorf("failed (state is %s)", state)
 	}
 
 	return cmdr.Success()
 }
+
+// TableHeaders implements cmdr.Table on resolveStateRecord.
+func (r resolveStateRecord) TableHeaders() []string {
+	return []string{"Repo", "Offset", "Flavor", "Cluster", "Tag", "State"}
+}
+
+// TableRows implements cmdr.Table on resolveStateRecord.
+func (r resolveStateRecord) TableRows() [][]string {
+	return [][]string{{r.Repo, r.Offset, r.Flavor, r.Cluster, r.Tag, r.State}}
+}
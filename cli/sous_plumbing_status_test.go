@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSousPlumbingStatus_Format(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		case "/servers":
+			rw.Write([]byte(`{"servers": []}`))
+		case "/gdm":
+			rw.Write([]byte(`{"deployments": []}`))
+		}
+	}))
+	defer srv.Close()
+	cl, err := restful.NewClient(srv.URL, sous.SilentLogSet())
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	sps := &SousPlumbingStatus{
+		StatusPoller: sous.NewStatusPoller(cl, &sous.ResolveFilter{Repo: "github.com/user/project"}, sous.User{}),
+		OutWriter:    out,
+	}
+	sps.DeployFilterFlags.Repo = "github.com/user/project"
+	sps.DeployFilterFlags.Cluster = "cluster-1"
+	require.NoError(t, sps.flags.format.Set("table=repo,state"))
+
+	res := sps.Execute(nil)
+	assert.NotEqual(t, 0, res.ExitCode(), "a deployment that isn't intended has not been resolved")
+	assert.Equal(t, "Repo                     State\ngithub.com/user/project  ResolveNotIntended\n", out.String())
+}
@@ -1,7 +1,7 @@
 package cli
 
 import (
-	"os"
+	"flag"
 
 	"github.com/opentable/sous/graph"
 	"github.com/opentable/sous/lib"
@@ -19,6 +19,7 @@ type SousQueryAds struct {
 	flags struct {
 		singularity string
 		registry    string
+		format      cmdr.OutputFormat
 	}
 }
 
@@ -29,6 +30,11 @@ const sousQueryAdsHelp = `The current state of deployment for every project and
 // Help prints the help
 func (*SousQueryAds) Help() string { return sousQueryAdsHelp }
 
+// AddFlags adds the flags for sous query ads.
+func (sb *SousQueryAds) AddFlags(fs *flag.FlagSet) {
+	fs.Var(&sb.flags.format, "format", cmdr.OutputFormatHelp)
+}
+
 // RegisterOn adds stuff to the graph.
 func (*SousQueryAds) RegisterOn(psy Addable) {
 	psy.Add(graph.DryrunNeither)
@@ -40,6 +46,5 @@ func (sb *SousQueryAds) Execute(args []string) cmdr.Result {
 	if err != nil {
 		return EnsureErrorResult(err)
 	}
-	sous.DumpDeployStatuses(os.Stdout, ads)
-	return cmdr.Success()
+	return cmdr.SuccessFormatted(sb.flags.format, ads.Records())
 }
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"flag"
+
 	"github.com/opentable/sous/graph"
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/cmdr"
@@ -9,7 +11,9 @@ import (
 // SousQueryArtifacts is the description of the `sous query gdm` command
 type SousQueryArtifacts struct {
 	*sous.RegistryDumper
-	graph.ErrWriter
+	flags struct {
+		format cmdr.OutputFormat
+	}
 }
 
 func init() { QuerySubcommands["artifacts"] = &SousQueryArtifacts{} }
@@ -20,6 +24,11 @@ Note that Sous may discover more images after attempting a rectify
 
 `
 
+// AddFlags adds the flags for sous query artifacts.
+func (sqa *SousQueryArtifacts) AddFlags(fs *flag.FlagSet) {
+	fs.Var(&sqa.flags.format, "format", cmdr.OutputFormatHelp)
+}
+
 func (*SousQueryArtifacts) RegisterOn(psy Addable) {
 	psy.Add(graph.DryrunNeither)
 }
@@ -29,6 +38,9 @@ func (*SousQueryArtifacts) Help() string { return sousQueryArtifactsHelp }
 
 // Execute defines the behavior of `sous query gdm`
 func (sqa *SousQueryArtifacts) Execute(args []string) cmdr.Result {
-	err := sqa.RegistryDumper.AsTable(sqa.ErrWriter)
-	return ProduceResult(err)
+	rs, err := sqa.RegistryDumper.Records()
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+	return cmdr.SuccessFormatted(sqa.flags.format, rs)
 }
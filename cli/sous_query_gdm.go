@@ -1,7 +1,7 @@
 package cli
 
 import (
-	"os"
+	"flag"
 
 	"github.com/opentable/sous/graph"
 	"github.com/opentable/sous/lib"
@@ -14,6 +14,7 @@ type SousQueryGDM struct {
 	flags struct {
 		singularity string
 		registry    string
+		format      cmdr.OutputFormat
 	}
 }
 
@@ -28,6 +29,11 @@ a problem is preventing sous from modifying the current state of Singularity.
 // Help prints the help
 func (*SousQueryGDM) Help() string { return sousQueryGDMHelp }
 
+// AddFlags adds the flags for sous query gdm.
+func (sb *SousQueryGDM) AddFlags(fs *flag.FlagSet) {
+	fs.Var(&sb.flags.format, "format", cmdr.OutputFormatHelp)
+}
+
 func (*SousQueryGDM) RegisterOn(psy Addable) {
 	psy.Add(graph.DryrunNeither)
 }
@@ -35,6 +41,5 @@ func (*SousQueryGDM) RegisterOn(psy Addable) {
 // Execute defines the behavior of `sous query gdm`
 func (sb *SousQueryGDM) Execute(args []string) cmdr.Result {
 	sous.Log.Vomit.Printf("%v", sb.GDM.Snapshot())
-	sous.DumpDeployments(os.Stdout, sb.GDM.Deployments)
-	return cmdr.Success()
+	return cmdr.SuccessFormatted(sb.flags.format, sb.GDM.Deployments.Records())
 }
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousQueryProvenance is the description of the `sous query provenance` command
+type SousQueryProvenance struct {
+	sous.Registry
+	flags struct {
+		format cmdr.OutputFormat
+	}
+}
+
+func init() { QuerySubcommands["provenance"] = &SousQueryProvenance{} }
+
+const sousQueryProvenanceHelp = `Shows how the artifact for a source ID was built.
+
+usage: sous query provenance <sourceid>
+
+Reports the user, host, Sous version, buildpack, base images and advisories
+recorded when the artifact was built, as well as when the build started and
+finished.
+`
+
+// AddFlags adds the flags for sous query provenance.
+func (sqp *SousQueryProvenance) AddFlags(fs *flag.FlagSet) {
+	fs.Var(&sqp.flags.format, "format", cmdr.OutputFormatHelp)
+}
+
+// RegisterOn adds the dryrun option to the graph, since querying never
+// modifies anything.
+func (*SousQueryProvenance) RegisterOn(psy Addable) {
+	psy.Add(graph.DryrunNeither)
+}
+
+// Help prints the help
+func (*SousQueryProvenance) Help() string { return sousQueryProvenanceHelp }
+
+// Execute defines the behavior of `sous query provenance`
+func (sqp *SousQueryProvenance) Execute(args []string) cmdr.Result {
+	if len(args) != 1 {
+		return cmdr.UsageErrorf("expected exactly one source ID, received %d arguments", len(args))
+	}
+	sid, err := sous.ParseSourceID(args[0])
+	if err != nil {
+		return cmdr.UsageErrorf("%s", err)
+	}
+	art, err := sqp.Registry.GetArtifact(sid)
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+	p, err := art.Provenance()
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+	return cmdr.SuccessFormatted(sqp.flags.format, p)
+}
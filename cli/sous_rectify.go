@@ -76,6 +76,7 @@ func (sr *SousRectify) Execute(args []string) cmdr.Result {
 			"(Or -all if you really mean to rectify the whole world; see 'sous help rectify'.)")
 	}
 
+	sr.Resolver.TrustedKeys = sr.State.Defs.TrustedKeys
 	if err := sr.Resolver.Begin(sr.GDM.Clone(), sr.State.Defs.Clusters).Wait(); err != nil {
 		return EnsureErrorResult(err)
 	}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"flag"
+	"time"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/ext/singularity"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/cmdr"
+)
+
+// SousRun is the command description for `sous run`
+type SousRun struct {
+	DeployFilterFlags config.DeployFilterFlags
+	TargetManifestID  graph.TargetManifestID
+	State             *sous.State
+	Runner            sous.TaskRunner
+	Log               *sous.LogSet
+
+	// pollInterval is how often the task is checked on. Zero means every two
+	// seconds.
+	pollInterval time.Duration
+
+	flags struct {
+		wait    bool
+		timeout time.Duration
+		dryrun  string
+	}
+}
+
+func init() { TopLevelCommands["run"] = &SousRun{} }
+
+const sousRunHelp = `runs an on-demand or run-once deployment
+
+usage: sous run -cluster <name> [-flavor <flavor>] [-wait] [-- args...]
+
+sous run starts a single task of the version of this application currently
+deployed in the named cluster, passing any arguments after -- to its command.
+Only deployments with kind "on-demand" or "once" can be run.
+
+The ID of the task is printed once it has started. With -wait, sous run
+reports the task's state until it completes, and exits with the task's exit
+code, so that it can be used in pipelines, e.g. to run database migrations.
+`
+
+// Help returns the help string for this command
+func (*SousRun) Help() string { return sousRunHelp }
+
+// AddFlags adds the flags for sous run.
+func (sr *SousRun) AddFlags(fs *flag.FlagSet) {
+	MustAddFlags(fs, &sr.DeployFilterFlags, MetadataFilterFlagsHelp)
+	fs.BoolVar(&sr.flags.wait, "wait", false,
+		"wait for the task to complete, and exit with its exit code")
+	fs.DurationVar(&sr.flags.timeout, "timeout", 0,
+		"give up waiting for the task after this long (default: wait forever)")
+	fs.StringVar(&sr.flags.dryrun, "dry-run", "none",
+		"prevent run from actually starting a task - "+
+			"values are none,scheduler,registry,both")
+}
+
+// RegisterOn adds the flags to the graph.
+func (sr *SousRun) RegisterOn(psy Addable) {
+	psy.Add(&sr.DeployFilterFlags)
+	psy.Add(graph.DryrunOption(sr.flags.dryrun))
+}
+
+// Execute fulfills the cmdr.Executor interface.
+func (sr *SousRun) Execute(args []string) cmdr.Result {
+	clusterName := sr.DeployFilterFlags.Cluster
+	if clusterName == "" {
+		return cmdr.UsageErrorf("-cluster is required")
+	}
+	cluster, ok := sr.State.Defs.Clusters[clusterName]
+	if !ok {
+		return cmdr.UsageErrorf("cluster %q not defined", clusterName)
+	}
+	mid := sous.ManifestID(sr.TargetManifestID)
+	m, ok := sr.State.Manifests.Get(mid)
+	if !ok {
+		return cmdr.UsageErrorf("no manifest for %s; see `sous init`", mid)
+	}
+	if m.Kind != sous.ManifestKindOnDemand && m.Kind != sous.ManifestKindOnce {
+		return cmdr.UsageErrorf("%s has kind %q; only %q and %q can be run",
+			mid, m.Kind, sous.ManifestKindOnDemand, sous.ManifestKindOnce)
+	}
+	if _, ok := m.Deployments[clusterName]; !ok {
+		return cmdr.UsageErrorf("%s is not deployed to cluster %q", mid, clusterName)
+	}
+
+	reqID, err := singularity.MakeRequestID(sous.DeploymentID{ManifestID: mid, Cluster: clusterName})
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+	runID, err := sr.Runner.Run(cluster.BaseURL, reqID, args)
+	if err != nil {
+		return EnsureErrorResult(err)
+	}
+
+	var deadline <-chan time.Time
+	if sr.flags.timeout > 0 {
+		deadline = time.After(sr.flags.timeout)
+	}
+	interval := sr.pollInterval
+	if interval == 0 {
+		interval = 2 * time.Second
+	}
+	poll := func(check func() (bool, error)) error {
+		for {
+			done, err := check()
+			if done || err != nil {
+				return err
+			}
+			select {
+			case <-deadline:
+				return cmdr.IOErrorf("timed out after %s waiting for run %q", sr.flags.timeout, runID)
+			case <-time.After(interval):
+			}
+		}
+	}
+
+	var taskID string
+	if err := poll(func() (bool, error) {
+		var err error
+		taskID, err = sr.Runner.RunTask(cluster.BaseURL, reqID, runID)
+		return taskID != "", err
+	}); err != nil {
+		return EnsureErrorResult(err)
+	}
+	if !sr.flags.wait {
+		return cmdr.Success(taskID)
+	}
+	sr.Log.Info.Printf("Started task %s", taskID)
+
+	var status sous.TaskStatus
+	if err := poll(func() (bool, error) {
+		prev := status.State
+		var err error
+		status, err = sr.Runner.TaskStatus(cluster.BaseURL, taskID)
+		if err == nil && status.State != prev && status.State != "" {
+			sr.Log.Info.Printf("Task %s: %s %s", taskID, status.State, status.Message)
+		}
+		return status.Done, err
+	}); err != nil {
+		return EnsureErrorResult(err)
+	}
+	if status.ExitCode != 0 {
+		return cmdr.ExitErrorf(status.ExitCode, "task %s %s: %s", taskID, status.State, status.Message)
+	}
+	return cmdr.Success(taskID)
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTaskRunner struct {
+	args     []string
+	polls    int
+	statuses []sous.TaskStatus
+}
+
+func (f *fakeTaskRunner) Run(cluster, reqID string, args []string) (string, error) {
+	f.args = args
+	return "run-1", nil
+}
+
+func (f *fakeTaskRunner) RunTask(cluster, reqID, runID string) (string, error) {
+	f.polls++
+	if f.polls < 2 {
+		return "", nil
+	}
+	return "task-1", nil
+}
+
+func (f *fakeTaskRunner) TaskStatus(cluster, taskID string) (sous.TaskStatus, error) {
+	s := f.statuses[0]
+	if len(f.statuses) > 1 {
+		f.statuses = f.statuses[1:]
+	}
+	return s, nil
+}
+
+func newTestSousRun(kind sous.ManifestKind, runner sous.TaskRunner) *SousRun {
+	state := makeTestState()
+	m, _ := state.Manifests.Get(sous.ManifestID{Source: project1})
+	m.Kind = kind
+	sr := &SousRun{
+		TargetManifestID: graph.TargetManifestID{Source: project1},
+		State:            state,
+		Runner:           runner,
+		Log:              sous.NewLogSet(os.Stderr, os.Stderr, os.Stderr),
+		pollInterval:     time.Millisecond,
+	}
+	sr.DeployFilterFlags.Cluster = "cluster-1"
+	return sr
+}
+
+func TestSousRun(t *testing.T) {
+	runner := &fakeTaskRunner{statuses: []sous.TaskStatus{{State: "TASK_RUNNING"}, {State: "TASK_FINISHED", Done: true}}}
+	sr := newTestSousRun(sous.ManifestKindOnce, runner)
+	res := sr.Execute([]string{"migrate", "up"})
+	assert.Equal(t, 0, res.ExitCode())
+	assert.Equal(t, []string{"migrate", "up"}, runner.args)
+	assert.Equal(t, 2, runner.polls)
+}
+
+func TestSousRunWaitExitCode(t *testing.T) {
+	runner := &fakeTaskRunner{statuses: []sous.TaskStatus{{State: "TASK_FAILED", Done: true, ExitCode: 3}}}
+	sr := newTestSousRun(sous.ManifestKindOnDemand, runner)
+	sr.flags.wait = true
+	res := sr.Execute(nil)
+	assert.Equal(t, 3, res.ExitCode())
+}
+
+func TestSousRunRefusesServices(t *testing.T) {
+	runner := &fakeTaskRunner{}
+	sr := newTestSousRun(sous.ManifestKindService, runner)
+	res := sr.Execute(nil)
+	assert.Equal(t, 64, res.ExitCode())
+	assert.Equal(t, 0, runner.polls)
+}
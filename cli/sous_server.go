@@ -23,6 +23,9 @@ type SousServer struct {
 	*config.Config
 	*graph.SousGraph
 	*sous.AutoResolver
+	*sous.Peers
+	Autoscaler   *sous.AutoscaleRunner
+	StateManager *graph.StateManager
 
 	flags struct {
 		dryrun,
@@ -77,6 +80,25 @@ func (ss *SousServer) Execute(args []string) cmdr.Result {
 
 	ss.AutoResolver.Kickoff()
 
+	if ss.Autoscaler != nil {
+		ss.Log.Info.Printf("Autoscaling deployments every %s using %s metrics.", ss.Config.Autoscale.Interval(), ss.Config.Autoscale.Provider)
+		ss.Autoscaler.Kickoff(ss.Config.Autoscale.Interval())
+	} else {
+		ss.Log.Info.Println("No Autoscale.Provider configured: this server will not autoscale deployments.")
+	}
+
+	ss.Peers.CheckReadiness(func() error {
+		return server.NotReady(ss.StateManager, ss.AutoResolver)
+	})
+	ss.Peers.Kickoff(sous.Peer{
+		ClusterName: ss.DeployFilterFlags.Cluster,
+		URL:         ss.Config.Peers.URL,
+		Version:     ss.Sous.Version.String(),
+	}, ss.Config.Peers.AnnounceInterval())
+	if ss.Config.Peers.URL == "" {
+		ss.Log.Warn.Println("No Peers.URL configured: this server will not announce itself to the others.")
+	}
+
 	ss.Log.Info.Printf("Sous Server v%s running at %s for %s", ss.Sous.Version, ss.flags.laddr, ss.DeployFilterFlags.Cluster)
 
 	if os.Getenv("SOUS_PROFILING") == "enable" {
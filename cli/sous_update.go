@@ -2,6 +2,7 @@ package cli
 
 import (
 	"flag"
+	"time"
 
 	"github.com/opentable/sous/config"
 	"github.com/opentable/sous/graph"
@@ -20,6 +21,8 @@ type SousUpdate struct {
 	StateManager      *graph.StateManager
 	ResolveFilter     *graph.RefinedResolveFilter
 	User              sous.User
+	State             *sous.State
+	Registry          sous.Registry
 }
 
 func init() { TopLevelCommands["update"] = &SousUpdate{} }
@@ -30,6 +33,10 @@ usage: sous update -cluster <name> [-tag <semver>] [-use-otpl-deploy|-ignore-otp
 
 sous update will update the version tag for this application in the named
 cluster. You can then use 'sous rectify' to have that version deployed.
+
+The advisories on the artifact for that version are checked against the
+advisory policy of the cluster, and the update is refused if the cluster would
+not accept them.
 `
 
 // Help returns the help string for this command
@@ -45,6 +52,7 @@ func (su *SousUpdate) AddFlags(fs *flag.FlagSet) {
 func (su *SousUpdate) RegisterOn(psy Addable) {
 	psy.Add(&su.DeployFilterFlags)
 	psy.Add(&su.OTPLFlags)
+	psy.Add(graph.DryrunNeither)
 }
 
 // Execute fulfills the cmdr.Executor interface.
@@ -61,6 +69,10 @@ func (su *SousUpdate) Execute(args []string) cmdr.Result {
 		return EnsureErrorResult(err)
 	}
 
+	if err := su.checkAdvisories(sid, did); err != nil {
+		return EnsureErrorResult(err)
+	}
+
 	gdm, err := updateRetryLoop(su.StateManager.StateManager, sid, did, su.User)
 	if err != nil {
 		return EnsureErrorResult(err)
@@ -73,6 +85,26 @@ func (su *SousUpdate) Execute(args []string) cmdr.Result {
 	return cmdr.Success("Updated global manifest.")
 }
 
+// checkAdvisories checks the artifact built from sid against the advisory
+// policy of the target cluster, so that users learn about unacceptable
+// advisories before the server rejects the deployment.
+func (su *SousUpdate) checkAdvisories(sid sous.SourceID, did sous.DeploymentID) error {
+	cluster, ok := su.State.Defs.Clusters[did.Cluster]
+	if !ok {
+		return nil
+	}
+	art, err := su.Registry.GetArtifact(sid)
+	if err != nil {
+		sous.Log.Warn.Printf("Unable to check advisories for %s: %v", sid, err)
+		return nil
+	}
+	warnings, err := cluster.CheckAdvisories(did.ManifestID, sid, art, time.Now())
+	for _, w := range warnings {
+		sous.Log.Warn.Printf("%s", w)
+	}
+	return err
+}
+
 // If multiple updates are attempted at once for different clusters, there's
 // the possibility that they will collide in their updates, either interleaving
 // their GDM retreive/manifest update operations, or the git pull/push
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// AuthConfig configures how Sous clients and servers authenticate one
+	// another.
+	AuthConfig struct {
+		// Token is the bearer token this client sends to the server.
+		Token string `env:"SOUS_AUTH_TOKEN" yaml:",omitempty"`
+		// ClientCert and ClientKey are PEM files containing the certificate
+		// and key this client presents to servers which ask for one.
+		ClientCert string `env:"SOUS_AUTH_CLIENT_CERT" yaml:",omitempty"`
+		ClientKey  string `env:"SOUS_AUTH_CLIENT_KEY" yaml:",omitempty"`
+		// CACert is a PEM file containing CA certificates. Clients trust
+		// servers with certificates signed by them, and servers accept client
+		// certificates signed by them.
+		CACert string `env:"SOUS_AUTH_CA_CERT" yaml:",omitempty"`
+		// ServerCert and ServerKey are PEM files containing the certificate
+		// and key the server uses to serve HTTPS. Client certificates are
+		// only accepted over HTTPS.
+		ServerCert string `yaml:",omitempty"`
+		ServerKey  string `yaml:",omitempty"`
+		// Tokens maps the bearer tokens the server accepts to the users they
+		// identify.
+		Tokens map[string]sous.User `yaml:",omitempty"`
+	}
+)
+
+// Validate returns an error if this AuthConfig is incomplete.
+func (a AuthConfig) Validate() error {
+	if (a.ClientCert == "") != (a.ClientKey == "") {
+		return errors.New("Config.Auth.ClientCert and ClientKey must be set together")
+	}
+	if (a.ServerCert == "") != (a.ServerKey == "") {
+		return errors.New("Config.Auth.ServerCert and ServerKey must be set together")
+	}
+	for token, u := range a.Tokens {
+		if token == "" {
+			return errors.New("Config.Auth.Tokens contains an empty token")
+		}
+		if u.Name == "" && u.Email == "" {
+			return errors.Errorf("Config.Auth.Tokens has a token for nobody; set its name or email")
+		}
+	}
+	return nil
+}
+
+// Authenticator returns the restful.Authenticator the server should use to
+// authenticate requests, or nil if it should not authenticate them.
+func (a AuthConfig) Authenticator() restful.Authenticator {
+	auths := restful.Authenticators{}
+	if len(a.Tokens) > 0 {
+		tokens := restful.BearerTokens{}
+		for token, u := range a.Tokens {
+			tokens[token] = restful.Principal{Name: u.Name, Email: u.Email}
+		}
+		auths = append(auths, tokens)
+	}
+	if a.ServerCert != "" && a.CACert != "" {
+		auths = append(auths, restful.ClientCerts{})
+	}
+	if len(auths) == 0 {
+		return nil
+	}
+	return auths
+}
+
+// ServerTLS returns the TLS configuration for the server, or nil if it
+// should serve plain HTTP. Client certificates are verified against CACert
+// if they are given, but not required, so that clients may authenticate with
+// a token instead.
+func (a AuthConfig) ServerTLS() (*tls.Config, error) {
+	if a.ServerCert == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(a.ServerCert, a.ServerKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading server certificate")
+	}
+	tc := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if a.CACert != "" {
+		pool, err := loadCertPool(a.CACert)
+		if err != nil {
+			return nil, err
+		}
+		tc.ClientCAs = pool
+		tc.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tc, nil
+}
+
+// ClientTLS returns the TLS configuration for the client, or nil if the
+// defaults will do.
+func (a AuthConfig) ClientTLS() (*tls.Config, error) {
+	if a.ClientCert == "" && a.CACert == "" {
+		return nil, nil
+	}
+	tc := &tls.Config{}
+	if a.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(a.ClientCert, a.ClientKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading client certificate")
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	if a.CACert != "" {
+		pool, err := loadCertPool(a.CACert)
+		if err != nil {
+			return nil, err
+		}
+		tc.RootCAs = pool
+	}
+	return tc, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading CA certificates")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// AutoscaleConfig configures how a Sous server autoscales the
+	// deployments which declare autoscale bounds.
+	AutoscaleConfig struct {
+		// Provider is where the metrics autoscaling is based on come from.
+		// The only provider is "singularity", which uses the statistics of
+		// each deployment's tasks. If empty, this server does not autoscale.
+		Provider string `env:"SOUS_AUTOSCALE_PROVIDER" yaml:",omitempty"`
+		// IntervalSeconds is how often deployments are autoscaled.
+		IntervalSeconds int `yaml:",omitempty"`
+		// DefaultTargetCPU is the CPU utilization deployments which do not
+		// declare a target are scaled towards.
+		DefaultTargetCPU float64 `yaml:",omitempty"`
+	}
+)
+
+// AutoscaleProviderSingularity is the Provider for metrics from Singularity.
+const AutoscaleProviderSingularity = "singularity"
+
+const (
+	defaultAutoscaleSeconds = 5 * 60
+	defaultTargetCPU        = 0.6
+)
+
+// Validate returns an error if this AutoscaleConfig is invalid.
+func (a AutoscaleConfig) Validate() error {
+	switch a.Provider {
+	default:
+		return errors.Errorf("Config.Autoscale.Provider %q not known; use %q, or leave it empty to disable autoscaling",
+			a.Provider, AutoscaleProviderSingularity)
+	case "", AutoscaleProviderSingularity:
+	}
+	if a.IntervalSeconds < 0 {
+		return errors.New("Config.Autoscale.IntervalSeconds must not be negative")
+	}
+	if a.DefaultTargetCPU < 0 || a.DefaultTargetCPU > 1 {
+		return errors.Errorf("Config.Autoscale.DefaultTargetCPU %g not between 0 and 1", a.DefaultTargetCPU)
+	}
+	return nil
+}
+
+// Enabled returns true if this server should autoscale deployments.
+func (a AutoscaleConfig) Enabled() bool {
+	return a.Provider != ""
+}
+
+// Interval returns how often deployments should be autoscaled.
+func (a AutoscaleConfig) Interval() time.Duration {
+	return secondsOr(a.IntervalSeconds, defaultAutoscaleSeconds)
+}
+
+// TargetCPU returns the CPU utilization deployments without a target of
+// their own should be scaled towards.
+func (a AutoscaleConfig) TargetCPU() float64 {
+	if a.DefaultTargetCPU == 0 {
+		return defaultTargetCPU
+	}
+	return a.DefaultTargetCPU
+}
@@ -1,8 +1,24 @@
 package config
 
+import "strings"
+
 type (
 	// PolicyFlags capture user intent about the processing of a build
 	PolicyFlags struct {
 		ForceClone, Strict bool
+		// Flavors is a comma-separated list of flavors to build alongside the
+		// main artifact.
+		Flavors string
 	}
 )
+
+// FlavorList returns the flavors listed in Flavors.
+func (pf *PolicyFlags) FlavorList() []string {
+	var flavors []string
+	for _, f := range strings.Split(pf.Flavors, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			flavors = append(flavors, f)
+		}
+	}
+	return flavors
+}
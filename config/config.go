@@ -24,10 +24,9 @@ type (
 		// considers the master. If this is not set, this node is considered
 		// to be a master. This value must be in URL format.
 		Server string `env:"SOUS_SERVER"`
-		// SiblingURLs is a temporary measure for setting up a distributed cluster
-		// of sous servers. Each server must be configured with accessible URLs for
-		// all the servers in production, as named by cluster.
-		// (someday this should be replaced with a gossip protocol)
+		// SiblingURLs are the URLs of other Sous servers, as named by cluster.
+		// Servers discover one another (see Peers), so only some of them need
+		// be listed here, for a new server to first announce itself to.
 		SiblingURLs map[string]string
 		// BuildStateDir is a directory where information about builds
 		// performed by this user on this machine are stored.
@@ -36,6 +35,12 @@ type (
 		Docker docker.Config
 		// User identifies the user of this client.
 		User sous.User
+		// Auth configures authentication between clients and the server.
+		Auth AuthConfig
+		// Peers configures how servers discover one another.
+		Peers PeerConfig
+		// Autoscale configures how servers autoscale deployments.
+		Autoscale AutoscaleConfig
 	}
 )
 
@@ -63,7 +68,7 @@ func (c Config) Validate() error {
 			return err
 		}
 	}
-	return nil
+	return firsterr.Returned(c.Auth.Validate, c.Peers.Validate, c.Autoscale.Validate)
 }
 
 // DefaultConfig returns the default configuration.
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/lib"
 )
 
 func TestDefaultStateLocation(t *testing.T) {
@@ -75,6 +76,15 @@ func TestConfig_Validate(t *testing.T) {
 
 	cfg.Server = ""
 	checkValid()
+
+	cfg.Autoscale.Provider = "prometheus"
+	checkNotValid()
+
+	cfg.Autoscale.Provider = AutoscaleProviderSingularity
+	checkValid()
+
+	cfg.Autoscale.DefaultTargetCPU = 2
+	checkNotValid()
 }
 
 func TestConfig_Equals(t *testing.T) {
@@ -165,3 +175,25 @@ func TestEnsureDirExists(t *testing.T) {
 		t.Errorf("got error %q; want %q", actualErr, expectedErr)
 	}
 }
+
+func TestConfigRedacted(t *testing.T) {
+	c := DefaultConfig()
+	c.Auth.Token = "secret"
+	c.Auth.Tokens = map[string]sous.User{"alsosecret": {Name: "Judson"}}
+	c.Docker.DatabaseConnection = "postgres://sous:password@db/sous"
+
+	r := c.Redacted()
+
+	if r.Auth.Token != Redaction {
+		t.Errorf("got token %q, want it redacted", r.Auth.Token)
+	}
+	if _, ok := r.Auth.Tokens["alsosecret"]; ok || len(r.Auth.Tokens) != 1 {
+		t.Errorf("got tokens %v, want one redacted token", r.Auth.Tokens)
+	}
+	if r.Docker.DatabaseConnection != Redaction {
+		t.Errorf("got database connection %q, want it redacted", r.Docker.DatabaseConnection)
+	}
+	if c.Auth.Token != "secret" || c.Auth.Tokens["alsosecret"].Name != "Judson" {
+		t.Errorf("redacting changed the original config")
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// PeerConfig configures how a Sous server discovers the servers for the
+	// other clusters. Servers announce themselves to every server they know
+	// of, starting with SiblingURLs, and learn of the others from the replies.
+	PeerConfig struct {
+		// URL is the URL the other servers can reach this server at. A
+		// server without a URL does not announce itself, but still learns
+		// of the others.
+		URL string `env:"SOUS_PEER_URL" yaml:",omitempty"`
+		// AnnounceSeconds is how often this server announces itself.
+		AnnounceSeconds int `yaml:",omitempty"`
+		// FailSeconds is how long after last hearing from a server it is
+		// considered to have failed.
+		FailSeconds int `yaml:",omitempty"`
+		// ForgetSeconds is how long after last hearing from a failed server
+		// it is forgotten.
+		ForgetSeconds int `yaml:",omitempty"`
+		// File is where the servers known are recorded, so that they are
+		// known again after a restart. It defaults to peers.json beside
+		// StateLocation.
+		File string `env:"SOUS_PEER_FILE" yaml:",omitempty"`
+	}
+)
+
+const (
+	defaultAnnounceSeconds = 30
+	defaultFailSeconds     = 3 * defaultAnnounceSeconds
+	defaultForgetSeconds   = 24 * 60 * 60
+)
+
+// Validate returns an error if this PeerConfig is invalid.
+func (p PeerConfig) Validate() error {
+	if p.URL != "" {
+		if err := checkURL(p.URL, "Config.Peers.URL"); err != nil {
+			return err
+		}
+	}
+	if p.AnnounceSeconds < 0 || p.FailSeconds < 0 || p.ForgetSeconds < 0 {
+		return errors.New("Config.Peers intervals must not be negative")
+	}
+	if p.FailAfter() <= p.AnnounceInterval() {
+		return errors.New("Config.Peers.FailSeconds must be longer than AnnounceSeconds")
+	}
+	return nil
+}
+
+// AnnounceInterval returns how often the server should announce itself.
+func (p PeerConfig) AnnounceInterval() time.Duration {
+	return secondsOr(p.AnnounceSeconds, defaultAnnounceSeconds)
+}
+
+// FailAfter returns how long a server may go unheard before it is
+// considered to have failed.
+func (p PeerConfig) FailAfter() time.Duration {
+	return secondsOr(p.FailSeconds, defaultFailSeconds)
+}
+
+// ForgetAfter returns how long a server may go unheard before it is
+// forgotten.
+func (p PeerConfig) ForgetAfter() time.Duration {
+	return secondsOr(p.ForgetSeconds, defaultForgetSeconds)
+}
+
+// PeerFile returns the file the servers known should be recorded in.
+func (c Config) PeerFile() string {
+	if c.Peers.File != "" || c.StateLocation == "" {
+		return c.Peers.File
+	}
+	return filepath.Join(filepath.Dir(c.StateLocation), "peers.json")
+}
+
+func secondsOr(s, def int) time.Duration {
+	if s == 0 {
+		s = def
+	}
+	return time.Duration(s) * time.Second
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/opentable/sous/lib"
+)
+
+// Redaction replaces secrets in a Redacted Config.
+const Redaction = "<redacted>"
+
+// Redacted returns a copy of this Config with its secrets replaced by
+// Redaction, so that it can be shown to users: the bearer token, the tokens
+// the server accepts, and the name cache database connection, which may
+// include a password.
+func (c Config) Redacted() Config {
+	if c.Auth.Token != "" {
+		c.Auth.Token = Redaction
+	}
+	if len(c.Auth.Tokens) > 0 {
+		tokens := make(map[string]sous.User, len(c.Auth.Tokens))
+		n := 0
+		for _, u := range c.Auth.Tokens {
+			n++
+			tokens[fmt.Sprintf("%s-%d", Redaction, n)] = u
+		}
+		c.Auth.Tokens = tokens
+	}
+	if c.Docker.DatabaseConnection != "" {
+		c.Docker.DatabaseConnection = Redaction
+	}
+	return c
+}
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/nyarly/inlinefiles/templatestore"
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/shell"
+	"github.com/pkg/errors"
 )
 
 //go:generate inlinefiles --vfs=templateVFS tmpl/ templates_vfs.go
@@ -20,6 +23,8 @@ type (
 		DockerRegistryHost        string
 		SourceShell, ScratchShell shell.Shell
 		Pack                      sous.Buildpack
+		// Signer, if not nil, signs the digest of each image pushed.
+		Signer *sous.ArtifactSigner
 	}
 	// BuildTarget represents a single target within a Build.
 	BuildTarget interface {
@@ -76,14 +81,30 @@ func (b *Builder) ApplyMetadata(br *sous.BuildResult, bc *sous.BuildContext) err
 }
 
 func (b *Builder) applyMetadata(br *sous.BuildResult, kind string, bc *sous.BuildContext) error {
+	if br.Flavor != "" {
+		kind = br.Flavor
+	}
 	br.VersionName = b.VersionTag(bc.Version(), kind)
 	br.RevisionName = b.RevisionTag(bc.Version(), kind, time.Now())
 
+	// Results of other kinds, such as the run image of a split build, aren't
+	// built from a Dockerfile in the source, so there's nowhere to find
+	// their base images.
+	if kind == "" || kind == br.Flavor {
+		b.completeProvenance(br, bc)
+	}
+
 	c := b.SourceShell.Cmd("docker", "build", "-t", br.VersionName, "-t", br.RevisionName, "-")
 	bf := b.metadataDockerfile(br, bc)
 	c.SetStdin(bf)
 
 	for kind, rez := range br.ExtraResults {
+		if rez.Provenance == nil && br.Provenance != nil {
+			p := *br.Provenance
+			p.Advisories = rez.Advisories
+			p.BaseImages = nil
+			rez.Provenance = &p
+		}
 		err := b.applyMetadata(rez, kind, bc)
 		if err != nil {
 			return err
@@ -101,18 +122,57 @@ func (b *Builder) metadataDockerfile(br *sous.BuildResult, bc *sous.BuildContext
 		panic(err)
 	}
 
+	labels := Labels(sv)
+	if br.Flavor != "" {
+		labels[DockerFlavorLabel] = br.Flavor
+	}
+	if br.Provenance != nil {
+		for k, v := range provenanceLabels(br.Provenance) {
+			labels[k] = v
+		}
+	}
+
 	md.Execute(&bf, struct {
 		ImageID    string
 		Labels     map[string]string
 		Advisories []string
 	}{
 		br.ImageID,
-		Labels(sv),
+		labels,
 		br.Advisories,
 	})
 	return &bf
 }
 
+// completeProvenance fills in the base images of br's Provenance, if the
+// buildpack did not already record them, from the Dockerfile being built.
+// Base images are resolved to digests where they are available locally.
+func (b *Builder) completeProvenance(br *sous.BuildResult, bc *sous.BuildContext) {
+	p := br.Provenance
+	if p == nil || len(p.BaseImages) != 0 || bc.Sh == nil {
+		return
+	}
+	dockerfile := "Dockerfile"
+	if br.Flavor != "" {
+		dockerfile += "." + br.Flavor
+	}
+	froms, err := dockerfileFroms(bc.Sh, filepath.Join(bc.Source.OffsetDir, dockerfile))
+	if err != nil {
+		b.debug(fmt.Sprintf("Could not determine base images: %v", err))
+		return
+	}
+	for _, from := range froms {
+		bi := baseImageFromName(from)
+		if bi.Digest == "" {
+			repoDigest, err := b.SourceShell.Stdout("docker", "inspect", "--format", "{{index .RepoDigests 0}}", from)
+			if err == nil {
+				bi.Digest = baseImageFromName(strings.TrimSpace(repoDigest)).Digest
+			}
+		}
+		p.BaseImages = append(p.BaseImages, bi)
+	}
+}
+
 // pushToRegistry sends the built image to the registry
 func (b *Builder) pushToRegistry(br *sous.BuildResult, bc *sous.BuildContext) error {
 	for _, rez := range br.ExtraResults {
@@ -130,8 +190,18 @@ func (b *Builder) pushToRegistry(br *sous.BuildResult, bc *sous.BuildContext) er
 	return verr
 }
 
-// recordName inserts metadata about the newly built image into our local name cache
+// recordName inserts metadata about the newly built image, and any flavored
+// images built alongside it, into our local name cache
 func (b *Builder) recordName(br *sous.BuildResult, bc *sous.BuildContext) error {
+	for _, rez := range br.ExtraResults {
+		if rez.Flavor == "" {
+			continue
+		}
+		if err := b.recordName(rez, bc); err != nil {
+			return err
+		}
+	}
+
 	sv := bc.Version()
 	in := br.VersionName
 	b.SourceShell.ConsoleEcho(fmt.Sprintf("[recording \"%s\" as the docker name for \"%s\"]", in, sv.String()))
@@ -139,9 +209,45 @@ func (b *Builder) recordName(br *sous.BuildResult, bc *sous.BuildContext) error
 	for _, adv := range br.Advisories {
 		qs = append(qs, sous.Quality{Name: adv, Kind: "advisory"})
 	}
+	if b.Signer != nil {
+		q, err := b.signature(sv, in)
+		if err != nil {
+			return err
+		}
+		qs = append(qs, q)
+	}
+	if br.Provenance != nil {
+		q, err := br.Provenance.Quality()
+		if err != nil {
+			return err
+		}
+		qs = append(qs, q)
+	}
+	if br.Flavor != "" {
+		qs = append(qs, sous.Quality{Name: br.Flavor, Kind: sous.FlavorQualityKind})
+	}
 	return b.ImageMapper.Insert(sv, in, "", qs)
 }
 
+// signature signs the digest of the pushed image named in, built from sv.
+func (b *Builder) signature(sv sous.SourceID, in string) (sous.Quality, error) {
+	repoDigest, err := b.SourceShell.Stdout("docker", "inspect", "--format", "{{index .RepoDigests 0}}", in)
+	if err != nil {
+		return sous.Quality{}, errors.Wrapf(err, "getting digest of %s to sign", in)
+	}
+	repoDigest = strings.TrimSpace(repoDigest)
+	i := strings.Index(repoDigest, "@")
+	if i < 0 {
+		return sous.Quality{}, errors.Errorf("no digest for %s to sign (got %q)", in, repoDigest)
+	}
+	sig, err := b.Signer.Sign(sv, repoDigest[i+1:])
+	if err != nil {
+		return sous.Quality{}, err
+	}
+	b.SourceShell.ConsoleEcho(fmt.Sprintf("[signed %s with key %q]", repoDigest, sig.KeyID))
+	return sig.Quality()
+}
+
 // VersionTag computes an image tag from a SourceVersion's version
 func (b *Builder) VersionTag(v sous.SourceID, kind string) string {
 	return versionTag(b.DockerRegistryHost, v, kind)
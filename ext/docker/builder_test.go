@@ -1,6 +1,7 @@
 package docker
 
 import (
+	"errors"
 	"io/ioutil"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/opentable/sous/util/shell"
 	"github.com/opentable/sous/util/spies"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -83,3 +85,88 @@ func TestBuilderApplyMetadata(t *testing.T) {
 	err = b.Register(br, bc)
 	assert.NoError(t, err)
 }
+
+func TestBuilderApplyMetadataBaseImagesPerResult(t *testing.T) {
+	srcSh, err := shell.NewTestShell("/src", map[string]string{
+		"Dockerfile":        "FROM golang:1.7\n",
+		"Dockerfile.canary": "FROM alpine:3.4 AS build\n",
+	})
+	require.NoError(t, err)
+	srcSh.CmdsF = func(name string, args []interface{}) *shell.DummyResult {
+		switch {
+		case name == "cat" && args[0] == "Dockerfile":
+			return &shell.DummyResult{SO: []byte("FROM golang:1.7\n")}
+		case name == "cat" && args[0] == "Dockerfile.canary":
+			return &shell.DummyResult{SO: []byte("FROM alpine:3.4 AS build\n")}
+		case name == "docker" && args[0] == "inspect" && args[len(args)-1] == "golang:1.7":
+			return &shell.DummyResult{SO: []byte("golang@sha256:abc\n")}
+		case name == "docker" && args[0] == "inspect":
+			return &shell.DummyResult{Err: errors.New("no such image"), Status: 1}
+		}
+		return nil
+	}
+
+	scratchSh, err := shell.NewTestShell("/tmp", map[string]string{"/tmp/__exists__": ""})
+	require.NoError(t, err)
+
+	b, err := NewBuilder(sous.NewInserterSpy(), "docker.example.com", srcSh, scratchSh)
+	require.NoError(t, err)
+
+	br := &sous.BuildResult{
+		Provenance: &sous.Provenance{},
+		ExtraResults: map[string]*sous.BuildResult{
+			"canary": {Flavor: "canary"},
+			"runner": {},
+		},
+	}
+	bc := &sous.BuildContext{Sh: srcSh}
+
+	require.NoError(t, b.ApplyMetadata(br, bc))
+
+	assert.Equal(t, []sous.BaseImage{{Name: "golang:1.7", Digest: "sha256:abc"}}, br.Provenance.BaseImages)
+	assert.Equal(t, []sous.BaseImage{{Name: "alpine:3.4"}}, br.ExtraResults["canary"].Provenance.BaseImages)
+	assert.Empty(t, br.ExtraResults["runner"].Provenance.BaseImages)
+}
+
+func TestBuilderRegistersFlavoredImagesUnderTheirSourceID(t *testing.T) {
+	srcSh, err := shell.NewTestShell("", map[string]string{})
+	require.NoError(t, err)
+	scratchSh, err := shell.NewTestShell("/tmp", map[string]string{"/tmp/__exists__": ""})
+	require.NoError(t, err)
+
+	flavors := map[string]string{}
+	nc := sous.NewInserterSpy()
+	nc.MatchMethod("Insert", func(args mock.Arguments) bool {
+		sid := args.Get(0).(sous.SourceID)
+		assert.Equal(t, "sub", sid.Location.Dir, "the flavor is not part of the source ID")
+		flavor, _ := flavorFromQualities(args.Get(3).([]sous.Quality))
+		flavors[args.String(1)] = flavor
+		return true
+	}, nil)
+
+	b, err := NewBuilder(nc, "docker.example.com", srcSh, scratchSh)
+	require.NoError(t, err)
+
+	br := &sous.BuildResult{
+		VersionName: "docker.example.com/test:2.3.7",
+		ExtraResults: map[string]*sous.BuildResult{
+			"worker": {Flavor: "worker", VersionName: "docker.example.com/test-worker:2.3.7"},
+		},
+	}
+	bc := &sous.BuildContext{Source: sous.SourceContext{
+		OffsetDir:  "sub",
+		RemoteURL:  "github.com/opentable/test",
+		NearestTag: sous.Tag{Name: "2.3.7"},
+	}}
+
+	require.NoError(t, b.Register(br, bc))
+	assert.Equal(t, map[string]string{
+		"docker.example.com/test:2.3.7":        "",
+		"docker.example.com/test-worker:2.3.7": "worker",
+	}, flavors)
+
+	mddf, err := ioutil.ReadAll(b.metadataDockerfile(br.ExtraResults["worker"], bc))
+	require.NoError(t, err)
+	assert.Contains(t, string(mddf), `com.opentable.sous.flavor="worker"`)
+	assert.Contains(t, string(mddf), `com.opentable.sous.repo_offset="sub"`)
+}
@@ -8,6 +8,12 @@ type Config struct {
 	// DatabaseConnection is the database connection string for local
 	// persistence.
 	DatabaseConnection string `env:"SOUS_DOCKER_DB_CONN"`
+	// SigningKey is the path to a PEM encoded ECDSA private key used to sign
+	// built images. If it is empty, images are not signed.
+	SigningKey string `env:"SOUS_DOCKER_SIGNING_KEY"`
+	// SigningKeyID names SigningKey; it must match the ID of a trusted key in
+	// the GDM for signatures to be accepted.
+	SigningKeyID string `env:"SOUS_DOCKER_SIGNING_KEY_ID"`
 }
 
 // DefaultConfig builds a default configuration, which can be then overridden by
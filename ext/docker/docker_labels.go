@@ -5,4 +5,15 @@ const (
 	DockerPathLabel     = "com.opentable.sous.repo_offset"
 	DockerVersionLabel  = "com.opentable.sous.version"
 	DockerRevisionLabel = "com.opentable.sous.revision"
+	DockerAdvisoryLabel = "com.opentable.sous.advisories"
+	DockerFlavorLabel   = "com.opentable.sous.flavor"
+
+	// The following labels record the provenance of a build.
+	DockerBuilderLabel       = "com.opentable.sous.build.user"
+	DockerHostLabel          = "com.opentable.sous.build.host"
+	DockerSousVersionLabel   = "com.opentable.sous.build.sous_version"
+	DockerBuildpackLabel     = "com.opentable.sous.build.buildpack"
+	DockerBaseImagesLabel    = "com.opentable.sous.build.base_images"
+	DockerBuildStartedLabel  = "com.opentable.sous.build.started"
+	DockerBuildFinishedLabel = "com.opentable.sous.build.finished"
 )
@@ -7,10 +7,15 @@ import (
 	"time"
 
 	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
 )
 
 // DockerfileBuildpack is a simple buildpack for building projects using
 // their own Dockerfile.
+//
+// Projects may also include Dockerfiles named Dockerfile.<flavor>. Those of
+// the flavors requested in the BuildContext are built alongside the main
+// Dockerfile, each producing an artifact for manifests of that flavor.
 type DockerfileBuildpack struct{}
 
 const (
@@ -25,6 +30,9 @@ const (
 var (
 	appVersionPattern  = regexp.MustCompile(`(?m)^ARG ` + AppVersionBuildArg + `\b`)
 	appRevisionPattern = regexp.MustCompile(`(?m)^ARG ` + AppRevisionBuildArg + `\b`)
+	// flavorPattern matches flavors which may be built. Flavors are used in
+	// image names, so must be valid Docker repository name components.
+	flavorPattern = regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*$`)
 )
 
 // datectData is data passed from the detect step to the build step as the
@@ -39,6 +47,19 @@ type detectData struct {
 
 	// RunImageSpecPath is used by the split container buildpack
 	RunImageSpecPath string
+
+	// Flavors lists the requested flavors, each with its own
+	// Dockerfile.<flavor>.
+	Flavors []flavorData
+}
+
+// flavorData describes a Dockerfile.<flavor> to be built alongside the main
+// Dockerfile.
+type flavorData struct {
+	Flavor string
+	// HasAppVersionArg and HasAppRevisionArg are as in detectData, but for
+	// Dockerfile.<Flavor>.
+	HasAppVersionArg, HasAppRevisionArg bool
 }
 
 // NewDockerfileBuildpack creates a Dockerfile buildpack
@@ -51,59 +72,129 @@ var successfulBuildRE = regexp.MustCompile(`Successfully built (\w+)`)
 // Build implements Buildpack.Build
 func (d *DockerfileBuildpack) Build(c *sous.BuildContext, dr *sous.DetectResult) (*sous.BuildResult, error) {
 	start := time.Now()
+	r := dr.Data.(detectData)
+
+	imageID, err := d.buildImage(c, r.HasAppVersionArg, r.HasAppRevisionArg, "")
+	if err != nil {
+		return nil, err
+	}
+
+	br := &sous.BuildResult{
+		ImageID:    imageID,
+		Elapsed:    time.Since(start),
+		Advisories: c.Advisories,
+	}
+
+	for _, fd := range r.Flavors {
+		flavor := fd.Flavor
+		flavorStart := time.Now()
+		imageID, err := d.buildImage(c, fd.HasAppVersionArg, fd.HasAppRevisionArg, "Dockerfile."+flavor)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building flavor %q", flavor)
+		}
+		if br.ExtraResults == nil {
+			br.ExtraResults = map[string]*sous.BuildResult{}
+		}
+		br.ExtraResults[flavor] = &sous.BuildResult{
+			ImageID:    imageID,
+			Elapsed:    time.Since(flavorStart),
+			Advisories: c.Advisories,
+			Flavor:     flavor,
+		}
+	}
+
+	return br, nil
+}
+
+// buildImage builds the named Dockerfile in the offset directory, or the
+// default Dockerfile if dockerfile is empty, and returns the built image ID.
+// The version and revision build args are passed if the Dockerfile declares
+// them.
+func (d *DockerfileBuildpack) buildImage(c *sous.BuildContext, versionArg, revisionArg bool, dockerfile string) (string, error) {
 	offset := c.Source.OffsetDir
 	if offset == "" {
 		offset = "."
 	}
 
 	cmd := []interface{}{"build", "--pull"}
-	r := dr.Data.(detectData)
-	if r.HasAppVersionArg {
+	if versionArg {
 		v := c.Version().Version
 		v.Meta = ""
 		cmd = append(cmd, "--build-arg", fmt.Sprintf("%s=%s", AppVersionBuildArg, v))
 	}
-	if r.HasAppRevisionArg {
+	if revisionArg {
 		cmd = append(cmd, "--build-arg", fmt.Sprintf("%s=%s", AppRevisionBuildArg, c.Version().RevID()))
 	}
+	if dockerfile != "" {
+		cmd = append(cmd, "--file", filepath.Join(offset, dockerfile))
+	}
 
 	cmd = append(cmd, offset)
 
 	output, err := c.Sh.Stdout("docker", cmd...)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	match := successfulBuildRE.FindStringSubmatch(string(output))
 	if match == nil {
-		return nil, fmt.Errorf("Couldn't find container id in:\n%s", output)
+		return "", fmt.Errorf("Couldn't find container id in:\n%s", output)
 	}
-
-	return &sous.BuildResult{
-		ImageID:    match[1],
-		Elapsed:    time.Since(start),
-		Advisories: c.Advisories,
-	}, nil
+	return match[1], nil
 }
 
-// Detect detects if c has a Dockerfile or not.
-func (d *DockerfileBuildpack) Detect(c *sous.BuildContext) (*sous.DetectResult, error) {
-	dfPath := filepath.Join(c.Source.OffsetDir, "Dockerfile")
+// detectArgs reads the Dockerfile at dfPath, and reports whether it declares
+// the version and revision build args.
+func detectArgs(c *sous.BuildContext, dfPath string) (versionArg, revisionArg bool, err error) {
 	if !c.Sh.Exists(dfPath) {
-		return nil, fmt.Errorf("%s does not exist", dfPath)
+		return false, false, fmt.Errorf("%s does not exist", dfPath)
 	}
 	sh := c.Sh.Clone()
 	sh.LongRunning(false)
 	df, err := sh.Stdout("cat", dfPath)
+	if err != nil {
+		return false, false, err
+	}
+	return appVersionPattern.MatchString(df), appRevisionPattern.MatchString(df), nil
+}
+
+// detectFlavors checks that each flavor requested in c has a
+// Dockerfile.<flavor> in the offset directory, and detects its build args.
+func detectFlavors(c *sous.BuildContext) ([]flavorData, error) {
+	var flavors []flavorData
+	for _, flavor := range c.Flavors {
+		if !flavorPattern.MatchString(flavor) {
+			return nil, fmt.Errorf("invalid flavor %q", flavor)
+		}
+		versionArg, revisionArg, err := detectArgs(c, filepath.Join(c.Source.OffsetDir, "Dockerfile."+flavor))
+		if err != nil {
+			return nil, errors.Wrapf(err, "flavor %q", flavor)
+		}
+		flavors = append(flavors, flavorData{
+			Flavor:            flavor,
+			HasAppVersionArg:  versionArg,
+			HasAppRevisionArg: revisionArg,
+		})
+	}
+	return flavors, nil
+}
+
+// Detect detects if c has a Dockerfile or not.
+func (d *DockerfileBuildpack) Detect(c *sous.BuildContext) (*sous.DetectResult, error) {
+	dfPath := filepath.Join(c.Source.OffsetDir, "Dockerfile")
+	hasAppVersion, hasAppRevision, err := detectArgs(c, dfPath)
+	if err != nil {
+		return nil, err
+	}
+	flavors, err := detectFlavors(c)
 	if err != nil {
 		return nil, err
 	}
-	hasAppVersion := appVersionPattern.MatchString(df)
-	hasAppRevision := appRevisionPattern.MatchString(df)
-	sous.Log.Debug.Printf("Detected a dockerfile at %q. Accepts version: %t, accepts revision: %t", dfPath, hasAppVersion, hasAppRevision)
+	sous.Log.Debug.Printf("Detected a dockerfile at %q. Accepts version: %t, accepts revision: %t, flavors: %v", dfPath, hasAppVersion, hasAppRevision, flavors)
 	result := &sous.DetectResult{Compatible: true, Data: detectData{
 		HasAppVersionArg:  hasAppVersion,
 		HasAppRevisionArg: hasAppRevision,
+		Flavors:           flavors,
 	}}
 	return result, nil
 }
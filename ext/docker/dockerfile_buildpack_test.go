@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
 	"testing"
 
 	"github.com/opentable/sous/lib"
@@ -135,8 +136,114 @@ func assertResult(expected *sous.DetectResult, actual *sous.DetectResult, err er
 	}
 	ad := actual.Data.(detectData)
 	ed := expected.Data.(detectData)
-	if ad != ed {
+	if !reflect.DeepEqual(ad, ed) {
 		return fmt.Errorf("Data = %#v; want %#v", ad, ed)
 	}
 	return nil
 }
+
+func flavorsBuildContext(t *testing.T, flavors ...string) *sous.BuildContext {
+	testDir := "testdata/gen/flavors"
+	os.RemoveAll(testDir)
+	if err := os.MkdirAll(testDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"Dockerfile":        "FROM blah\nARG APP_REVISION\n",
+		"Dockerfile.worker": "FROM blah\nARG APP_VERSION\n",
+		"Dockerfile.api":    "FROM blah\n",
+		"Dockerfile.dev":    "FROM blah\n",
+	} {
+		if err := ioutil.WriteFile(path.Join(testDir, name), []byte(content), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sh, err := shell.DefaultInDir(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sous.BuildContext{Sh: sh, Flavors: flavors}
+}
+
+func TestDetectFlavors(t *testing.T) {
+	dr, err := (&DockerfileBuildpack{}).Detect(flavorsBuildContext(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := dr.Data.(detectData).Flavors; len(actual) != 0 {
+		t.Errorf("Flavors = %v; want none unless requested", actual)
+	}
+
+	dr, err = (&DockerfileBuildpack{}).Detect(flavorsBuildContext(t, "worker", "api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := detectData{
+		HasAppRevisionArg: true,
+		Flavors: []flavorData{
+			{Flavor: "worker", HasAppVersionArg: true},
+			{Flavor: "api"},
+		},
+	}
+	if actual := dr.Data.(detectData); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Data = %#v; want %#v", actual, expected)
+	}
+
+	for _, flavor := range []string{"missing", "Bad~Flavor"} {
+		if _, err := (&DockerfileBuildpack{}).Detect(flavorsBuildContext(t, flavor)); err == nil {
+			t.Errorf("Requesting flavor %q should be an error", flavor)
+		}
+	}
+}
+
+func TestBuildFlavors(t *testing.T) {
+	sh, err := shell.NewTestShell(".", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builds := map[string][]interface{}{}
+	sh.CmdsF = func(name string, args []interface{}) *shell.DummyResult {
+		if name != "docker" || args[0] != "build" {
+			return nil
+		}
+		dockerfile := "Dockerfile"
+		for i, a := range args {
+			if a == "--file" {
+				dockerfile = path.Base(args[i+1].(string))
+			}
+		}
+		builds[dockerfile] = args
+		return &shell.DummyResult{SO: []byte("Successfully built abc123\n")}
+	}
+	c := &sous.BuildContext{Sh: sh, Source: sous.SourceContext{NearestTag: sous.Tag{Name: "1.2.3"}}}
+	dr := &sous.DetectResult{Data: detectData{
+		HasAppRevisionArg: true,
+		Flavors:           []flavorData{{Flavor: "worker", HasAppVersionArg: true}},
+	}}
+
+	br, err := (&DockerfileBuildpack{}).Build(c, dr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 2 || br.ExtraResults["worker"] == nil {
+		t.Fatalf("Expected the main and worker images to be built, got %v", builds)
+	}
+
+	hasArg := func(args []interface{}, arg string) bool {
+		for _, a := range args {
+			if s, ok := a.(string); ok && len(s) > len(arg) && s[:len(arg)+1] == arg+"=" {
+				return true
+			}
+		}
+		return false
+	}
+	for dockerfile, expected := range map[string][2]bool{
+		"Dockerfile":        {false, true},
+		"Dockerfile.worker": {true, false},
+	} {
+		args := builds[dockerfile]
+		if hasArg(args, AppVersionBuildArg) != expected[0] || hasArg(args, AppRevisionBuildArg) != expected[1] {
+			t.Errorf("Build args for %s not as declared by it: %v", dockerfile, args)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nyarly/inlinefiles/templatestore"
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+// generateDockerfile renders the named template from the templates VFS with
+// data.
+func generateDockerfile(name string, data interface{}) ([]byte, error) {
+	tmpl, err := templatestore.LoadText(templateVFS, name, name+".tmpl")
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// generatedDockerfileName is the name of the Dockerfile written into the build
+// context by buildGenerated. Docker before 17.05 only accepts a Dockerfile
+// from inside the build context.
+const generatedDockerfileName = ".sous-generated.Dockerfile"
+
+// buildGenerated builds the source in c using dockerfile, which is not part
+// of the project but generated by a buildpack. The build context is a copy of
+// the source at the offset directory, staged in the scratch directory so that
+// the Dockerfile can be written into it without touching the project. The
+// Dockerfile is added to the copy's .dockerignore, so it isn't copied into the
+// image.
+func buildGenerated(c *sous.BuildContext, dockerfile []byte) (*sous.BuildResult, error) {
+	start := time.Now()
+
+	dir, err := ioutil.TempDir(c.Scratch.RootDir, "generated-build")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := stageGeneratedContext(c.Sh.Abs(c.Source.OffsetDir), dir, dockerfile); err != nil {
+		return nil, errors.Wrapf(err, "staging build context")
+	}
+
+	v := c.Version().Version
+	v.Meta = ""
+	output, err := c.Sh.Stdout("docker", "build", "--pull",
+		"--file", filepath.Join(dir, generatedDockerfileName),
+		"--build-arg", fmt.Sprintf("%s=%s", AppVersionBuildArg, v),
+		"--build-arg", fmt.Sprintf("%s=%s", AppRevisionBuildArg, c.Version().RevID()),
+		dir)
+	if err != nil {
+		return nil, err
+	}
+
+	match := successfulBuildRE.FindStringSubmatch(string(output))
+	if match == nil {
+		return nil, fmt.Errorf("Couldn't find container id in:\n%s", output)
+	}
+
+	br := &sous.BuildResult{
+		ImageID:    match[1],
+		Elapsed:    time.Since(start),
+		Advisories: c.Advisories,
+	}
+	if ast, err := parseDocker(bytes.NewReader(dockerfile)); err == nil {
+		br.Provenance = &sous.Provenance{}
+		for _, from := range fromsInAST(ast) {
+			br.Provenance.BaseImages = append(br.Provenance.BaseImages, baseImageFromName(from))
+		}
+	}
+	return br, nil
+}
+
+// stageGeneratedContext copies the source in src to dir, and writes
+// dockerfile there, ignored by the copy's .dockerignore.
+func stageGeneratedContext(src, dir string, dockerfile []byte) error {
+	if err := copyTree(src, dir); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, generatedDockerfileName), dockerfile, 0644); err != nil {
+		return err
+	}
+	ignore, err := os.OpenFile(filepath.Join(dir, ".dockerignore"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(ignore, "\n%s\n", generatedDockerfileName); err != nil {
+		ignore.Close()
+		return err
+	}
+	return ignore.Close()
+}
+
+// copyTree copies the files, directories and symlinks under src into dst,
+// which must exist.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.Mkdir(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// readSourceFile reads the file at name relative to the offset directory of c.
+func readSourceFile(c *sous.BuildContext, name string) (string, error) {
+	path := filepath.Join(c.Source.OffsetDir, name)
+	if !c.Sh.Exists(path) {
+		return "", fmt.Errorf("%s does not exist", path)
+	}
+	sh := c.Sh.Clone()
+	sh.LongRunning(false)
+	return sh.Stdout("cat", path)
+}
+
+// sourceFileExists reports whether there is a file at name relative to the
+// offset directory of c.
+func sourceFileExists(c *sous.BuildContext, name string) bool {
+	return c.Sh.Exists(filepath.Join(c.Source.OffsetDir, name))
+}
+
+// firstField returns the first whitespace separated field of s, or the empty
+// string.
+func firstField(s string) string {
+	fs := strings.Fields(s)
+	if len(fs) == 0 {
+		return ""
+	}
+	return fs[0]
+}
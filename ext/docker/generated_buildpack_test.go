@@ -0,0 +1,133 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generatedBuildContext(t *testing.T, name string, files map[string]string) *sous.BuildContext {
+	testDir := path.Join("testdata/gen", name)
+	os.RemoveAll(testDir)
+	require.NoError(t, os.MkdirAll(testDir, 0777))
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(path.Join(testDir, name), []byte(content), 0777))
+	}
+	sh, err := shell.DefaultInDir(testDir)
+	require.NoError(t, err)
+	return &sous.BuildContext{Sh: sh}
+}
+
+func TestGoBuildpackDetect(t *testing.T) {
+	c := generatedBuildContext(t, "go", map[string]string{
+		"go.mod": "module github.com/opentable/example\n\ngo 1.12\n",
+	})
+	dr, err := NewGoBuildpack().Detect(c)
+	require.NoError(t, err)
+	assert.True(t, dr.Compatible)
+	assert.Equal(t, goDetectData{Module: "github.com/opentable/example", GoVersion: "1.12", Package: "."}, dr.Data)
+
+	df, err := generateDockerfile("goDockerfile", dr.Data)
+	require.NoError(t, err)
+	ast, err := parseDocker(strings.NewReader(string(df)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"golang:1.12", "scratch"}, fromsInAST(ast))
+
+	_, err = NewGoBuildpack().Detect(generatedBuildContext(t, "nogo", nil))
+	assert.Error(t, err)
+}
+
+func TestNodeBuildpackDetect(t *testing.T) {
+	c := generatedBuildContext(t, "node", map[string]string{
+		"package.json":      `{"engines": {"node": ">=8.9.0"}, "scripts": {"build": "webpack", "start": "node ."}}`,
+		"package-lock.json": `{}`,
+	})
+	dr, err := NewNodeBuildpack().Detect(c)
+	require.NoError(t, err)
+	assert.True(t, dr.Compatible)
+	expected := nodeDetectData{NodeVersion: "8", InstallCommand: "npm ci", HasBuildScript: true}
+	assert.Equal(t, expected, dr.Data)
+
+	df, err := generateDockerfile("nodeDockerfile", dr.Data)
+	require.NoError(t, err)
+	assert.Contains(t, string(df), "RUN npm run build")
+	ast, err := parseDocker(strings.NewReader(string(df)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node:8", "node:8-alpine"}, fromsInAST(ast))
+
+	c = generatedBuildContext(t, "yarn", map[string]string{
+		"package.json": `{"scripts": {"start": "node ."}}`,
+		"yarn.lock":    ``,
+	})
+	dr, err = NewNodeBuildpack().Detect(c)
+	require.NoError(t, err)
+	expected = nodeDetectData{NodeVersion: DefaultNodeVersion, InstallCommand: "yarn install --frozen-lockfile", HasYarnLock: true}
+	assert.Equal(t, expected, dr.Data)
+}
+
+func TestBuildGeneratedStagesContextInScratch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-generated-build")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	scratch, err := ioutil.TempDir("", "sous-generated-scratch")
+	require.NoError(t, err)
+	defer os.RemoveAll(scratch)
+	require.NoError(t, os.MkdirAll(path.Join(dir, "sub", "src"), 0777))
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "sub", "src", "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "sub", ".dockerignore"), []byte("*.log"), 0644))
+
+	sh, err := shell.NewTestShell(dir, nil)
+	require.NoError(t, err)
+	var built, ignored, copied string
+	sh.CmdsF = func(name string, args []interface{}) *shell.DummyResult {
+		if name != "docker" || args[0] != "build" {
+			return nil
+		}
+		context := args[len(args)-1].(string)
+		assert.True(t, strings.HasPrefix(context, scratch+"/"), "the build context is staged in the scratch directory")
+		for i, a := range args {
+			if a == "--file" {
+				df, err := ioutil.ReadFile(args[i+1].(string))
+				require.NoError(t, err)
+				built = string(df)
+				assert.Equal(t, path.Join(context, generatedDockerfileName), args[i+1])
+			}
+		}
+		di, err := ioutil.ReadFile(path.Join(context, ".dockerignore"))
+		require.NoError(t, err)
+		ignored = string(di)
+		src, err := ioutil.ReadFile(path.Join(context, "src", "main.go"))
+		require.NoError(t, err)
+		copied = string(src)
+		return &shell.DummyResult{SO: []byte("Successfully built abc123\n")}
+	}
+	c := &sous.BuildContext{
+		Sh:      sh,
+		Scratch: sous.ScratchContext{RootDir: scratch},
+		Source: sous.SourceContext{
+			OffsetDir:  "sub",
+			NearestTag: sous.Tag{Name: "1.2.3"},
+		},
+	}
+
+	br, err := buildGenerated(c, []byte("FROM golang:1.12\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", br.ImageID)
+	assert.Equal(t, "FROM golang:1.12\n", built)
+	assert.Equal(t, "package main\n", copied)
+	assert.Equal(t, "*.log\n"+generatedDockerfileName+"\n", ignored, "the Dockerfile is not copied into the image")
+
+	files, err := ioutil.ReadDir(path.Join(dir, "sub"))
+	require.NoError(t, err)
+	assert.Len(t, files, 2, "nothing is written into the project")
+	files, err = ioutil.ReadDir(scratch)
+	require.NoError(t, err)
+	assert.Empty(t, files, "the staged context is removed after the build")
+}
@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"regexp"
+
+	"github.com/opentable/sous/lib"
+)
+
+// GoBuildpack builds Go projects which use Go modules, without requiring a
+// Dockerfile. The main package at the root of the module is compiled in a
+// golang image, and the static binary copied into an otherwise empty image.
+type GoBuildpack struct{}
+
+// goDetectData is passed from the detect step to the build step as the Data
+// field of the DetectResult.
+type goDetectData struct {
+	// Module is the module path declared in go.mod.
+	Module string
+	// GoVersion is the Go version declared in go.mod, used to select the
+	// golang image tag.
+	GoVersion string
+	// Package is the package to build, relative to the module root.
+	Package string
+}
+
+// DefaultGoVersion is the golang image tag used when go.mod does not declare
+// a Go version.
+const DefaultGoVersion = "1"
+
+var (
+	goModulePattern  = regexp.MustCompile(`(?m)^module\s+"?([^"\s]+)"?`)
+	goVersionPattern = regexp.MustCompile(`(?m)^go\s+(\d+\.\d+)`)
+)
+
+// NewGoBuildpack creates a Go buildpack.
+func NewGoBuildpack() *GoBuildpack {
+	return &GoBuildpack{}
+}
+
+// Detect implements Buildpack.Detect. It is compatible with projects that
+// have a go.mod.
+func (*GoBuildpack) Detect(c *sous.BuildContext) (*sous.DetectResult, error) {
+	gomod, err := readSourceFile(c, "go.mod")
+	if err != nil {
+		return nil, err
+	}
+	data := goDetectData{GoVersion: DefaultGoVersion, Package: "."}
+	if m := goModulePattern.FindStringSubmatch(gomod); m != nil {
+		data.Module = m[1]
+	}
+	if m := goVersionPattern.FindStringSubmatch(gomod); m != nil {
+		data.GoVersion = m[1]
+	}
+	sous.Log.Debug.Printf("Detected Go module %q, Go version %s", data.Module, data.GoVersion)
+	return &sous.DetectResult{Compatible: true, Data: data}, nil
+}
+
+// Build implements Buildpack.Build.
+func (*GoBuildpack) Build(c *sous.BuildContext, dr *sous.DetectResult) (*sous.BuildResult, error) {
+	df, err := generateDockerfile("goDockerfile", dr.Data.(goDetectData))
+	if err != nil {
+		return nil, err
+	}
+	return buildGenerated(c, df)
+}
@@ -1,17 +1,13 @@
 package docker
 
 import (
-	"bytes"
-	"crypto/sha256"
 	"database/sql"
-	"encoding/base64"
 	"fmt"
 	"io"
 	"regexp"
 	"strings"
 	"sync"
 	"text/tabwriter"
-	"time"
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/reference"
@@ -86,15 +82,18 @@ func (e NotModifiedErr) Error() string {
 	return "Not modified"
 }
 
-// NewNameCache builds a new name cache.
-func NewNameCache(drh string, cl docker_registry.Client, db *sql.DB) *NameCache {
+// NewNameCache builds a new name cache. It returns an error if the database
+// cannot be brought up to date with GroomDatabase.
+func NewNameCache(drh string, cl docker_registry.Client, db *sql.DB) (*NameCache, error) {
 	nc := &NameCache{
 		RegistryClient:     cl,
 		DB:                 db,
 		DockerRegistryHost: drh,
 	}
-	nc.GroomDatabase()
-	return nc
+	if err := nc.GroomDatabase(); err != nil {
+		return nil, errors.Wrap(err, "name cache database")
+	}
+	return nc, nil
 }
 
 // ListSourceIDs lists all the known SourceIDs.
@@ -102,6 +101,14 @@ func (nc *NameCache) ListSourceIDs() ([]sous.SourceID, error) {
 	return nc.dbQueryAllSourceIds()
 }
 
+// Ping returns an error if the name cache's database cannot be reached.
+func (nc *NameCache) Ping() error {
+	if nc.DB == nil {
+		return errors.New("no name cache database")
+	}
+	return nc.DB.Ping()
+}
+
 // Warmup warms up the cache.
 func (nc *NameCache) Warmup(r string) error {
 	ref, err := reference.ParseNamed(r)
@@ -137,11 +144,60 @@ func (nc *NameCache) ImageLabels(in string) (map[string]string, error) {
 
 // GetArtifact implements sous.Registry.GetArtifact.
 func (nc *NameCache) GetArtifact(sid sous.SourceID) (*sous.BuildArtifact, error) {
-	name, qls, err := nc.getImageName(sid)
+	return nc.getArtifact(sid, "")
+}
+
+// GetFlavoredArtifact implements sous.Registry.GetFlavoredArtifact.
+func (nc *NameCache) GetFlavoredArtifact(sid sous.SourceID, flavor string) (*sous.BuildArtifact, error) {
+	art, err := nc.getArtifact(sid, flavor)
+	if _, notFound := errors.Cause(err).(NoImageNameFound); notFound {
+		return nil, &sous.ArtifactNotFoundError{SourceID: sid, Flavor: flavor}
+	}
+	return art, err
+}
+
+func (nc *NameCache) getArtifact(sid sous.SourceID, flavor string) (*sous.BuildArtifact, error) {
+	name, qls, err := nc.getImageName(sid, flavor)
 	if err != nil {
 		return nil, err
 	}
-	return NewBuildArtifact(name, qls), nil
+	pinned, err := nc.pinDigest(name)
+	if err != nil {
+		Log.Warn.Printf("Could not pin %s to a digest (deploying by tag): %v", name, err)
+		pinned = name
+	}
+	return NewBuildArtifact(pinned, qls), nil
+}
+
+// pinDigest returns the digest-qualified name of the image the cache knows as
+// in. If in names a tag, the registry is asked for the digest of the manifest
+// it currently points to, and that digest is recorded as the image's canonical
+// name, so that later retagging the image does not change what its source ID
+// means.
+func (nc *NameCache) pinDigest(in string) (string, error) {
+	ref, err := reference.ParseNamed(in)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing %q", in)
+	}
+	if _, digested := ref.(reference.Digested); digested {
+		return in, nil
+	}
+
+	md, err := nc.RegistryClient.GetImageMetadata(in, "")
+	if err != nil {
+		return "", errors.Wrapf(err, "getting digest of %s", in)
+	}
+	i := strings.LastIndex(md.CanonicalName, "@")
+	if i < 0 {
+		return "", errors.Errorf("no digest for %s (got %q)", in, md.CanonicalName)
+	}
+	pinned := ref.Name() + md.CanonicalName[i:]
+
+	if err := nc.dbPinCanonicalName(in, pinned, md.Etag); err != nil {
+		return "", errors.Wrapf(err, "recording %s as %s", in, pinned)
+	}
+	Log.Debug.Printf("Pinned %s to %s", in, pinned)
+	return pinned, nil
 }
 
 func meansBodyUnchanged(err error) bool {
@@ -192,6 +248,7 @@ func (nc *NameCache) GetSourceID(a *sous.BuildArtifact) (sous.SourceID, error) {
 	if err != nil {
 		return sid, err
 	}
+	flavor := md.Labels[DockerFlavorLabel]
 
 	qualities := qualitiesFromLabels(md.Labels)
 
@@ -207,7 +264,7 @@ func (nc *NameCache) GetSourceID(a *sous.BuildArtifact) (sous.SourceID, error) {
 	}
 
 	Log.Vomit.Printf("Recording %q (with etag: %s) as canonical for %v", fullCanon, md.Etag, newSID)
-	err = nc.dbInsert(newSID, fullCanon, md.Etag, qualities)
+	err = nc.dbInsert(newSID, flavor, fullCanon, md.Etag, qualities)
 	if err != nil {
 		Log.Debug.Printf("Err recording %q: %v", fullCanon, err)
 		return sid, err
@@ -228,10 +285,10 @@ func (nc *NameCache) GetSourceID(a *sous.BuildArtifact) (sous.SourceID, error) {
 	return newSID, err
 }
 
-// GetImageName returns the docker image name for a given source ID
-func (nc *NameCache) getImageName(sid sous.SourceID) (string, strpairs, error) {
-	Log.Vomit.Printf("Getting image name for %+v", sid)
-	name, qualities, err := nc.getImageNameFromCache(sid)
+// GetImageName returns the docker image name for a given source ID and flavor
+func (nc *NameCache) getImageName(sid sous.SourceID, flavor string) (string, strpairs, error) {
+	Log.Vomit.Printf("Getting image name for %+v flavor %q", sid, flavor)
+	name, qualities, err := nc.getImageNameFromCache(sid, flavor)
 	defer func() { Log.Debug.Printf("SourceID: %q -> image name %s", sid, name) }()
 	if err == nil {
 		// We got it from the cache first time.
@@ -243,34 +300,37 @@ func (nc *NameCache) getImageName(sid sous.SourceID) (string, strpairs, error) {
 		return "", nil, errors.Wrapf(err, "getting name from cache of %s", nc.DockerRegistryHost)
 	}
 	// The error was a NoImageNameFound.
-	if name, qualities, err = nc.getImageNameAfterHarvest(sid); err != nil {
+	if name, qualities, err = nc.getImageNameAfterHarvest(sid, flavor); err != nil {
 		// Failed even after a harvest, give up.
 		return "", nil, errors.Wrapf(err, "getting image from cache after harvest from %s", nc.DockerRegistryHost)
 	}
 	return name, qualities, nil
 }
 
-func (nc *NameCache) getImageNameFromCache(sid sous.SourceID) (string, strpairs, error) {
-	cn, _, qls, err := nc.dbQueryOnSourceID(sid)
+func (nc *NameCache) getImageNameFromCache(sid sous.SourceID, flavor string) (string, strpairs, error) {
+	cn, _, qls, err := nc.dbQueryOnSourceID(sid, flavor)
 	return cn, qls, err
 }
 
-func (nc *NameCache) getImageNameAfterHarvest(sid sous.SourceID) (string, strpairs, error) {
+func (nc *NameCache) getImageNameAfterHarvest(sid sous.SourceID, flavor string) (string, strpairs, error) {
 	if err := nc.harvest(sid.Location); err != nil {
 		Log.Debug.Printf("getImageName: harvest error: %v", err)
 		return "", nil, err
 	}
-	return nc.getImageNameFromCache(sid)
+	return nc.getImageNameFromCache(sid, flavor)
 }
 
 func qualitiesFromLabels(lm map[string]string) []sous.Quality {
-	advs, ok := lm[`com.opentable.sous.advisories`]
-	if !ok {
-		return []sous.Quality{}
+	qs := []sous.Quality{}
+	if advs, ok := lm[DockerAdvisoryLabel]; ok {
+		for _, adv := range strings.Split(advs, `,`) {
+			qs = append(qs, sous.Quality{Name: adv, Kind: "advisory"})
+		}
 	}
-	var qs []sous.Quality
-	for _, adv := range strings.Split(advs, `,`) {
-		qs = append(qs, sous.Quality{Name: adv, Kind: "advisory"})
+	if p, ok := ProvenanceFromLabels(lm); ok {
+		if q, err := p.Quality(); err == nil {
+			qs = append(qs, q)
+		}
 	}
 	return qs
 }
@@ -285,7 +345,23 @@ func (nc *NameCache) GetCanonicalName(in string) (string, error) {
 // Insert puts a given SourceID/image name pair into the name cache
 // used by Builder at the moment to register after a build
 func (nc *NameCache) Insert(sid sous.SourceID, in, etag string, qs []sous.Quality) error {
-	return nc.dbInsert(sid, in, etag, qs)
+	flavor, qs := flavorFromQualities(qs)
+	return nc.dbInsert(sid, flavor, in, etag, qs)
+}
+
+// flavorFromQualities returns the flavor named by a sous.FlavorQualityKind
+// quality in qs, if any, and the rest of qs.
+func flavorFromQualities(qs []sous.Quality) (string, []sous.Quality) {
+	flavor := ""
+	rest := []sous.Quality{}
+	for _, q := range qs {
+		if q.Kind == sous.FlavorQualityKind {
+			flavor = q.Name
+			continue
+		}
+		rest = append(rest, q)
+	}
+	return flavor, rest
 }
 
 func (nc *NameCache) harvest(sl sous.SourceLocation) error {
@@ -341,67 +417,6 @@ type DBConfig struct {
 	Driver, Connection string
 }
 
-var schema = []string{
-	"pragma foreign_keys = ON;",
-
-	"create table _database_metadata_(" +
-		"name text not null unique on conflict replace" +
-		", value text" +
-		");",
-
-	"create table docker_repo_name(" +
-		"repo_name_id integer primary key autoincrement" +
-		", name text not null" +
-		", constraint upsertable unique (name)" +
-		");",
-
-	"create table docker_search_location(" +
-		"location_id integer primary key autoincrement" +
-		", repo text not null" +
-		", offset text not null" +
-		", constraint upsertable unique (repo, offset)" +
-		");",
-
-	"create table repo_through_location(" +
-		"repo_name_id references docker_repo_name" +
-		"    not null" +
-		", location_id references docker_search_location" +
-		"    not null" +
-		",  primary key (repo_name_id, location_id)" +
-		");",
-
-	"create table docker_search_metadata(" +
-		"metadata_id integer primary key autoincrement" +
-		", location_id references docker_search_location" +
-		"    not null" +
-		", etag text not null" +
-		", canonicalName text not null" +
-		", version text not null" +
-		", constraint upsertable unique (location_id, version)" +
-		", constraint canonical unique (canonicalName)" +
-		");",
-
-	"create table docker_search_name(" +
-		"name_id integer primary key autoincrement" +
-		", metadata_id references docker_search_metadata" +
-		"    on delete cascade not null" +
-		", name text not null unique" +
-		");",
-
-	// "qualities" includes advisories. assuming that assertions will also
-	// be represented here
-	"create table docker_image_qualities(" +
-		"assertion_id integer primary key autoincrement" +
-		", metadata_id references docker_search_metadata" +
-		"    not null" +
-		", quality text not null" +
-		", kind text not null" +
-		", constraint upsertable unique (metadata_id, quality, kind) on conflict ignore" +
-		");",
-}
-
-var schemaFingerprint = fingerPrintSchema(schema)
-
 var registerSQLOnce = &sync.Once{}
 
 // GetDatabase initialises a new database for a NameCache.
@@ -451,74 +466,6 @@ func semverEqual(a, b string) (bool, error) {
 	return aVer.Equals(bVer), nil
 }
 
-// GroomDatabase ensures that the database to back the cache is the correct schema
-func (nc *NameCache) GroomDatabase() error {
-	db := nc.DB
-	var tgp string
-	err := db.QueryRow("select value from _database_metadata_ where name = 'fingerprint';").Scan(&tgp)
-	if err != nil || tgp != schemaFingerprint {
-		//log.Println(err, tgp, schemaFingerprint)
-		err = nil
-		repos := captureRepos(db)
-
-		clobber(db)
-
-		for _, cmd := range schema {
-			if err := sqlExec(db, cmd); err != nil {
-				return errors.Wrapf(err, "groom DB/create: %v", db)
-			}
-		}
-		if _, err := db.Exec("insert into _database_metadata_ (name, value) values"+
-			" ('fingerprint', ?),"+
-			" ('created', ?);",
-			schemaFingerprint, time.Now().UTC().Format(time.UnixDate)); err != nil {
-			return errors.Wrapf(err, "groom DB/fp: %v", db)
-		}
-		for _, r := range repos {
-			if err := nc.Warmup(r); err != nil {
-				return errors.Wrap(err, "groom DB")
-			}
-		}
-	}
-
-	return errors.Wrap(err, "groom DB")
-}
-
-func clobber(db *sql.DB) {
-	Log.Debug.Print("DB Clobbering time!")
-	sqlExec(db, "PRAGMA writable_schema = 1;")
-	sqlExec(db, "delete from sqlite_master where type in ('table', 'index', 'trigger');")
-	sqlExec(db, "PRAGMA writable_schema = 0;")
-	sqlExec(db, "vacuum;")
-}
-
-func captureRepos(db *sql.DB) (repos []string) {
-	res, err := db.Query("select name from docker_repo_name;")
-	if err != nil {
-		Log.Debug.Print(err)
-		return
-	}
-	defer res.Close()
-	for res.Next() {
-		var repo string
-		res.Scan(&repo)
-		repos = append(repos, repo)
-	}
-	return
-}
-
-func fingerPrintSchema(schema []string) string {
-	h := sha256.New()
-	for i, s := range schema {
-		fmt.Fprintf(h, "%d:%s\n", i, s)
-	}
-	buf := &bytes.Buffer{}
-	b6 := base64.NewEncoder(base64.StdEncoding, buf)
-	b6.Write(h.Sum([]byte(``)))
-	b6.Close()
-	return buf.String()
-}
-
 func (nc *NameCache) dumpRows(io io.Writer, sql string) {
 	fmt.Fprintln(io, sql)
 	rows, err := nc.DB.Query(sql)
@@ -606,7 +553,7 @@ func (nc *NameCache) ensureInDB(sel, ins string, args ...interface{}) (id int64,
 	return id, errors.Wrapf(err, "getting id of new value: %q %v", ins, args[0:insN])
 }
 
-func (nc *NameCache) dbInsert(sid sous.SourceID, in, etag string, quals []sous.Quality) error {
+func (nc *NameCache) dbInsert(sid sous.SourceID, flavor, in, etag string, quals []sous.Quality) error {
 	ref, err := reference.ParseNamed(in)
 	Log.Debug.Printf("Parsed image name: %v from %q", ref, in)
 	if err != nil {
@@ -645,12 +592,12 @@ func (nc *NameCache) dbInsert(sid sous.SourceID, in, etag string, quals []sous.Q
 	}
 
 	versionString := sid.Version.Format(semv.Complete)
-	Log.Vomit.Printf("Inserting metadata id:%v etag:%v name:%v version:%v", id, etag, in, versionString)
+	Log.Vomit.Printf("Inserting metadata id:%v etag:%v name:%v version:%v flavor:%v", id, etag, in, versionString, flavor)
 
 	id, err = nc.ensureInDB(
 		"select metadata_id from docker_search_metadata  where canonicalName = $1",
-		"insert or replace into docker_search_metadata (canonicalName, location_id, etag, version) values ($1, $2, $3, $4);",
-		in, id, etag, versionString)
+		"insert or replace into docker_search_metadata (canonicalName, location_id, etag, version, flavor) values ($1, $2, $3, $4, $5);",
+		in, id, etag, versionString, flavor)
 
 	if err != nil {
 		return err
@@ -660,6 +607,11 @@ func (nc *NameCache) dbInsert(sid sous.SourceID, in, etag string, quals []sous.Q
 		if q.Kind == "advisory" && q.Name == "" {
 			continue
 		}
+		if q.Kind == sous.ProvenanceQualityKind {
+			// There is only ever one provenance attestation per image.
+			nc.DB.Exec("delete from docker_image_qualities where metadata_id = $1 and kind = $2",
+				id, sous.ProvenanceQualityKind)
+		}
 		nc.DB.Exec("insert into docker_image_qualities"+
 			"  (metadata_id, quality, kind)"+
 			"  values"+
@@ -701,6 +653,19 @@ func (nc *NameCache) dbAddNames(cn string, ins []string) error {
 	return nc.dbAddNamesForID(id, ins)
 }
 
+// dbPinCanonicalName makes pinned the canonical name of the image whose
+// canonical name was in, keeping in as one of its names.
+func (nc *NameCache) dbPinCanonicalName(in, pinned, etag string) error {
+	nc.Lock()
+	_, err := nc.DB.Exec("update docker_search_metadata set canonicalName = $1, etag = $2 "+
+		"where canonicalName = $3", pinned, etag, in)
+	nc.Unlock()
+	if err != nil {
+		return err
+	}
+	return nc.dbAddNames(pinned, []string{in, pinned})
+}
+
 func (nc *NameCache) dbQueryOnName(in string) (etag, repo, offset, version, cname string, err error) {
 	row := nc.DB.QueryRow("select "+
 		"docker_search_metadata.etag, "+
@@ -750,7 +715,7 @@ func (nc *NameCache) dbQueryOnSL(sl sous.SourceLocation) (rs []string, err error
 }
 
 func (nc *NameCache) dbQueryAllSourceIds() (ids []sous.SourceID, err error) {
-	rows, err := nc.DB.Query("select docker_search_location.repo, " +
+	rows, err := nc.DB.Query("select distinct docker_search_location.repo, " +
 		"docker_search_location.offset, " +
 		"docker_search_metadata.version " +
 		"from " +
@@ -777,8 +742,8 @@ func (nc *NameCache) dbQueryAllSourceIds() (ids []sous.SourceID, err error) {
 type strpairs []strpair
 type strpair [2]string
 
-func (nc *NameCache) dbQueryOnSourceID(sid sous.SourceID) (cn string, ins []string, quals strpairs, err error) {
-	cn, ins, err = nc.dbQueryCNameforSourceID(sid)
+func (nc *NameCache) dbQueryOnSourceID(sid sous.SourceID, flavor string) (cn string, ins []string, quals strpairs, err error) {
+	cn, ins, err = nc.dbQueryCNameforSourceID(sid, flavor)
 	if err != nil {
 		return
 	}
@@ -786,7 +751,7 @@ func (nc *NameCache) dbQueryOnSourceID(sid sous.SourceID) (cn string, ins []stri
 	return
 }
 
-func (nc *NameCache) dbQueryCNameforSourceID(sid sous.SourceID) (cn string, ins []string, err error) {
+func (nc *NameCache) dbQueryCNameforSourceID(sid sous.SourceID, flavor string) (cn string, ins []string, err error) {
 	rows, err := nc.DB.Query("select docker_search_metadata.canonicalName, "+
 		"docker_search_name.name "+
 		"from "+
@@ -795,10 +760,11 @@ func (nc *NameCache) dbQueryCNameforSourceID(sid sous.SourceID) (cn string, ins
 		"where "+
 		"docker_search_location.repo = $1 and "+
 		"docker_search_location.offset = $2 and "+
-		"semverEqual(docker_search_metadata.version, $3)",
-		sid.Location.Repo, sid.Location.Dir, sid.Version.String())
+		"semverEqual(docker_search_metadata.version, $3) and "+
+		"docker_search_metadata.flavor = $4",
+		sid.Location.Repo, sid.Location.Dir, sid.Version.String(), flavor)
 
-	Log.Vomit.Printf("Selecting on %q %q %q", sid.Location.Repo, sid.Location.Dir, sid.Version.String())
+	Log.Vomit.Printf("Selecting on %q %q %q %q", sid.Location.Repo, sid.Location.Dir, sid.Version.String(), flavor)
 
 	if err == sql.ErrNoRows {
 		err = errors.Wrap(NoImageNameFound{sid}, "")
@@ -29,7 +29,7 @@ func inMemoryDB(name string) *sql.DB {
 func BenchmarkFPSchema(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		fingerPrintSchema(schema)
+		fingerPrintSchema(legacySchema)
 	}
 }
 
@@ -85,7 +85,8 @@ func TestReharvest(t *testing.T) {
 	host := "docker.repo.io"
 	base := "ot/wackadoo"
 
-	nc := NewNameCache(host, dc, inMemoryDB("reharvest"))
+	nc, err := NewNameCache(host, dc, inMemoryDB("reharvest"))
+	require.NoError(t, err)
 
 	vstr := "1.2.3"
 	sv := sous.MustNewSourceID("https://github.com/opentable/wackadoo", "nested/there", vstr)
@@ -106,7 +107,8 @@ func TestReharvest(t *testing.T) {
 	}
 	nc.dump(os.Stderr)
 
-	nc.DB.Exec("update _database_metadata_ set value='' where name='fingerprint'")
+	// Make the schema unrecognisable, so that the cache is rebuilt.
+	nc.DB.Exec("delete from _database_metadata_ where name='schema_version'")
 
 	dc.FeedTags([]string{"version" + vstr})
 	dc.FeedMetadata(docker_registry.Metadata{
@@ -131,7 +133,8 @@ func TestHarvestGuessedRepo(t *testing.T) {
 	dc := docker_registry.NewDummyClient()
 
 	host := "docker.repo.io"
-	nc := NewNameCache(host, dc, inMemoryDB("guessed_repo"))
+	nc, err := NewNameCache(host, dc, inMemoryDB("guessed_repo"))
+	require.NoError(t, err)
 
 	sl := sous.SourceLocation{
 		Repo: "https://github.com/opentable/wackadoo",
@@ -152,20 +155,21 @@ func TestRoundTrip(t *testing.T) {
 	host := "docker.repo.io"
 	base := "ot/wackadoo"
 
-	nc := NewNameCache(host, dc, inMemoryDB("roundtrip"))
+	nc, err := NewNameCache(host, dc, inMemoryDB("roundtrip"))
+	require.NoError(t, err)
 
 	sv := sous.MustNewSourceID("https://github.com/opentable/wackadoo", "nested/there", "1.2.3")
 
 	in := base + ":version-1.2.3"
 	digest := "sha256:012345678901234567890123456789AB012345678901234567890123456789AB"
-	err := nc.Insert(sv, in, digest, []sous.Quality{})
+	err = nc.Insert(sv, in, digest, []sous.Quality{})
 	assert.NoError(err)
 
 	cn, err := nc.GetCanonicalName(in)
 	if assert.NoError(err) {
 		assert.Equal(in, cn)
 	}
-	nin, _, err := nc.getImageName(sv)
+	nin, _, err := nc.getImageName(sv, "")
 	if assert.NoError(err) {
 		assert.Equal(in, nin)
 	}
@@ -200,7 +204,8 @@ func TestCanonicalizesToConfiguredRegistry(t *testing.T) {
 	dockerCache := "nearby-docker-cache.repo.io"
 	base := "ot/wackadoo"
 
-	nc := NewNameCache(dockerCache, dc, inMemoryDB("canonsucceeds"))
+	nc, err := NewNameCache(dockerCache, dc, inMemoryDB("canonsucceeds"))
+	require.NoError(t, err)
 
 	in := base + ":version-1.2.3"
 	digest := "sha256:012345678901234567890123456789AB012345678901234567890123456789AB"
@@ -258,7 +263,8 @@ func TestLeavesRegistryUnchangedWhenUnknown(t *testing.T) {
 	dockerCache := "nearby-docker-cache.repo.io"
 	base := "ot/wackadoo"
 
-	nc := NewNameCache(dockerCache, dc, inMemoryDB("canonsucceeds"))
+	nc, err := NewNameCache(dockerCache, dc, inMemoryDB("canonsucceeds"))
+	require.NoError(t, err)
 
 	in := base + ":version-1.2.3"
 	digest := "sha256:012345678901234567890123456789AB012345678901234567890123456789AB"
@@ -304,7 +310,8 @@ func TestHarvestAlso(t *testing.T) {
 	base := "ot/wackadoo"
 	repo := "github.com/opentable/test-app"
 
-	nc := NewNameCache(host, dc, inMemoryDB("harvest_also"))
+	nc, err := NewNameCache(host, dc, inMemoryDB("harvest_also"))
+	require.NoError(t, err)
 
 	stuffBA := func(n, v string) sous.SourceID {
 		ba := &sous.BuildArtifact{
@@ -335,7 +342,7 @@ func TestHarvestAlso(t *testing.T) {
 	sid2 := stuffBA("dick", "0.2.2")
 	sid3 := stuffBA("harry", "0.2.3")
 
-	_, err := nc.GetArtifact(sid1) //which should not miss
+	_, err = nc.GetArtifact(sid1) //which should not miss
 	assert.NoError(err)
 	_, err = nc.GetArtifact(sid2) //which should not miss
 	assert.NoError(err)
@@ -351,7 +358,8 @@ func TestSecondCanonicalName(t *testing.T) {
 
 	host := "docker.repo.io"
 	base := "ot/wackadoo"
-	nc := NewNameCache(host, dc, inMemoryDB("secondCN"))
+	nc, err := NewNameCache(host, dc, inMemoryDB("secondCN"))
+	require.NoError(t, err)
 
 	repo := "github.com/opentable/test-app"
 
@@ -386,7 +394,7 @@ func TestSecondCanonicalName(t *testing.T) {
 	sid1 := stuffBA(`012345678901234567890123456789AB012345678901234567890123456789AB`)
 	sid2 := stuffBA(`ABCDEFABCDEFABCDEABCDEABCDEABCDEABCDEABCDEABCDEABCDEF12341234566`)
 
-	_, err := nc.GetArtifact(sid1) //which should not miss
+	_, err = nc.GetArtifact(sid1) //which should not miss
 	assert.NoError(err)
 
 	_, err = nc.GetArtifact(sid2) //which should not miss
@@ -399,7 +407,8 @@ func TestHarvesting(t *testing.T) {
 
 	host := "docker.repo.io"
 	base := "ot/wackadoo"
-	nc := NewNameCache(host, dc, inMemoryDB("harvesting"))
+	nc, err := NewNameCache(host, dc, inMemoryDB("harvesting"))
+	require.NoError(t, err)
 
 	v := "1.2.3"
 	sv := sous.MustNewSourceID("https://github.com/opentable/wackadoo", "nested/there", v)
@@ -421,7 +430,7 @@ func TestHarvesting(t *testing.T) {
 	})
 
 	// a la a SetCollector getting the SV
-	_, err := nc.GetSourceID(NewBuildArtifact(in, nil))
+	_, err = nc.GetSourceID(NewBuildArtifact(in, nil))
 	if err != nil {
 		fmt.Printf("%+v", err)
 	}
@@ -456,7 +465,8 @@ func TestRecordAdvisories(t *testing.T) {
 	dc := docker_registry.NewDummyClient()
 	host := "docker.repo.io"
 	base := "ot/wackadoo"
-	nc := NewNameCache(host, dc, inMemoryDB("advisories"))
+	nc, err := NewNameCache(host, dc, inMemoryDB("advisories"))
+	require.NoError(err)
 	v := "1.2.3"
 	sv := sous.MustNewSourceID("https://github.com/opentable/wackadoo", "nested/there", v)
 	digest := "sha256:012345678901234567890123456789AB012345678901234567890123456789AB"
@@ -464,7 +474,7 @@ func TestRecordAdvisories(t *testing.T) {
 
 	qs := []sous.Quality{{Name: "ephemeral_tag", Kind: "advisory"}}
 
-	err := nc.Insert(sv, cn, digest, qs)
+	err = nc.Insert(sv, cn, digest, qs)
 	assert.NoError(err)
 
 	arty, err := nc.GetArtifact(sv)
@@ -480,7 +490,8 @@ func TestDump(t *testing.T) {
 	io := &bytes.Buffer{}
 
 	dc := docker_registry.NewDummyClient()
-	nc := NewNameCache("", dc, inMemoryDB("dump"))
+	nc, err := NewNameCache("", dc, inMemoryDB("dump"))
+	require.NoError(t, err)
 
 	nc.dump(io)
 	assert.Regexp(`name_id`, io.String())
@@ -489,12 +500,13 @@ func TestDump(t *testing.T) {
 func TestMissingName(t *testing.T) {
 	assert := assert.New(t)
 	dc := docker_registry.NewDummyClient()
-	nc := NewNameCache("", dc, inMemoryDB("missing"))
+	nc, err := NewNameCache("", dc, inMemoryDB("missing"))
+	require.NoError(t, err)
 
 	v := "4.5.6"
 	sv := sous.MustNewSourceID("https://github.com/opentable/brand-new-idea", "nested/there", v)
 
-	name, _, err := nc.getImageName(sv)
+	name, _, err := nc.getImageName(sv, "")
 	assert.Equal("", name)
 	assert.Error(err)
 }
@@ -512,3 +524,91 @@ func TestUnion(t *testing.T) {
 	assert.Contains(all, "c")
 	assert.Contains(all, "d")
 }
+
+func TestFlavoredArtifacts(t *testing.T) {
+	assert := assert.New(t)
+	dc := docker_registry.NewDummyClient()
+	host := "docker.repo.io"
+	nc, err := NewNameCache(host, dc, inMemoryDB("flavors"))
+	require.NoError(t, err)
+
+	sv := sous.MustNewSourceID("https://github.com/opentable/wackadoo", "nested/there", "1.2.3")
+	digest := "@sha256:012345678901234567890123456789ab012345678901234567890123456789ab"
+	worker := []sous.Quality{{Name: "worker", Kind: sous.FlavorQualityKind}}
+	dc.MatchMethod("AllTags", spies.AnyArgs, []string{}, nil)
+
+	assert.NoError(nc.Insert(sv, host+"/ot/wackadoo"+digest, "", nil))
+	assert.NoError(nc.Insert(sv, host+"/ot/wackadoo-worker"+digest, "", worker))
+
+	art, err := nc.GetArtifact(sv)
+	if assert.NoError(err) {
+		assert.Equal(host+"/ot/wackadoo"+digest, art.Name)
+	}
+	art, err = nc.GetFlavoredArtifact(sv, "worker")
+	if assert.NoError(err) {
+		assert.Equal(host+"/ot/wackadoo-worker"+digest, art.Name)
+		assert.Empty(art.Qualities, "the flavor is not a quality of the artifact")
+	}
+	_, err = nc.GetFlavoredArtifact(sv, "web")
+	assert.IsType(&sous.ArtifactNotFoundError{}, err)
+
+	ids, err := nc.ListSourceIDs()
+	if assert.NoError(err) {
+		assert.Len(ids, 1)
+		assert.Equal("nested/there", ids[0].Location.Dir, "the flavor is not part of the source ID")
+	}
+}
+
+func TestGetArtifactPinsDigest(t *testing.T) {
+	assert := assert.New(t)
+	dc := docker_registry.NewDummyClient()
+	host := "docker.repo.io"
+	nc, err := NewNameCache(host, dc, inMemoryDB("pins"))
+	require.NoError(t, err)
+
+	sid := sous.MustNewSourceID("https://github.com/opentable/wackadoo", "nested/there", "1.2.3")
+	tagged := host + "/ot/wackadoo:1.2.3"
+	digest := "sha256:012345678901234567890123456789ab012345678901234567890123456789ab"
+	pinned := host + "/ot/wackadoo@" + digest
+	qs := []sous.Quality{{Name: "ephemeral_tag", Kind: "advisory"}}
+
+	dc.AddMetadata(`wackadoo:1\.2\.3`, docker_registry.Metadata{CanonicalName: "ot/wackadoo@" + digest, Etag: digest})
+	assert.NoError(nc.Insert(sid, tagged, "", qs))
+
+	art, err := nc.GetArtifact(sid)
+	if assert.NoError(err) {
+		assert.Equal(pinned, art.Name)
+		assert.Len(art.Qualities, 1)
+	}
+	assert.Len(dc.CallsTo("GetImageMetadata"), 1)
+
+	// The digest is recorded, so the registry is not asked again, and the tag
+	// still names the same image.
+	art, err = nc.GetArtifact(sid)
+	if assert.NoError(err) {
+		assert.Equal(pinned, art.Name)
+	}
+	assert.Len(dc.CallsTo("GetImageMetadata"), 1)
+	cn, err := nc.GetCanonicalName(tagged)
+	if assert.NoError(err) {
+		assert.Equal(pinned, cn)
+	}
+}
+
+func TestGetArtifactUnpinnedWhenRegistryFails(t *testing.T) {
+	assert := assert.New(t)
+	dc := docker_registry.NewDummyClient()
+	host := "docker.repo.io"
+	nc, err := NewNameCache(host, dc, inMemoryDB("unpinned"))
+	require.NoError(t, err)
+
+	sid := sous.MustNewSourceID("https://github.com/opentable/wackadoo", "nested/there", "1.2.3")
+	tagged := host + "/ot/wackadoo:1.2.3"
+	dc.MatchMethod("GetImageMetadata", spies.AnyArgs, docker_registry.Metadata{}, errors.Errorf("no such MD"))
+	assert.NoError(nc.Insert(sid, tagged, "", nil))
+
+	art, err := nc.GetArtifact(sid)
+	if assert.NoError(err) {
+		assert.Equal(tagged, art.Name)
+	}
+}
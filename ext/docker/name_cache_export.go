@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+type (
+	// A NameCacheExport is the content of a NameCache, in a form that can be
+	// moved between servers.
+	NameCacheExport struct {
+		// SchemaVersion is the schema version of the exported cache.
+		SchemaVersion int
+		// Images are the images the cache knows about.
+		Images []ExportedImage
+	}
+
+	// An ExportedImage is a single image known to a NameCache.
+	ExportedImage struct {
+		Repo, Offset, Version string
+		// Flavor is the flavor the image was built for, if any.
+		Flavor string
+		// CanonicalName is the digested name of the image.
+		CanonicalName string
+		Etag          string
+		// Names are all the names the image is known by.
+		Names     []string
+		Qualities []sous.Quality
+	}
+)
+
+// Export returns everything the cache knows about the images it has seen,
+// including those inserted directly, which can't be harvested again from the
+// registry.
+func (nc *NameCache) Export() (*NameCacheExport, error) {
+	rows, err := nc.DB.Query("select" +
+		" docker_search_metadata.metadata_id," +
+		" docker_search_location.repo," +
+		" docker_search_location.offset," +
+		" docker_search_metadata.version," +
+		" docker_search_metadata.flavor," +
+		" docker_search_metadata.canonicalName," +
+		" docker_search_metadata.etag" +
+		" from" +
+		" docker_search_metadata natural join docker_search_location" +
+		" order by docker_search_metadata.metadata_id")
+	if err != nil {
+		return nil, errors.Wrap(err, "exporting name cache")
+	}
+	ids := []int64{}
+	images := []ExportedImage{}
+	for rows.Next() {
+		var id int64
+		var im ExportedImage
+		if err := rows.Scan(&id, &im.Repo, &im.Offset, &im.Version, &im.Flavor, &im.CanonicalName, &im.Etag); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, "exporting name cache")
+		}
+		ids = append(ids, id)
+		images = append(images, im)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "exporting name cache")
+	}
+
+	for i, id := range ids {
+		if images[i].Names, err = nc.dbQueryNamesForID(id); err != nil {
+			return nil, errors.Wrapf(err, "exporting names of %s", images[i].CanonicalName)
+		}
+		quals, err := nc.dbQueryQualsForCName(images[i].CanonicalName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "exporting qualities of %s", images[i].CanonicalName)
+		}
+		images[i].Qualities = []sous.Quality{}
+		for _, q := range quals {
+			images[i].Qualities = append(images[i].Qualities, sous.Quality{Name: q[0], Kind: q[1]})
+		}
+	}
+	return &NameCacheExport{SchemaVersion: SchemaVersion(), Images: images}, nil
+}
+
+// Import adds every image in ex to the cache. Images the cache already knows
+// keep their etags, and gain any names and qualities they have in ex.
+func (nc *NameCache) Import(ex *NameCacheExport) error {
+	if ex.SchemaVersion > SchemaVersion() {
+		return errors.Errorf("cannot import name cache schema version %d into version %d", ex.SchemaVersion, SchemaVersion())
+	}
+	for _, im := range ex.Images {
+		sid, err := sous.NewSourceID(im.Repo, im.Offset, im.Version)
+		if err != nil {
+			return errors.Wrapf(err, "importing %s", im.CanonicalName)
+		}
+		if err := nc.dbInsert(sid, im.Flavor, im.CanonicalName, im.Etag, im.Qualities); err != nil {
+			return errors.Wrapf(err, "importing %s", im.CanonicalName)
+		}
+		if err := nc.dbAddNames(im.CanonicalName, im.Names); err != nil {
+			return errors.Wrapf(err, "importing names of %s", im.CanonicalName)
+		}
+	}
+	return nil
+}
+
+func (nc *NameCache) dbQueryNamesForID(id int64) ([]string, error) {
+	rows, err := nc.DB.Query("select name from docker_search_name where metadata_id = $1 order by name", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := []string{}
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	return names, rows.Err()
+}
@@ -0,0 +1,363 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	sqlite "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+type (
+	// A migration changes the name cache schema from one version to the next.
+	migration struct {
+		description string
+		statements  []string
+	}
+)
+
+// unknownSchema is the schema version of a database whose schema was not
+// created by any migration.
+const unknownSchema = -1
+
+// migrations bring the name cache database from each schema version to the
+// next: a database at version n has had the first n migrations applied.
+// Migrations are forward-only, and must never be changed once released; to
+// change the schema, append a new one.
+var migrations = []migration{
+	{
+		description: "initial schema",
+		statements: []string{
+			"create table _database_metadata_(" +
+				"name text not null unique on conflict replace" +
+				", value text" +
+				");",
+
+			"create table docker_repo_name(" +
+				"repo_name_id integer primary key autoincrement" +
+				", name text not null" +
+				", constraint upsertable unique (name)" +
+				");",
+
+			"create table docker_search_location(" +
+				"location_id integer primary key autoincrement" +
+				", repo text not null" +
+				", offset text not null" +
+				", constraint upsertable unique (repo, offset)" +
+				");",
+
+			"create table repo_through_location(" +
+				"repo_name_id references docker_repo_name" +
+				"    not null" +
+				", location_id references docker_search_location" +
+				"    not null" +
+				",  primary key (repo_name_id, location_id)" +
+				");",
+
+			"create table docker_search_metadata(" +
+				"metadata_id integer primary key autoincrement" +
+				", location_id references docker_search_location" +
+				"    not null" +
+				", etag text not null" +
+				", canonicalName text not null" +
+				", version text not null" +
+				", constraint upsertable unique (location_id, version)" +
+				", constraint canonical unique (canonicalName)" +
+				");",
+
+			"create table docker_search_name(" +
+				"name_id integer primary key autoincrement" +
+				", metadata_id references docker_search_metadata" +
+				"    on delete cascade not null" +
+				", name text not null unique" +
+				");",
+
+			// "qualities" includes advisories. assuming that assertions will also
+			// be represented here
+			"create table docker_image_qualities(" +
+				"assertion_id integer primary key autoincrement" +
+				", metadata_id references docker_search_metadata" +
+				"    not null" +
+				", quality text not null" +
+				", kind text not null" +
+				", constraint upsertable unique (metadata_id, quality, kind) on conflict ignore" +
+				");",
+		},
+	},
+	{
+		// SQLite can't change a table's constraints, so the table is rebuilt.
+		description: "key images by flavor as well as source ID",
+		statements: []string{
+			"create table docker_search_metadata_flavored(" +
+				"metadata_id integer primary key autoincrement" +
+				", location_id references docker_search_location" +
+				"    not null" +
+				", etag text not null" +
+				", canonicalName text not null" +
+				", version text not null" +
+				", flavor text not null default ''" +
+				", constraint upsertable unique (location_id, version, flavor)" +
+				", constraint canonical unique (canonicalName)" +
+				");",
+
+			"insert into docker_search_metadata_flavored" +
+				" (metadata_id, location_id, etag, canonicalName, version)" +
+				" select metadata_id, location_id, etag, canonicalName, version" +
+				" from docker_search_metadata;",
+
+			"drop table docker_search_metadata;",
+
+			"alter table docker_search_metadata_flavored rename to docker_search_metadata;",
+		},
+	},
+}
+
+// legacySchema is the schema created by versions of Sous which recorded only
+// a fingerprint of it, and rebuilt the database whenever that changed. A
+// database with its fingerprint is at schema version 1.
+var legacySchema = append([]string{"pragma foreign_keys = ON;"}, migrations[0].statements...)
+
+var legacySchemaFingerprint = fingerPrintSchema(legacySchema)
+
+// SchemaVersion is the name cache schema version this Sous creates.
+func SchemaVersion() int {
+	return len(migrations)
+}
+
+// GroomDatabase ensures that the database to back the cache is the correct
+// schema, by applying in turn each migration it has not had yet. A database
+// on disk is backed up before it is migrated. A database Sous does not
+// recognise is rebuilt, and the repos it knew about are harvested again.
+func (nc *NameCache) GroomDatabase() error {
+	db := nc.DB
+	version, err := schemaVersion(db)
+	if err != nil {
+		return errors.Wrap(err, "groom DB")
+	}
+	if version > len(migrations) {
+		return errors.Errorf("groom DB: schema version %d is newer than this Sous understands (%d)", version, len(migrations))
+	}
+	if version == len(migrations) {
+		return nil
+	}
+
+	if version != 0 {
+		backup, err := backupDatabase(db, version)
+		if err != nil {
+			return errors.Wrap(err, "groom DB/backup")
+		}
+		if backup != "" {
+			Log.Info.Printf("Backed up name cache schema version %d to %s", version, backup)
+		}
+	}
+
+	var repos []string
+	if version == unknownSchema {
+		Log.Warn.Printf("Name cache schema not recognised: rebuilding it")
+		repos = captureRepos(db)
+		clobber(db)
+		version = 0
+	}
+
+	if err := sqlExec(db, "pragma foreign_keys = ON;"); err != nil {
+		return errors.Wrap(err, "groom DB")
+	}
+	for ; version < len(migrations); version++ {
+		if err := migrate(db, version+1, migrations[version]); err != nil {
+			return errors.Wrapf(err, "groom DB/migrate to version %d", version+1)
+		}
+	}
+
+	for _, r := range repos {
+		if err := nc.Warmup(r); err != nil {
+			return errors.Wrap(err, "groom DB")
+		}
+	}
+	return nil
+}
+
+// schemaVersion returns the schema version of db: 0 if it is empty, or
+// unknownSchema if Sous did not create it.
+func schemaVersion(db *sql.DB) (int, error) {
+	var tables int
+	if err := db.QueryRow("select count(*) from sqlite_master where type = 'table';").Scan(&tables); err != nil {
+		return 0, err
+	}
+	if tables == 0 {
+		return 0, nil
+	}
+
+	var value string
+	err := db.QueryRow("select value from _database_metadata_ where name = 'schema_version';").Scan(&value)
+	if err == nil {
+		version, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, errors.Wrapf(err, "parsing schema version %q", value)
+		}
+		return version, nil
+	}
+
+	err = db.QueryRow("select value from _database_metadata_ where name = 'fingerprint';").Scan(&value)
+	if err == nil && value == legacySchemaFingerprint {
+		return 1, nil
+	}
+	return unknownSchema, nil
+}
+
+// migrate applies m to db in a single transaction, and records that db is
+// then at version. Foreign keys are not enforced until the migration is
+// complete, so that tables can be rebuilt; they are checked before it is
+// committed.
+func migrate(db *sql.DB, version int, m migration) error {
+	Log.Debug.Printf("Migrating name cache to schema version %d: %s", version, m.description)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// foreign_keys can't be changed inside a transaction.
+	if _, err := conn.ExecContext(ctx, "pragma foreign_keys = OFF;"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "pragma foreign_keys = ON;")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, cmd := range m.statements {
+		if _, err := tx.Exec(cmd); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Error: %s in SQL: %s", err, cmd)
+		}
+	}
+	if _, err := tx.Exec("insert into _database_metadata_ (name, value) values"+
+		" ('schema_version', ?),"+
+		" ('migrated', ?);",
+		strconv.Itoa(version), time.Now().UTC().Format(time.UnixDate)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := checkForeignKeys(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkForeignKeys returns an error if any row refers to a row that doesn't
+// exist.
+func checkForeignKeys(tx *sql.Tx) error {
+	rows, err := tx.Query("pragma foreign_key_check;")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fkid int
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return err
+		}
+		return errors.Errorf("row %d of %s refers to a missing row of %s", rowid.Int64, table, parent)
+	}
+	return rows.Err()
+}
+
+// backupDatabase copies db to a file beside it, named for its schema version,
+// and returns the name of that file. In-memory databases are not backed up,
+// and "" is returned for them.
+func backupDatabase(db *sql.DB, version int) (string, error) {
+	var seq int
+	var name, file string
+	if err := db.QueryRow("pragma database_list;").Scan(&seq, &name, &file); err != nil {
+		return "", err
+	}
+	if file == "" {
+		return "", nil
+	}
+	v := strconv.Itoa(version)
+	if version == unknownSchema {
+		v = "unknown"
+	}
+	backup := fmt.Sprintf("%s.v%s-%s.bak", file, v, time.Now().UTC().Format("20060102T150405Z"))
+	if err := copyDatabase(file, backup); err != nil {
+		os.Remove(backup)
+		return "", errors.Wrapf(err, "backing up %s to %s", file, backup)
+	}
+	return backup, nil
+}
+
+// copyDatabase copies the database in the file from to the file to, using
+// SQLite's online backup API, so that the copy is consistent even if the
+// database is in use.
+func copyDatabase(from, to string) error {
+	driver := &sqlite.SQLiteDriver{}
+	src, err := driver.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dest, err := driver.Open(to)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	b, err := dest.(*sqlite.SQLiteConn).Backup("main", src.(*sqlite.SQLiteConn), "main")
+	if err != nil {
+		return err
+	}
+	done, err := b.Step(-1)
+	if ferr := b.Finish(); err == nil {
+		err = ferr
+	}
+	if err == nil && !done {
+		err = errors.New("database is locked")
+	}
+	return err
+}
+
+func clobber(db *sql.DB) {
+	Log.Debug.Print("DB Clobbering time!")
+	sqlExec(db, "PRAGMA writable_schema = 1;")
+	sqlExec(db, "delete from sqlite_master where type in ('table', 'index', 'trigger');")
+	sqlExec(db, "PRAGMA writable_schema = 0;")
+	sqlExec(db, "vacuum;")
+}
+
+func captureRepos(db *sql.DB) (repos []string) {
+	res, err := db.Query("select name from docker_repo_name;")
+	if err != nil {
+		Log.Debug.Print(err)
+		return
+	}
+	defer res.Close()
+	for res.Next() {
+		var repo string
+		res.Scan(&repo)
+		repos = append(repos, repo)
+	}
+	return
+}
+
+func fingerPrintSchema(schema []string) string {
+	h := sha256.New()
+	for i, s := range schema {
+		fmt.Fprintf(h, "%d:%s\n", i, s)
+	}
+	buf := &bytes.Buffer{}
+	b6 := base64.NewEncoder(base64.StdEncoding, buf)
+	b6.Write(h.Sum([]byte(``)))
+	b6.Close()
+	return buf.String()
+}
@@ -0,0 +1,130 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/docker_registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyDB creates a database the way Sous did before schema migrations.
+func legacyDB(t *testing.T, name string) {
+	db := inMemoryDB(name)
+	for _, cmd := range legacySchema {
+		require.NoError(t, sqlExec(db, cmd))
+	}
+	_, err := db.Exec("insert into _database_metadata_ (name, value) values ('fingerprint', ?);", legacySchemaFingerprint)
+	require.NoError(t, err)
+	_, err = db.Exec("insert into docker_search_location (repo, offset) values ('github.com/example/app', '');")
+	require.NoError(t, err)
+	_, err = db.Exec("insert into docker_search_metadata (location_id, etag, canonicalName, version) values (1, 'etag', 'docker.example.com/app@sha256:1', '1.0.0');")
+	require.NoError(t, err)
+	_, err = db.Exec("insert into docker_search_name (metadata_id, name) values (1, 'docker.example.com/app@sha256:1');")
+	require.NoError(t, err)
+}
+
+func TestGroomDatabase_AdoptsLegacySchema(t *testing.T) {
+	legacyDB(t, "legacy_schema")
+	dc := docker_registry.NewDummyClient()
+	nc, err := NewNameCache("docker.example.com", dc, inMemoryDB("legacy_schema"))
+	require.NoError(t, err)
+
+	version, err := schemaVersion(nc.DB)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion(), version)
+
+	ids, err := nc.ListSourceIDs()
+	require.NoError(t, err)
+	assert.Len(t, ids, 1, "the legacy data is kept")
+	name, _, err := nc.getImageNameFromCache(ids[0], "")
+	require.NoError(t, err)
+	assert.Equal(t, "docker.example.com/app@sha256:1", name, "images keep their names when migrated")
+	assert.Len(t, dc.CallsTo("AllTags"), 0, "nothing is harvested again")
+}
+
+func TestGroomDatabase_RefusesNewerSchema(t *testing.T) {
+	nc, err := NewNameCache("docker.example.com", docker_registry.NewDummyClient(), inMemoryDB("newer_schema"))
+	require.NoError(t, err)
+	_, err = nc.DB.Exec("insert into _database_metadata_ (name, value) values ('schema_version', '999');")
+	require.NoError(t, err)
+	assert.Error(t, nc.GroomDatabase())
+
+	version, err := schemaVersion(nc.DB)
+	require.NoError(t, err)
+	assert.Equal(t, 999, version)
+}
+
+func TestGroomDatabase_BacksUpBeforeMigrating(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-namecache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "cache.db")
+
+	db, err := GetDatabase(&DBConfig{"sqlite3_sous", file})
+	require.NoError(t, err)
+	for _, cmd := range legacySchema {
+		require.NoError(t, sqlExec(db, cmd))
+	}
+	_, err = db.Exec("insert into _database_metadata_ (name, value) values ('fingerprint', 'not a fingerprint Sous knows');")
+	require.NoError(t, err)
+
+	nc, err := NewNameCache("docker.example.com", docker_registry.NewDummyClient(), db)
+	require.NoError(t, err)
+	version, err := schemaVersion(nc.DB)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion(), version)
+
+	backups, err := filepath.Glob(file + ".vunknown-*.bak")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+
+	backup, err := GetDatabase(&DBConfig{"sqlite3_sous", backups[0]})
+	require.NoError(t, err)
+	defer backup.Close()
+	var fingerprint string
+	require.NoError(t, backup.QueryRow("select value from _database_metadata_ where name = 'fingerprint';").Scan(&fingerprint))
+	assert.Equal(t, "not a fingerprint Sous knows", fingerprint)
+}
+
+func TestCopyDatabase_Fails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-namecache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "cache.db")
+	db, err := GetDatabase(&DBConfig{"sqlite3_sous", file})
+	require.NoError(t, err)
+	require.NoError(t, sqlExec(db, "create table t (x text);"))
+
+	assert.Error(t, copyDatabase(file, filepath.Join(dir, "missing", "cache.db.bak")))
+}
+
+func TestNameCache_ExportImport(t *testing.T) {
+	from, err := NewNameCache("docker.example.com", docker_registry.NewDummyClient(), inMemoryDB("export_from"))
+	require.NoError(t, err)
+	sid := sous.MustNewSourceID("github.com/example/app", "", "1.2.3")
+	cn := "docker.example.com/app@sha256:012345678901234567890123456789AB012345678901234567890123456789AB"
+	require.NoError(t, from.Insert(sid, cn, "etag", []sous.Quality{{Name: "ephemeral_tag", Kind: "advisory"}}))
+	require.NoError(t, from.dbAddNames(cn, []string{"docker.example.com/app:1.2.3"}))
+
+	ex, err := from.Export()
+	require.NoError(t, err)
+	require.Len(t, ex.Images, 1)
+	assert.Equal(t, []string{"docker.example.com/app:1.2.3", cn}, ex.Images[0].Names)
+
+	to, err := NewNameCache("docker.example.com", docker_registry.NewDummyClient(), inMemoryDB("export_to"))
+	require.NoError(t, err)
+	require.NoError(t, to.Import(ex))
+	again, err := to.Export()
+	require.NoError(t, err)
+	assert.Equal(t, ex, again)
+
+	name, quals, err := to.getImageNameFromCache(sid, "")
+	require.NoError(t, err)
+	assert.Equal(t, cn, name)
+	assert.Equal(t, strpairs{{"ephemeral_tag", "advisory"}}, quals)
+}
@@ -0,0 +1,86 @@
+package docker
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+// NodeBuildpack builds Node.js projects without requiring a Dockerfile.
+// Dependencies are installed and the project built in a node image, and the
+// result, pruned of development dependencies, copied into an alpine node
+// image which runs "npm start".
+type NodeBuildpack struct{}
+
+// nodeDetectData is passed from the detect step to the build step as the
+// Data field of the DetectResult.
+type nodeDetectData struct {
+	// NodeVersion is used to select the node image tag.
+	NodeVersion string
+	// InstallCommand installs the project's dependencies.
+	InstallCommand string
+	// HasBuildScript is true if package.json defines a "build" script.
+	HasBuildScript bool
+	// HasYarnLock is true if the project has a yarn.lock.
+	HasYarnLock bool
+}
+
+// DefaultNodeVersion is the node image tag used when package.json does not
+// declare a usable engines.node version.
+const DefaultNodeVersion = "lts"
+
+var nodeMajorPattern = regexp.MustCompile(`^[\^~>=v\s]*(\d+)`)
+
+type packageJSON struct {
+	Engines struct {
+		Node string `json:"node"`
+	} `json:"engines"`
+	Scripts map[string]string `json:"scripts"`
+}
+
+// NewNodeBuildpack creates a Node.js buildpack.
+func NewNodeBuildpack() *NodeBuildpack {
+	return &NodeBuildpack{}
+}
+
+// Detect implements Buildpack.Detect. It is compatible with projects that
+// have a package.json.
+func (*NodeBuildpack) Detect(c *sous.BuildContext) (*sous.DetectResult, error) {
+	pj, err := readSourceFile(c, "package.json")
+	if err != nil {
+		return nil, err
+	}
+	pkg := packageJSON{}
+	if err := json.Unmarshal([]byte(pj), &pkg); err != nil {
+		return nil, errors.Wrap(err, "parsing package.json")
+	}
+
+	data := nodeDetectData{
+		NodeVersion:    DefaultNodeVersion,
+		InstallCommand: "npm install",
+		HasBuildScript: pkg.Scripts["build"] != "",
+	}
+	if m := nodeMajorPattern.FindStringSubmatch(firstField(pkg.Engines.Node)); m != nil {
+		data.NodeVersion = m[1]
+	}
+	switch {
+	case sourceFileExists(c, "yarn.lock"):
+		data.HasYarnLock = true
+		data.InstallCommand = "yarn install --frozen-lockfile"
+	case sourceFileExists(c, "package-lock.json"):
+		data.InstallCommand = "npm ci"
+	}
+	sous.Log.Debug.Printf("Detected Node.js project, node version %s, install with %q", data.NodeVersion, data.InstallCommand)
+	return &sous.DetectResult{Compatible: true, Data: data}, nil
+}
+
+// Build implements Buildpack.Build.
+func (*NodeBuildpack) Build(c *sous.BuildContext, dr *sous.DetectResult) (*sous.BuildResult, error) {
+	df, err := generateDockerfile("nodeDockerfile", dr.Data.(nodeDetectData))
+	if err != nil {
+		return nil, err
+	}
+	return buildGenerated(c, df)
+}
@@ -0,0 +1,109 @@
+package docker
+
+import (
+	"strings"
+	"time"
+
+	"github.com/docker/docker/builder/dockerfile/parser"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/shell"
+)
+
+// provenanceLabels computes the labels recording p on an image.
+func provenanceLabels(p *sous.Provenance) map[string]string {
+	labels := map[string]string{
+		DockerBuilderLabel:       p.Builder.String(),
+		DockerHostLabel:          p.Machine.FullHost,
+		DockerSousVersionLabel:   p.SousVersion,
+		DockerBuildpackLabel:     p.Buildpack,
+		DockerBaseImagesLabel:    strings.Join(p.BaseImageNames(), ","),
+		DockerBuildStartedLabel:  p.BuildStarted.Format(time.RFC3339),
+		DockerBuildFinishedLabel: p.BuildFinished.Format(time.RFC3339),
+	}
+	if labels[DockerHostLabel] == "" {
+		labels[DockerHostLabel] = p.Machine.Host
+	}
+	for k, v := range labels {
+		labels[k] = strings.Replace(v, `"`, `\"`, -1)
+	}
+	return labels
+}
+
+// ProvenanceFromLabels reconstructs the Provenance of an image from its
+// labels. It returns false if the image was not labelled with its provenance.
+func ProvenanceFromLabels(labels map[string]string) (*sous.Provenance, bool) {
+	bp, ok := labels[DockerBuildpackLabel]
+	if !ok {
+		return nil, false
+	}
+	sid, err := SourceIDFromLabels(labels)
+	if err != nil {
+		return nil, false
+	}
+	p := &sous.Provenance{
+		SourceID:    sid,
+		Machine:     sous.Machine{Host: labels[DockerHostLabel], FullHost: labels[DockerHostLabel]},
+		SousVersion: labels[DockerSousVersionLabel],
+		Buildpack:   bp,
+	}
+	p.Builder = parseUser(labels[DockerBuilderLabel])
+	for _, name := range splitList(labels[DockerBaseImagesLabel]) {
+		p.BaseImages = append(p.BaseImages, baseImageFromName(name))
+	}
+	p.Advisories = splitList(labels[DockerAdvisoryLabel])
+	p.BuildStarted, _ = time.Parse(time.RFC3339, labels[DockerBuildStartedLabel])
+	p.BuildFinished, _ = time.Parse(time.RFC3339, labels[DockerBuildFinishedLabel])
+	return p, true
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// parseUser is the inverse of sous.User.String().
+func parseUser(s string) sous.User {
+	u := sous.User{Name: s}
+	if i := strings.Index(s, "<"); i >= 0 && strings.HasSuffix(s, ">") {
+		u.Name = strings.TrimSpace(s[:i])
+		u.Email = s[i+1 : len(s)-1]
+	}
+	return u
+}
+
+func baseImageFromName(name string) sous.BaseImage {
+	if i := strings.Index(name, "@"); i >= 0 {
+		return sous.BaseImage{Name: name[:i], Digest: name[i+1:]}
+	}
+	return sous.BaseImage{Name: name}
+}
+
+// dockerfileFroms lists the images named in FROM instructions of the
+// Dockerfile at path, relative to the working directory of sh.
+func dockerfileFroms(sh shell.Shell, path string) ([]string, error) {
+	if !sh.Exists(path) {
+		return nil, nil
+	}
+	df, err := sh.Stdout("cat", path)
+	if err != nil {
+		return nil, err
+	}
+	ast, err := parseDocker(strings.NewReader(df))
+	if err != nil {
+		return nil, err
+	}
+	return fromsInAST(ast), nil
+}
+
+func fromsInAST(ast *parser.Node) []string {
+	froms := []string{}
+	for _, node := range ast.Children {
+		if node.Value == "from" && node.Next != nil {
+			// Drop any stage name, as in "FROM image AS stage".
+			froms = append(froms, firstField(node.Next.Value))
+		}
+	}
+	return froms
+}
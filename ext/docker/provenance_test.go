@@ -0,0 +1,59 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opentable/sous/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenanceLabelsRoundTrip(t *testing.T) {
+	sid := sous.MustNewSourceID("github.com/opentable/test", "sub", "2.3.7")
+	started := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := &sous.Provenance{
+		SourceID:      sid,
+		Builder:       sous.User{Name: "Judson", Email: "judson@example.com"},
+		Machine:       sous.Machine{Host: "build", FullHost: "build.example.com"},
+		SousVersion:   "0.5.0",
+		Buildpack:     "docker.DockerfileBuildpack",
+		BaseImages:    []sous.BaseImage{{Name: "golang", Digest: "sha256:abc"}, {Name: "alpine:3.4"}},
+		Advisories:    []string{"dirty workspace", "ephemeral tag"},
+		BuildStarted:  started,
+		BuildFinished: started.Add(time.Minute),
+	}
+
+	labels := Labels(sid)
+	for k, v := range provenanceLabels(p) {
+		labels[k] = v
+	}
+	labels[DockerAdvisoryLabel] = "dirty workspace,ephemeral tag"
+
+	got, ok := ProvenanceFromLabels(labels)
+	require.True(t, ok)
+	assert.Equal(t, p.SourceID.String(), got.SourceID.String())
+	assert.Equal(t, p.Builder, got.Builder)
+	assert.Equal(t, p.Machine.FullHost, got.Machine.FullHost)
+	assert.Equal(t, p.SousVersion, got.SousVersion)
+	assert.Equal(t, p.Buildpack, got.Buildpack)
+	assert.Equal(t, p.BaseImages, got.BaseImages)
+	assert.Equal(t, p.Advisories, got.Advisories)
+	assert.True(t, p.BuildStarted.Equal(got.BuildStarted))
+	assert.True(t, p.BuildFinished.Equal(got.BuildFinished))
+
+	_, ok = ProvenanceFromLabels(Labels(sid))
+	assert.False(t, ok)
+}
+
+func TestQualitiesFromLabelsIncludesProvenance(t *testing.T) {
+	sid := sous.MustNewSourceID("github.com/opentable/test", "", "1.0.0")
+	labels := Labels(sid)
+	labels[DockerBuildpackLabel] = "docker.DockerfileBuildpack"
+	labels[DockerAdvisoryLabel] = "ephemeral tag"
+
+	qs := qualitiesFromLabels(labels)
+	require.Len(t, qs, 2)
+	assert.Equal(t, "advisory", qs[0].Kind)
+	assert.Equal(t, sous.ProvenanceQualityKind, qs[1].Kind)
+}
@@ -0,0 +1,102 @@
+package docker
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+type (
+	// RegistryEvents is the envelope in which a Docker registry sends
+	// notifications of pushes and pulls.
+	RegistryEvents struct {
+		Events []RegistryEvent `json:"events"`
+	}
+
+	// A RegistryEvent is a single Docker registry notification.
+	RegistryEvent struct {
+		ID     string              `json:"id"`
+		Action string              `json:"action"`
+		Target RegistryEventTarget `json:"target"`
+	}
+
+	// RegistryEventTarget is the object a RegistryEvent happened to.
+	RegistryEventTarget struct {
+		MediaType  string `json:"mediaType"`
+		Digest     string `json:"digest"`
+		Repository string `json:"repository"`
+		URL        string `json:"url"`
+		Tag        string `json:"tag"`
+	}
+
+	// NotSousImage is returned by RecordPush for images which don't carry
+	// the labels Sous needs to identify their source.
+	NotSousImage struct {
+		ImageName string
+		Err       error
+	}
+
+	// ForeignRegistryEvent is returned by RecordPush for events about a
+	// registry other than the name cache's own, which are never fetched.
+	ForeignRegistryEvent struct {
+		URL, Registry string
+	}
+)
+
+func (e NotSousImage) Error() string {
+	return fmt.Sprintf("%s is not a Sous image: %v", e.ImageName, e.Err)
+}
+
+func (e ForeignRegistryEvent) Error() string {
+	return fmt.Sprintf("event for %q is not from registry %q", e.URL, e.Registry)
+}
+
+// IsManifestPush returns true if ev records an image manifest being pushed.
+// Registries also send events for each layer pushed, which are of no interest.
+func (ev RegistryEvent) IsManifestPush() bool {
+	return ev.Action == "push" && strings.Contains(ev.Target.MediaType, "manifest")
+}
+
+// RecordPush records the image pushed in ev in the cache, by its digest, and
+// by its tag if it has one. Its source ID comes from its labels; images
+// without Sous labels are not recorded, and a NotSousImage is returned for
+// them. The image is always fetched from the name cache's own registry:
+// events which name another are refused with a ForeignRegistryEvent.
+func (nc *NameCache) RecordPush(ev RegistryEvent) (sous.SourceID, error) {
+	host := nc.DockerRegistryHost
+	if ev.Target.URL != "" {
+		u, err := url.Parse(ev.Target.URL)
+		if err != nil || host == "" || u.Host != host {
+			return sous.SourceID{}, ForeignRegistryEvent{URL: ev.Target.URL, Registry: host}
+		}
+	}
+	digested := host + "/" + ev.Target.Repository + "@" + ev.Target.Digest
+
+	md, err := nc.RegistryClient.GetImageMetadata(digested, "")
+	if err != nil {
+		return sous.SourceID{}, errors.Wrapf(err, "getting metadata for %s", digested)
+	}
+	sid, err := SourceIDFromLabels(md.Labels)
+	if err != nil {
+		return sous.SourceID{}, NotSousImage{ImageName: digested, Err: err}
+	}
+
+	etag := md.Etag
+	if etag == "" {
+		etag = ev.Target.Digest
+	}
+	if err := nc.dbInsert(sid, md.Labels[DockerFlavorLabel], digested, etag, qualitiesFromLabels(md.Labels)); err != nil {
+		return sid, errors.Wrapf(err, "recording %s", digested)
+	}
+	if ev.Target.Tag != "" {
+		tagged := host + "/" + ev.Target.Repository + ":" + ev.Target.Tag
+		if err := nc.dbAddNames(digested, []string{tagged}); err != nil {
+			return sid, errors.Wrapf(err, "recording %s", tagged)
+		}
+	}
+	Log.Debug.Printf("Recorded pushed image %s as %v", digested, sid)
+	return sid, nil
+}
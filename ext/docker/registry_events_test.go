@@ -0,0 +1,102 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/docker_registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:012345678901234567890123456789AB012345678901234567890123456789AB"
+
+func pushEvent(tag string) RegistryEvent {
+	return RegistryEvent{
+		ID:     "event-1",
+		Action: "push",
+		Target: RegistryEventTarget{
+			MediaType:  "application/vnd.docker.distribution.manifest.v2+json",
+			Digest:     testDigest,
+			Repository: "example/app",
+			URL:        "https://docker.example.com/v2/example/app/manifests/" + testDigest,
+			Tag:        tag,
+		},
+	}
+}
+
+func TestRegistryEvent_IsManifestPush(t *testing.T) {
+	assert.True(t, pushEvent("").IsManifestPush())
+
+	layer := pushEvent("")
+	layer.Target.MediaType = "application/octet-stream"
+	assert.False(t, layer.IsManifestPush())
+
+	pull := pushEvent("")
+	pull.Action = "pull"
+	assert.False(t, pull.IsManifestPush())
+}
+
+func TestNameCache_RecordPush(t *testing.T) {
+	dc := docker_registry.NewDummyClient()
+	nc, err := NewNameCache("docker.example.com", dc, inMemoryDB("record_push"))
+	require.NoError(t, err)
+	sid := sous.MustNewSourceID("github.com/example/app", "", "1.2.3")
+	dc.FeedMetadata(docker_registry.Metadata{
+		Registry:      "docker.example.com",
+		Labels:        Labels(sid),
+		Etag:          testDigest,
+		CanonicalName: "example/app@" + testDigest,
+	})
+
+	got, err := nc.RecordPush(pushEvent("1.2.3"))
+	require.NoError(t, err)
+	assert.Equal(t, sid, got)
+
+	name, _, err := nc.getImageNameFromCache(sid, "")
+	require.NoError(t, err)
+	assert.Equal(t, "docker.example.com/example/app@"+testDigest, name)
+
+	cn, err := nc.GetCanonicalName("docker.example.com/example/app:1.2.3")
+	require.NoError(t, err)
+	assert.Equal(t, name, cn)
+	assert.Len(t, dc.CallsTo("AllTags"), 0, "no harvest needed")
+}
+
+func TestNameCache_RecordPush_NotSousImage(t *testing.T) {
+	dc := docker_registry.NewDummyClient()
+	nc, err := NewNameCache("docker.example.com", dc, inMemoryDB("record_push_not_sous"))
+	require.NoError(t, err)
+	dc.FeedMetadata(docker_registry.Metadata{
+		Registry:      "docker.example.com",
+		Labels:        map[string]string{"maintainer": "someone"},
+		CanonicalName: "example/app@" + testDigest,
+	})
+
+	_, err = nc.RecordPush(pushEvent(""))
+	assert.IsType(t, NotSousImage{}, err)
+	sids, err := nc.ListSourceIDs()
+	require.NoError(t, err)
+	assert.Empty(t, sids)
+}
+
+func TestNameCache_RecordPush_ForeignRegistry(t *testing.T) {
+	dc := docker_registry.NewDummyClient()
+	nc, err := NewNameCache("docker.example.com", dc, inMemoryDB("record_push_foreign"))
+	require.NoError(t, err)
+
+	for _, u := range []string{
+		"http://169.254.169.254/v2/example/app/manifests/" + testDigest,
+		"https://docker.example.com.evil.com/v2/example/app/manifests/" + testDigest,
+		"://docker.example.com",
+	} {
+		ev := pushEvent("1.2.3")
+		ev.Target.URL = u
+		_, err = nc.RecordPush(ev)
+		assert.IsType(t, ForeignRegistryEvent{}, err, u)
+	}
+	assert.Len(t, dc.CallsTo("GetImageMetadata"), 0, "nothing is fetched")
+	sids, err := nc.ListSourceIDs()
+	require.NoError(t, err)
+	assert.Empty(t, sids)
+}
@@ -5,5 +5,7 @@
 package docker
 
 const (
+	goDockerfileTmpl       = "FROM golang:{{.GoVersion}} AS build\nWORKDIR /src\nCOPY go.mod go.sum* ./\nRUN go mod download\nCOPY . .\nARG APP_VERSION\nARG APP_REVISION\nRUN CGO_ENABLED=0 go build -ldflags \"-X main.Version=${APP_VERSION} -X main.Revision=${APP_REVISION}\" -o /out/app {{.Package}}\n\nFROM scratch\nCOPY --from=build /etc/ssl/certs/ca-certificates.crt /etc/ssl/certs/\nCOPY --from=build /out/app /app\nENTRYPOINT [\"/app\"]\n"
 	metadataDockerfileTmpl = "FROM {{.ImageID}}\nLABEL {{- range $key, $value := .Labels}} \\\n  {{$key}}=\"{{$value}}\"\n  {{- end -}}\n  {{- with .Advisories}} \\\n  com.opentable.sous.advisories=\"\n  {{- range $index, $element := . -}}\n  {{if $index}},{{end}}{{.}}\n  {{- end}}\"\n  {{- end -}}\n"
+	nodeDockerfileTmpl     = "FROM node:{{.NodeVersion}} AS build\nWORKDIR /src\nCOPY package*.json {{if .HasYarnLock}}yarn.lock {{end}}./\nRUN {{.InstallCommand}}\nCOPY . .\n{{- if .HasBuildScript}}\nRUN npm run build\n{{- end}}\nRUN npm prune --production\n\nFROM node:{{.NodeVersion}}-alpine\nWORKDIR /app\nENV NODE_ENV=production\nCOPY --from=build /src /app\nCMD [\"npm\", \"start\"]\n"
 )
@@ -25,6 +25,10 @@ type (
 	SingularityJSON struct {
 		Resources SingularityResources
 		Env       sous.Env
+		// LoadBalancerGroups and ServiceBasePath register the deployment with
+		// the load balancer.
+		LoadBalancerGroups []string
+		ServiceBasePath    string
 	}
 	// SingularityResources represents the resources section in SingularityJSON.
 	SingularityResources map[string]float64
@@ -35,8 +39,16 @@ type (
 		Instances int
 		// Owners is a comma-separated list of email addresses.
 		Owners []string
-		// NOTE: We do not currently support Daemon, RackSensitive or LoadBalanced
-		//Daemon, RackSensitive, LoadBalanced bool
+		// Daemon is false for on-demand requests. If it is not set, the kind
+		// of the manifest is left to the defaults.
+		Daemon *bool
+		// RackSensitive spreads instances evenly across racks.
+		RackSensitive bool
+		// LoadBalanced is true if the load balancer groups in singularity.json
+		// are used.
+		LoadBalanced bool
+		// RequiredSlaveAttributes constrain which slaves tasks are placed on.
+		RequiredSlaveAttributes map[string]string
 	}
 )
 
@@ -65,6 +77,7 @@ type otplDeployConfig struct {
 	// It is unique for all OTPL configs in a single project.
 	Name   string
 	Owners []string
+	Kind   sous.ManifestKind
 	Spec   *sous.DeploySpec
 }
 
@@ -105,14 +118,25 @@ func (mp *ManifestParser) ParseManifests(wd shell.Shell) sous.Manifests {
 	}
 	deployConfigs := sous.DeploySpecs{}
 	owners := sous.NewOwnerSet()
+	kinds := map[sous.ManifestKind]struct{}{}
+	var kind sous.ManifestKind
 	for s := range c {
 		deployConfigs[s.Name] = *s.Spec
 		for _, o := range s.Owners {
 			owners.Add(o)
 		}
+		if s.Kind != "" {
+			kinds[s.Kind] = struct{}{}
+			kind = s.Kind
+		}
+	}
+	if len(kinds) > 1 {
+		mp.debugf("otpl configs disagree on daemon; leaving kind unset")
+		kind = ""
 	}
 	return sous.NewManifests(
 		&sous.Manifest{
+			Kind:        kind,
 			Deployments: deployConfigs,
 			Owners:      owners.Slice(),
 		},
@@ -140,6 +164,10 @@ func (mp *ManifestParser) parseSingleOTPLConfig(wd shell.Shell) *otplDeployConfi
 			DeployConfig: sous.DeployConfig{
 				Resources: v.Resources.SousResources(),
 				Env:       v.Env,
+				Placement: sous.Placement{
+					LoadBalancerGroups: v.LoadBalancerGroups,
+					ServiceBasePath:    v.ServiceBasePath,
+				},
 			},
 		},
 	}
@@ -155,5 +183,18 @@ func (mp *ManifestParser) parseSingleOTPLConfig(wd shell.Shell) *otplDeployConfi
 	}
 	deploySpec.Spec.NumInstances = request.Instances
 	deploySpec.Owners = request.Owners
+	placement := &deploySpec.Spec.Placement
+	placement.RackSensitive = request.RackSensitive
+	placement.RequiredAttributes = request.RequiredSlaveAttributes
+	if !request.LoadBalanced {
+		placement.LoadBalancerGroups = nil
+		placement.ServiceBasePath = ""
+	}
+	if request.Daemon != nil {
+		deploySpec.Kind = sous.ManifestKindService
+		if !*request.Daemon {
+			deploySpec.Kind = sous.ManifestKindOnDemand
+		}
+	}
 	return deploySpec
 }
@@ -123,3 +123,50 @@ func TestManifestParser_ParseManifest(t *testing.T) {
 		}
 	}
 }
+
+func TestManifestParser_ParsePlacement(t *testing.T) {
+	files := filemap.FileMap{
+		"config/cluster1/singularity-request.json": `{
+	        "daemon": false,
+	        "rackSensitive": true,
+	        "loadBalanced": true,
+	        "requiredSlaveAttributes": {"instance-type": "large"}
+	    }`,
+		"config/cluster1/singularity.json": `{
+	      "resources": {"numPorts": 1},
+	      "loadBalancerGroups": ["internal"],
+	      "serviceBasePath": "/app"
+	    }`,
+	}
+
+	const testDataDir = "testdata/gen"
+
+	var actual sous.Manifests
+
+	if fileMapErr := files.Session(testDataDir, func() {
+		wd, err := shell.DefaultInDir(testDataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual = NewManifestParser().ParseManifests(wd)
+	}); fileMapErr != nil {
+		t.Fatal(fileMapErr)
+	}
+
+	m, ok := actual.Single(func(*sous.Manifest) bool { return true })
+	if !ok {
+		t.Fatalf("expected a single manifest, got %d", actual.Len())
+	}
+	if m.Kind != sous.ManifestKindOnDemand {
+		t.Errorf("got kind %q; want %q", m.Kind, sous.ManifestKindOnDemand)
+	}
+	expected := sous.Placement{
+		RackSensitive:      true,
+		RequiredAttributes: map[string]string{"instance-type": "large"},
+		LoadBalancerGroups: []string{"internal"},
+		ServiceBasePath:    "/app",
+	}
+	if p := m.Deployments["cluster1"].Placement; !p.Equal(expected) {
+		t.Errorf("got placement %v; want %v", p, expected)
+	}
+}
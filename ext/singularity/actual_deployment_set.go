@@ -3,14 +3,16 @@ package singularity
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"runtime/debug"
 	"sync"
-	"time"
 
 	"github.com/opentable/go-singularity"
 	"github.com/opentable/go-singularity/dtos"
 	"github.com/opentable/sous/lib"
+	"github.com/opentable/swaggering"
 	"github.com/pkg/errors"
 )
 
@@ -40,17 +42,36 @@ type (
 		ReqParent *dtos.SingularityRequestParent
 	}
 
-	retryCounter map[string]uint
+	// clusterError is an error talking to the Singularity at url. If reqID is
+	// set, it occurred while assembling the deployment for that request.
+	clusterError struct {
+		url, reqID string
+		err        error
+	}
 )
 
+func (ce *clusterError) Error() string {
+	if ce.reqID == "" {
+		return fmt.Sprintf("%s: %s", ce.url, ce.err)
+	}
+	return fmt.Sprintf("%s request %s: %s", ce.url, ce.reqID, ce.err)
+}
+
+// Cause returns the underlying error, for errors.Cause.
+func (ce *clusterError) Cause() error {
+	return ce.err
+}
+
 // RunningDeployments collects data from the Singularity clusters and
-// returns a list of actual deployments.
+// returns a list of actual deployments. If some clusters cannot be reached,
+// the deployments of the others are returned along with a
+// *sous.UnreachableClustersError.
 func (sc *deployer) RunningDeployments(reg sous.Registry, clusters sous.Clusters) (sous.DeployStates, error) {
 	var deps sous.DeployStates
-	retries := make(retryCounter)
 	errCh := make(chan error)
 	deps = sous.NewDeployStates()
 	sings := make(map[string]struct{})
+	failed := make(map[string]error)
 	reqCh := make(chan SingReq, len(clusters)*ReqsPerServer)
 	depCh := make(chan *sous.DeployState, ReqsPerServer)
 
@@ -61,6 +82,8 @@ func (sc *deployer) RunningDeployments(reg sous.Registry, clusters sous.Clusters
 
 	var depAssWait, singWait, depWait sync.WaitGroup
 
+	sc.clients.configure(clusters)
+
 	Log.Vomit.Printf("Setting up to wait for %d clusters", len(clusters))
 	singWait.Add(len(clusters))
 	for _, url := range clusters {
@@ -75,7 +98,14 @@ func (sc *deployer) RunningDeployments(reg sous.Registry, clusters sous.Clusters
 		go singPipeline(reg, url, client, &depWait, &singWait, reqCh, errCh, clusters)
 	}
 
-	go depPipeline(reg, clusters, MaxAssemblers, &depAssWait, reqCh, depCh, errCh)
+	sc.cache.expire()
+	defer func() {
+		stats := sc.cache.Stats()
+		Log.Debug.Printf("Deployment cache: %d entries, %d hits, %d misses (%.0f%%), %d invalidated, %d expired",
+			stats.Entries, stats.Hits, stats.Misses, 100*stats.HitRate(), stats.Invalidations, stats.Expirations)
+	}()
+
+	go depPipeline(reg, clusters, sc.cache, MaxAssemblers, &depAssWait, reqCh, depCh, errCh)
 
 	go func() {
 		defer catchAndSend("closing channels", errCh)
@@ -104,47 +134,81 @@ func (sc *deployer) RunningDeployments(reg sous.Registry, clusters sous.Clusters
 		case err, cont := <-errCh:
 			if !cont {
 				Log.Debug.Printf("Errors channel closed. Finishing up.")
-				return deps, nil
+				sc.pruneTombstones(deps, clusters, failed)
+				return withoutUnreachable(deps, clusters, failed)
 			}
-			if isMalformed(err) || ignorableDeploy(err) {
-				Log.Debug.Print(err)
+			ce, fromCluster := err.(*clusterError)
+			if fromCluster && ce.reqID != "" {
 				depWait.Done()
-			} else {
-				retryable := retries.maybe(err, reqCh)
-				if !retryable {
-					Log.Notice.Printf("Cannot retry: %v. Exiting", err)
-					return deps, err
+			}
+			switch {
+			case fromCluster && (ce.reqID == "" || unreachable(err)):
+				// Requests have already been retried as far as the cluster's
+				// ClientPolicy allows. Without its request list, or with a
+				// request missing, the cluster's deployments are unknown.
+				Log.Warn.Printf("Singularity at %s failed: %v", ce.url, ce.err)
+				if _, seen := failed[ce.url]; !seen {
+					failed[ce.url] = ce.err
 				}
+			case isMalformed(err) || ignorableDeploy(err):
+				Log.Debug.Print(err)
+			default:
+				Log.Notice.Printf("Cannot continue: %v. Exiting", err)
+				return deps, err
 			}
 		}
 	}
 }
 
-const retryLimit = 3
-
-func (rc retryCounter) maybe(err error, reqCh chan SingReq) bool {
-	rt, ok := errors.Cause(err).(*canRetryRequest)
-	if !ok {
-		return false
+// unreachable returns true if err means that a Singularity could not be
+// reached, or could not serve a request, rather than that something was
+// wrong with the request or the deployment it describes.
+func unreachable(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case *url.Error:
+		return true
+	case *swaggering.ReqError:
+		return e.Status >= 500 || e.Status == http.StatusTooManyRequests
 	}
+	return false
+}
 
-	Log.Debug.Printf("%T err = %+v\n", errors.Cause(err), errors.Cause(err))
-	count, ok := rc[rt.name()]
-	if !ok {
-		count = 0
+// pruneTombstones forgets the tombstones of deployments which are no longer
+// running in the clusters whose deployments were all collected.
+func (sc *deployer) pruneTombstones(deps sous.DeployStates, clusters sous.Clusters, failed map[string]error) {
+	if sc.Tombstones == nil {
+		return
 	}
-	if count > retryLimit {
-		return false
+	running := map[sous.DeploymentID]struct{}{}
+	for id := range deps.Snapshot() {
+		running[id] = struct{}{}
 	}
+	for name, cluster := range clusters {
+		if _, down := failed[cluster.BaseURL]; !down {
+			sc.Tombstones.Prune(name, running)
+		}
+	}
+}
 
-	rc[rt.name()] = count + 1
-	go func() {
-		defer catchAll("retrying: " + rt.req.SourceURL)
-		time.Sleep(time.Millisecond * 50)
-		reqCh <- rt.req
-	}()
-
-	return true
+// withoutUnreachable returns deps without the deployments in clusters whose
+// Singularity failed, and an error naming those clusters, if there are any.
+// A cluster whose requests were only partly collected might otherwise appear
+// to be missing deployments.
+func withoutUnreachable(deps sous.DeployStates, clusters sous.Clusters, failed map[string]error) (sous.DeployStates, error) {
+	if len(failed) == 0 {
+		return deps, nil
+	}
+	unreachable := &sous.UnreachableClustersError{Clusters: map[string]error{}}
+	for name, cluster := range clusters {
+		if err, ok := failed[cluster.BaseURL]; ok {
+			unreachable.Clusters[name] = err
+		}
+	}
+	deps = deps.Filter(func(ds *sous.DeployState) bool {
+		_, down := unreachable.Clusters[ds.ClusterName]
+		return !down
+	})
+	return deps, unreachable
 }
 
 func catchAll(from string) {
@@ -212,7 +276,7 @@ func singPipeline(
 	srp, err := getSingularityRequestParents(client)
 	if err != nil {
 		Log.Vomit.Print(err) //XXX connection reset by peer should be retried
-		errs <- errors.Wrap(err, "getting request list")
+		errs <- &clusterError{url: url, err: errors.Wrap(err, "getting request list")}
 		return
 	}
 
@@ -245,6 +309,7 @@ func convertSingularityRequestParentsToSingReqs(url string, client *singularity.
 func depPipeline(
 	reg sous.Registry,
 	clusters sous.Clusters,
+	cache *deploymentCache,
 	poolCount int,
 	depAssWait *sync.WaitGroup,
 	reqCh chan SingReq,
@@ -265,10 +330,10 @@ func depPipeline(
 				<-poolLimit
 			}()
 
-			dep, err := assembleDeployState(reg, clusters, req)
+			dep, err := assembleDeployState(reg, clusters, req, cache)
 
 			if err != nil {
-				errCh <- errors.Wrap(err, "assembly problem")
+				errCh <- &clusterError{url: req.SourceURL, reqID: reqID(req.ReqParent), err: errors.Wrap(err, "assembly problem")}
 			} else {
 				depCh <- dep
 			}
@@ -276,9 +341,9 @@ func depPipeline(
 	}
 }
 
-func assembleDeployState(reg sous.Registry, clusters sous.Clusters, req SingReq) (*sous.DeployState, error) {
+func assembleDeployState(reg sous.Registry, clusters sous.Clusters, req SingReq, cache *deploymentCache) (*sous.DeployState, error) {
 	Log.Vomit.Printf("Assembling from: %s %s", req.SourceURL, reqID(req.ReqParent))
-	tgt, err := BuildDeployment(reg, clusters, req)
+	tgt, err := buildDeployment(reg, clusters, req, cache)
 	Log.Vomit.Printf("Collected deployment: %#v", tgt)
 	return &tgt, errors.Wrap(err, "Building deployment")
 }
@@ -1,7 +1,11 @@
 package singularity
 
 import (
+	"errors"
+	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/opentable/go-singularity"
 	"github.com/opentable/go-singularity/dtos"
@@ -17,7 +21,7 @@ func TestGetDepSetWorks(t *testing.T) {
 
 	reg := sous.NewDummyRegistry()
 	client := sous.NewDummyRectificationClient()
-	dep := deployer{client,
+	dep := deployer{client, sous.NewTombstones(),
 		func(url string) *singularity.Client {
 			cl, co := singularity.NewDummyClient(url)
 
@@ -62,6 +66,9 @@ func TestGetDepSetWorks(t *testing.T) {
 			whip[url] = co
 			return cl
 		},
+		newClientPool(),
+		time.Now,
+		nil,
 	}
 
 	clusters := sous.Clusters{"test": {BaseURL: "http://test-singularity.org/"}}
@@ -69,3 +76,32 @@ func TestGetDepSetWorks(t *testing.T) {
 	assert.NoError(err)
 	assert.NotNil(res)
 }
+
+func TestPruneTombstonesKeepsUnreachableClusters(t *testing.T) {
+	now := time.Now()
+	ts := sous.NewTombstones()
+	up := &sous.Deployment{ClusterName: "up", Cluster: &sous.Cluster{Name: "up", BaseURL: "http://up"}}
+	down := &sous.Deployment{ClusterName: "down", Cluster: &sous.Cluster{Name: "down", BaseURL: "http://down"}}
+	ts.Mark(up, "up-req", now)
+	ts.Mark(down, "down-req", now)
+
+	dep := &deployer{Tombstones: ts}
+	clusters := sous.Clusters{"up": up.Cluster, "down": down.Cluster}
+	dep.pruneTombstones(sous.NewDeployStates(), clusters, map[string]error{"http://down": errors.New("unreachable")})
+
+	_, ok := ts.Get(up.ID())
+	assert.False(t, ok, "no longer running in a reachable cluster")
+	_, ok = ts.Get(down.ID())
+	assert.True(t, ok, "kept while its cluster can't be reached")
+}
+
+func TestUnreachable(t *testing.T) {
+	assert := assert.New(t)
+
+	dial := &url.Error{Op: "Get", URL: "http://singularity", Err: errors.New("connection refused")}
+	assert.True(unreachable(&clusterError{url: "http://singularity", reqID: "req", err: dial}))
+	assert.True(unreachable(&swaggering.ReqError{Status: http.StatusServiceUnavailable}))
+	assert.False(unreachable(&swaggering.ReqError{Status: http.StatusNotFound}))
+	assert.False(unreachable(&clusterError{url: "http://singularity", reqID: "req", err: malformedResponse{"no deploy"}}))
+	assert.False(unreachable(errors.New("no labels for image")))
+}
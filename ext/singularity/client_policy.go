@@ -0,0 +1,263 @@
+package singularity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/opentable/go-singularity"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/swaggering"
+)
+
+type (
+	// clientPool holds one Singularity client for each cluster URL. Each
+	// client applies the ClientPolicy of its cluster, and has its own circuit
+	// breaker, which is shared by everything using the pool.
+	clientPool struct {
+		sync.Mutex
+		clients map[string]*policyClient
+		now     func() time.Time
+		sleep   func(time.Duration)
+	}
+
+	policyClient struct {
+		client    *singularity.Client
+		transport *policyTransport
+	}
+
+	// policyTransport is an http.RoundTripper which limits, retries and
+	// circuit breaks requests to a single Singularity according to a
+	// sous.ClientPolicy.
+	policyTransport struct {
+		url     string
+		base    http.RoundTripper
+		now     func() time.Time
+		sleep   func(time.Duration)
+		breaker circuitBreaker
+
+		sync.Mutex
+		policy sous.ClientPolicy
+	}
+
+	// circuitBreaker counts consecutive failures. Once they reach the
+	// threshold, it opens: requests fail immediately until the cooldown has
+	// passed. Then requests are tried again; the first to succeed closes the
+	// breaker, and any failure opens it again.
+	circuitBreaker struct {
+		sync.Mutex
+		failures  int
+		openUntil time.Time
+	}
+
+	// CircuitOpenError is returned for requests to a Singularity which has
+	// failed too often recently to be tried again yet.
+	CircuitOpenError struct {
+		URL   string
+		Until time.Time
+	}
+
+	// cancelOnClose cancels the context of a request attempt once its
+	// response body is closed.
+	cancelOnClose struct {
+		io.ReadCloser
+		cancel context.CancelFunc
+	}
+)
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s failed too often recently; not retrying until %s", e.URL, e.Until.Format(time.RFC3339))
+}
+
+func newClientPool() *clientPool {
+	return &clientPool{
+		clients: map[string]*policyClient{},
+		now:     time.Now,
+		sleep:   time.Sleep,
+	}
+}
+
+// configure applies the ClientPolicy of each of clusters to the client for
+// its URL. Clusters which share a URL should share a policy.
+func (cp *clientPool) configure(clusters sous.Clusters) {
+	for _, c := range clusters {
+		cp.get(c.BaseURL).transport.setPolicy(c.ClientPolicy)
+	}
+}
+
+// configureCluster applies the ClientPolicy of c to the client for its URL.
+func (cp *clientPool) configureCluster(c *sous.Cluster) {
+	if c == nil {
+		return
+	}
+	cp.get(c.BaseURL).transport.setPolicy(c.ClientPolicy)
+}
+
+// client returns the client for url. Until the cluster at url is configured,
+// it uses the default policy.
+func (cp *clientPool) client(url string) *singularity.Client {
+	return cp.get(url).client
+}
+
+func (cp *clientPool) get(url string) *policyClient {
+	cp.Lock()
+	defer cp.Unlock()
+	if pc, ok := cp.clients[url]; ok {
+		return pc
+	}
+	rt := &policyTransport{
+		url:    url,
+		base:   http.DefaultTransport,
+		now:    cp.now,
+		sleep:  cp.sleep,
+		policy: sous.DefaultClientPolicy,
+	}
+	pc := &policyClient{
+		client: &singularity.Client{Requester: &swaggering.GenericClient{
+			BaseURL: url,
+			Logger:  swaggering.NullLogger{},
+			HTTP:    http.Client{Transport: rt},
+		}},
+		transport: rt,
+	}
+	cp.clients[url] = pc
+	return pc
+}
+
+func (rt *policyTransport) setPolicy(p sous.ClientPolicy) {
+	rt.Lock()
+	defer rt.Unlock()
+	rt.policy = p.WithDefaults()
+}
+
+func (rt *policyTransport) currentPolicy() sous.ClientPolicy {
+	rt.Lock()
+	defer rt.Unlock()
+	return rt.policy
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := rt.currentPolicy()
+	if err := rt.breaker.allow(rt.url, rt.now()); err != nil {
+		return nil, err
+	}
+	var res *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		res, err = rt.attempt(req, p.Timeout)
+		if attempt >= p.MaxRetries || !shouldRetry(req, res, err) {
+			break
+		}
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+		backoff := p.Backoff(attempt)
+		Log.Debug.Printf("Retrying %s %s in %s after attempt %d: %s", req.Method, req.URL, backoff, attempt+1, describeFailure(res, err))
+		rt.sleep(backoff)
+	}
+	rt.breaker.record(failed(res, err), p, rt.now())
+	return res, err
+}
+
+// attempt makes a single attempt at req, limited to timeout.
+func (rt *policyTransport) attempt(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	r := req.WithContext(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+	res, err := rt.base.RoundTrip(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// shouldRetry returns true if req may succeed when tried again. Requests with
+// a body are only retried if the body can be sent again. Since the server
+// may have acted on any request which reached it, even one which timed out
+// or failed, only requests that change nothing are retried after a failure;
+// other requests are only retried if they could not be sent at all.
+func shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return notConnected(err)
+	}
+	if err != nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// notConnected returns true if err means that no connection could be made to
+// the server, so that a request cannot have been received.
+func notConnected(err error) bool {
+	oe, ok := err.(*net.OpError)
+	return ok && oe.Op == "dial"
+}
+
+// failed returns true if a request ended with res and err because something
+// is wrong with the server, rather than with the request.
+func failed(res *http.Response, err error) bool {
+	return err != nil || res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+}
+
+func describeFailure(res *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return res.Status
+}
+
+// allow returns a CircuitOpenError if the breaker is open at now.
+func (cb *circuitBreaker) allow(url string, now time.Time) error {
+	cb.Lock()
+	defer cb.Unlock()
+	if now.Before(cb.openUntil) {
+		return &CircuitOpenError{URL: url, Until: cb.openUntil}
+	}
+	return nil
+}
+
+// record counts the outcome of a request completed at now.
+func (cb *circuitBreaker) record(failure bool, p sous.ClientPolicy, now time.Time) {
+	cb.Lock()
+	defer cb.Unlock()
+	if !failure {
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.failures >= p.BreakerThreshold {
+		cb.openUntil = now.Add(p.BreakerCooldown)
+	}
+}
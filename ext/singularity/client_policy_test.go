@@ -0,0 +1,279 @@
+package singularity
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opentable/go-singularity/dtos"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/swaggering"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSingularity is an in-process Singularity which serves an empty request
+// list, after failing a set number of times.
+type fakeSingularity struct {
+	sync.Mutex
+	*httptest.Server
+	failures int
+	status   int
+	delay    time.Duration
+	hits     int
+	bodies   []string
+}
+
+func newFakeSingularity() *fakeSingularity {
+	fs := &fakeSingularity{status: http.StatusServiceUnavailable}
+	fs.Server = httptest.NewServer(http.HandlerFunc(fs.serve))
+	return fs
+}
+
+func (fs *fakeSingularity) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	fs.Lock()
+	fs.hits++
+	fs.bodies = append(fs.bodies, string(body))
+	fail := fs.failures > 0
+	if fail {
+		fs.failures--
+	}
+	status, delay := fs.status, fs.delay
+	fs.Unlock()
+
+	time.Sleep(delay)
+	if fail {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == "GET" {
+		w.Write([]byte("[]"))
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func (fs *fakeSingularity) fail(n, status int) {
+	fs.Lock()
+	defer fs.Unlock()
+	fs.failures, fs.status = n, status
+}
+
+func (fs *fakeSingularity) hitCount() int {
+	fs.Lock()
+	defer fs.Unlock()
+	return fs.hits
+}
+
+// testClientPool returns a pool which does not sleep between retries, and
+// whose clock is set by the returned function.
+func testClientPool() (*clientPool, func(time.Time)) {
+	var mu sync.Mutex
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	cp := newClientPool()
+	cp.sleep = func(time.Duration) {}
+	cp.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	return cp, func(t time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = t
+	}
+}
+
+// circuitOpen returns true if err is a CircuitOpenError, as returned by
+// http.Client.
+func circuitOpen(err error) bool {
+	if ue, ok := errors.Cause(err).(*url.Error); ok {
+		err = ue.Err
+	}
+	_, ok := err.(*CircuitOpenError)
+	return ok
+}
+
+func TestClientPolicy_RetriesUnavailable(t *testing.T) {
+	fs := newFakeSingularity()
+	defer fs.Close()
+	cp, _ := testClientPool()
+	cp.configureCluster(&sous.Cluster{BaseURL: fs.URL, ClientPolicy: sous.ClientPolicy{MaxRetries: 2}})
+
+	fs.fail(2, http.StatusServiceUnavailable)
+	_, err := cp.client(fs.URL).GetRequests()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fs.hitCount())
+
+	fs.fail(3, http.StatusServiceUnavailable)
+	_, err = cp.client(fs.URL).GetRequests()
+	require.Error(t, err)
+	if re, ok := errors.Cause(err).(*swaggering.ReqError); assert.True(t, ok, "%T", err) {
+		assert.Equal(t, http.StatusServiceUnavailable, re.Status)
+	}
+	assert.Equal(t, 6, fs.hitCount())
+}
+
+// refusingTransport fails its first dials, as if the server were not
+// listening, then sends requests on.
+type refusingTransport struct {
+	http.RoundTripper
+	refusals int
+}
+
+func (rt *refusingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.refusals > 0 {
+		rt.refusals--
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	return rt.RoundTripper.RoundTrip(req)
+}
+
+func TestClientPolicy_ResendsBody(t *testing.T) {
+	fs := newFakeSingularity()
+	defer fs.Close()
+	cp, _ := testClientPool()
+	cp.get(fs.URL).transport.base = &refusingTransport{RoundTripper: http.DefaultTransport, refusals: 1}
+
+	_, err := cp.client(fs.URL).PostRequest(&dtos.SingularityRequest{})
+	require.NoError(t, err)
+	require.Len(t, fs.bodies, 1)
+	assert.NotEmpty(t, fs.bodies[0])
+}
+
+func TestClientPolicy_FailuresOnlyRetriedIfSafe(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		fs := newFakeSingularity()
+		cp, _ := testClientPool()
+
+		fs.fail(1, status)
+		_, err := cp.client(fs.URL).PostRequest(&dtos.SingularityRequest{})
+		assert.Error(t, err)
+		assert.Equal(t, 1, fs.hitCount(), "POST failing with %d", status)
+
+		fs.fail(1, status)
+		_, err = cp.client(fs.URL).GetRequests()
+		assert.NoError(t, err)
+		assert.Equal(t, 3, fs.hitCount(), "GET failing with %d", status)
+		fs.Close()
+	}
+}
+
+func TestClientPolicy_PostTimeoutNotRetried(t *testing.T) {
+	fs := newFakeSingularity()
+	defer fs.Close()
+	fs.delay = 200 * time.Millisecond
+	cp, _ := testClientPool()
+	cp.configureCluster(&sous.Cluster{BaseURL: fs.URL, ClientPolicy: sous.ClientPolicy{
+		Timeout:    10 * time.Millisecond,
+		MaxRetries: 2,
+	}})
+
+	_, err := cp.client(fs.URL).PostRequest(&dtos.SingularityRequest{})
+	assert.Error(t, err)
+	assert.Equal(t, 1, fs.hitCount())
+}
+
+func TestClientPolicy_ClientErrorsNotRetried(t *testing.T) {
+	fs := newFakeSingularity()
+	defer fs.Close()
+	cp, _ := testClientPool()
+	cp.configureCluster(&sous.Cluster{BaseURL: fs.URL, ClientPolicy: sous.ClientPolicy{BreakerThreshold: 1}})
+
+	fs.fail(1, http.StatusNotFound)
+	_, err := cp.client(fs.URL).GetRequests()
+	assert.Error(t, err)
+	assert.Equal(t, 1, fs.hitCount())
+
+	// Client errors do not count against the breaker.
+	_, err = cp.client(fs.URL).GetRequests()
+	assert.NoError(t, err)
+}
+
+func TestClientPolicy_Timeout(t *testing.T) {
+	fs := newFakeSingularity()
+	defer fs.Close()
+	fs.delay = 200 * time.Millisecond
+	cp, _ := testClientPool()
+	cp.configureCluster(&sous.Cluster{BaseURL: fs.URL, ClientPolicy: sous.ClientPolicy{
+		Timeout:    10 * time.Millisecond,
+		MaxRetries: 1,
+	}})
+
+	_, err := cp.client(fs.URL).GetRequests()
+	assert.Error(t, err)
+	assert.Equal(t, 2, fs.hitCount())
+}
+
+func TestClientPolicy_CircuitBreaker(t *testing.T) {
+	fs := newFakeSingularity()
+	defer fs.Close()
+	cp, setNow := testClientPool()
+	start := cp.now()
+	cp.configureCluster(&sous.Cluster{BaseURL: fs.URL, ClientPolicy: sous.ClientPolicy{
+		MaxRetries:       -1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	}})
+	client := cp.client(fs.URL)
+
+	fs.fail(10, http.StatusServiceUnavailable)
+	for i := 0; i < 2; i++ {
+		_, err := client.GetRequests()
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 2, fs.hitCount())
+
+	_, err := client.GetRequests()
+	assert.True(t, circuitOpen(err), "%T: %v", err, err)
+	assert.Equal(t, 2, fs.hitCount(), "requests made while the breaker is open")
+
+	// Once the cooldown has passed, a single failure opens the breaker again.
+	setNow(start.Add(2 * time.Minute))
+	_, err = client.GetRequests()
+	assert.Error(t, err)
+	assert.Equal(t, 3, fs.hitCount())
+	_, err = client.GetRequests()
+	assert.True(t, circuitOpen(err), "%T: %v", err, err)
+
+	// A success closes it.
+	fs.fail(0, 0)
+	setNow(start.Add(4 * time.Minute))
+	for i := 0; i < 3; i++ {
+		_, err = client.GetRequests()
+		assert.NoError(t, err)
+	}
+}
+
+func TestRunningDeployments_UnreachableCluster(t *testing.T) {
+	up := newFakeSingularity()
+	defer up.Close()
+	down := newFakeSingularity()
+	defer down.Close()
+	down.fail(100, http.StatusServiceUnavailable)
+
+	rc := NewRectiAgent(sous.NewDummyRegistry())
+	dep := NewTombstoningDeployer(rc, sous.NewTombstones()).(*deployer)
+	rc.clients.sleep = func(time.Duration) {}
+	clusters := sous.Clusters{
+		"up":   &sous.Cluster{Name: "up", BaseURL: up.URL},
+		"down": &sous.Cluster{Name: "down", BaseURL: down.URL},
+	}
+
+	ds, err := dep.RunningDeployments(sous.NewDummyRegistry(), clusters)
+	require.Error(t, err)
+	unreachable, ok := errors.Cause(err).(*sous.UnreachableClustersError)
+	require.True(t, ok, "%T: %v", err, err)
+	assert.Equal(t, []string{"down"}, unreachable.Names())
+	assert.Equal(t, 0, ds.Len())
+	assert.Equal(t, 1, up.hitCount())
+	assert.Equal(t, 1+sous.DefaultClientPolicy.MaxRetries, down.hitCount())
+}
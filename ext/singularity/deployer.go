@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"runtime/debug"
 	"strings"
+	"time"
 
 	"github.com/opentable/go-singularity"
 	"github.com/opentable/sous/lib"
@@ -39,20 +40,12 @@ dChans := intendedSet.Diff(existingSet)
 
 type (
 	deployer struct {
-		Client  rectificationClient
-		singFac func(string) *singularity.Client
-	}
-
-	// rectificationClient abstracts the raw interactions with Singularity.
-	rectificationClient interface {
-		// Deploy creates a new deploy on a particular requeust
-		Deploy(d sous.Deployable, reqID string) error
-
-		// PostRequest sends a request to a Singularity cluster to initiate
-		PostRequest(d sous.Deployable, reqID string) error
-
-		// DeleteRequest instructs Singularity to delete a particular request
-		DeleteRequest(cluster, reqID, message string) error
+		Client     sous.RectificationClient
+		Tombstones *sous.Tombstones
+		singFac    func(string) *singularity.Client
+		clients    *clientPool
+		now        func() time.Time
+		cache      *deploymentCache
 	}
 
 	// DTOMap is shorthand for map[string]interface{}
@@ -68,8 +61,33 @@ func stripDeployID(in string) string {
 }
 
 // NewDeployer creates a new Singularity-based sous.Deployer.
-func NewDeployer(c rectificationClient) sous.Deployer {
-	return &deployer{Client: c}
+func NewDeployer(c sous.RectificationClient) sous.Deployer {
+	return NewTombstoningDeployer(c, sous.NewTombstones())
+}
+
+// NewTombstoningDeployer creates a new Singularity-based sous.Deployer which
+// records orphaned deployments in ts.
+func NewTombstoningDeployer(c sous.RectificationClient, ts *sous.Tombstones) sous.Deployer {
+	// Sharing the RectiAgent's clients means a cluster found unreachable
+	// while rectifying is not polled again until its breaker closes, and vice
+	// versa.
+	clients := newClientPool()
+	if ra, ok := c.(*RectiAgent); ok {
+		clients = ra.clients
+	}
+	return &deployer{
+		Client:     c,
+		Tombstones: ts,
+		clients:    clients,
+		now:        time.Now,
+		cache:      newDeploymentCache(DefaultDeploymentCacheMaxAge),
+	}
+}
+
+// DeploymentCacheStats returns the statistics of r's cache of deployments
+// reconstructed from Singularity.
+func (r *deployer) DeploymentCacheStats() DeploymentCacheStats {
+	return r.cache.Stats()
 }
 
 // RectifyCreates implements sous.Deployer on deployer
@@ -102,7 +120,7 @@ func (r *deployer) SetSingularityFactory(fn func(string) *singularity.Client) {
 
 func (r *deployer) buildSingClient(url string) *singularity.Client {
 	if r.singFac == nil {
-		return singularity.NewClient(url)
+		return r.clients.client(url)
 	}
 	return r.singFac(url)
 }
@@ -136,31 +154,56 @@ func (r *deployer) RectifySingleCreate(d *sous.DeployablePair) (err error) {
 func (r *deployer) RectifyDeletes(dc <-chan *sous.DeployablePair, errs chan<- sous.DiffResolution) {
 	for d := range dc {
 		result := sous.DiffResolution{DeploymentID: d.ID()}
-		if err := r.RectifySingleDelete(d); err != nil {
+		if desc, err := r.RectifySingleDelete(d); err != nil {
 			result.Error = sous.WrapResolveError(&sous.DeleteError{Deployment: d.Prior.Deployment.Clone(), Err: err})
 			result.Desc = "not deleted"
 		} else {
-			result.Desc = "deleted"
+			result.Desc = desc
 		}
 		Log.Vomit.Printf("Reporting result of delete: %#v", result)
 		errs <- result
 	}
 }
 
-func (r *deployer) RectifySingleDelete(d *sous.DeployablePair) (err error) {
+// RectifySingleDelete handles a deployment with no manifest. If deletion is
+// enabled for its cluster, it is first scaled to zero and tombstoned, and
+// only deleted once the cluster's OrphanGracePeriod has elapsed. Otherwise
+// it is left alone.
+func (r *deployer) RectifySingleDelete(d *sous.DeployablePair) (desc sous.ResolutionType, err error) {
 	defer rectifyRecover(d, "RectifySingleDelete", &err)
 	data, ok := d.ExecutorData.(*singularityTaskData)
 	if !ok {
-		return errors.Errorf("Delete record %#v doesn't contain Singularity compatible data: was %T\n\t%#v", d.ID(), data, d)
+		return "", errors.Errorf("Delete record %#v doesn't contain Singularity compatible data: was %T\n\t%#v", d.ID(), data, d)
 	}
 	requestID := data.requestID
+	dep := d.Prior.Deployment
 
-	// TODO: Alert the owner of this request that there is no manifest for it;
-	// they should either delete the request manually, or else add the manifest back.
-	sous.Log.Warn.Printf("NOT DELETING REQUEST %q (FOR: %q)", requestID, d.ID())
-	return nil
-	// The following line deletes requests when it is not commented out.
-	//return r.Client.DeleteRequest(d.Cluster.BaseURL, requestID, "deleting request for removed manifest")
+	if dep.Cluster == nil || !dep.Cluster.EnableOrphanDeletion {
+		sous.Log.Warn.Printf("NOT DELETING REQUEST %q (FOR: %q): orphan deletion not enabled", requestID, d.ID())
+		return sous.StableDiff, nil
+	}
+
+	now := r.now()
+	orphan, isNew := r.Tombstones.Mark(dep, requestID, now)
+	if isNew || dep.NumInstances != 0 {
+		if err := r.Client.Scale(dep.Cluster.BaseURL, requestID, 0, "scaling down orphaned request"); err != nil {
+			r.Tombstones.Remove(dep.ID())
+			return "", err
+		}
+	}
+	if isNew {
+		sous.Log.Warn.Printf("Request %q (for: %q, owners: %v) has no manifest; scaled to zero. Add the manifest back, or delete it with `sous plumbing delete`.",
+			requestID, d.ID(), orphan.Owners)
+	}
+	if !orphan.Expired(now) {
+		return sous.TombstoneDiff, nil
+	}
+
+	if err := r.Client.DeleteRequest(dep.Cluster.BaseURL, requestID, "deleting request for removed manifest"); err != nil {
+		return "", err
+	}
+	r.Tombstones.Remove(dep.ID())
+	return sous.DeleteDiff, nil
 }
 
 func (r *deployer) RectifyModifies(
@@ -203,6 +246,7 @@ func (r *deployer) RectifySingleModification(pair *sous.DeployablePair) (err err
 		return err
 	}
 	reqID := data.requestID
+	r.Tombstones.Remove(pair.ID())
 
 	changesApplied := false
 	Log.Vomit.Printf("Operating on request %q", reqID)
@@ -213,6 +257,14 @@ func (r *deployer) RectifySingleModification(pair *sous.DeployablePair) (err err
 			return err
 		}
 		changesApplied = true
+	} else if changesInstances(pair) {
+		Log.Debug.Printf("Scaling...")
+		msg := fmt.Sprintf("scaling from %d to %d instances", pair.Prior.NumInstances, pair.Post.NumInstances)
+		if err := r.Client.Scale(pair.Post.Cluster.BaseURL, reqID, pair.Post.NumInstances, msg); err != nil {
+			Log.Warn.Println(err)
+			return err
+		}
+		changesApplied = true
 	} else {
 		Log.Debug.Printf("Request %q does not require changes", reqID)
 	}
@@ -236,6 +288,34 @@ func (r *deployer) RectifySingleModification(pair *sous.DeployablePair) (err err
 }
 
 func changesReq(pair *sous.DeployablePair) bool {
+	return !pair.Prior.Schedule.Equal(pair.Post.Schedule) ||
+		pair.Prior.Placement.LoadBalanced() != pair.Post.Placement.LoadBalanced() ||
+		!requestPlacement(pair.Prior.Placement).Equal(requestPlacement(pair.Post.Placement))
+}
+
+// requestPlacement returns the parts of p that are set on the Singularity
+// request, rather than on each deploy.
+func requestPlacement(p sous.Placement) sous.Placement {
+	return sous.Placement{
+		RackSensitive:      p.RackSensitive,
+		SlavePlacement:     p.SlavePlacement,
+		RequiredAttributes: p.RequiredAttributes,
+	}
+}
+
+// deployPlacement returns the parts of p that are set on each Singularity
+// deploy.
+func deployPlacement(p sous.Placement) sous.Placement {
+	return sous.Placement{
+		LoadBalancerGroups: p.LoadBalancerGroups,
+		ServiceBasePath:    p.ServiceBasePath,
+	}
+}
+
+// changesInstances returns true if the number of instances of pair changes.
+// When nothing else about the request changes, this is applied by scaling
+// the request rather than re-posting it.
+func changesInstances(pair *sous.DeployablePair) bool {
 	return pair.Prior.NumInstances != pair.Post.NumInstances
 }
 
@@ -246,6 +326,10 @@ func changesDep(pair *sous.DeployablePair) bool {
 			pair.Prior.Resources.Equal(pair.Post.Resources) &&
 			pair.Prior.Env.Equal(pair.Post.Env) &&
 			pair.Prior.DeployConfig.Volumes.Equal(pair.Post.DeployConfig.Volumes) &&
+			pair.Prior.NetworkMode.Equal(pair.Post.NetworkMode) &&
+			pair.Prior.Ports.Equal(pair.Post.Ports) &&
+			pair.Prior.Autoscale == pair.Post.Autoscale &&
+			deployPlacement(pair.Prior.Placement).Equal(deployPlacement(pair.Post.Placement)) &&
 			pair.Prior.Startup.Equal(pair.Post.Startup))
 }
 
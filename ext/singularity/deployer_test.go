@@ -353,10 +353,41 @@ func TestChangesReq(t *testing.T) {
 	changed := baseDep.Clone()
 	changed.DeployConfig.NumInstances = 100
 
-	if !changesReq(testPair(changed)) {
+	if changesReq(testPair(changed)) {
+		t.Error("Change in NumInstances mis-reported to change requirement")
+	}
+	if !changesInstances(testPair(changed)) {
 		t.Error("Change in NumInstances ignored")
 	}
 
+	changed = baseDep.Clone()
+	changed.Schedule.Cron = "0 3 * * *"
+
+	if !changesReq(testPair(changed)) {
+		t.Error("Change in Schedule ignored")
+	}
+
+	changed = baseDep.Clone()
+	changed.Placement.RequiredAttributes = map[string]string{"rack": "a"}
+
+	if !changesReq(testPair(changed)) {
+		t.Error("Change in required attributes ignored")
+	}
+
+	changed = baseDep.Clone()
+	changed.Placement.SlavePlacement = "SEPARATE"
+
+	if !changesReq(testPair(changed)) {
+		t.Error("Change in slave placement ignored")
+	}
+
+	changed = baseDep.Clone()
+	changed.Placement.ServiceBasePath = "/app"
+
+	if changesReq(testPair(changed)) {
+		t.Error("Change in service base path mis-reported to change requirement")
+	}
+
 	changed = baseDep.Clone()
 	changed.Env["VAR"] = "VALUE"
 
@@ -427,6 +458,36 @@ func TestChangesDep(t *testing.T) {
 		t.Error("Change to volumes on deployment reported as no change")
 	}
 
+	changed = baseDep.Clone()
+	changed.NetworkMode = sous.NetworkHost
+	if !changesDep(testPair(changed)) {
+		t.Error("Change to network mode on deployment reported as no change")
+	}
+
+	changed = baseDep.Clone()
+	changed.Ports = sous.PortMappings{{Name: "http", ContainerPort: 8080}}
+	if !changesDep(testPair(changed)) {
+		t.Error("Change to ports on deployment reported as no change")
+	}
+
+	changed = baseDep.Clone()
+	changed.Placement.LoadBalancerGroups = []string{"internal"}
+	if !changesDep(testPair(changed)) {
+		t.Error("Change to load balancer groups on deployment reported as no change")
+	}
+
+	changed = baseDep.Clone()
+	changed.Autoscale = sous.Autoscale{MinInstances: 1, MaxInstances: 4}
+	if !changesDep(testPair(changed)) {
+		t.Error("Change to autoscale bounds on deployment reported as no change")
+	}
+
+	changed = baseDep.Clone()
+	changed.Placement.RackSensitive = true
+	if changesDep(testPair(changed)) {
+		t.Error("Change to rack sensitivity mis-reported as changed deploy")
+	}
+
 	changed = baseDep.Clone()
 	hcpath := "/something/something/healthcheck"
 	changed.Startup.CheckReadyURIPath = &hcpath
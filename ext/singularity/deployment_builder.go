@@ -24,11 +24,8 @@ type (
 		req       SingReq
 		registry  sous.ImageLabeller
 		reqID     string
-	}
-
-	canRetryRequest struct {
-		cause error
-		req   SingReq
+		labels    map[string]string
+		cache     *deploymentCache
 	}
 
 	malformedResponse struct {
@@ -80,36 +77,23 @@ func isMalformed(err error) bool {
 	return isMal || isUMT || isUMF || isUST || isUSV
 }
 
-func (cr *canRetryRequest) Error() string {
-	return fmt.Sprintf("%s: %s", cr.cause, cr.name())
-}
-
-func (cr *canRetryRequest) name() string {
-	return fmt.Sprintf("%s:%s", cr.req.SourceURL, cr.req.ReqParent.Request.Id)
-}
-
-func (db *deploymentBuilder) canRetry(err error) error {
-	if err == nil || !db.isRetryable(err) {
-		return err
-	}
-	return &canRetryRequest{err, db.req}
-}
-
-func (db *deploymentBuilder) isRetryable(err error) bool {
-	return !isMalformed(err) &&
-		!ignorableDeploy(err) &&
-		db.req.SourceURL != "" &&
-		db.req.ReqParent != nil &&
-		db.req.ReqParent.Request != nil &&
-		db.req.ReqParent.Request.Id != ""
-}
-
 // BuildDeployment does all the work to collect the data for a Deployment
 // from Singularity based on the initial SingularityRequest.
 func BuildDeployment(reg sous.ImageLabeller, clusters sous.Clusters, req SingReq) (sous.DeployState, error) {
+	return buildDeployment(reg, clusters, req, nil)
+}
+
+// buildDeployment is BuildDeployment, but reuses the deploy history and image
+// labels in cache if they are still valid for req, and stores them there
+// otherwise.
+func buildDeployment(reg sous.ImageLabeller, clusters sous.Clusters, req SingReq, cache *deploymentCache) (sous.DeployState, error) {
 	Log.Vomit.Printf("%#v", req.ReqParent)
-	db := deploymentBuilder{registry: reg, clusters: clusters, req: req}
-	return db.Target, db.canRetry(db.completeConstruction())
+	db := deploymentBuilder{registry: reg, clusters: clusters, req: req, cache: cache}
+	err := db.completeConstruction()
+	if err == nil {
+		cache.put(req, db.history, db.labels)
+	}
+	return db.Target, err
 }
 
 func (db *deploymentBuilder) completeConstruction() error {
@@ -198,6 +182,12 @@ func (db *deploymentBuilder) determineDeployStatus() error {
 }
 
 func (db *deploymentBuilder) retrieveDeployHistory() error {
+	if entry, ok := db.cache.get(db.req); ok {
+		Log.Vomit.Printf("%q Using cached history for deploy %q", db.reqID, entry.deployID)
+		db.history = entry.history
+		db.labels = entry.labels
+		return nil
+	}
 	if db.depMarker == nil {
 		return db.retrieveHistoricDeploy()
 	}
@@ -317,18 +307,25 @@ func (db *deploymentBuilder) extractArtifactName() error {
 	}
 
 	db.imageName = dkr.Image
+	db.Target.Artifact = &sous.BuildArtifact{Name: dkr.Image, Type: "docker"}
 	return nil
 }
 
 func (db *deploymentBuilder) retrieveImageLabels() error {
 	// XXX coupled to Docker registry as ImageMapper
 	// !!! HTTP request
-	labels, err := db.registry.ImageLabels(db.imageName)
-	if err != nil {
-		return malformedResponse{err.Error()}
+	labels := db.labels
+	if labels == nil {
+		var err error
+		labels, err = db.registry.ImageLabels(db.imageName)
+		if err != nil {
+			return malformedResponse{err.Error()}
+		}
+		db.labels = labels
 	}
 	Log.Vomit.Printf("%q Labels: %v", db.reqID, labels)
 
+	var err error
 	db.Target.SourceID, err = docker.SourceIDFromLabels(labels)
 	if err != nil {
 		return errors.Wrapf(malformedResponse{err.Error()}, "For reqID: %s", reqID(db.req.ReqParent))
@@ -343,15 +340,23 @@ func (db *deploymentBuilder) assignClusterName() error {
 	if !ok {
 		return malformedResponse{fmt.Sprintf("Deploy Metadata did not include a %s", sous.ClusterNameLabel)}
 	}
+	// Carry the cluster's configuration, e.g. its orphan deletion policy, but
+	// keep the URL this deployment was actually found at.
+	if cluster, ok := db.clusters[db.Target.ClusterName]; ok && cluster != nil {
+		db.Target.Cluster = cluster.Clone()
+		db.Target.Cluster.BaseURL = db.req.SourceURL
+	}
 	return nil
 }
 
 func (db *deploymentBuilder) unpackDeployConfig() error {
-	db.Target.Env = db.deploy.Env
-	Log.Vomit.Printf("%q Env: %+v", db.reqID, db.deploy.Env)
-	if db.Target.Env == nil {
-		db.Target.Env = make(map[string]string)
+	// The deploy may be cached and reused, so its Env is copied rather than
+	// shared.
+	db.Target.Env = make(map[string]string, len(db.deploy.Env))
+	for k, v := range db.deploy.Env {
+		db.Target.Env[k] = v
 	}
+	Log.Vomit.Printf("%q Env: %+v", db.reqID, db.deploy.Env)
 
 	singRez := db.deploy.Resources
 	if singRez == nil {
@@ -381,6 +386,14 @@ func (db *deploymentBuilder) unpackDeployConfig() error {
 		Log.Debug.Printf("%q %+v", db.reqID, db.Target.DeployConfig.Volumes[0])
 	}
 
+	if err := db.unpackNetwork(); err != nil {
+		return err
+	}
+	db.unpackPlacement()
+	if err := db.unpackAutoscale(); err != nil {
+		return err
+	}
+
 	if db.deploy.HealthcheckUri != "" { // terrible hack
 		val := string(db.deploy.HealthcheckUri)
 		db.Target.Startup.CheckReadyURIPath = &val
@@ -396,9 +409,96 @@ func (db *deploymentBuilder) unpackDeployConfig() error {
 		db.Target.Startup.Timeout = &val
 	}
 
+	if db.deploy.HealthcheckIntervalSeconds != 0 {
+		val := int(db.deploy.HealthcheckIntervalSeconds)
+		db.Target.Startup.CheckReadyInterval = &val
+	}
+
+	if db.deploy.HealthcheckMaxRetries != 0 {
+		val := int(db.deploy.HealthcheckMaxRetries)
+		db.Target.Startup.CheckReadyRetries = &val
+	}
+
+	if db.deploy.HealthcheckPortIndex != 0 {
+		val := int(db.deploy.HealthcheckPortIndex)
+		db.Target.Startup.CheckReadyPortIndex = &val
+	}
+
+	if db.deploy.SkipHealthchecksOnDeploy {
+		val := true
+		db.Target.Startup.SkipCheckReady = &val
+	}
+
 	return nil
 }
 
+func (db *deploymentBuilder) unpackNetwork() error {
+	docker := db.deploy.ContainerInfo.Docker
+	if docker == nil {
+		return nil
+	}
+	switch docker.Network {
+	default:
+		return malformedResponse{fmt.Sprintf("Unrecognized docker network type: %q", docker.Network)}
+	case "", dtos.SingularityDockerInfoSingularityDockerNetworkTypeBRIDGE:
+		db.Target.NetworkMode = sous.NetworkBridge
+	case dtos.SingularityDockerInfoSingularityDockerNetworkTypeHOST:
+		db.Target.NetworkMode = sous.NetworkHost
+	case dtos.SingularityDockerInfoSingularityDockerNetworkTypeNONE:
+		db.Target.NetworkMode = sous.NetworkNone
+	}
+
+	var names []string
+	if n := db.deploy.Metadata[sous.PortNamesLabel]; n != "" {
+		names = strings.Split(n, ",")
+	}
+	for i, pm := range docker.PortMappings {
+		if pm == nil {
+			continue
+		}
+		p := sous.PortMapping{
+			ContainerPort: int(pm.ContainerPort),
+			Protocol:      pm.Protocol,
+		}
+		if i < len(names) {
+			p.Name = names[i]
+		}
+		db.Target.Ports = append(db.Target.Ports, p)
+	}
+	return nil
+}
+
+func (db *deploymentBuilder) unpackAutoscale() error {
+	label := db.deploy.Metadata[sous.AutoscaleLabel]
+	if label == "" {
+		return nil
+	}
+	a := sous.Autoscale{}
+	if _, err := fmt.Sscanf(label, "%d,%d,%g", &a.MinInstances, &a.MaxInstances, &a.TargetCPU); err != nil {
+		return malformedResponse{fmt.Sprintf("Deploy metadata %s=%q not valid: %v", sous.AutoscaleLabel, label, err)}
+	}
+	db.Target.Autoscale = a
+	return nil
+}
+
+func (db *deploymentBuilder) unpackPlacement() {
+	p := sous.Placement{
+		RackSensitive:  db.request.RackSensitive,
+		SlavePlacement: string(db.request.SlavePlacement),
+	}
+	if len(db.request.RequiredSlaveAttributes) != 0 {
+		p.RequiredAttributes = make(map[string]string, len(db.request.RequiredSlaveAttributes))
+		for k, v := range db.request.RequiredSlaveAttributes {
+			p.RequiredAttributes[k] = v
+		}
+	}
+	if db.request.LoadBalanced {
+		p.LoadBalancerGroups = append([]string{}, db.deploy.LoadBalancerGroups...)
+		p.ServiceBasePath = db.deploy.ServiceBasePath
+	}
+	db.Target.Placement = p
+}
+
 func (db *deploymentBuilder) determineManifestKind() error {
 	switch db.request.RequestType {
 	default:
@@ -411,6 +511,10 @@ func (db *deploymentBuilder) determineManifestKind() error {
 		db.Target.Kind = sous.ManifestKindOnDemand
 	case dtos.SingularityRequestRequestTypeSCHEDULED:
 		db.Target.Kind = sous.ManifestKindScheduled
+		db.Target.Schedule = sous.Schedule{
+			Cron:     db.request.Schedule,
+			TimeZone: db.request.ScheduleTimeZone,
+		}
 	case dtos.SingularityRequestRequestTypeRUN_ONCE:
 		db.Target.Kind = sous.ManifestKindOnce
 	}
@@ -0,0 +1,166 @@
+package singularity
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultDeploymentCacheMaxAge is how long the deploy history of a request is
+// reused for, even if Singularity reports no new deploy for it.
+const DefaultDeploymentCacheMaxAge = 10 * time.Minute
+
+type (
+	// deploymentCache remembers the deploy history and image labels of each
+	// request between resolve cycles, so that requests with no new deploy can
+	// be reconstructed without fetching them again. The request itself is
+	// always taken fresh from Singularity, since e.g. its instances change
+	// without a new deploy.
+	//
+	// A nil *deploymentCache caches nothing.
+	deploymentCache struct {
+		sync.Mutex
+		maxAge  time.Duration
+		now     func() time.Time
+		entries map[deploymentCacheKey]deploymentCacheEntry
+		stats   DeploymentCacheStats
+	}
+
+	deploymentCacheKey struct {
+		url, requestID string
+	}
+
+	deploymentCacheEntry struct {
+		// deployID is the active deploy of the request when the entry was
+		// stored. The entry is only valid while it remains active.
+		deployID string
+		history  sHistory
+		labels   map[string]string
+		stored   time.Time
+	}
+
+	// DeploymentCacheStats records the effectiveness of the cache of
+	// reconstructed deployments.
+	DeploymentCacheStats struct {
+		// Hits and Misses count lookups.
+		Hits, Misses uint64
+		// Invalidations counts entries dropped because Singularity reported a
+		// new deploy for the request.
+		Invalidations uint64
+		// Expirations counts entries dropped because they were older than the
+		// cache's maximum age.
+		Expirations uint64
+		// Entries is the number of entries currently cached.
+		Entries int
+	}
+)
+
+func newDeploymentCache(maxAge time.Duration) *deploymentCache {
+	return &deploymentCache{
+		maxAge:  maxAge,
+		now:     time.Now,
+		entries: map[deploymentCacheKey]deploymentCacheEntry{},
+	}
+}
+
+// HitRate returns the fraction of lookups which were hits.
+func (s DeploymentCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// get returns the entry for the request described by req, if there is a
+// valid one. Entries are invalid once the request has a pending deploy, or a
+// different active deploy.
+func (dc *deploymentCache) get(req SingReq) (deploymentCacheEntry, bool) {
+	if dc == nil {
+		return deploymentCacheEntry{}, false
+	}
+	key, activeID, pending := cacheKey(req)
+	dc.Lock()
+	defer dc.Unlock()
+	entry, ok := dc.entries[key]
+	switch {
+	case !ok:
+	case pending || entry.deployID != activeID:
+		delete(dc.entries, key)
+		dc.stats.Invalidations++
+		ok = false
+	case dc.now().Sub(entry.stored) > dc.maxAge:
+		delete(dc.entries, key)
+		dc.stats.Expirations++
+		ok = false
+	}
+	if ok {
+		dc.stats.Hits++
+	} else {
+		dc.stats.Misses++
+	}
+	return entry, ok
+}
+
+// put stores the history and labels retrieved for req. Requests with a
+// pending deploy, or whose latest deploy has no result yet, are not stored,
+// since their history is still changing.
+func (dc *deploymentCache) put(req SingReq, history sHistory, labels map[string]string) {
+	if dc == nil || history == nil || history.DeployResult == nil {
+		return
+	}
+	key, activeID, pending := cacheKey(req)
+	if pending || activeID == "" {
+		return
+	}
+	dc.Lock()
+	defer dc.Unlock()
+	dc.entries[key] = deploymentCacheEntry{
+		deployID: activeID,
+		history:  history,
+		labels:   labels,
+		stored:   dc.now(),
+	}
+}
+
+// expire drops all entries older than the maximum age.
+func (dc *deploymentCache) expire() {
+	if dc == nil {
+		return
+	}
+	dc.Lock()
+	defer dc.Unlock()
+	now := dc.now()
+	for key, entry := range dc.entries {
+		if now.Sub(entry.stored) > dc.maxAge {
+			delete(dc.entries, key)
+			dc.stats.Expirations++
+		}
+	}
+}
+
+// Stats returns the statistics of the cache so far.
+func (dc *deploymentCache) Stats() DeploymentCacheStats {
+	if dc == nil {
+		return DeploymentCacheStats{}
+	}
+	dc.Lock()
+	defer dc.Unlock()
+	stats := dc.stats
+	stats.Entries = len(dc.entries)
+	return stats
+}
+
+func cacheKey(req SingReq) (key deploymentCacheKey, activeID string, pending bool) {
+	key.url = req.SourceURL
+	rp := req.ReqParent
+	if rp == nil {
+		return
+	}
+	key.requestID = reqID(rp)
+	if rds := rp.RequestDeployState; rds != nil {
+		pending = rds.PendingDeploy != nil
+		if rds.ActiveDeploy != nil {
+			activeID = rds.ActiveDeploy.DeployId
+		}
+	}
+	return
+}
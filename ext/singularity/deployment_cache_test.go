@@ -0,0 +1,130 @@
+package singularity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opentable/go-singularity/dtos"
+	"github.com/opentable/sous/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	countingSingClient struct {
+		fakeSingClient
+		calls int
+	}
+
+	countingImageLabeller struct {
+		fakeImageLabeller
+		calls int
+	}
+)
+
+func (c *countingSingClient) GetDeploy(requestID string, deployID string) (*dtos.SingularityDeployHistory, error) {
+	c.calls++
+	return c.fakeSingClient.GetDeploy(requestID, deployID)
+}
+
+func (c *countingSingClient) GetDeploys(requestID string, count, page int32) (dtos.SingularityDeployHistoryList, error) {
+	c.calls++
+	return c.fakeSingClient.GetDeploys(requestID, count, page)
+}
+
+func (c *countingImageLabeller) ImageLabels(imageName string) (map[string]string, error) {
+	c.calls++
+	return c.fakeImageLabeller.ImageLabels(imageName)
+}
+
+func TestDeploymentCache(t *testing.T) {
+	url := "http://example.com/singularity"
+	clusters := sous.Clusters{"left": &sous.Cluster{Name: "left", BaseURL: url}}
+
+	sing := &countingSingClient{fakeSingClient: fakeSingClient{
+		cannedAnswer: &dtos.SingularityDeployHistory{
+			DeployResult: &dtos.SingularityDeployResult{
+				DeployState: dtos.SingularityDeployResultDeployStateSUCCEEDED,
+			},
+			DeployMarker: &dtos.SingularityDeployMarker{DeployId: "deploy-1"},
+			Deploy: &dtos.SingularityDeploy{
+				Metadata: map[string]string{sous.ClusterNameLabel: "left"},
+				Env:      map[string]string{"VAR": "value"},
+				ContainerInfo: &dtos.SingularityContainerInfo{
+					Type:   "DOCKER",
+					Docker: &dtos.SingularityDockerInfo{Image: "image-name"},
+				},
+				Resources: &dtos.Resources{},
+			},
+		},
+	}}
+	reg := &countingImageLabeller{fakeImageLabeller: fakeImageLabeller{
+		cannedAnswer: map[string]string{
+			"com.opentable.sous.repo_url":    "repo_url",
+			"com.opentable.sous.revision":    "revision",
+			"com.opentable.sous.repo_offset": "repo_offset",
+			"com.opentable.sous.version":     "1.2.3",
+		},
+	}}
+	newReq := func(active string, instances int32, pending bool) SingReq {
+		rds := &dtos.SingularityRequestDeployState{
+			ActiveDeploy: &dtos.SingularityDeployMarker{DeployId: active},
+		}
+		if pending {
+			rds.PendingDeploy = &dtos.SingularityDeployMarker{RequestId: "req", DeployId: "deploy-2"}
+		}
+		return SingReq{
+			SourceURL: url,
+			Sing:      sing,
+			ReqParent: &dtos.SingularityRequestParent{
+				RequestDeployState: rds,
+				Request: &dtos.SingularityRequest{
+					Id:          "req",
+					RequestType: dtos.SingularityRequestRequestTypeSERVICE,
+					Instances:   instances,
+				},
+			},
+		}
+	}
+
+	now := time.Now()
+	cache := newDeploymentCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	build := func(req SingReq) sous.DeployState {
+		ds, err := buildDeployment(reg, clusters, req, cache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ds
+	}
+
+	build(newReq("deploy-1", 1, false))
+	assert.Equal(t, 2, sing.calls)
+	assert.Equal(t, 1, reg.calls)
+
+	// The request changes, but not its deploy: only the request is re-read.
+	ds := build(newReq("deploy-1", 3, false))
+	assert.Equal(t, 2, sing.calls)
+	assert.Equal(t, 1, reg.calls)
+	assert.Equal(t, 3, ds.NumInstances)
+	ds.Env["VAR"] = "changed"
+	assert.Equal(t, "value", sing.cannedAnswer.Deploy.Env["VAR"], "cached deploy shared")
+
+	// A pending deploy invalidates the entry, and is not cached itself.
+	build(newReq("deploy-1", 3, true))
+	assert.Equal(t, 3, sing.calls)
+	build(newReq("deploy-1", 3, true))
+	assert.Equal(t, 4, sing.calls)
+
+	// A new active deploy misses, and is cached.
+	build(newReq("deploy-2", 3, false))
+	assert.Equal(t, 6, sing.calls)
+	build(newReq("deploy-2", 3, false))
+	assert.Equal(t, 6, sing.calls)
+
+	now = now.Add(2 * time.Minute)
+	cache.expire()
+
+	stats := cache.Stats()
+	assert.Equal(t, DeploymentCacheStats{Hits: 2, Misses: 4, Invalidations: 1, Expirations: 1}, stats)
+	assert.InDelta(t, 1.0/3, stats.HitRate(), 0.001)
+}
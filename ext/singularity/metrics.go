@@ -0,0 +1,109 @@
+package singularity
+
+import (
+	"sync"
+
+	"github.com/opentable/sous/lib"
+	"github.com/pkg/errors"
+)
+
+type (
+	// MetricsProvider is a sous.MetricsProvider which reports the CPU
+	// utilization of deployments from the Mesos statistics of their active
+	// tasks in Singularity.
+	MetricsProvider struct {
+		clusters sous.Clusters
+		clients  *clientPool
+
+		sync.Mutex
+		// samples are the statistics last seen for the active tasks of each
+		// request, so that utilization is measured since the previous call
+		// rather than over the whole life of each task.
+		samples map[string]map[string]cpuSample
+	}
+
+	cpuSample struct {
+		// cpuSecs is the CPU time the task has used, in seconds.
+		cpuSecs float64
+		// at is when the sample was taken, in seconds since the epoch.
+		at float64
+	}
+)
+
+// NewMetricsProvider returns a MetricsProvider for the deployments to
+// clusters.
+func NewMetricsProvider(clusters sous.Clusters) *MetricsProvider {
+	clients := newClientPool()
+	clients.configure(clusters)
+	return &MetricsProvider{
+		clusters: clusters,
+		clients:  clients,
+		samples:  map[string]map[string]cpuSample{},
+	}
+}
+
+// Metrics implements sous.MetricsProvider.
+func (mp *MetricsProvider) Metrics(id sous.DeploymentID) (sous.DeploymentMetrics, error) {
+	m := sous.DeploymentMetrics{}
+	cluster, ok := mp.clusters[id.Cluster]
+	if !ok {
+		return m, errors.Errorf("no cluster named %q", id.Cluster)
+	}
+	reqID, err := MakeRequestID(id)
+	if err != nil {
+		return m, err
+	}
+	client := mp.clients.client(cluster.BaseURL)
+	tasks, err := client.GetTaskHistoryForActiveRequest(reqID)
+	if err != nil {
+		return m, errors.Wrapf(err, "listing tasks of %s", reqID)
+	}
+
+	mp.Lock()
+	prev := mp.samples[reqID]
+	mp.Unlock()
+	cur := map[string]cpuSample{}
+	var total float64
+	for _, task := range tasks {
+		if task == nil || task.TaskId == nil {
+			continue
+		}
+		stats, err := client.GetTaskStatistics(task.TaskId.Id)
+		if err != nil {
+			return m, errors.Wrapf(err, "getting statistics of %s", task.TaskId.Id)
+		}
+		sample := cpuSample{
+			cpuSecs: stats.CpusUserTimeSecs + stats.CpusSystemTimeSecs,
+			at:      stats.Timestamp,
+		}
+		cur[task.TaskId.Id] = sample
+		last, ok := prev[task.TaskId.Id]
+		if !ok {
+			last = cpuSample{at: float64(task.TaskId.StartedAt) / 1000}
+		}
+		u, ok := cpuUtilization(last, sample, stats.CpusLimit)
+		if !ok {
+			continue
+		}
+		total += u
+		m.Instances++
+	}
+	mp.Lock()
+	mp.samples[reqID] = cur
+	mp.Unlock()
+	if m.Instances == 0 {
+		return m, errors.Errorf("no statistics for the tasks of %s", reqID)
+	}
+	m.CPUUtilization = total / float64(m.Instances)
+	return m, nil
+}
+
+// cpuUtilization returns the fraction of limit CPUs used between samples
+// prev and cur.
+func cpuUtilization(prev, cur cpuSample, limit float64) (float64, bool) {
+	elapsed := cur.at - prev.at
+	if elapsed <= 0 || limit <= 0 {
+		return 0, false
+	}
+	return (cur.cpuSecs - prev.cpuSecs) / elapsed / limit, true
+}
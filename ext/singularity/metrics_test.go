@@ -0,0 +1,19 @@
+package singularity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCPUUtilization(t *testing.T) {
+	u, ok := cpuUtilization(cpuSample{cpuSecs: 10, at: 100}, cpuSample{cpuSecs: 13, at: 110}, 0.5)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.6, u, 1e-9)
+
+	_, ok = cpuUtilization(cpuSample{at: 100}, cpuSample{at: 100}, 0.5)
+	assert.False(t, ok, "no time elapsed")
+
+	_, ok = cpuUtilization(cpuSample{at: 100}, cpuSample{cpuSecs: 1, at: 110}, 0)
+	assert.False(t, ok, "no CPU limit")
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
-	"sync"
 
 	"github.com/opentable/go-singularity"
 	"github.com/opentable/go-singularity/dtos"
@@ -34,8 +33,7 @@ func stripMetadata(in string) string {
 type (
 	// RectiAgent is an implementation of the RectificationClient interface
 	RectiAgent struct {
-		singClients map[string]*singularity.Client
-		sync.RWMutex
+		clients  *clientPool
 		labeller sous.ImageLabeller
 	}
 
@@ -47,8 +45,8 @@ type (
 // NewRectiAgent returns a set-up RectiAgent
 func NewRectiAgent(l sous.ImageLabeller) *RectiAgent {
 	return &RectiAgent{
-		singClients: make(map[string]*singularity.Client),
-		labeller:    l,
+		clients:  newClientPool(),
+		labeller: l,
 	}
 }
 
@@ -67,7 +65,7 @@ func (ra *RectiAgent) Deploy(d sous.Deployable, reqID string) error {
 	if d.BuildArtifact == nil {
 		return &sous.MissingImageNameError{Cause: fmt.Errorf("Missing BuildArtifact on Deployable")}
 	}
-	dockerImage := d.BuildArtifact.Name
+	dockerImage := pinnedImageName(d.BuildArtifact)
 	clusterURI := d.Deployment.Cluster.BaseURL
 	labels, err := ra.labeller.ImageLabels(dockerImage)
 	if err != nil {
@@ -81,28 +79,61 @@ func (ra *RectiAgent) Deploy(d sous.Deployable, reqID string) error {
 	}
 
 	Log.Debug.Printf("Deploy req: %+ v", depReq)
+	ra.clients.configureCluster(d.Deployment.Cluster)
 	_, err = ra.singularityClient(clusterURI).Deploy(depReq)
 	return err
 }
 
+// pinnedImageName returns the name to deploy art by: its repository and
+// digest, so that what is deployed cannot change if the image is retagged. An
+// artifact whose digest is unknown can only be deployed by its tag.
+func pinnedImageName(art *sous.BuildArtifact) string {
+	dg := art.Digest()
+	if dg == "" {
+		Log.Warn.Printf("Deploying %s by tag: its digest is unknown", art.Name)
+		return art.Name
+	}
+	repo := art.Name[:strings.Index(art.Name, "@")]
+	// Drop any tag: beside a digest it is ignored, and would only mislead.
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	return repo + "@" + dg
+}
+
 func buildDeployRequest(d sous.Deployable, reqID string, metadata map[string]string) (*dtos.SingularityDeployRequest, error) {
 	var depReq swaggering.Fielder
 	depID := computeDeployID(&d)
-	dockerImage := d.BuildArtifact.Name
+	dockerImage := pinnedImageName(d.BuildArtifact)
 	r := d.Deployment.DeployConfig.Resources
 	e := d.Deployment.DeployConfig.Env
 	vols := d.Deployment.DeployConfig.Volumes
 
 	metadata[sous.ClusterNameLabel] = d.Deployment.ClusterName
 	metadata[sous.FlavorLabel] = d.Deployment.Flavor
+	if ports := d.Deployment.DeployConfig.Ports; len(ports) != 0 {
+		metadata[sous.PortNamesLabel] = strings.Join(ports.Names(), ",")
+	}
+	if a := d.Deployment.DeployConfig.Autoscale; a != (sous.Autoscale{}) {
+		metadata[sous.AutoscaleLabel] = fmt.Sprintf("%d,%d,%g", a.MinInstances, a.MaxInstances, a.TargetCPU)
+	}
 
 	checkReadyPath := d.Deployment.DeployConfig.Startup.CheckReadyURIPath
 	checkReadyPathTimeout := d.Deployment.DeployConfig.Startup.CheckReadyURITimeout
 	checkReadyTimeout := d.Deployment.DeployConfig.Startup.Timeout
 
+	network, err := dockerNetworkType(d.Deployment.DeployConfig.NetworkMode)
+	if err != nil {
+		return nil, err
+	}
+	portMappings, err := dockerPortMappings(d.Deployment.DeployConfig.Ports)
+	if err != nil {
+		return nil, err
+	}
 	dockerInfo, err := swaggering.LoadMap(&dtos.SingularityDockerInfo{}, dtoMap{
-		"Image":   dockerImage,
-		"Network": dtos.SingularityDockerInfoSingularityDockerNetworkTypeBRIDGE, //defaulting to all bridge
+		"Image":        dockerImage,
+		"Network":      network,
+		"PortMappings": portMappings,
 	})
 	if err != nil {
 		return nil, err
@@ -159,6 +190,15 @@ func buildDeployRequest(d sous.Deployable, reqID string, metadata map[string]str
 		Log.Debug.Printf("Override SingularityDeploy HealthcheckUri with %s", *checkReadyPath)
 	}
 
+	if placement := d.Deployment.DeployConfig.Placement; placement.LoadBalanced() {
+		if err := dep.SetField("LoadBalancerGroups", swaggering.StringList(placement.LoadBalancerGroups)); err != nil {
+			return nil, err
+		}
+		if err := dep.SetField("ServiceBasePath", placement.ServiceBasePath); err != nil {
+			return nil, err
+		}
+	}
+
 	if checkReadyPathTimeout != nil {
 		if err := dep.SetField("HealthcheckTimeoutSeconds", int64(*checkReadyPathTimeout)); err != nil {
 			return nil, err
@@ -173,6 +213,10 @@ func buildDeployRequest(d sous.Deployable, reqID string, metadata map[string]str
 		Log.Debug.Printf("Override SingularityDeploy DeployHealthTimeoutSeconds with %d", *checkReadyTimeout)
 	}
 
+	if err := setHealthchecks(dep, d.Deployment.DeployConfig.Startup); err != nil {
+		return nil, err
+	}
+
 	Log.Debug.Printf("Deploy: %+ v", dep)
 	Log.Debug.Printf("  Container: %+ v", ci)
 	Log.Debug.Printf("  Docker: %+ v", dockerInfo)
@@ -184,6 +228,63 @@ func buildDeployRequest(d sous.Deployable, reqID string, metadata map[string]str
 	return depReq.(*dtos.SingularityDeployRequest), nil
 }
 
+// setHealthchecks sets the health check options on dep which are set in
+// startup.
+func setHealthchecks(dep swaggering.Fielder, startup sous.Startup) error {
+	fields := dtoMap{}
+	if startup.CheckReadyInterval != nil {
+		fields["HealthcheckIntervalSeconds"] = int64(*startup.CheckReadyInterval)
+	}
+	if startup.CheckReadyRetries != nil {
+		fields["HealthcheckMaxRetries"] = int32(*startup.CheckReadyRetries)
+	}
+	if startup.CheckReadyPortIndex != nil {
+		fields["HealthcheckPortIndex"] = int32(*startup.CheckReadyPortIndex)
+	}
+	if startup.SkipCheckReady != nil {
+		fields["SkipHealthchecksOnDeploy"] = *startup.SkipCheckReady
+	}
+	for name, value := range fields {
+		if err := dep.SetField(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dockerNetworkType(mode sous.NetworkMode) (dtos.SingularityDockerInfoSingularityDockerNetworkType, error) {
+	switch mode.Normalized() {
+	default:
+		return "", fmt.Errorf("Unrecognized Sous network mode: %q", mode)
+	case sous.NetworkBridge:
+		return dtos.SingularityDockerInfoSingularityDockerNetworkTypeBRIDGE, nil
+	case sous.NetworkHost:
+		return dtos.SingularityDockerInfoSingularityDockerNetworkTypeHOST, nil
+	case sous.NetworkNone:
+		return dtos.SingularityDockerInfoSingularityDockerNetworkTypeNONE, nil
+	}
+}
+
+// dockerPortMappings maps each port to its fixed container port from the
+// host port at the same index in the ports offered to the task.
+func dockerPortMappings(ports sous.PortMappings) (dtos.SingularityDockerPortMappingList, error) {
+	pms := dtos.SingularityDockerPortMappingList{}
+	for i, p := range ports {
+		pm, err := swaggering.LoadMap(&dtos.SingularityDockerPortMapping{}, dtoMap{
+			"ContainerPortType": dtos.SingularityDockerPortMappingSingularityPortMappingTypeLITERAL,
+			"ContainerPort":     int32(p.ContainerPort),
+			"HostPortType":      dtos.SingularityDockerPortMappingSingularityPortMappingTypeFROM_OFFER,
+			"HostPort":          int32(i),
+			"Protocol":          p.NormalizedProtocol(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		pms = append(pms, pm.(*dtos.SingularityDockerPortMapping))
+	}
+	return pms, nil
+}
+
 func singRequestFromDeployment(dep *sous.Deployment, reqID string) (string, *dtos.SingularityRequest, error) {
 	cluster := dep.Cluster.BaseURL
 	instanceCount := dep.DeployConfig.NumInstances
@@ -194,12 +295,32 @@ func singRequestFromDeployment(dep *sous.Deployment, reqID string) (string, *dto
 	if err != nil {
 		return "", nil, err
 	}
-	req, err := swaggering.LoadMap(&dtos.SingularityRequest{}, dtoMap{
+	reqFields := dtoMap{
 		"Id":          reqID,
 		"RequestType": reqType,
 		"Instances":   int32(instanceCount),
 		"Owners":      swaggering.StringList(owners.Slice()),
-	})
+	}
+	if sched := dep.DeployConfig.Schedule; sched.Cron != "" {
+		reqFields["Schedule"] = sched.Cron
+		if sched.TimeZone != "" {
+			reqFields["ScheduleTimeZone"] = sched.TimeZone
+		}
+	}
+	placement := dep.DeployConfig.Placement
+	if placement.RackSensitive {
+		reqFields["RackSensitive"] = true
+	}
+	if placement.SlavePlacement != "" {
+		reqFields["SlavePlacement"] = dtos.SingularityRequestSlavePlacement(placement.SlavePlacement)
+	}
+	if len(placement.RequiredAttributes) != 0 {
+		reqFields["RequiredSlaveAttributes"] = placement.Clone().RequiredAttributes
+	}
+	if placement.LoadBalanced() {
+		reqFields["LoadBalanced"] = true
+	}
+	req, err := swaggering.LoadMap(&dtos.SingularityRequest{}, reqFields)
 
 	if err != nil {
 		return "", nil, err
@@ -216,6 +337,7 @@ func (ra *RectiAgent) PostRequest(d sous.Deployable, reqID string) error {
 	}
 
 	Log.Debug.Printf("Create Request: %+ v", req)
+	ra.clients.configureCluster(d.Deployment.Cluster)
 	_, err = ra.singularityClient(cluster).PostRequest(req)
 	return err
 }
@@ -261,7 +383,7 @@ func (ra *RectiAgent) Scale(cluster, reqID string, instanceCount int, message st
 		"ActionId": "SOUS_RECTIFY_" + StripDeployID(uuid.NewV4().String()), // not positive this is appropriate
 		// omitting DurationMillis - bears discussion
 		"Instances":        int32(instanceCount),
-		"Message":          "Sous" + message,
+		"Message":          "Sous: " + message,
 		"SkipHealthchecks": false,
 	})
 
@@ -274,22 +396,9 @@ func (ra *RectiAgent) Scale(cluster, reqID string, instanceCount int, message st
 	return err
 }
 
-func (ra *RectiAgent) getSingularityClient(url string) (*singularity.Client, bool) {
-	ra.RLock()
-	defer ra.RUnlock()
-	cl, ok := ra.singClients[url]
-	return cl, ok
-}
-
+// singularityClient returns the client for the Singularity at url, which
+// retries and circuit breaks requests according to the ClientPolicy of its
+// cluster.
 func (ra *RectiAgent) singularityClient(url string) *singularity.Client {
-	cl, ok := ra.getSingularityClient(url)
-	if ok {
-		return cl
-	}
-	ra.Lock()
-	defer ra.Unlock()
-	cl = singularity.NewClient(url)
-	//cl.Debug = true
-	ra.singClients[url] = cl
-	return cl
+	return ra.clients.client(url)
 }
@@ -87,6 +87,23 @@ func TestDetermineRequestType(t *testing.T) {
 	}
 }
 
+func TestPinnedImageName(t *testing.T) {
+	digest := "sha256:0123456789012345678901234567890123456789012345678901234567890123"
+	tbl := map[string]string{
+		"docker.example.com/ot/app:1.2.3":                "docker.example.com/ot/app:1.2.3",
+		"docker.example.com/ot/app@" + digest:            "docker.example.com/ot/app@" + digest,
+		"docker.example.com/ot/app:1.2.3@" + digest:      "docker.example.com/ot/app@" + digest,
+		"docker.example.com:5000/ot/app@" + digest:       "docker.example.com:5000/ot/app@" + digest,
+		"docker.example.com:5000/ot/app:1.2.3@" + digest: "docker.example.com:5000/ot/app@" + digest,
+	}
+	for in, out := range tbl {
+		got := pinnedImageName(&sous.BuildArtifact{Name: in, Type: "docker"})
+		if got != out {
+			t.Errorf("pinnedImageName(%q): got %q, want %q", in, got, out)
+		}
+	}
+}
+
 func TestFailOnNilBuildArtifact(t *testing.T) {
 	r := sous.NewDummyRegistry()
 	d := sous.Deployable{}
@@ -133,3 +150,178 @@ func TestContainerStartupOptions(t *testing.T) {
 	}
 
 }
+
+func TestScheduleRoundtrip(t *testing.T) {
+	dep := &sous.Deployment{
+		Kind:    sous.ManifestKindScheduled,
+		Cluster: &sous.Cluster{BaseURL: "http://singularity.example.com"},
+	}
+	dep.Schedule = sous.Schedule{Cron: "30 2 * * 1-5", TimeZone: "America/Los_Angeles"}
+
+	_, req, err := singRequestFromDeployment(dep, "fake-request-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Schedule != dep.Schedule.Cron {
+		t.Errorf("Schedule: got %q, expected %q", req.Schedule, dep.Schedule.Cron)
+	}
+	if req.ScheduleTimeZone != dep.Schedule.TimeZone {
+		t.Errorf("ScheduleTimeZone: got %q, expected %q", req.ScheduleTimeZone, dep.Schedule.TimeZone)
+	}
+
+	db := &deploymentBuilder{request: req}
+	if err := db.determineManifestKind(); err != nil {
+		t.Fatal(err)
+	}
+	if db.Target.Schedule != dep.Schedule {
+		t.Errorf("Rebuilt schedule: got %v, expected %v", db.Target.Schedule, dep.Schedule)
+	}
+}
+
+func TestNetworkRoundtrip(t *testing.T) {
+	d := sous.Deployable{
+		Deployment:    &sous.Deployment{},
+		BuildArtifact: &sous.BuildArtifact{Name: "docker.example.com/app:1.2.3"},
+	}
+	d.NetworkMode = sous.NetworkBridge
+	d.Ports = sous.PortMappings{
+		{Name: "http", ContainerPort: 8080},
+		{Name: "metrics", ContainerPort: 9125, Protocol: "udp"},
+	}
+
+	dr, err := buildDeployRequest(d, "fake-request-id", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docker := dr.Deploy.ContainerInfo.Docker
+	if docker.Network != dtos.SingularityDockerInfoSingularityDockerNetworkTypeBRIDGE {
+		t.Errorf("Network: got %q, expected BRIDGE", docker.Network)
+	}
+	if len(docker.PortMappings) != 2 {
+		t.Fatalf("PortMappings: got %d, expected 2", len(docker.PortMappings))
+	}
+	if pm := docker.PortMappings[1]; pm.ContainerPort != 9125 || pm.HostPort != 1 || pm.Protocol != "udp" {
+		t.Errorf("PortMappings[1]: got %+v", pm)
+	}
+
+	db := &deploymentBuilder{deploy: dr.Deploy}
+	if err := db.unpackNetwork(); err != nil {
+		t.Fatal(err)
+	}
+	if !db.Target.NetworkMode.Equal(d.NetworkMode) {
+		t.Errorf("Rebuilt network mode: got %q, expected %q", db.Target.NetworkMode, d.NetworkMode)
+	}
+	if !db.Target.Ports.Equal(d.Ports) {
+		t.Errorf("Rebuilt ports: got %v, expected %v", db.Target.Ports, d.Ports)
+	}
+
+	d.NetworkMode = sous.NetworkHost
+	d.Ports = nil
+	dr, err = buildDeployRequest(d, "fake-request-id", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = &deploymentBuilder{deploy: dr.Deploy}
+	if err := db.unpackNetwork(); err != nil {
+		t.Fatal(err)
+	}
+	if db.Target.NetworkMode != sous.NetworkHost {
+		t.Errorf("Rebuilt network mode: got %q, expected %q", db.Target.NetworkMode, sous.NetworkHost)
+	}
+}
+
+func TestPlacementRoundtrip(t *testing.T) {
+	d := sous.Deployable{
+		Deployment:    &sous.Deployment{Kind: sous.ManifestKindService, Cluster: &sous.Cluster{BaseURL: "http://singularity.example.com"}},
+		BuildArtifact: &sous.BuildArtifact{Name: "docker.example.com/app:1.2.3"},
+	}
+	d.Placement = sous.Placement{
+		RackSensitive:      true,
+		SlavePlacement:     "SEPARATE_BY_DEPLOY",
+		RequiredAttributes: map[string]string{"instance-type": "large"},
+		LoadBalancerGroups: []string{"internal"},
+		ServiceBasePath:    "/app",
+	}
+
+	_, req, err := singRequestFromDeployment(d.Deployment, "fake-request-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !req.RackSensitive || !req.LoadBalanced {
+		t.Errorf("Request: got RackSensitive %t, LoadBalanced %t, expected both", req.RackSensitive, req.LoadBalanced)
+	}
+	if req.SlavePlacement != dtos.SingularityRequestSlavePlacementSEPARATE_BY_DEPLOY {
+		t.Errorf("Request: got SlavePlacement %q, expected %q", req.SlavePlacement, "SEPARATE_BY_DEPLOY")
+	}
+	dr, err := buildDeployRequest(d, "fake-request-id", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dr.Deploy.ServiceBasePath != "/app" {
+		t.Errorf("ServiceBasePath: got %q, expected %q", dr.Deploy.ServiceBasePath, "/app")
+	}
+
+	db := &deploymentBuilder{request: req, deploy: dr.Deploy}
+	db.unpackPlacement()
+	if !db.Target.Placement.Equal(d.Placement) {
+		t.Errorf("Rebuilt placement: got %v, expected %v", db.Target.Placement, d.Placement)
+	}
+}
+
+func TestHealthcheckRoundtrip(t *testing.T) {
+	interval, retries, portIndex, skip := 5, 3, 1, true
+	d := sous.Deployable{
+		Deployment:    &sous.Deployment{},
+		BuildArtifact: &sous.BuildArtifact{Name: "docker.example.com/app:1.2.3"},
+	}
+	d.Startup = sous.Startup{
+		CheckReadyInterval:  &interval,
+		CheckReadyRetries:   &retries,
+		CheckReadyPortIndex: &portIndex,
+		SkipCheckReady:      &skip,
+	}
+
+	dr, err := buildDeployRequest(d, "fake-request-id", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dr.Deploy.HealthcheckIntervalSeconds != 5 || dr.Deploy.HealthcheckMaxRetries != 3 ||
+		dr.Deploy.HealthcheckPortIndex != 1 || !dr.Deploy.SkipHealthchecksOnDeploy {
+		t.Errorf("Deploy health checks not set: %+v", dr.Deploy)
+	}
+
+	db := &deploymentBuilder{deploy: dr.Deploy, request: &dtos.SingularityRequest{}}
+	if err := db.unpackDeployConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if !db.Target.Startup.Equal(d.Startup) {
+		t.Errorf("Rebuilt startup: got %v, expected %v", db.Target.Startup, d.Startup)
+	}
+}
+
+func TestAutoscaleRoundtrip(t *testing.T) {
+	d := sous.Deployable{
+		Deployment:    &sous.Deployment{},
+		BuildArtifact: &sous.BuildArtifact{Name: "docker.example.com/app:1.2.3"},
+	}
+	d.Autoscale = sous.Autoscale{MinInstances: 2, MaxInstances: 10, TargetCPU: 0.65}
+
+	dr, err := buildDeployRequest(d, "fake-request-id", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := &deploymentBuilder{deploy: dr.Deploy, request: &dtos.SingularityRequest{}}
+	if err := db.unpackDeployConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if db.Target.Autoscale != d.Autoscale {
+		t.Errorf("Rebuilt autoscale: got %v, expected %v", db.Target.Autoscale, d.Autoscale)
+	}
+
+	dr.Deploy.Metadata[sous.AutoscaleLabel] = "lots"
+	db = &deploymentBuilder{deploy: dr.Deploy, request: &dtos.SingularityRequest{}}
+	if err := db.unpackDeployConfig(); err == nil {
+		t.Errorf("Expected an error for malformed autoscale metadata")
+	}
+}
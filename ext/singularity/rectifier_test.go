@@ -3,6 +3,7 @@ package singularity
 import (
 	"log"
 	"testing"
+	"time"
 
 	"github.com/opentable/sous/lib"
 	"github.com/samsalisbury/semv"
@@ -159,8 +160,10 @@ func TestModifyScale(t *testing.T) {
 	}
 
 	assert.Len(client.Deployed, 0)
-	if assert.Len(client.Created, 1) {
-		assert.Equal(24, client.Created[0].Deployment.DeployConfig.NumInstances)
+	assert.Len(client.Created, 0)
+	if assert.Len(client.Scaled, 1) {
+		assert.Equal(24, client.Scaled[0].Instances)
+		assert.Equal("cluster", client.Scaled[0].Cluster)
 	}
 }
 
@@ -269,8 +272,9 @@ func TestModify(t *testing.T) {
 		}
 	}
 
-	if assert.Len(client.Created, 1) {
-		assert.Equal(24, client.Created[0].Deployment.DeployConfig.NumInstances)
+	assert.Len(client.Created, 0)
+	if assert.Len(client.Scaled, 1) {
+		assert.Equal(24, client.Scaled[0].Instances)
 	}
 
 	if assert.Len(client.Deployed, 1) {
@@ -298,7 +302,8 @@ func TestDeletes(t *testing.T) {
 				},
 				ClusterName: "",
 				Cluster: &sous.Cluster{
-					BaseURL: "cluster",
+					BaseURL:              "cluster",
+					EnableOrphanDeletion: true,
 				},
 			},
 		},
@@ -319,22 +324,81 @@ func TestDeletes(t *testing.T) {
 		if e.Error != nil {
 			t.Error(e)
 		}
+		assert.Equal(sous.TombstoneDiff, e.Desc)
 	}
 
 	assert.Len(client.Deployed, 0)
 	assert.Len(client.Created, 0)
 
-	// We no longer expect any deletions; See deployer.RectifySingleDelete.
-	//expectedDeletions := 1
-	expectedDeletions := 0
-
-	assert.Len(client.Deleted, expectedDeletions)
-	//if assert.Len(client.Deleted, expectedDeletions) {
-	// We no longer expect any deletions; See deployer.RectifySingleDelete.
-	//req := client.Deleted[0]
-	//assert.Equal("cluster", req.Cluster)
-	//assert.Equal("reqid::", req.Reqid)
-	//}
+	// Orphans are scaled down and tombstoned, not deleted straight away.
+	assert.Len(client.Deleted, 0)
+	if assert.Len(client.Scaled, 1) {
+		assert.Equal("cluster", client.Scaled[0].Cluster)
+		assert.Equal("reqid", client.Scaled[0].Reqid)
+		assert.Equal(0, client.Scaled[0].Instances)
+	}
+}
+
+func TestDeletesAfterGracePeriod(t *testing.T) {
+	assert := assert.New(t)
+
+	orphan := func(numInstances int, cluster *sous.Cluster) *sous.DeployablePair {
+		return &sous.DeployablePair{
+			ExecutorData: &singularityTaskData{requestID: "reqid"},
+			Prior: &sous.Deployable{
+				Deployment: &sous.Deployment{
+					SourceID: sous.SourceID{
+						Location: sous.SourceLocation{
+							Repo: "fake.tld/org/project",
+						},
+					},
+					DeployConfig: sous.DeployConfig{
+						NumInstances: numInstances,
+					},
+					ClusterName: "cluster",
+					Cluster:     cluster,
+				},
+			},
+		}
+	}
+
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	client := sous.NewDummyRectificationClient()
+	ts := sous.NewTombstones()
+	dep := &deployer{Client: client, Tombstones: ts, now: func() time.Time { return now }}
+	cluster := &sous.Cluster{BaseURL: "cluster", EnableOrphanDeletion: true, OrphanGracePeriod: time.Hour}
+
+	desc, err := dep.RectifySingleDelete(orphan(3, cluster))
+	assert.NoError(err)
+	assert.Equal(sous.TombstoneDiff, desc)
+	assert.Len(client.Scaled, 1)
+	if orphans := ts.Snapshot(); assert.Len(orphans, 1) {
+		assert.Equal(now.Add(time.Hour), orphans[0].DeleteAfter)
+	}
+
+	now = now.Add(30 * time.Minute)
+	desc, err = dep.RectifySingleDelete(orphan(0, cluster))
+	assert.NoError(err)
+	assert.Equal(sous.TombstoneDiff, desc)
+	assert.Len(client.Scaled, 1, "already scaled down")
+	assert.Len(client.Deleted, 0)
+
+	now = now.Add(30 * time.Minute)
+	desc, err = dep.RectifySingleDelete(orphan(0, cluster))
+	assert.NoError(err)
+	assert.Equal(sous.DeleteDiff, desc)
+	if assert.Len(client.Deleted, 1) {
+		assert.Equal("reqid", client.Deleted[0].Reqid)
+	}
+	assert.Len(ts.Snapshot(), 0)
+
+	disabled := &sous.Cluster{BaseURL: "cluster", OrphanGracePeriod: time.Hour}
+	desc, err = dep.RectifySingleDelete(orphan(3, disabled))
+	assert.NoError(err)
+	assert.Equal(sous.StableDiff, desc)
+	assert.Len(client.Scaled, 1)
+	assert.Len(client.Deleted, 1)
+	assert.Len(ts.Snapshot(), 0)
 }
 
 func TestCreates(t *testing.T) {
@@ -0,0 +1,210 @@
+package singularity
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opentable/go-singularity/dtos"
+	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/ext/singularity/singularitytest"
+	"github.com/opentable/sous/lib"
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// labellingRegistry names an image for each source ID it is asked for, and
+// labels it with that source ID, like a registry of images built by Sous.
+type labellingRegistry struct {
+	*sous.DummyRegistry
+	sync.Mutex
+	sids map[string]sous.SourceID
+}
+
+func newLabellingRegistry() *labellingRegistry {
+	return &labellingRegistry{DummyRegistry: sous.NewDummyRegistry(), sids: map[string]sous.SourceID{}}
+}
+
+func (lr *labellingRegistry) GetArtifact(sid sous.SourceID) (*sous.BuildArtifact, error) {
+	lr.Lock()
+	defer lr.Unlock()
+	name := "docker.example.com/" + sid.Location.Repo + ":" + sid.Version.String()
+	lr.sids[name] = sid
+	return &sous.BuildArtifact{Name: name, Type: "docker"}, nil
+}
+
+func (lr *labellingRegistry) ImageLabels(name string) (map[string]string, error) {
+	lr.Lock()
+	defer lr.Unlock()
+	return docker.Labels(lr.sids[name]), nil
+}
+
+// resolveCycle resolves a state against a fake Singularity, using the real
+// deployer and RectiAgent.
+type resolveCycle struct {
+	t        *testing.T
+	server   *singularitytest.Server
+	state    *sous.State
+	resolver *sous.Resolver
+}
+
+func newResolveCycle(t *testing.T) *resolveCycle {
+	server := singularitytest.NewServer()
+	reg := newLabellingRegistry()
+	deployer := NewTombstoningDeployer(NewRectiAgent(reg), sous.NewTombstones())
+	cluster := &sous.Cluster{
+		Name:    "test",
+		Kind:    "singularity",
+		BaseURL: server.URL,
+		ClientPolicy: sous.ClientPolicy{
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     time.Millisecond,
+		},
+	}
+	state := sous.NewState()
+	state.Defs.Clusters = sous.Clusters{"test": cluster}
+	state.Manifests.Add(&sous.Manifest{
+		Source: sous.SourceLocation{Repo: "github.com/example/app"},
+		Owners: []string{"owner@example.com"},
+		Kind:   sous.ManifestKindService,
+		Deployments: sous.DeploySpecs{
+			"test": sous.DeploySpec{
+				Version: semv.MustParse("1.0.0"),
+				DeployConfig: sous.DeployConfig{
+					Resources:    sous.Resources{"cpus": "0.1", "memory": "32", "ports": "1"},
+					Env:          sous.Env{"GREETING": "hello"},
+					NumInstances: 2,
+				},
+			},
+		},
+	})
+	return &resolveCycle{
+		t:        t,
+		server:   server,
+		state:    state,
+		resolver: sous.NewResolver(deployer, reg, &sous.ResolveFilter{}),
+	}
+}
+
+// spec changes the deployment of the app in the state.
+func (rc *resolveCycle) spec(f func(*sous.DeploySpec)) {
+	m, ok := rc.state.Manifests.Get(sous.ManifestID{Source: sous.SourceLocation{Repo: "github.com/example/app"}})
+	require.True(rc.t, ok)
+	spec := m.Deployments["test"]
+	f(&spec)
+	m.Deployments["test"] = spec
+}
+
+// resolve runs a single resolve cycle, and returns its status.
+func (rc *resolveCycle) resolve() sous.ResolveStatus {
+	gdm, err := rc.state.Deployments()
+	require.NoError(rc.t, err)
+	recorder := rc.resolver.Begin(gdm, rc.state.Defs.Clusters)
+	recorder.Wait()
+	return recorder.CurrentStatus()
+}
+
+func resolutions(status sous.ResolveStatus) []sous.ResolutionType {
+	descs := []sous.ResolutionType{}
+	for _, rez := range status.Log {
+		descs = append(descs, rez.Desc)
+	}
+	return descs
+}
+
+func (rc *resolveCycle) onlyRequestID() string {
+	ids := rc.server.RequestIDs()
+	require.Len(rc.t, ids, 1)
+	return ids[0]
+}
+
+func TestResolveCycle(t *testing.T) {
+	rc := newResolveCycle(t)
+	defer rc.server.Close()
+
+	status := rc.resolve()
+	assert.Empty(t, status.Errs.Causes)
+	assert.Equal(t, []sous.ResolutionType{sous.CreateDiff}, resolutions(status))
+	reqID := rc.onlyRequestID()
+	req, _ := rc.server.Request(reqID)
+	assert.EqualValues(t, 2, req.Instances)
+	dep, ok := rc.server.ActiveDeploy(reqID)
+	require.True(t, ok)
+	assert.Equal(t, "hello", dep.Env["GREETING"])
+
+	// Nothing changes while the GDM and Singularity agree.
+	status = rc.resolve()
+	assert.Empty(t, status.Errs.Causes)
+	assert.Equal(t, []sous.ResolutionType{sous.StableDiff}, resolutions(status))
+	assert.Len(t, rc.server.DeployIDs(reqID), 1)
+
+	// Changing only the instances scales the request.
+	rc.spec(func(s *sous.DeploySpec) { s.NumInstances = 3 })
+	status = rc.resolve()
+	assert.Equal(t, []sous.ResolutionType{sous.ModifyDiff}, resolutions(status))
+	req, _ = rc.server.Request(reqID)
+	assert.EqualValues(t, 3, req.Instances)
+	assert.Len(t, rc.server.DeployIDs(reqID), 1)
+
+	// A new version is a new deploy. Nothing more is deployed while it is
+	// pending.
+	rc.server.SetDeployOutcome("")
+	rc.spec(func(s *sous.DeploySpec) { s.Version = semv.MustParse("1.1.0") })
+	status = rc.resolve()
+	assert.Equal(t, []sous.ResolutionType{sous.ModifyDiff}, resolutions(status))
+	assert.Len(t, rc.server.DeployIDs(reqID), 2)
+	status = rc.resolve()
+	assert.Empty(t, status.Errs.Causes)
+	assert.Len(t, rc.server.DeployIDs(reqID), 2)
+
+	// A failed deploy is reported as an error.
+	require.NoError(t, rc.server.FinishDeploy(reqID, dtos.SingularityDeployResultDeployStateFAILED, "health checks failed"))
+	status = rc.resolve()
+	assert.Len(t, status.Errs.Causes, 1)
+}
+
+func TestResolveCycle_UnreachableSingularity(t *testing.T) {
+	rc := newResolveCycle(t)
+	defer rc.server.Close()
+	rc.resolve()
+	reqID := rc.onlyRequestID()
+
+	rc.server.Fail(singularitytest.Failure{Method: "GET", Path: "/api/requests", Status: 503})
+	rc.spec(func(s *sous.DeploySpec) { s.Version = semv.MustParse("2.0.0") })
+	status := rc.resolve()
+	assert.Equal(t, "finished", status.Phase)
+	assert.Contains(t, status.UnreachableClusters, "test")
+	assert.Empty(t, resolutions(status))
+	assert.Len(t, rc.server.DeployIDs(reqID), 1)
+
+	rc.server.ClearFailures()
+	status = rc.resolve()
+	assert.Empty(t, status.UnreachableClusters)
+	assert.Equal(t, []sous.ResolutionType{sous.ModifyDiff}, resolutions(status))
+	assert.Len(t, rc.server.DeployIDs(reqID), 2)
+}
+
+func TestResolveCycle_AutoResolver(t *testing.T) {
+	rc := newResolveCycle(t)
+	defer rc.server.Close()
+
+	ar := sous.NewAutoResolver(rc.resolver, &sous.DummyStateManager{State: rc.state}, sous.SilentLogSet())
+	ar.UpdateTime = 10 * time.Millisecond
+	done := ar.Kickoff()
+	defer close(done)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stable, _ := ar.Statuses()
+		if stable != nil && len(rc.server.RequestIDs()) == 1 {
+			if _, ok := rc.server.ActiveDeploy(rc.onlyRequestID()); ok {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the auto-resolver to deploy")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,565 @@
+// Package singularitytest provides an in-process fake Singularity, for testing
+// code which uses go-singularity without a real cluster.
+//
+// The fake implements the request, deploy, deploy history and task endpoints
+// that Sous uses. Requests and deploys are stored as the JSON they were
+// posted as, and served back the same way, since the go-singularity DTOs only
+// marshal fields which were explicitly set. Deploys complete immediately with
+// the outcome set by SetDeployOutcome, or are held pending until
+// FinishDeploy is called. Failures are scripted with Fail.
+package singularitytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opentable/go-singularity/dtos"
+)
+
+type (
+	// Server is a fake Singularity listening on a local port.
+	Server struct {
+		*httptest.Server
+		// Now returns the time used for timestamps. It defaults to time.Now.
+		Now func() time.Time
+
+		sync.Mutex
+		requests     map[string]*request
+		tasks        map[string]*task
+		failures     []*Failure
+		calls        []Call
+		deployResult dtos.SingularityDeployResultDeployState
+		taskResult   dtos.SingularityTaskHistoryUpdateExtendedTaskState
+		taskCount    int
+	}
+
+	// A Failure makes matching calls fail, or respond slowly.
+	Failure struct {
+		// Method and Path select the calls which fail. An empty Method matches
+		// every method. Path is matched with path.Match, so that e.g.
+		// "/api/requests/request/*/scale" matches scaling any request.
+		Method, Path string
+		// Status is the status the calls fail with. If zero, calls are only
+		// delayed, then handled as usual.
+		Status int
+		// Delay is how long to wait before responding.
+		Delay time.Duration
+		// Times is the number of calls which fail. If zero, every matching
+		// call fails until ClearFailures is called.
+		Times int
+	}
+
+	// A Call records a call made to the Server.
+	Call struct {
+		Method, Path string
+		// Status is the status of the response.
+		Status int
+	}
+
+	request struct {
+		json    jsonObject
+		deploys []*deploy
+		// active and pending are the IDs of the active and pending deploys.
+		active, pending string
+	}
+
+	deploy struct {
+		id        string
+		json      jsonObject
+		timestamp int64
+		// result is empty while the deploy is pending.
+		result  dtos.SingularityDeployResultDeployState
+		message string
+	}
+
+	task struct {
+		id, requestID, deployID, runID string
+		updates                        []jsonObject
+	}
+
+	jsonObject map[string]interface{}
+
+	// httpError is an error with the status Singularity responds with.
+	httpError struct {
+		status  int
+		message string
+	}
+)
+
+// NewServer starts a fake Singularity with no requests. Deploys succeed, and
+// tasks finish, as soon as they are started. Call Close when done with it.
+func NewServer() *Server {
+	s := &Server{
+		Now:          time.Now,
+		requests:     map[string]*request{},
+		tasks:        map[string]*task{},
+		deployResult: dtos.SingularityDeployResultDeployStateSUCCEEDED,
+		taskResult:   dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_FINISHED,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func errorf(status int, format string, args ...interface{}) error {
+	return &httpError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// Fail adds a scripted failure. Failures are matched in the order they were
+// added.
+func (s *Server) Fail(f Failure) {
+	s.Lock()
+	defer s.Unlock()
+	s.failures = append(s.failures, &f)
+}
+
+// ClearFailures removes all scripted failures.
+func (s *Server) ClearFailures() {
+	s.Lock()
+	defer s.Unlock()
+	s.failures = nil
+}
+
+// SetDeployOutcome sets the state new deploys finish in. If state is empty,
+// new deploys stay pending until FinishDeploy is called.
+func (s *Server) SetDeployOutcome(state dtos.SingularityDeployResultDeployState) {
+	s.Lock()
+	defer s.Unlock()
+	s.deployResult = state
+}
+
+// FinishDeploy finishes the pending deploy of a request in state, with
+// message as the reason given for failures.
+func (s *Server) FinishDeploy(requestID string, state dtos.SingularityDeployResultDeployState, message string) error {
+	s.Lock()
+	defer s.Unlock()
+	r, ok := s.requests[requestID]
+	if !ok {
+		return fmt.Errorf("no request %q", requestID)
+	}
+	if r.pending == "" {
+		return fmt.Errorf("request %q has no pending deploy", requestID)
+	}
+	r.finish(state, message)
+	return nil
+}
+
+// SetTaskOutcome sets the state new tasks finish in. If state is empty, new
+// tasks keep running until UpdateTask is called.
+func (s *Server) SetTaskOutcome(state dtos.SingularityTaskHistoryUpdateExtendedTaskState) {
+	s.Lock()
+	defer s.Unlock()
+	s.taskResult = state
+}
+
+// UpdateTask adds an update to the history of a task.
+func (s *Server) UpdateTask(taskID string, state dtos.SingularityTaskHistoryUpdateExtendedTaskState, message string) error {
+	s.Lock()
+	defer s.Unlock()
+	t, ok := s.tasks[taskID]
+	if !ok {
+		return fmt.Errorf("no task %q", taskID)
+	}
+	t.update(state, message, s.timestamp())
+	return nil
+}
+
+// Calls returns the calls made to the server so far, in order.
+func (s *Server) Calls() []Call {
+	s.Lock()
+	defer s.Unlock()
+	return append([]Call{}, s.calls...)
+}
+
+// RequestIDs returns the IDs of the requests on the server, in order.
+func (s *Server) RequestIDs() []string {
+	s.Lock()
+	defer s.Unlock()
+	ids := make([]string, 0, len(s.requests))
+	for id := range s.requests {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Request returns the request with id, as it was last posted or scaled.
+func (s *Server) Request(id string) (*dtos.SingularityRequest, bool) {
+	s.Lock()
+	defer s.Unlock()
+	r, ok := s.requests[id]
+	if !ok {
+		return nil, false
+	}
+	req := &dtos.SingularityRequest{}
+	return req, convert(r.json, req) == nil
+}
+
+// ActiveDeploy returns the active deploy of the request with id.
+func (s *Server) ActiveDeploy(requestID string) (*dtos.SingularityDeploy, bool) {
+	s.Lock()
+	defer s.Unlock()
+	r, ok := s.requests[requestID]
+	if !ok || r.active == "" {
+		return nil, false
+	}
+	dep := &dtos.SingularityDeploy{}
+	return dep, convert(r.deploy(r.active).json, dep) == nil
+}
+
+// DeployIDs returns the IDs of the deploys of the request with id, oldest
+// first.
+func (s *Server) DeployIDs(requestID string) []string {
+	s.Lock()
+	defer s.Unlock()
+	r, ok := s.requests[requestID]
+	if !ok {
+		return nil
+	}
+	ids := make([]string, 0, len(r.deploys))
+	for _, d := range r.deploys {
+		ids = append(ids, d.id)
+	}
+	return ids
+}
+
+func (s *Server) serve(w http.ResponseWriter, req *http.Request) {
+	var body jsonObject
+	if req.Body != nil {
+		json.NewDecoder(req.Body).Decode(&body)
+	}
+
+	delay, failStatus := s.scriptedFailure(req)
+	time.Sleep(delay)
+
+	status := http.StatusOK
+	var res interface{}
+	var err error
+	if failStatus != 0 {
+		err = errorf(failStatus, "scripted failure")
+	} else {
+		s.Lock()
+		res, err = s.route(req, body)
+		s.Unlock()
+	}
+	if he, ok := err.(*httpError); ok {
+		status = he.status
+		res = jsonObject{"message": he.message}
+	}
+
+	s.Lock()
+	s.calls = append(s.calls, Call{Method: req.Method, Path: req.URL.Path, Status: status})
+	s.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
+
+// scriptedFailure returns the delay and status of the first failure which
+// matches req, and counts it against that failure.
+func (s *Server) scriptedFailure(req *http.Request) (time.Duration, int) {
+	s.Lock()
+	defer s.Unlock()
+	for i, f := range s.failures {
+		if f.Method != "" && f.Method != req.Method {
+			continue
+		}
+		if ok, _ := path.Match(f.Path, req.URL.Path); !ok {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.failures = append(s.failures[:i:i], s.failures[i+1:]...)
+			}
+		}
+		return f.Delay, f.Status
+	}
+	return 0, 0
+}
+
+// route handles a call, with s locked.
+func (s *Server) route(req *http.Request, body jsonObject) (interface{}, error) {
+	p := req.URL.Path
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	is := func(method, pattern string) bool {
+		ok, _ := path.Match(pattern, p)
+		return ok && req.Method == method
+	}
+	switch {
+	case is("GET", "/api/requests"):
+		return s.listRequests(), nil
+	case is("POST", "/api/requests"):
+		return s.postRequest(body)
+	case is("GET", "/api/requests/request/*"):
+		return s.getRequest(parts[3])
+	case is("DELETE", "/api/requests/request/*"):
+		return s.deleteRequest(parts[3])
+	case is("PUT", "/api/requests/request/*/scale"):
+		return s.scale(parts[3], body)
+	case is("POST", "/api/requests/request/*/run"):
+		return s.run(parts[3], body)
+	case is("GET", "/api/requests/request/*/run/*"):
+		return s.taskByRunID(parts[3], parts[5])
+	case is("POST", "/api/deploys"):
+		return s.deploy(body)
+	case is("GET", "/api/history/request/*/deploy/*"):
+		return s.deployHistory(parts[3], parts[5])
+	case is("GET", "/api/history/request/*/deploys"):
+		return s.deployHistories(parts[3], req)
+	case is("GET", "/api/history/task/*"):
+		return s.taskHistory(parts[3])
+	}
+	return nil, errorf(http.StatusNotFound, "no route for %s %s", req.Method, p)
+}
+
+func (s *Server) timestamp() int64 {
+	return s.Now().UnixNano() / int64(time.Millisecond)
+}
+
+func (s *Server) listRequests() []jsonObject {
+	list := []jsonObject{}
+	for _, id := range s.sortedRequestIDs() {
+		list = append(list, s.requests[id].parent())
+	}
+	return list
+}
+
+func (s *Server) sortedRequestIDs() []string {
+	ids := make([]string, 0, len(s.requests))
+	for id := range s.requests {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *Server) postRequest(body jsonObject) (interface{}, error) {
+	id, _ := body["id"].(string)
+	if id == "" {
+		return nil, errorf(http.StatusBadRequest, "request must have an id")
+	}
+	r, ok := s.requests[id]
+	if !ok {
+		r = &request{}
+		s.requests[id] = r
+	}
+	r.json = body
+	return r.parent(), nil
+}
+
+func (s *Server) getRequest(id string) (interface{}, error) {
+	r, ok := s.requests[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "request %q not found", id)
+	}
+	return r.parent(), nil
+}
+
+func (s *Server) deleteRequest(id string) (interface{}, error) {
+	r, ok := s.requests[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "request %q not found", id)
+	}
+	delete(s.requests, id)
+	return r.json, nil
+}
+
+func (s *Server) scale(id string, body jsonObject) (interface{}, error) {
+	r, ok := s.requests[id]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "request %q not found", id)
+	}
+	instances, ok := body["instances"].(float64)
+	if !ok || instances < 0 {
+		return nil, errorf(http.StatusBadRequest, "scale request needs a number of instances")
+	}
+	r.json["instances"] = instances
+	return r.parent(), nil
+}
+
+func (s *Server) deploy(body jsonObject) (interface{}, error) {
+	dep, _ := body["deploy"].(map[string]interface{})
+	id, _ := dep["id"].(string)
+	requestID, _ := dep["requestId"].(string)
+	if id == "" || requestID == "" {
+		return nil, errorf(http.StatusBadRequest, "deploy must have an id and a requestId")
+	}
+	r, ok := s.requests[requestID]
+	if !ok {
+		return nil, errorf(http.StatusBadRequest, "no request %q to deploy to", requestID)
+	}
+	if r.pending != "" {
+		return nil, errorf(http.StatusConflict, "pending deploy %q already in progress for %q", r.pending, requestID)
+	}
+	if r.deploy(id) != nil {
+		return nil, errorf(http.StatusBadRequest, "deploy %q already exists for %q", id, requestID)
+	}
+	r.deploys = append(r.deploys, &deploy{id: id, json: dep, timestamp: s.timestamp()})
+	r.pending = id
+	if s.deployResult != "" {
+		r.finish(s.deployResult, "")
+	}
+	return r.parent(), nil
+}
+
+func (s *Server) deployHistory(requestID, deployID string) (interface{}, error) {
+	r, ok := s.requests[requestID]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "request %q not found", requestID)
+	}
+	d := r.deploy(deployID)
+	if d == nil {
+		return nil, errorf(http.StatusNotFound, "deploy %q not found for %q", deployID, requestID)
+	}
+	h := d.history(requestID)
+	h["deploy"] = d.json
+	return h, nil
+}
+
+// deployHistories lists the deploys of a request, newest first, without the
+// deploys themselves, like Singularity does.
+func (s *Server) deployHistories(requestID string, req *http.Request) (interface{}, error) {
+	r, ok := s.requests[requestID]
+	if !ok {
+		return []jsonObject{}, nil
+	}
+	// The go-singularity client does not send its query parameters, so
+	// without them every deploy is listed.
+	q := req.URL.Query()
+	count, err := strconv.Atoi(q.Get("count"))
+	if err != nil || count < 1 {
+		count = len(r.deploys)
+	}
+	page, err := strconv.Atoi(q.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	list := []jsonObject{}
+	for i := len(r.deploys) - 1 - (page-1)*count; i >= 0 && len(list) < count; i-- {
+		list = append(list, r.deploys[i].history(requestID))
+	}
+	return list, nil
+}
+
+func (s *Server) run(requestID string, body jsonObject) (interface{}, error) {
+	r, ok := s.requests[requestID]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "request %q not found", requestID)
+	}
+	if r.active == "" {
+		return nil, errorf(http.StatusBadRequest, "request %q has no active deploy to run", requestID)
+	}
+	runID, _ := body["runId"].(string)
+	s.taskCount++
+	t := &task{
+		id:        fmt.Sprintf("%s-%s-%d-1-fakehost-fakerack", requestID, r.active, s.taskCount),
+		requestID: requestID,
+		deployID:  r.active,
+		runID:     runID,
+	}
+	t.update(dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_LAUNCHED, "", s.timestamp())
+	if s.taskResult != "" {
+		t.update(s.taskResult, "", s.timestamp())
+	}
+	s.tasks[t.id] = t
+	return r.parent(), nil
+}
+
+func (s *Server) taskByRunID(requestID, runID string) (interface{}, error) {
+	for _, t := range s.tasks {
+		if t.requestID == requestID && t.runID == runID {
+			return t.taskID(), nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "no task for run %q of %q", runID, requestID)
+}
+
+func (s *Server) taskHistory(taskID string) (interface{}, error) {
+	t, ok := s.tasks[taskID]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "task %q not found", taskID)
+	}
+	return jsonObject{"task": jsonObject{"taskId": t.taskID()}, "taskUpdates": t.updates}, nil
+}
+
+func (r *request) deploy(id string) *deploy {
+	for _, d := range r.deploys {
+		if d.id == id {
+			return d
+		}
+	}
+	return nil
+}
+
+// finish finishes the pending deploy of r in state.
+func (r *request) finish(state dtos.SingularityDeployResultDeployState, message string) {
+	d := r.deploy(r.pending)
+	d.result, d.message = state, message
+	if state == dtos.SingularityDeployResultDeployStateSUCCEEDED {
+		r.active = d.id
+	}
+	r.pending = ""
+}
+
+func (r *request) parent() jsonObject {
+	requestID, _ := r.json["id"].(string)
+	rds := jsonObject{"requestId": requestID}
+	if r.active != "" {
+		rds["activeDeploy"] = r.deploy(r.active).marker(requestID)
+	}
+	if r.pending != "" {
+		rds["pendingDeploy"] = r.deploy(r.pending).marker(requestID)
+	}
+	return jsonObject{
+		"request":            r.json,
+		"state":              "ACTIVE",
+		"requestDeployState": rds,
+	}
+}
+
+func (d *deploy) marker(requestID string) jsonObject {
+	return jsonObject{"requestId": requestID, "deployId": d.id, "timestamp": d.timestamp}
+}
+
+func (d *deploy) history(requestID string) jsonObject {
+	h := jsonObject{"deployMarker": d.marker(requestID)}
+	if d.result != "" {
+		h["deployResult"] = jsonObject{"deployState": d.result, "message": d.message, "timestamp": d.timestamp}
+	}
+	return h
+}
+
+func (t *task) taskID() jsonObject {
+	return jsonObject{"id": t.id, "requestId": t.requestID, "deployId": t.deployID, "instanceNo": 1, "host": "fakehost"}
+}
+
+func (t *task) update(state dtos.SingularityTaskHistoryUpdateExtendedTaskState, message string, timestamp int64) {
+	t.updates = append(t.updates, jsonObject{
+		"taskId":        t.taskID(),
+		"taskState":     state,
+		"statusMessage": message,
+		"timestamp":     timestamp,
+	})
+}
+
+// convert decodes the JSON encoding of from into to.
+func convert(from interface{}, to interface{}) error {
+	b, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, to)
+}
@@ -0,0 +1,194 @@
+package singularitytest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opentable/go-singularity"
+	"github.com/opentable/go-singularity/dtos"
+	"github.com/opentable/swaggering"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postRequest(t *testing.T, client *singularity.Client, id string, instances int32) {
+	req, err := swaggering.LoadMap(&dtos.SingularityRequest{}, map[string]interface{}{
+		"Id":          id,
+		"RequestType": dtos.SingularityRequestRequestTypeSERVICE,
+		"Instances":   instances,
+	})
+	require.NoError(t, err)
+	_, err = client.PostRequest(req.(*dtos.SingularityRequest))
+	require.NoError(t, err)
+}
+
+func postDeploy(client *singularity.Client, requestID, deployID string) error {
+	dep, err := swaggering.LoadMap(&dtos.SingularityDeploy{}, map[string]interface{}{
+		"Id":        deployID,
+		"RequestId": requestID,
+		"Metadata":  map[string]string{"key": "value"},
+	})
+	if err != nil {
+		return err
+	}
+	dr, err := swaggering.LoadMap(&dtos.SingularityDeployRequest{}, map[string]interface{}{
+		"Deploy": dep,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.Deploy(dr.(*dtos.SingularityDeployRequest))
+	return err
+}
+
+func status(err error) int {
+	if re, ok := err.(*swaggering.ReqError); ok {
+		return re.Status
+	}
+	return 0
+}
+
+func TestServer_RequestsAndDeploys(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := singularity.NewClient(s.URL)
+
+	postRequest(t, client, "req", 2)
+	require.NoError(t, postDeploy(client, "req", "one"))
+
+	rps, err := client.GetRequests()
+	require.NoError(t, err)
+	require.Len(t, rps, 1)
+	assert.Equal(t, "req", rps[0].Request.Id)
+	assert.EqualValues(t, 2, rps[0].Request.Instances)
+	require.NotNil(t, rps[0].RequestDeployState.ActiveDeploy)
+	assert.Equal(t, "one", rps[0].RequestDeployState.ActiveDeploy.DeployId)
+	assert.Nil(t, rps[0].RequestDeployState.PendingDeploy)
+
+	h, err := client.GetDeploy("req", "one")
+	require.NoError(t, err)
+	assert.Equal(t, dtos.SingularityDeployResultDeployStateSUCCEEDED, h.DeployResult.DeployState)
+	assert.Equal(t, "value", h.Deploy.Metadata["key"])
+
+	dep, ok := s.ActiveDeploy("req")
+	require.True(t, ok)
+	assert.Equal(t, "one", dep.Id)
+}
+
+func TestServer_DeployTransitions(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := singularity.NewClient(s.URL)
+	postRequest(t, client, "req", 1)
+	require.NoError(t, postDeploy(client, "req", "one"))
+
+	s.SetDeployOutcome("")
+	require.NoError(t, postDeploy(client, "req", "two"))
+	assert.Equal(t, http.StatusConflict, status(postDeploy(client, "req", "three")))
+
+	rp, err := client.GetRequest("req")
+	require.NoError(t, err)
+	assert.Equal(t, "one", rp.RequestDeployState.ActiveDeploy.DeployId)
+	assert.Equal(t, "two", rp.RequestDeployState.PendingDeploy.DeployId)
+	h, err := client.GetDeploy("req", "two")
+	require.NoError(t, err)
+	assert.Nil(t, h.DeployResult)
+
+	require.NoError(t, s.FinishDeploy("req", dtos.SingularityDeployResultDeployStateFAILED, "health checks failed"))
+	hs, err := client.GetDeploys("req", 1, 1)
+	require.NoError(t, err)
+	require.Len(t, hs, 2)
+	assert.Equal(t, "two", hs[0].DeployMarker.DeployId, "newest first")
+	assert.Equal(t, dtos.SingularityDeployResultDeployStateFAILED, hs[0].DeployResult.DeployState)
+	assert.Equal(t, "health checks failed", hs[0].DeployResult.Message)
+
+	rp, err = client.GetRequest("req")
+	require.NoError(t, err)
+	assert.Equal(t, "one", rp.RequestDeployState.ActiveDeploy.DeployId)
+	assert.Nil(t, rp.RequestDeployState.PendingDeploy)
+	assert.Equal(t, []string{"one", "two"}, s.DeployIDs("req"))
+}
+
+func TestServer_ScaleAndDelete(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := singularity.NewClient(s.URL)
+	postRequest(t, client, "req", 1)
+
+	sr, err := swaggering.LoadMap(&dtos.SingularityScaleRequest{}, map[string]interface{}{"Instances": int32(5)})
+	require.NoError(t, err)
+	_, err = client.Scale("req", sr.(*dtos.SingularityScaleRequest))
+	require.NoError(t, err)
+	req, ok := s.Request("req")
+	require.True(t, ok)
+	assert.EqualValues(t, 5, req.Instances)
+
+	_, err = client.DeleteRequest("req", &dtos.SingularityDeleteRequestRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, s.RequestIDs())
+	_, err = client.GetRequest("req")
+	assert.Equal(t, http.StatusNotFound, status(err))
+}
+
+func TestServer_Tasks(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.SetTaskOutcome("")
+	client := singularity.NewClient(s.URL)
+	postRequest(t, client, "req", 1)
+	require.NoError(t, postDeploy(client, "req", "one"))
+
+	rr, err := swaggering.LoadMap(&dtos.SingularityRunNowRequest{}, map[string]interface{}{"RunId": "run-1"})
+	require.NoError(t, err)
+	_, err = client.ScheduleImmediately("req", rr.(*dtos.SingularityRunNowRequest))
+	require.NoError(t, err)
+
+	tid, err := client.GetTaskByRunId("req", "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, "one", tid.DeployId)
+
+	require.NoError(t, s.UpdateTask(tid.Id, dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_FAILED, "Command exited with status 3"))
+	th, err := client.GetHistoryForTask(tid.Id)
+	require.NoError(t, err)
+	require.Len(t, th.TaskUpdates, 2)
+	assert.Equal(t, dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_FAILED, th.TaskUpdates[1].TaskState)
+
+	_, err = client.GetTaskByRunId("req", "run-2")
+	assert.Equal(t, http.StatusNotFound, status(err))
+}
+
+func TestServer_Failures(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := singularity.NewClient(s.URL)
+
+	s.Fail(Failure{Method: "GET", Path: "/api/requests", Status: http.StatusServiceUnavailable, Times: 2})
+	for i := 0; i < 2; i++ {
+		_, err := client.GetRequests()
+		assert.Equal(t, http.StatusServiceUnavailable, status(err))
+	}
+	_, err := client.GetRequests()
+	assert.NoError(t, err)
+
+	s.Fail(Failure{Path: "/api/requests/request/*/scale", Status: http.StatusInternalServerError})
+	postRequest(t, client, "req", 1)
+	sr, _ := swaggering.LoadMap(&dtos.SingularityScaleRequest{}, map[string]interface{}{"Instances": int32(2)})
+	for i := 0; i < 3; i++ {
+		_, err = client.Scale("req", sr.(*dtos.SingularityScaleRequest))
+		assert.Equal(t, http.StatusInternalServerError, status(err))
+	}
+	s.ClearFailures()
+	_, err = client.Scale("req", sr.(*dtos.SingularityScaleRequest))
+	assert.NoError(t, err)
+
+	s.Fail(Failure{Path: "/api/requests", Delay: 50 * time.Millisecond, Times: 1})
+	start := time.Now()
+	_, err = client.GetRequests()
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	calls := s.Calls()
+	require.NotEmpty(t, calls)
+	assert.Equal(t, Call{Method: "GET", Path: "/api/requests", Status: http.StatusServiceUnavailable}, calls[0])
+}
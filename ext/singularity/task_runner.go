@@ -0,0 +1,95 @@
+package singularity
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/opentable/go-singularity/dtos"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/swaggering"
+	"github.com/satori/go.uuid"
+)
+
+// exitStatusRE matches the Mesos status message of tasks whose command
+// exited, e.g. "Command exited with status 3".
+var exitStatusRE = regexp.MustCompile(`exited with status (\d+)`)
+
+// Run implements sous.TaskRunner on RectiAgent, by asking Singularity to run
+// the request immediately.
+func (ra *RectiAgent) Run(cluster, reqID string, args []string) (string, error) {
+	runID := "SOUS_RUN_" + StripDeployID(uuid.NewV4().String())
+	Log.Debug.Printf("Running %s %s %v as %s", cluster, reqID, args, runID)
+	rr, err := swaggering.LoadMap(&dtos.SingularityRunNowRequest{}, dtoMap{
+		"RunId":           runID,
+		"CommandLineArgs": swaggering.StringList(args),
+		"Message":         "Sous: sous run",
+	})
+	if err != nil {
+		return "", err
+	}
+	_, err = ra.singularityClient(cluster).ScheduleImmediately(reqID, rr.(*dtos.SingularityRunNowRequest))
+	return runID, err
+}
+
+// RunTask implements sous.TaskRunner on RectiAgent.
+func (ra *RectiAgent) RunTask(cluster, reqID, runID string) (string, error) {
+	tid, err := ra.singularityClient(cluster).GetTaskByRunId(reqID, runID)
+	if notFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return tid.Id, nil
+}
+
+// TaskStatus implements sous.TaskRunner on RectiAgent.
+func (ra *RectiAgent) TaskStatus(cluster, taskID string) (sous.TaskStatus, error) {
+	th, err := ra.singularityClient(cluster).GetHistoryForTask(taskID)
+	if notFound(err) {
+		return sous.TaskStatus{TaskID: taskID}, nil
+	}
+	if err != nil {
+		return sous.TaskStatus{}, err
+	}
+	return taskStatusFromUpdates(taskID, th.TaskUpdates), nil
+}
+
+func notFound(err error) bool {
+	re, ok := err.(*swaggering.ReqError)
+	return ok && re.Status == 404
+}
+
+// taskStatusFromUpdates computes the status of a task from the latest of its
+// history updates.
+func taskStatusFromUpdates(taskID string, updates dtos.SingularityTaskHistoryUpdateList) sous.TaskStatus {
+	ts := sous.TaskStatus{TaskID: taskID}
+	if len(updates) == 0 {
+		return ts
+	}
+	sorted := make(dtos.SingularityTaskHistoryUpdateList, len(updates))
+	copy(sorted, updates)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+	last := sorted[len(sorted)-1]
+
+	ts.State = string(last.TaskState)
+	ts.Message = last.StatusMessage
+	switch last.TaskState {
+	case dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_FINISHED:
+		ts.Done = true
+	case dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_FAILED,
+		dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_KILLED,
+		dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_LOST,
+		dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_LOST_WHILE_DOWN,
+		dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_ERROR:
+		ts.Done = true
+		ts.ExitCode = 1
+		if m := exitStatusRE.FindStringSubmatch(last.StatusMessage); m != nil {
+			if code, err := strconv.Atoi(m[1]); err == nil && code != 0 {
+				ts.ExitCode = code
+			}
+		}
+	}
+	return ts
+}
@@ -0,0 +1,44 @@
+package singularity
+
+import (
+	"testing"
+
+	"github.com/opentable/go-singularity/dtos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskStatusFromUpdates(t *testing.T) {
+	update := func(ts int64, state dtos.SingularityTaskHistoryUpdateExtendedTaskState, msg string) *dtos.SingularityTaskHistoryUpdate {
+		return &dtos.SingularityTaskHistoryUpdate{Timestamp: ts, TaskState: state, StatusMessage: msg}
+	}
+
+	status := taskStatusFromUpdates("t", nil)
+	assert.False(t, status.Done)
+
+	status = taskStatusFromUpdates("t", dtos.SingularityTaskHistoryUpdateList{
+		update(2, dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_RUNNING, ""),
+		update(1, dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_LAUNCHED, ""),
+	})
+	assert.Equal(t, "TASK_RUNNING", status.State)
+	assert.False(t, status.Done)
+
+	status = taskStatusFromUpdates("t", dtos.SingularityTaskHistoryUpdateList{
+		update(1, dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_RUNNING, ""),
+		update(2, dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_FINISHED, "Command exited with status 0"),
+	})
+	assert.True(t, status.Done)
+	assert.Equal(t, 0, status.ExitCode)
+
+	status = taskStatusFromUpdates("t", dtos.SingularityTaskHistoryUpdateList{
+		update(1, dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_RUNNING, ""),
+		update(2, dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_FAILED, "Command exited with status 3"),
+	})
+	assert.True(t, status.Done)
+	assert.Equal(t, 3, status.ExitCode)
+
+	status = taskStatusFromUpdates("t", dtos.SingularityTaskHistoryUpdateList{
+		update(1, dtos.SingularityTaskHistoryUpdateExtendedTaskStateTASK_KILLED, "killed by user"),
+	})
+	assert.True(t, status.Done)
+	assert.Equal(t, 1, status.ExitCode)
+}
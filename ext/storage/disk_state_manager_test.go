@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/yaml"
@@ -150,3 +153,35 @@ func exampleState() *sous.State {
 		},
 	}
 }
+
+func TestWriteState_orphans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sous-orphans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := exampleState()
+	s.Orphans = []sous.Orphan{{
+		DeploymentID: sous.DeploymentID{
+			ManifestID: sous.ManifestID{Source: sous.SourceLocation{Repo: "github.com/user/gone"}},
+			Cluster:    "cluster-1",
+		},
+		RequestID:   "github.com>user>gone::cluster-1",
+		Owners:      []string{"owner@example.com"},
+		Tombstoned:  time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC),
+		DeleteAfter: time.Date(2017, 3, 1, 13, 0, 0, 0, time.UTC),
+	}}
+
+	dsm := NewDiskStateManager(dir)
+	if err := dsm.WriteState(s, sous.User{}); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := dsm.ReadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.Orphans, actual.Orphans) {
+		t.Errorf("got orphans %#v; want %#v", actual.Orphans, s.Orphans)
+	}
+}
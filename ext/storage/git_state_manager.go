@@ -86,6 +86,14 @@ func (gsm *GitStateManager) ReadState() (*sous.State, error) {
 	return gsm.DiskStateManager.ReadState()
 }
 
+// Revision returns the commit of the GDM repository that state is read from.
+func (gsm *GitStateManager) Revision() (string, error) {
+	gsm.Lock()
+	defer gsm.Unlock()
+	out, err := gsm.gitOut("rev-parse", "HEAD")
+	return strings.TrimSpace(out), err
+}
+
 func (gsm *GitStateManager) needCommit() bool {
 	err := gsm.git("diff-index", "--exit-code", "HEAD")
 	if ee, is := errors.Cause(err).(*exec.ExitError); is {
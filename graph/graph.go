@@ -7,6 +7,7 @@ import (
 	"log" //ok
 	"os"
 	"os/user"
+	"strings"
 
 	"github.com/opentable/sous/config"
 	"github.com/opentable/sous/ext/docker"
@@ -195,6 +196,7 @@ func AddNetwork(graph adder) {
 	graph.Add(
 		newDockerClient,
 		newHTTPClient,
+		newPeers,
 	)
 }
 
@@ -210,7 +212,11 @@ func AddDocker(graph adder) {
 // AddSingularity adds Singularity clients to the graph.
 func AddSingularity(graph adder) {
 	graph.Add(
+		newRectificationClient,
+		newTaskRunner,
 		newDeployer,
+		newTombstones,
+		newAutoscaleRunner,
 	)
 }
 
@@ -251,6 +257,7 @@ func AddInternals(graph adder) {
 		newAutoResolver,
 		newInserter,
 		newStatusPoller,
+		newServerDoctor,
 	)
 }
 
@@ -258,8 +265,10 @@ func newResolveFilter(sf *config.DeployFilterFlags, shc sous.SourceHostChooser)
 	return sf.BuildFilter(shc.ParseSourceLocation)
 }
 
-func newResolver(filter *sous.ResolveFilter, d sous.Deployer, r sous.Registry) *sous.Resolver {
-	return sous.NewResolver(d, r, filter)
+func newResolver(filter *sous.ResolveFilter, d sous.Deployer, r sous.Registry, ts *sous.Tombstones) *sous.Resolver {
+	rez := sous.NewResolver(d, r, filter)
+	rez.Tombstones = ts
+	return rez
 }
 
 func newAutoResolver(rez *sous.Resolver, sr StateReader, ls *sous.LogSet) *sous.AutoResolver {
@@ -335,10 +344,18 @@ func (scd *SourceContextDiscovery) GetContext() *sous.SourceContext {
 	return scd.SourceContext
 }
 
-func newBuildContext(wd LocalWorkDirShell, c *sous.SourceContext) *sous.BuildContext {
+func newBuildContext(wd LocalWorkDirShell, scratch ScratchDirShell, c *sous.SourceContext, u sous.User, v Version) *sous.BuildContext {
 	sh := wd.Sh.Clone()
 	sh.LongRunning(true)
-	return &sous.BuildContext{Sh: sh, Source: *c}
+	host, _ := os.Hostname()
+	return &sous.BuildContext{
+		Sh:          sh,
+		Source:      *c,
+		Scratch:     sous.ScratchContext{Sh: scratch.Sh, RootDir: scratch.Dir()},
+		SousUser:    u,
+		SousVersion: v.Version,
+		Machine:     sous.Machine{Host: strings.SplitN(host, ".", 2)[0], FullHost: host},
+	}
 }
 
 func newBuildConfig(f *config.DeployFilterFlags, p *config.PolicyFlags, bc *sous.BuildContext) *sous.BuildConfig {
@@ -353,6 +370,7 @@ func newBuildConfig(f *config.DeployFilterFlags, p *config.PolicyFlags, bc *sous
 		Revision:   f.Revision,
 		Strict:     p.Strict,
 		ForceClone: p.ForceClone,
+		Flavors:    p.FlavorList(),
 		Context:    bc,
 	}
 	cfg.Resolve()
@@ -428,16 +446,39 @@ func newSelector(regClient LocalDockerClient, log *sous.LogSet) sous.Selector {
 				log.Info.Printf("Building with simple dockerfile buildpack")
 				return dfbp, nil
 			}
+
+			gobp := docker.NewGoBuildpack()
+			dr, err = gobp.Detect(ctx)
+			if err == nil && dr.Compatible {
+				log.Info.Printf("Building with Go buildpack")
+				return gobp, nil
+			}
+
+			nodebp := docker.NewNodeBuildpack()
+			dr, err = nodebp.Detect(ctx)
+			if err == nil && dr.Compatible {
+				log.Info.Printf("Building with Node.js buildpack")
+				return nodebp, nil
+			}
 			return nil, errors.New("no buildpack detected for project")
 		},
 	}
 }
 
-func newDockerBuilder(cfg LocalSousConfig, nc *docker.NameCache, ctx *sous.SourceContext, source LocalWorkDirShell, scratch ScratchDirShell) (*docker.Builder, error) {
+func newDockerBuilder(cfg LocalSousConfig, ins sous.Inserter, ctx *sous.SourceContext, source LocalWorkDirShell, scratch ScratchDirShell) (*docker.Builder, error) {
 	drh := cfg.Docker.RegistryHost
 	source.Sh = source.Sh.Clone().(*shell.Sh)
 	source.Sh.LongRunning(true)
-	return docker.NewBuilder(nc, drh, source.Sh, scratch.Sh)
+	b, err := docker.NewBuilder(ins, drh, source.Sh, scratch.Sh)
+	if err != nil || cfg.Docker.SigningKey == "" {
+		return b, err
+	}
+	key, err := ioutil.ReadFile(cfg.Docker.SigningKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading signing key")
+	}
+	b.Signer, err = sous.NewArtifactSigner(cfg.Docker.SigningKeyID, key)
+	return b, err
 }
 
 func newLabeller(db *docker.Builder) sous.Labeller {
@@ -448,21 +489,62 @@ func newRegistrar(db *docker.Builder) sous.Registrar {
 	return db
 }
 
-func newRegistry(dryrun DryrunOption, cfg LocalSousConfig, cl LocalDockerClient) (sous.Registry, error) {
+// newRegistry returns the local Docker registry, wrapped so that the name
+// cache of the Sous server is consulted first, if a server is configured.
+func newRegistry(dryrun DryrunOption, cfg LocalSousConfig, cl LocalDockerClient, hc HTTPClient) (sous.Registry, error) {
 	if dryrun == DryrunBoth || dryrun == DryrunRegistry {
 		return sous.NewDummyRegistry(), nil
 	}
-	return newDockerRegistry(cfg, cl)
+	nc, err := newDockerRegistry(cfg, cl)
+	if err != nil || cfg.Server == "" || hc.HTTPClient == nil {
+		return nc, err
+	}
+	return sous.NewHTTPRegistry(hc, nc), nil
 }
 
-func newDeployer(dryrun DryrunOption, nc *docker.NameCache) sous.Deployer {
+func newRectificationClient(dryrun DryrunOption, nc *docker.NameCache) sous.RectificationClient {
 	// Eventually, based on configuration, we may make different decisions here.
 	if dryrun == DryrunBoth || dryrun == DryrunScheduler {
 		drc := sous.NewDummyRectificationClient()
 		drc.SetLogger(log.New(os.Stdout, "rectify: ", 0))
-		return singularity.NewDeployer(drc)
+		return drc
+	}
+	return singularity.NewRectiAgent(nc)
+}
+
+func newTaskRunner(rc sous.RectificationClient) (sous.TaskRunner, error) {
+	tr, ok := rc.(sous.TaskRunner)
+	if !ok {
+		return nil, errors.Errorf("%T cannot run tasks", rc)
+	}
+	return tr, nil
+}
+
+func newDeployer(rc sous.RectificationClient, ts *sous.Tombstones) sous.Deployer {
+	return singularity.NewTombstoningDeployer(rc, ts)
+}
+
+// newTombstones returns the tombstones recorded in the local state, or, when
+// state is kept by a server, tombstones kept only in memory: only the server
+// persists them.
+func newTombstones(cfg LocalSousConfig, state *sous.State, sm *StateManager, u sous.User) *sous.Tombstones {
+	if cfg.Server != "" {
+		return sous.NewTombstones()
+	}
+	return sous.NewStateTombstones(state, sm, u)
+}
+
+// newAutoscaleRunner returns an AutoscaleRunner using the metrics provider
+// configured in c.Autoscale, or nil if autoscaling is not configured.
+func newAutoscaleRunner(c LocalSousConfig, state *sous.State, sm *StateManager) *sous.AutoscaleRunner {
+	if !c.Autoscale.Enabled() {
+		return nil
+	}
+	return &sous.AutoscaleRunner{
+		StateManager:    sm,
+		MetricsProvider: singularity.NewMetricsProvider(state.Defs.Clusters),
+		Autoscaler:      sous.UtilizationAutoscaler{DefaultTargetCPU: c.Autoscale.TargetCPU()},
 	}
-	return singularity.NewDeployer(singularity.NewRectiAgent(nc))
 }
 
 func newDockerClient() LocalDockerClient {
@@ -478,8 +560,56 @@ func newHTTPClient(c LocalSousConfig, user sous.User, log *sous.LogSet) (HTTPCli
 		return HTTPClient{}, nil
 	}
 	sous.Log.Debug.Printf("Using server at %s", c.Server)
-	cl, err := restful.NewClient(c.Server, log)
-	return HTTPClient{HTTPClient: cl}, err
+	cl, err := newAuthedClient(c.Server, c, log)
+	if err != nil {
+		return HTTPClient{}, err
+	}
+	return HTTPClient{HTTPClient: cl}, nil
+}
+
+// newAuthedClient returns a client for the Sous server at serverURL, which
+// authenticates as configured in c.Auth.
+func newAuthedClient(serverURL string, c LocalSousConfig, log *sous.LogSet) (*restful.LiveHTTPClient, error) {
+	cl, err := restful.NewClient(serverURL, log)
+	if err != nil {
+		return nil, err
+	}
+	if c.Auth.Token != "" {
+		cl.UseBearerToken(c.Auth.Token)
+	}
+	tc, err := c.Auth.ClientTLS()
+	if err != nil {
+		return nil, err
+	}
+	if tc != nil {
+		cl.UseTLSConfig(tc)
+	}
+	return cl, nil
+}
+
+// newPeers returns the servers known to this one, starting with those
+// recorded by a previous run and those listed in c.SiblingURLs.
+func newPeers(c LocalSousConfig, log *sous.LogSet) *sous.Peers {
+	newClient := func(url string) (sous.PeerClient, error) {
+		return newAuthedClient(url, c, log)
+	}
+	ps := sous.NewPeers(c.PeerFile(), c.Peers.FailAfter(), c.Peers.ForgetAfter(), newClient, log)
+	for name, url := range c.SiblingURLs {
+		ps.Seed(name, url)
+	}
+	return ps
+}
+
+// newServerDoctor returns a ServerDoctor for the servers known to cl's server,
+// or nil if there is no server configured.
+func newServerDoctor(cl HTTPClient, c LocalSousConfig, user sous.User, log *sous.LogSet) *sous.ServerDoctor {
+	if cl.HTTPClient == nil {
+		return nil
+	}
+	newClient := func(url string) (restful.HTTPClient, error) {
+		return newAuthedClient(url, c, log)
+	}
+	return sous.NewServerDoctor(cl, newClient, user)
 }
 
 // newStateManager returns a wrapped sous.HTTPStateManager if cl is not nil.
@@ -551,14 +681,17 @@ func newDockerRegistry(cfg LocalSousConfig, cl LocalDockerClient) (*docker.NameC
 		return nil, errors.Wrap(err, "building name cache DB")
 	}
 	drh := cfg.Docker.RegistryHost
-	return docker.NewNameCache(drh, cl.Client, db), nil
+	return docker.NewNameCache(drh, cl.Client, db)
 }
 
-func newInserter(cfg LocalSousConfig, cl LocalDockerClient) (sous.Inserter, error) {
-	if cfg.Server == "" {
+// newInserter returns the Inserter builds are recorded with: the Sous server,
+// if there is one, so that it learns of the artifacts and their signatures,
+// or the local name cache otherwise.
+func newInserter(cfg LocalSousConfig, cl LocalDockerClient, hc HTTPClient) (sous.Inserter, error) {
+	if cfg.Server == "" || hc.HTTPClient == nil {
 		return newDockerRegistry(cfg, cl)
 	}
-	return sous.NewHTTPNameInserter(cfg.Server)
+	return sous.NewHTTPNameInserter(hc.HTTPClient), nil
 }
 
 // initErr returns nil if error is nil, otherwise an initialisation error.
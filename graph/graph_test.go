@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"io/ioutil"
 	"log"
+	"reflect"
 	"testing"
 
 	"github.com/opentable/sous/config"
 	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/ext/singularity"
 	"github.com/opentable/sous/ext/storage"
 	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
 	"github.com/opentable/sous/util/shell"
 	"github.com/samsalisbury/psyringe"
 )
@@ -22,6 +25,7 @@ func TestBuildGraph(t *testing.T) {
 	g.Add(&config.DeployFilterFlags{})
 	g.Add(&config.PolicyFlags{}) //provided by SousBuild
 	g.Add(&config.OTPLFlags{})   //provided by SousInit and SousDeploy
+	g.Add(Version{})             //provided by the CLI
 
 	if err := g.Test(); err != nil {
 		t.Fatalf("unexpected error: %s", err)
@@ -67,13 +71,18 @@ func TestStateManagerSelectsGit(t *testing.T) {
 }
 
 func testBuildInserter(t *testing.T, serverStr string) sous.Inserter {
-	ins, err := newInserter(LocalSousConfig{Config: &config.Config{
+	cfg := LocalSousConfig{Config: &config.Config{
 		Server: serverStr,
 		Docker: docker.Config{
 			DatabaseDriver:     "sqlite3_sous",
 			DatabaseConnection: docker.InMemory,
 		},
-	}}, LocalDockerClient{})
+	}}
+	hc, err := newHTTPClient(cfg, sous.User{}, sous.SilentLogSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ins, err := newInserter(cfg, LocalDockerClient{}, hc)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -90,7 +99,53 @@ func TestNameInserterSelectsNameCache(t *testing.T) {
 func TestNameInserterSelectsHTTP(t *testing.T) {
 	ins := testBuildInserter(t, "http//example.com")
 	if _, ok := ins.(*sous.HTTPNameInserter); !ok {
-		t.Errorf("Injected %#v which isn't a docker.NameCache", ins)
+		t.Errorf("Injected %#v which isn't a sous.HTTPNameInserter", ins)
+	}
+}
+
+func testBuildRegistry(t *testing.T, serverStr string) sous.Registry {
+	cfg := LocalSousConfig{Config: &config.Config{
+		Server: serverStr,
+		Docker: docker.Config{
+			DatabaseDriver:     "sqlite3_sous",
+			DatabaseConnection: docker.InMemory,
+		},
+	}}
+	hc := HTTPClient{HTTPClient: &restful.DummyHTTPClient{}}
+	r, err := newRegistry(DryrunNeither, cfg, LocalDockerClient{}, hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRegistrySelectsNameCache(t *testing.T) {
+	r := testBuildRegistry(t, "")
+	if _, ok := r.(*docker.NameCache); !ok {
+		t.Errorf("Injected %#v which isn't a docker.NameCache", r)
+	}
+}
+
+func TestRegistrySelectsHTTP(t *testing.T) {
+	r := testBuildRegistry(t, "http://example.com")
+	if _, ok := r.(*sous.HTTPRegistry); !ok {
+		t.Errorf("Injected %#v which isn't a sous.HTTPRegistry", r)
+	}
+}
+
+func TestNewAutoscaleRunner(t *testing.T) {
+	cfg := LocalSousConfig{Config: &config.Config{}}
+	if ar := newAutoscaleRunner(cfg, sous.NewState(), &StateManager{}); ar != nil {
+		t.Errorf("Got %#v with no autoscale provider configured", ar)
+	}
+
+	cfg.Autoscale.Provider = config.AutoscaleProviderSingularity
+	ar := newAutoscaleRunner(cfg, sous.NewState(), &StateManager{})
+	if ar == nil {
+		t.Fatal("Got no AutoscaleRunner with an autoscale provider configured")
+	}
+	if _, ok := ar.MetricsProvider.(*singularity.MetricsProvider); !ok {
+		t.Errorf("Got %#v which isn't a singularity.MetricsProvider", ar.MetricsProvider)
 	}
 }
 
@@ -121,5 +176,13 @@ func TestNewBuildConfig(t *testing.T) {
 	if err := cfg.Validate(); err != nil {
 		t.Errorf("Not valid build config: %+v", err)
 	}
+	if len(cfg.Flavors) != 0 {
+		t.Errorf("Build config has flavors without any requested: %v", cfg.Flavors)
+	}
 
+	p.Flavors = "worker, api,"
+	cfg = newBuildConfig(f, p, bc)
+	if !reflect.DeepEqual(cfg.Flavors, []string{"worker", "api"}) {
+		t.Errorf("Build config's flavors were %v", cfg.Flavors)
+	}
 }
@@ -41,6 +41,7 @@ func addTestFilesystem(graph adder) {
 func addTestNetwork(graph adder) {
 	graph.Add(newDummyHTTPClient)
 	graph.Add(newDummyDockerClient)
+	graph.Add(newPeers)
 }
 
 func newDummyHTTPClient() HTTPClient {
@@ -48,7 +48,10 @@ func TestBuildDeployments(t *testing.T) {
 	clusterNick := "tcluster"
 	reqID := appLocation + clusterNick
 
-	nc := docker.NewNameCache("", drc, db)
+	nc, err := docker.NewNameCache("", drc, db)
+	if err != nil {
+		panic(err)
+	}
 
 	singCl := sing.NewClient(SingularityURL)
 	//singCl.Debug = true
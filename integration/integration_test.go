@@ -97,7 +97,9 @@ func (suite *integrationSuite) newNameCache(name string) *docker.NameCache {
 
 	suite.Require().NoError(err)
 
-	return docker.NewNameCache(registryName, suite.registry, db)
+	nc, err := docker.NewNameCache(registryName, suite.registry, db)
+	suite.Require().NoError(err)
+	return nc
 }
 
 func (suite *integrationSuite) waitUntilSettledStatus(clusters []string, sourceRepo string) *sous.DeployState {
@@ -468,9 +470,8 @@ func (suite *integrationSuite) TestResolve() {
 		}
 	}
 
-	// We no longer expect any deletions; See deployer.RectifySingleDelete.
-	//expectedInstances := 0
-	expectedInstances := 1
+	// Orphans are scaled down rather than deleted; See deployer.RectifySingleDelete.
+	expectedInstances := 0
 
 	which = suite.findRepo(ds, repoOne)
 	if which != none {
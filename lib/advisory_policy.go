@@ -0,0 +1,154 @@
+package sous
+
+import (
+	"fmt"
+	"time"
+)
+
+type (
+	// AdvisorySeverity describes how a cluster treats artifacts carrying a
+	// particular advisory.
+	AdvisorySeverity string
+
+	// AdvisoryPolicies is a collection of AdvisoryPolicy.
+	AdvisoryPolicies []AdvisoryPolicy
+
+	// An AdvisoryPolicy describes how a cluster treats artifacts carrying an
+	// advisory.
+	AdvisoryPolicy struct {
+		// Advisory is the name of the advisory, e.g. "dirty workspace".
+		Advisory string
+		// Severity is one of "block", "warn" or "allow-with-approval".
+		Severity AdvisorySeverity
+		// Expires is the time after which this policy no longer applies, and the
+		// advisory is blocked. The zero value means the policy never expires.
+		Expires time.Time `yaml:",omitempty"`
+		// Exemptions allow specific manifests to deploy artifacts carrying this
+		// advisory when Severity is "allow-with-approval", and silence the
+		// warning when it is "warn". They never override "block".
+		Exemptions []AdvisoryExemption `yaml:",omitempty"`
+	}
+
+	// An AdvisoryExemption allows a single manifest to deploy artifacts
+	// carrying an advisory. Exemptions are how approval is granted for
+	// advisories with severity "allow-with-approval".
+	AdvisoryExemption struct {
+		// Manifest is the manifest which is exempted.
+		Manifest ManifestID
+		// Owner is the person responsible for the exemption.
+		Owner string
+		// Reason explains why the exemption was granted.
+		Reason string
+		// Expires is the time after which this exemption no longer applies. The
+		// zero value means the exemption never expires.
+		Expires time.Time `yaml:",omitempty"`
+	}
+)
+
+const (
+	// AdvisoryBlock prevents artifacts with the advisory being deployed.
+	AdvisoryBlock AdvisorySeverity = "block"
+	// AdvisoryWarn allows artifacts with the advisory to be deployed, but warns
+	// about it.
+	AdvisoryWarn AdvisorySeverity = "warn"
+	// AdvisoryAllowWithApproval allows artifacts with the advisory to be
+	// deployed only by manifests with an exemption.
+	AdvisoryAllowWithApproval AdvisorySeverity = "allow-with-approval"
+)
+
+// Clone returns a deep copy of this AdvisoryPolicies.
+func (aps AdvisoryPolicies) Clone() AdvisoryPolicies {
+	if aps == nil {
+		return nil
+	}
+	c := make(AdvisoryPolicies, len(aps))
+	for i, ap := range aps {
+		exs := make([]AdvisoryExemption, len(ap.Exemptions))
+		copy(exs, ap.Exemptions)
+		ap.Exemptions = exs
+		c[i] = ap
+	}
+	return c
+}
+
+// Get returns the policy for advisory, and true, or false if there is none.
+func (aps AdvisoryPolicies) Get(advisory string) (AdvisoryPolicy, bool) {
+	for _, ap := range aps {
+		if ap.Advisory == advisory {
+			return ap, true
+		}
+	}
+	return AdvisoryPolicy{}, false
+}
+
+func expired(t, now time.Time) bool {
+	return !t.IsZero() && now.After(t)
+}
+
+// Exemption returns the unexpired exemption for mid, if there is one.
+func (ap AdvisoryPolicy) Exemption(mid ManifestID, now time.Time) (AdvisoryExemption, bool) {
+	for _, ex := range ap.Exemptions {
+		if ex.Manifest == mid && !expired(ex.Expires, now) {
+			return ex, true
+		}
+	}
+	return AdvisoryExemption{}, false
+}
+
+// CheckAdvisory decides whether artifacts carrying advisory may be deployed
+// to this cluster by manifest mid. It returns a non-empty warning for
+// advisories which are allowed but should be brought to the user's
+// attention, and a non-empty reason for advisories which are not allowed.
+func (c *Cluster) CheckAdvisory(mid ManifestID, advisory string, now time.Time) (warning, reason string) {
+	policy, ok := c.AdvisoryPolicies.Get(advisory)
+	if !ok {
+		for _, aa := range c.AllowedAdvisories {
+			if aa == advisory {
+				return "", ""
+			}
+		}
+		return "", fmt.Sprintf("not allowed in cluster %q", c.Name)
+	}
+	if expired(policy.Expires, now) {
+		return "", fmt.Sprintf("policy in cluster %q expired at %s", c.Name, policy.Expires.Format(time.RFC3339))
+	}
+	if policy.Severity == AdvisoryBlock {
+		return "", fmt.Sprintf("blocked in cluster %q", c.Name)
+	}
+	ex, exempted := policy.Exemption(mid, now)
+	switch policy.Severity {
+	default:
+		return "", fmt.Sprintf("blocked by unknown severity %q in cluster %q", policy.Severity, c.Name)
+	case AdvisoryWarn:
+		if exempted {
+			return "", ""
+		}
+		return fmt.Sprintf("advisory %q is allowed in cluster %q, but should be addressed", advisory, c.Name), ""
+	case AdvisoryAllowWithApproval:
+		if exempted {
+			return fmt.Sprintf("advisory %q exempted for %s by %s: %s", advisory, mid, ex.Owner, ex.Reason), ""
+		}
+		return "", fmt.Sprintf("requires an approved exemption for %s in cluster %q", mid, c.Name)
+	}
+}
+
+// CheckAdvisories checks all the advisories on art against this cluster's
+// advisory policies, on behalf of manifest mid. It returns warnings about
+// allowed advisories, and an *UnacceptableAdvisory for the first advisory
+// which is not allowed.
+func (c *Cluster) CheckAdvisories(mid ManifestID, sid SourceID, art *BuildArtifact, now time.Time) ([]string, error) {
+	var warnings []string
+	for _, q := range art.Qualities {
+		if q.Kind != "advisory" || q.Name == "" {
+			continue
+		}
+		warning, reason := c.CheckAdvisory(mid, q.Name, now)
+		if reason != "" {
+			return warnings, &UnacceptableAdvisory{Quality: q, SourceID: &sid, Reason: reason}
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings, nil
+}
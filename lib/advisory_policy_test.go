@@ -0,0 +1,74 @@
+package sous
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterCheckAdvisory(t *testing.T) {
+	now := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	exempt := MustParseManifestID("github.com/ot/exempt")
+	other := MustParseManifestID("github.com/ot/other")
+
+	c := &Cluster{
+		Name:              "prod",
+		AllowedAdvisories: []string{"legacy"},
+		AdvisoryPolicies: AdvisoryPolicies{
+			{Advisory: "blocked", Severity: AdvisoryBlock,
+				Exemptions: []AdvisoryExemption{{Manifest: exempt, Owner: "sam", Reason: "hotfix"}}},
+			{Advisory: "warned", Severity: AdvisoryWarn,
+				Exemptions: []AdvisoryExemption{{Manifest: exempt, Owner: "sam", Reason: "known"}}},
+			{Advisory: "approved", Severity: AdvisoryAllowWithApproval,
+				Exemptions: []AdvisoryExemption{
+					{Manifest: exempt, Owner: "judson", Reason: "accepted risk"},
+					{Manifest: other, Owner: "judson", Reason: "lapsed", Expires: now.Add(-time.Hour)},
+				}},
+			{Advisory: "expired", Severity: AdvisoryWarn, Expires: now.Add(-time.Hour)},
+			{Advisory: "current", Severity: AdvisoryWarn, Expires: now.Add(time.Hour)},
+		},
+	}
+
+	check := func(mid ManifestID, adv string, allowed, warns bool) {
+		warning, reason := c.CheckAdvisory(mid, adv, now)
+		assert.Equal(t, allowed, reason == "", "%s for %s: reason %q", adv, mid, reason)
+		assert.Equal(t, warns, warning != "", "%s for %s: warning %q", adv, mid, warning)
+	}
+
+	check(other, "legacy", true, false)
+	check(other, "unknown", false, false)
+	check(other, "blocked", false, false)
+	check(exempt, "blocked", false, false)
+	check(other, "warned", true, true)
+	check(exempt, "warned", true, false)
+	check(other, "approved", false, false)
+	check(exempt, "approved", true, true)
+	check(other, "expired", false, false)
+	check(other, "current", true, true)
+}
+
+func TestClusterCheckAdvisories(t *testing.T) {
+	mid := MustParseManifestID("github.com/ot/one")
+	sid := MustParseSourceID(`github.com/ot/one,1.3.5`)
+	c := &Cluster{
+		Name: "prod",
+		AdvisoryPolicies: AdvisoryPolicies{
+			{Advisory: "dirty workspace", Severity: AdvisoryWarn},
+			{Advisory: "ephemeral tag", Severity: AdvisoryAllowWithApproval},
+		},
+	}
+
+	art := &BuildArtifact{Name: "ot-docker/one", Type: "docker", Qualities: []Quality{
+		{Name: "dirty workspace", Kind: "advisory"},
+	}}
+	warnings, err := c.CheckAdvisories(mid, sid, art, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+
+	art.Qualities = append(art.Qualities, Quality{Name: "ephemeral tag", Kind: "advisory"})
+	_, err = c.CheckAdvisories(mid, sid, art, time.Now())
+	if assert.IsType(t, &UnacceptableAdvisory{}, err) {
+		assert.Contains(t, err.Error(), "requires an approved exemption")
+	}
+}
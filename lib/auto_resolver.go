@@ -3,6 +3,8 @@ package sous
 import (
 	"sync"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 type (
@@ -26,7 +28,12 @@ type (
 		listeners []autoResolveListener
 		sync.RWMutex
 		stableStatus, liveStatus *ResolveStatus
-		currentRecorder          *ResolveRecorder
+		// stableAt is when stableStatus was recorded.
+		stableAt        time.Time
+		currentRecorder *ResolveRecorder
+		// lastResolved records when each cluster was last resolved without
+		// errors.
+		lastResolved map[string]time.Time
 	}
 )
 
@@ -42,6 +49,8 @@ func NewAutoResolver(rez *Resolver, sr StateReader, ls *LogSet) *AutoResolver {
 		StateReader: sr,
 		LogSet:      ls,
 		listeners:   make([]autoResolveListener, 0),
+
+		lastResolved: map[string]time.Time{},
 	}
 	ar.StandardListeners()
 	return ar
@@ -92,10 +101,10 @@ func (ar *AutoResolver) Kickoff() TriggerChannel {
 }
 
 func (ar *AutoResolver) updateStatus() {
-	if ar.currentRecorder == nil {
-		return
-	}
 	ar.write(func() {
+		if ar.currentRecorder == nil {
+			return
+		}
 		ls := ar.currentRecorder.CurrentStatus()
 		Log.Debug.Printf("Recording live status from %p: %v", ar, ls)
 		ar.liveStatus = &ls
@@ -113,6 +122,57 @@ func (ar *AutoResolver) Statuses() (stable, live *ResolveStatus) {
 	return ar.stableStatus, ar.liveStatus
 }
 
+// StableStatus returns the status of the last resolve to complete, as
+// Statuses does. An error is returned instead if no resolve has completed
+// yet, or if the last one completed more than maxAge before now, which means
+// that resolving has stalled, and the status no longer says anything about the
+// clusters.
+func (ar *AutoResolver) StableStatus(maxAge time.Duration, now time.Time) (*ResolveStatus, error) {
+	ar.RLock()
+	defer ar.RUnlock()
+	if ar.stableStatus == nil {
+		return nil, errors.New("no resolve has completed yet")
+	}
+	if age := now.Sub(ar.stableAt); age > maxAge {
+		return nil, errors.Errorf("the last resolve completed %s ago", age.Truncate(time.Second))
+	}
+	return ar.stableStatus, nil
+}
+
+// LastResolved returns when each cluster was last resolved without errors.
+// Clusters which have not been are absent.
+func (ar *AutoResolver) LastResolved() map[string]time.Time {
+	ar.RLock()
+	defer ar.RUnlock()
+	lr := make(map[string]time.Time, len(ar.lastResolved))
+	for name, t := range ar.lastResolved {
+		lr[name] = t
+	}
+	return lr
+}
+
+// recordResolved records that each of clusters was resolved at now, unless
+// status shows it was unreachable, or that it had errors.
+func (ar *AutoResolver) recordResolved(clusters Clusters, status ResolveStatus, now time.Time) {
+	failed := map[string]bool{}
+	for name := range status.UnreachableClusters {
+		failed[name] = true
+	}
+	for _, rez := range status.Log {
+		if rez.Error != nil {
+			failed[rez.DeploymentID.Cluster] = true
+		}
+	}
+	if ar.lastResolved == nil {
+		ar.lastResolved = map[string]time.Time{}
+	}
+	for name := range clusters {
+		if !failed[name] {
+			ar.lastResolved[name] = now
+		}
+	}
+}
+
 func loopTilDone(f func(), done TriggerChannel) {
 	for {
 		select {
@@ -172,6 +232,7 @@ func (ar *AutoResolver) resolveOnce(ac announceChannel) {
 	}
 
 	ar.write(func() {
+		ar.Resolver.TrustedKeys = state.Defs.TrustedKeys
 		ar.currentRecorder = ar.Resolver.Begin(ar.GDM, state.Defs.Clusters)
 	})
 	defer ar.write(func() {
@@ -182,6 +243,10 @@ func (ar *AutoResolver) resolveOnce(ac announceChannel) {
 		ss := ar.currentRecorder.CurrentStatus()
 		Log.Debug.Printf("Recording stable status from %p: %v", ar, ss)
 		ar.stableStatus = &ss
+		ar.stableAt = time.Now()
+		if !ar.currentRecorder.earlyExit() {
+			ar.recordResolved(ar.Resolver.FilteredClusters(state.Defs.Clusters), ss, time.Now())
+		}
 	})
 	ar.Statuses() // XXX this is debugging
 	ar.LogSet.Debug.Print("Completed resolve")
@@ -98,3 +98,41 @@ func TestResolveLoop(t *testing.T) {
 		t.Error("Should have announced a result")
 	}
 }
+
+func TestAutoResolver_LastResolved(t *testing.T) {
+	assert := assert.New(t)
+	ar := setupAR()
+
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	clusters := Clusters{"ok": {}, "down": {}, "erred": {}}
+	status := ResolveStatus{
+		UnreachableClusters: map[string]string{"down": "connection refused"},
+		Log: []DiffResolution{
+			{DeploymentID: DeploymentID{Cluster: "ok"}},
+			{DeploymentID: DeploymentID{Cluster: "erred"}, Error: &ErrorWrapper{}},
+		},
+	}
+	ar.recordResolved(clusters, status, now)
+
+	assert.Equal(map[string]time.Time{"ok": now}, ar.LastResolved())
+}
+
+func TestAutoResolver_StableStatus(t *testing.T) {
+	assert := assert.New(t)
+	ar := setupAR()
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := ar.StableStatus(time.Minute, now)
+	assert.Error(err, "no resolve has completed")
+
+	ar.stableStatus = &ResolveStatus{Phase: "finished"}
+	ar.stableAt = now.Add(-30 * time.Second)
+	status, err := ar.StableStatus(time.Minute, now)
+	assert.NoError(err)
+	assert.Equal("finished", status.Phase)
+
+	_, err = ar.StableStatus(time.Minute, now.Add(time.Hour))
+	if assert.Error(err, "the status is stale") {
+		assert.Contains(err.Error(), "1h0m30s ago")
+	}
+}
@@ -0,0 +1,272 @@
+package sous
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Autoscale bounds the number of instances the autoscaler may set for a
+	// deployment. The zero value disables autoscaling.
+	Autoscale struct {
+		// MinInstances and MaxInstances are the inclusive bounds on the
+		// number of instances. Autoscaling is enabled if MaxInstances is
+		// non-zero.
+		MinInstances int `yaml:",omitempty"`
+		MaxInstances int `yaml:",omitempty"`
+		// TargetCPU is the fraction of its CPU allocation each instance should
+		// use on average, e.g. 0.6. If zero, the autoscaler's default applies.
+		TargetCPU float64 `yaml:",omitempty"`
+	}
+
+	// DeploymentMetrics are the metrics an Autoscaler works from.
+	DeploymentMetrics struct {
+		// Instances is the number of instances the metrics were collected
+		// from.
+		Instances int
+		// CPUUtilization is the mean fraction of its CPU allocation used by
+		// each instance.
+		CPUUtilization float64
+	}
+
+	// A MetricsProvider reports the current metrics of deployments.
+	MetricsProvider interface {
+		Metrics(DeploymentID) (DeploymentMetrics, error)
+	}
+
+	// An Autoscaler decides how many instances a deployment should have.
+	Autoscaler interface {
+		// DesiredInstances returns the number of instances for a deployment
+		// with config dc and current metrics m. The result is clamped to the
+		// bounds in dc.Autoscale by the caller.
+		DesiredInstances(dc DeployConfig, m DeploymentMetrics) (int, error)
+	}
+
+	// UtilizationAutoscaler sizes deployments so that their mean CPU
+	// utilization is near their target.
+	UtilizationAutoscaler struct {
+		// DefaultTargetCPU is used for deployments which do not set one.
+		DefaultTargetCPU float64
+	}
+
+	// DummyMetricsProvider is a MetricsProvider for testing, which returns
+	// metrics set on it directly.
+	DummyMetricsProvider struct {
+		sync.Mutex
+		metrics map[DeploymentID]DeploymentMetrics
+	}
+
+	// AutoscaleRunner adjusts the instances of autoscaled deployments in the
+	// GDM. The resolver then applies the adjustments like any other change.
+	AutoscaleRunner struct {
+		StateManager
+		MetricsProvider
+		Autoscaler
+	}
+
+	// An AutoscaleAdjustment records a change in the instances of a
+	// deployment made by the autoscaler.
+	AutoscaleAdjustment struct {
+		DeploymentID
+		From, To int
+	}
+)
+
+// AutoscalerUser is the user the autoscaler records its changes to the GDM
+// as.
+var AutoscalerUser = User{Name: "Sous Autoscaler", Email: "sous-autoscaler@localhost"}
+
+// Enabled returns true if a deployment with these bounds is autoscaled.
+func (a Autoscale) Enabled() bool {
+	return a.MaxInstances != 0
+}
+
+// Clamp returns n, limited to these bounds.
+func (a Autoscale) Clamp(n int) int {
+	if n < a.MinInstances {
+		n = a.MinInstances
+	}
+	if a.Enabled() && n > a.MaxInstances {
+		n = a.MaxInstances
+	}
+	return n
+}
+
+func (a Autoscale) String() string {
+	if !a.Enabled() {
+		return "no autoscaling"
+	}
+	return fmt.Sprintf("autoscale %d-%d @%g", a.MinInstances, a.MaxInstances, a.TargetCPU)
+}
+
+func (a Autoscale) diff(o Autoscale) []string {
+	diffs := []string{}
+	if a.MinInstances != o.MinInstances {
+		diffs = append(diffs, fmt.Sprintf("autoscale min instances; this: %d; other: %d", a.MinInstances, o.MinInstances))
+	}
+	if a.MaxInstances != o.MaxInstances {
+		diffs = append(diffs, fmt.Sprintf("autoscale max instances; this: %d; other: %d", a.MaxInstances, o.MaxInstances))
+	}
+	if a.TargetCPU != o.TargetCPU {
+		diffs = append(diffs, fmt.Sprintf("autoscale target CPU; this: %g; other: %g", a.TargetCPU, o.TargetCPU))
+	}
+	return diffs
+}
+
+// validateAutoscale checks the autoscale bounds of dc, and that its instances
+// are within them.
+func (dc *DeployConfig) validateAutoscale() []Flaw {
+	a := dc.Autoscale
+	if a == (Autoscale{}) {
+		return nil
+	}
+	if !a.Enabled() {
+		return []Flaw{FatalFlaw("autoscale requires MaxInstances")}
+	}
+	var flaws []Flaw
+	if a.MinInstances < 0 || a.MinInstances > a.MaxInstances {
+		flaws = append(flaws, FatalFlaw("autoscale bounds %d-%d not valid", a.MinInstances, a.MaxInstances))
+	}
+	if a.TargetCPU < 0 || a.TargetCPU > 1 {
+		flaws = append(flaws, FatalFlaw("autoscale target CPU %g not between 0 and 1", a.TargetCPU))
+	}
+	if len(flaws) != 0 {
+		return flaws
+	}
+	if n := dc.NumInstances; n != 0 && a.Clamp(n) != n {
+		flaws = append(flaws, NewFlaw(
+			fmt.Sprintf("%d instances outside autoscale bounds %d-%d", n, a.MinInstances, a.MaxInstances),
+			func() error { dc.NumInstances = dc.Autoscale.Clamp(dc.NumInstances); return nil },
+		))
+	}
+	return flaws
+}
+
+// DesiredInstances implements Autoscaler.
+func (ua UtilizationAutoscaler) DesiredInstances(dc DeployConfig, m DeploymentMetrics) (int, error) {
+	target := dc.Autoscale.TargetCPU
+	if target == 0 {
+		target = ua.DefaultTargetCPU
+	}
+	if target <= 0 {
+		return 0, errors.Errorf("no target CPU utilization")
+	}
+	instances := m.Instances
+	if instances == 0 {
+		instances = dc.NumInstances
+	}
+	return int(math.Ceil(float64(instances) * m.CPUUtilization / target)), nil
+}
+
+// NewDummyMetricsProvider returns an empty DummyMetricsProvider.
+func NewDummyMetricsProvider() *DummyMetricsProvider {
+	return &DummyMetricsProvider{metrics: map[DeploymentID]DeploymentMetrics{}}
+}
+
+// Set sets the metrics returned for id.
+func (dmp *DummyMetricsProvider) Set(id DeploymentID, m DeploymentMetrics) {
+	dmp.Lock()
+	defer dmp.Unlock()
+	dmp.metrics[id] = m
+}
+
+// Metrics implements MetricsProvider.
+func (dmp *DummyMetricsProvider) Metrics(id DeploymentID) (DeploymentMetrics, error) {
+	dmp.Lock()
+	defer dmp.Unlock()
+	m, ok := dmp.metrics[id]
+	if !ok {
+		return m, errors.Errorf("no metrics for %s", id)
+	}
+	return m, nil
+}
+
+// Adjust sets the instances of each autoscaled deployment in the GDM to the
+// number its Autoscaler decides on, within its bounds. If any are changed,
+// the GDM is written as AutoscalerUser. Deployments whose metrics or
+// decisions fail are left alone, and the first such error is returned along
+// with the adjustments that were made.
+func (ar *AutoscaleRunner) Adjust() ([]AutoscaleAdjustment, error) {
+	state, err := ar.ReadState()
+	if err != nil {
+		return nil, err
+	}
+	state = state.Clone()
+
+	var adjustments []AutoscaleAdjustment
+	var firstErr error
+	for _, m := range state.Manifests.Snapshot() {
+		clusters := make([]string, 0, len(m.Deployments))
+		for c := range m.Deployments {
+			clusters = append(clusters, c)
+		}
+		sort.Strings(clusters)
+		for _, c := range clusters {
+			spec := m.Deployments[c]
+			if !spec.Autoscale.Enabled() {
+				continue
+			}
+			id := DeploymentID{ManifestID: m.ID(), Cluster: c}
+			to, err := ar.desiredInstances(id, spec.DeployConfig)
+			if err != nil {
+				Log.Warn.Printf("Not autoscaling %s: %s", id, err)
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			if to == spec.NumInstances {
+				continue
+			}
+			Log.Notice.Printf("Autoscaling %s from %d to %d instances", id, spec.NumInstances, to)
+			adjustments = append(adjustments, AutoscaleAdjustment{DeploymentID: id, From: spec.NumInstances, To: to})
+			spec.NumInstances = to
+			m.Deployments[c] = spec
+		}
+	}
+	if len(adjustments) == 0 {
+		return nil, firstErr
+	}
+	if err := ar.WriteState(state, AutoscalerUser); err != nil {
+		return nil, err
+	}
+	return adjustments, firstErr
+}
+
+// Kickoff starts adjusting the autoscaled deployments in the GDM every
+// interval, until the returned channel is closed.
+func (ar *AutoscaleRunner) Kickoff(interval time.Duration) chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if _, err := ar.Adjust(); err != nil {
+				Log.Warn.Printf("Autoscaling: %s", err)
+			}
+		}
+	}()
+	return done
+}
+
+func (ar *AutoscaleRunner) desiredInstances(id DeploymentID, dc DeployConfig) (int, error) {
+	metrics, err := ar.Metrics(id)
+	if err != nil {
+		return 0, err
+	}
+	n, err := ar.DesiredInstances(dc, metrics)
+	if err != nil {
+		return 0, err
+	}
+	return dc.Autoscale.Clamp(n), nil
+}
@@ -0,0 +1,103 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployConfigValidateAutoscale(t *testing.T) {
+	newConfig := func(n int, a Autoscale) *DeployConfig {
+		return &DeployConfig{
+			Resources:    Resources{"cpus": "0.1", "memory": "100", "ports": "1"},
+			NumInstances: n,
+			Autoscale:    a,
+		}
+	}
+
+	assert.Empty(t, newConfig(3, Autoscale{}).Validate())
+	assert.Empty(t, newConfig(3, Autoscale{MinInstances: 2, MaxInstances: 10, TargetCPU: 0.6}).Validate())
+
+	bad := []Autoscale{
+		{MinInstances: 2},
+		{MinInstances: 5, MaxInstances: 4},
+		{MaxInstances: 4, TargetCPU: 1.5},
+	}
+	for _, a := range bad {
+		flaws := newConfig(3, a).Validate()
+		if assert.Len(t, flaws, 1, "%v", a) {
+			assert.Error(t, flaws[0].Repair())
+		}
+	}
+
+	dc := newConfig(20, Autoscale{MinInstances: 2, MaxInstances: 10})
+	flaws := dc.Validate()
+	if assert.Len(t, flaws, 1) {
+		assert.NoError(t, flaws[0].Repair())
+		assert.Equal(t, 10, dc.NumInstances)
+	}
+}
+
+func TestUtilizationAutoscaler(t *testing.T) {
+	ua := UtilizationAutoscaler{DefaultTargetCPU: 0.5}
+	dc := DeployConfig{NumInstances: 4}
+
+	n, err := ua.DesiredInstances(dc, DeploymentMetrics{Instances: 4, CPUUtilization: 0.9})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, n)
+
+	dc.Autoscale.TargetCPU = 0.8
+	n, err = ua.DesiredInstances(dc, DeploymentMetrics{CPUUtilization: 0.1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = UtilizationAutoscaler{}.DesiredInstances(DeployConfig{}, DeploymentMetrics{})
+	assert.Error(t, err)
+}
+
+func TestAutoscaleRunnerAdjust(t *testing.T) {
+	mid := ManifestID{Source: SourceLocation{Repo: "github.com/example/app"}}
+	state := NewState()
+	state.Manifests.Add(&Manifest{
+		Source: mid.Source,
+		Deployments: DeploySpecs{
+			"autoscaled": {DeployConfig: DeployConfig{
+				NumInstances: 4,
+				Autoscale:    Autoscale{MinInstances: 2, MaxInstances: 6},
+			}},
+			"stable": {DeployConfig: DeployConfig{
+				NumInstances: 3,
+				Autoscale:    Autoscale{MinInstances: 2, MaxInstances: 6},
+			}},
+			"fixed": {DeployConfig: DeployConfig{NumInstances: 4}},
+		},
+	})
+	sm := &DummyStateManager{State: state}
+	metrics := NewDummyMetricsProvider()
+	metrics.Set(DeploymentID{ManifestID: mid, Cluster: "autoscaled"}, DeploymentMetrics{Instances: 4, CPUUtilization: 1})
+	metrics.Set(DeploymentID{ManifestID: mid, Cluster: "stable"}, DeploymentMetrics{Instances: 3, CPUUtilization: 0.5})
+
+	ar := &AutoscaleRunner{
+		StateManager:    sm,
+		MetricsProvider: metrics,
+		Autoscaler:      UtilizationAutoscaler{DefaultTargetCPU: 0.5},
+	}
+	adjustments, err := ar.Adjust()
+	assert.NoError(t, err)
+	assert.Equal(t, []AutoscaleAdjustment{
+		{DeploymentID: DeploymentID{ManifestID: mid, Cluster: "autoscaled"}, From: 4, To: 6},
+	}, adjustments)
+	assert.Equal(t, 1, sm.WriteCount)
+
+	m, ok := sm.State.Manifests.Get(mid)
+	if assert.True(t, ok) {
+		assert.Equal(t, 6, m.Deployments["autoscaled"].NumInstances)
+		assert.Equal(t, 3, m.Deployments["stable"].NumInstances)
+		assert.Equal(t, 4, m.Deployments["fixed"].NumInstances)
+	}
+
+	adjustments, err = ar.Adjust()
+	assert.NoError(t, err)
+	assert.Empty(t, adjustments)
+	assert.Equal(t, 1, sm.WriteCount)
+}
@@ -11,7 +11,9 @@ type (
 	BuildConfig struct {
 		Repo, Offset, Tag, Revision string
 		Strict, ForceClone          bool
-		Context                     *BuildContext
+		// Flavors are the flavors to build alongside the main artifact.
+		Flavors []string
+		Context *BuildContext
 	}
 
 	// An AdvisoryName is the type for advisory tokens.
@@ -65,11 +67,14 @@ func (c *BuildConfig) NewContext() *BuildContext {
 	tag := c.chooseTag()
 	sh.CD(sc.RootDir)
 	bc := BuildContext{
-		Sh:      sh,
-		Scratch: ctx.Scratch,
-		Machine: ctx.Machine,
-		User:    ctx.User,
-		Changes: ctx.Changes,
+		Sh:          sh,
+		Scratch:     ctx.Scratch,
+		Machine:     ctx.Machine,
+		User:        ctx.User,
+		Changes:     ctx.Changes,
+		SousUser:    ctx.SousUser,
+		SousVersion: ctx.SousVersion,
+		Flavors:     c.Flavors,
 		Source: SourceContext{
 			OffsetDir:      c.chooseOffset(),
 			RemoteURL:      c.chooseRemoteURL(),
@@ -4,6 +4,7 @@ import (
 	"os/user"
 
 	"github.com/opentable/sous/util/shell"
+	"github.com/samsalisbury/semv"
 )
 
 type (
@@ -16,6 +17,13 @@ type (
 		User       user.User
 		Changes    Changes
 		Advisories []string
+		// SousUser is the Sous identity of the user performing the build.
+		SousUser User
+		// SousVersion is the version of Sous performing the build.
+		SousVersion semv.Version
+		// Flavors are the flavors to build alongside the main artifact, for
+		// buildpacks which support them.
+		Flavors []string
 	}
 
 	// ScratchContext represents an isolated copy of a project's source code
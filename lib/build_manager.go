@@ -3,6 +3,7 @@ package sous
 import (
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/opentable/sous/util/firsterr"
 	"github.com/pkg/errors"
@@ -28,6 +29,7 @@ func (m *BuildManager) Build() (*BuildResult, error) {
 		bc *BuildContext
 		br *BuildResult
 	)
+	started := time.Now()
 	err := firsterr.Set(
 		func(e *error) { *e = m.BuildConfig.Validate() },
 		func(e *error) { bc = m.BuildConfig.NewContext() },
@@ -38,12 +40,24 @@ func (m *BuildManager) Build() (*BuildResult, error) {
 		func(e *error) { dr, *e = bp.Detect(bc) },
 		func(e *error) { br, *e = bp.Build(bc, dr) },
 		func(e *error) { br.Advisories = bc.Advisories },
+		func(e *error) { br.Provenance = m.provenance(bc, bp, br, started) },
 		func(e *error) { *e = m.ApplyMetadata(br, bc) },
 		func(e *error) { *e = m.RegisterAndWarnAdvisories(br, bc) },
 	)
 	return br, err
 }
 
+// provenance starts the Provenance of a build; the Labeller is expected to
+// complete it with details specific to the kind of artifact built.
+func (m *BuildManager) provenance(bc *BuildContext, bp Buildpack, br *BuildResult, started time.Time) *Provenance {
+	p := NewProvenance(bc, bp, started)
+	if br.Provenance != nil {
+		p.BaseImages = br.Provenance.BaseImages
+	}
+	p.BuildFinished = time.Now().UTC()
+	return p
+}
+
 // RegisterAndWarnAdvisories registers the image if there are no blocking
 // advisories; warns about the advisories and does not register otherwise.
 func (m *BuildManager) RegisterAndWarnAdvisories(br *BuildResult, bc *BuildContext) error {
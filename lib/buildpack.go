@@ -72,6 +72,12 @@ type (
 		Advisories                []string
 		Elapsed                   time.Duration
 		ExtraResults              map[string]*BuildResult
+		// Flavor, if not empty, marks this result as the artifact to deploy for
+		// manifests of that flavor, rather than an auxiliary image. It is
+		// registered against the flavor-qualified SourceID.
+		Flavor string
+		// Provenance records the circumstances of this build.
+		Provenance *Provenance
 	}
 
 	// EchoSelector wraps a buildpack Factory. But why?
@@ -0,0 +1,116 @@
+package sous
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// ClientPolicy configures how Sous talks to the scheduler of a cluster:
+	// how long it waits for each request, how failed requests are retried, and
+	// when it stops trying the cluster for a while. Zero values take the
+	// defaults in DefaultClientPolicy.
+	ClientPolicy struct {
+		// Timeout limits each attempt at a request.
+		Timeout time.Duration `yaml:",omitempty"`
+		// MaxRetries is the number of times a failed request is retried. If
+		// negative, requests are never retried. Requests which change
+		// anything are only retried if they could not be sent at all.
+		MaxRetries int `yaml:",omitempty"`
+		// InitialBackoff is the wait before the first retry. Each subsequent
+		// retry waits twice as long as the last, up to MaxBackoff.
+		InitialBackoff time.Duration `yaml:",omitempty"`
+		MaxBackoff     time.Duration `yaml:",omitempty"`
+		// BreakerThreshold is the number of consecutive failed requests after
+		// which the cluster is considered unreachable.
+		BreakerThreshold int `yaml:",omitempty"`
+		// BreakerCooldown is how long requests to an unreachable cluster fail
+		// immediately, before it is tried again.
+		BreakerCooldown time.Duration `yaml:",omitempty"`
+	}
+
+	// UnreachableClustersError is returned by Deployer.RunningDeployments,
+	// along with the deployments of the other clusters, when some clusters
+	// could not be reached. The resolver leaves the deployments in those
+	// clusters alone until they can be reached again.
+	UnreachableClustersError struct {
+		// Clusters maps the name of each unreachable cluster to the error
+		// which made it so.
+		Clusters map[string]error
+	}
+)
+
+// DefaultClientPolicy is used for the options not set in a cluster's
+// ClientPolicy.
+var DefaultClientPolicy = ClientPolicy{
+	Timeout:          30 * time.Second,
+	MaxRetries:       3,
+	InitialBackoff:   100 * time.Millisecond,
+	MaxBackoff:       5 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+// WithDefaults returns a copy of p with each option that is not set taken
+// from DefaultClientPolicy.
+func (p ClientPolicy) WithDefaults() ClientPolicy {
+	d := DefaultClientPolicy
+	if p.Timeout <= 0 {
+		p.Timeout = d.Timeout
+	}
+	switch {
+	case p.MaxRetries < 0:
+		p.MaxRetries = 0
+	case p.MaxRetries == 0:
+		p.MaxRetries = d.MaxRetries
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = d.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = d.MaxBackoff
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	if p.BreakerThreshold <= 0 {
+		p.BreakerThreshold = d.BreakerThreshold
+	}
+	if p.BreakerCooldown <= 0 {
+		p.BreakerCooldown = d.BreakerCooldown
+	}
+	return p
+}
+
+// Backoff returns how long to wait before retry number n, counting from 0.
+func (p ClientPolicy) Backoff(n int) time.Duration {
+	b := p.InitialBackoff
+	for i := 0; i < n && b < p.MaxBackoff; i++ {
+		b *= 2
+	}
+	if b > p.MaxBackoff {
+		b = p.MaxBackoff
+	}
+	return b
+}
+
+func (e *UnreachableClustersError) Error() string {
+	names := e.Names()
+	msgs := make([]string, 0, len(names))
+	for _, n := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", n, e.Clusters[n]))
+	}
+	return fmt.Sprintf("unreachable clusters: %s", strings.Join(msgs, "; "))
+}
+
+// Names returns the names of the unreachable clusters, in order.
+func (e *UnreachableClustersError) Names() []string {
+	names := make([]string, 0, len(e.Clusters))
+	for n := range e.Clusters {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,42 @@
+package sous
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientPolicy_WithDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(DefaultClientPolicy, ClientPolicy{}.WithDefaults())
+
+	p := ClientPolicy{
+		Timeout:        time.Second,
+		MaxRetries:     -1,
+		InitialBackoff: 10 * time.Second,
+	}.WithDefaults()
+	assert.Equal(time.Second, p.Timeout)
+	assert.Equal(0, p.MaxRetries)
+	assert.Equal(10*time.Second, p.MaxBackoff, "MaxBackoff below InitialBackoff")
+	assert.Equal(DefaultClientPolicy.BreakerThreshold, p.BreakerThreshold)
+}
+
+func TestClientPolicy_Backoff(t *testing.T) {
+	p := ClientPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for n, ms := range expected {
+		assert.Equal(t, ms*time.Millisecond, p.Backoff(n), "retry %d", n)
+	}
+}
+
+func TestUnreachableClustersError(t *testing.T) {
+	err := &UnreachableClustersError{Clusters: map[string]error{
+		"b": fmt.Errorf("timeout"),
+		"a": fmt.Errorf("refused"),
+	}}
+	assert.Equal(t, []string{"a", "b"}, err.Names())
+	assert.Equal(t, "unreachable clusters: a: refused; b: timeout", err.Error())
+}
@@ -21,3 +21,11 @@ const VersionLabel = "com.opentable.sous.version"
 
 // RevisionLabel is a metadata fieldname that records the git revision ID of a Sous-controlled service.
 const RevisionLabel = "com.opentable.sous.revision"
+
+// PortNamesLabel is a metadata fieldname that records the names of the ports
+// of a Sous-controlled service, in the order of its port mappings.
+const PortNamesLabel = "com.opentable.sous.port_names"
+
+// AutoscaleLabel is a metadata fieldname that records the autoscale bounds
+// of a Sous-controlled service, as "min,max,targetCPU".
+const AutoscaleLabel = "com.opentable.sous.autoscale"
@@ -34,6 +34,21 @@ type (
 		Volumes Volumes
 		// Startup containts healthcheck options for this deploy.
 		Startup Startup `yaml:",omitempty"`
+		// Schedule is when the tasks of a scheduled deployment run. It is
+		// required for scheduled kinds, and not allowed for others.
+		Schedule Schedule `yaml:",omitempty"`
+		// NetworkMode is the container networking mode: bridge (the default),
+		// host or none.
+		NetworkMode NetworkMode `yaml:",omitempty"`
+		// Ports lists the named container ports, which are mapped from ports
+		// allocated on the host. Only allowed in bridge network mode.
+		Ports PortMappings `yaml:",omitempty"`
+		// Placement controls which slaves and racks the tasks run on, and
+		// their registration with the load balancer.
+		Placement Placement `yaml:",omitempty"`
+		// Autoscale bounds the instances the autoscaler may set. If not set,
+		// NumInstances is only changed by hand.
+		Autoscale Autoscale `yaml:",omitempty"`
 	}
 
 	// A DeployConfigs is a map from cluster name to DeployConfig
@@ -44,11 +59,28 @@ type (
 	Env map[string]string
 
 	// Startup is a struct of options related to container startup.  Members are
-	// pointers so that they can be ignored if nil.
+	// pointers so that they can be ignored if nil, in which case the cluster's
+	// default applies.
+	//
+	// The checks are readiness checks: they are made while a deploy is in
+	// progress, and the deploy succeeds once they pass. Singularity does not
+	// check the liveness of tasks once they are running. Checks are always made
+	// over HTTP; the Singularity client Sous uses cannot set the protocol.
 	Startup struct {
 		CheckReadyURIPath    *string `yaml:",omitempty"`
 		CheckReadyURITimeout *int    `yaml:",omitempty"`
 		Timeout              *int    `yaml:",omitempty"`
+		// CheckReadyInterval is the number of seconds between checks.
+		CheckReadyInterval *int `yaml:",omitempty"`
+		// CheckReadyRetries is the number of failed checks allowed before a
+		// task is considered to have failed.
+		CheckReadyRetries *int `yaml:",omitempty"`
+		// CheckReadyPortIndex is the index of the port, among those allocated
+		// to each task, that checks are made on.
+		CheckReadyPortIndex *int `yaml:",omitempty"`
+		// SkipCheckReady, if true, means deploys succeed without waiting for
+		// checks to pass.
+		SkipCheckReady *bool `yaml:",omitempty"`
 	}
 
 	// Metadata represents an opaque map of metadata - Sous is agnostic about
@@ -80,6 +112,10 @@ func (dc *DeployConfig) Validate() []Flaw {
 	}
 
 	flaws = append(flaws, rezs.Validate()...)
+	flaws = append(flaws, dc.validateNetwork()...)
+	flaws = append(flaws, dc.validatePlacement()...)
+	flaws = append(flaws, dc.validateAutoscale()...)
+	flaws = append(flaws, dc.validateStartup()...)
 
 	for _, f := range flaws {
 		f.AddContext("deploy config", dc)
@@ -110,7 +146,7 @@ func (dc *DeployConfig) Repair(fs []Flaw) error {
 }
 
 func (dc *DeployConfig) String() string {
-	return fmt.Sprintf("#%d %s %+v : %+v %+v", dc.NumInstances, spew.Sprintf("%v", dc.Startup), dc.Resources, dc.Env, dc.Volumes)
+	return fmt.Sprintf("#%d %s %+v : %+v %+v %s %s %v %s %s", dc.NumInstances, spew.Sprintf("%v", dc.Startup), dc.Resources, dc.Env, dc.Volumes, dc.Schedule, dc.NetworkMode.Normalized(), dc.Ports, dc.Placement, dc.Autoscale)
 }
 
 // Equal is used to compare DeployConfigs
@@ -153,6 +189,15 @@ func (dc *DeployConfig) Diff(o DeployConfig) (bool, []string) {
 		}
 	}
 	diffs = append(diffs, dc.Startup.diff(o.Startup)...)
+	diffs = append(diffs, dc.Schedule.diff(o.Schedule)...)
+	if !dc.NetworkMode.Equal(o.NetworkMode) {
+		diffs = append(diffs, fmt.Sprintf("network mode; this: %s; other: %s", dc.NetworkMode.Normalized(), o.NetworkMode.Normalized()))
+	}
+	if !dc.Ports.Equal(o.Ports) {
+		diffs = append(diffs, fmt.Sprintf("ports; this: %v; other: %v", dc.Ports, o.Ports))
+	}
+	diffs = append(diffs, dc.Placement.diff(o.Placement)...)
+	diffs = append(diffs, dc.Autoscale.diff(o.Autoscale)...)
 	// TODO: Compare Args
 	return len(diffs) == 0, diffs
 }
@@ -202,6 +247,7 @@ func (s Startup) diff(o Startup) []string {
 		}
 	}
 
+	diffs = append(diffs, s.diffChecks(o)...)
 	return diffs
 }
 
@@ -223,21 +269,12 @@ func (dc DeployConfig) Clone() (c DeployConfig) {
 		}
 	}
 	c.Volumes = dc.Volumes.Clone()
-
-	if dc.Startup.CheckReadyURIPath != nil {
-		uripath := *dc.Startup.CheckReadyURIPath
-		c.Startup.CheckReadyURIPath = &uripath
-	}
-
-	if dc.Startup.CheckReadyURITimeout != nil {
-		crtimeout := *dc.Startup.CheckReadyURITimeout
-		c.Startup.CheckReadyURITimeout = &crtimeout
-	}
-
-	if dc.Startup.Timeout != nil {
-		timeout := *dc.Startup.Timeout
-		c.Startup.Timeout = &timeout
-	}
+	c.Schedule = dc.Schedule
+	c.NetworkMode = dc.NetworkMode
+	c.Ports = dc.Ports.Clone()
+	c.Placement = dc.Placement.Clone()
+	c.Autoscale = dc.Autoscale
+	c.Startup = dc.Startup.Clone()
 
 	return
 }
@@ -308,6 +345,18 @@ func flattenDeployConfigs(dcs []DeployConfig) DeployConfig {
 			break
 		}
 	}
+	for _, c := range dcs {
+		if c.NetworkMode != "" {
+			dc.NetworkMode = c.NetworkMode
+			break
+		}
+	}
+	for _, c := range dcs {
+		if len(c.Ports) != 0 {
+			dc.Ports = c.Ports
+			break
+		}
+	}
 	for _, c := range dcs {
 		for n, v := range c.Resources {
 			if _, set := dc.Resources[n]; !set {
@@ -325,14 +374,15 @@ func flattenDeployConfigs(dcs []DeployConfig) DeployConfig {
 			}
 		}
 
-		if dc.Startup.CheckReadyURIPath == nil {
-			dc.Startup.CheckReadyURIPath = c.Startup.CheckReadyURIPath
+		dc.Startup = dc.Startup.Inherit(c.Startup)
+		if dc.Schedule.IsZero() {
+			dc.Schedule = c.Schedule
 		}
-		if dc.Startup.CheckReadyURITimeout == nil {
-			dc.Startup.CheckReadyURITimeout = c.Startup.CheckReadyURITimeout
+		if dc.Placement.IsZero() {
+			dc.Placement = c.Placement.Clone()
 		}
-		if dc.Startup.Timeout == nil {
-			dc.Startup.Timeout = c.Startup.Timeout
+		if !dc.Autoscale.Enabled() {
+			dc.Autoscale = c.Autoscale
 		}
 	}
 	return dc
@@ -3,6 +3,9 @@ package sous
 import (
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 type (
@@ -69,62 +72,85 @@ func (d *DeployableChans) collect() diffSet {
 }
 
 // GuardImage checks that a deployment is valid before deploying it.
-func GuardImage(r Registry, d *Deployment) (*BuildArtifact, error) {
+// If the deployment's cluster requires signed artifacts, the artifact must
+// carry a valid signature by one of keys.
+func GuardImage(r Registry, d *Deployment, keys TrustedKeys) (*BuildArtifact, error) {
 	if d.NumInstances == 0 {
 		Log.Info.Printf("Deployment %q has 0 instances, skipping artifact check.", d.ID())
 		return nil, nil
 	}
-	art, err := r.GetArtifact(d.SourceID)
+	art, err := artifactFor(r, d)
 	if err != nil {
 		return nil, &MissingImageNameError{err}
 	}
-	for _, q := range art.Qualities {
-		if q.Kind == "advisory" {
-			if q.Name == "" {
-				continue
-			}
-			advisoryIsValid := false
-			var allowedAdvisories []string
-			if d.Cluster == nil {
-				return nil, fmt.Errorf("nil cluster on deployment %q", d)
-			}
-			allowedAdvisories = d.Cluster.AllowedAdvisories
-			for _, aa := range allowedAdvisories {
-				if aa == q.Name {
-					advisoryIsValid = true
-					break
-				}
-			}
-			if !advisoryIsValid {
-				return nil, &UnacceptableAdvisory{q, &d.SourceID}
-			}
+	if d.Cluster == nil {
+		// Without a cluster there's no policy to check advisories against,
+		// but an artifact without advisories needs no policy.
+		if hasAdvisories(art) {
+			return nil, fmt.Errorf("nil cluster on deployment %q", d)
+		}
+		return art, nil
+	}
+	warnings, err := d.Cluster.CheckAdvisories(d.ManifestID(), d.SourceID, art, time.Now())
+	for _, w := range warnings {
+		Log.Warn.Printf("Deployment %q: %s", d.ID(), w)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.Cluster.RequireSignedArtifacts {
+		if err := keys.Verify(d.SourceID, art); err != nil {
+			return nil, &UntrustedArtifactError{SourceID: d.SourceID, Cluster: d.ClusterName, Reason: err.Error()}
 		}
 	}
 	return art, err
 }
 
+func hasAdvisories(art *BuildArtifact) bool {
+	for _, q := range art.Qualities {
+		if q.Kind == "advisory" && q.Name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// artifactFor returns the artifact to deploy for d. An artifact built
+// specifically for d's flavor is preferred to the artifact for d's SourceID,
+// which is only used if the registry knows of no flavored one.
+func artifactFor(r Registry, d *Deployment) (*BuildArtifact, error) {
+	if d.Flavor != "" {
+		art, err := r.GetFlavoredArtifact(d.SourceID, d.Flavor)
+		if _, notFound := errors.Cause(err).(*ArtifactNotFoundError); !notFound {
+			return art, err
+		}
+	}
+	return r.GetArtifact(d.SourceID)
+}
+
 // ID returns the ID of this DeployablePair.
 func (dp *DeployablePair) ID() DeploymentID {
 	return dp.name
 }
 
-// ResolveNames resolves diffs.
-func (d *DeployableChans) ResolveNames(r Registry, diff *DeployableChans, errs chan *DiffResolution) {
+// ResolveNames resolves diffs. Artifacts are verified against keys for
+// clusters that require signed artifacts.
+func (d *DeployableChans) ResolveNames(r Registry, keys TrustedKeys, diff *DeployableChans, errs chan *DiffResolution) {
 	d.WaitGroup = sync.WaitGroup{}
 	d.Add(4)
-	go func() { resolveCreates(r, diff.Start, d.Start, errs); d.Done() }()
-	go func() { maybeResolveDeletes(r, diff.Stop, d.Stop, errs); d.Done() }()
-	go func() { maybeResolveRetains(r, diff.Stable, d.Stable, errs); d.Done() }()
-	go func() { resolvePairs(r, diff.Update, d.Update, errs); d.Done() }()
+	go func() { resolveCreates(r, keys, diff.Start, d.Start, errs); d.Done() }()
+	go func() { maybeResolveDeletes(r, keys, diff.Stop, d.Stop, errs); d.Done() }()
+	go func() { maybeResolveRetains(r, keys, diff.Stable, d.Stable, errs); d.Done() }()
+	go func() { resolvePairs(r, keys, diff.Update, d.Update, errs); d.Done() }()
 	go func() { d.Wait(); close(errs) }()
 }
 
-func resolveCreates(r Registry, from chan *DeployablePair, to chan *DeployablePair, errs chan *DiffResolution) {
+func resolveCreates(r Registry, keys TrustedKeys, from chan *DeployablePair, to chan *DeployablePair, errs chan *DiffResolution) {
 	for dp := range from {
 		dep := dp.Post
 		Log.Vomit.Printf("Deployment processed, needs artifact: %#v", dep)
 
-		da, err := resolveName(r, dep)
+		da, err := resolveName(r, keys, dep)
 		if err != nil {
 			Log.Info.Printf("Unable to create new deployment %q: %s", dep.ID(), err)
 			Log.Debug.Printf("Failed create deployment %q: % #v", dep.ID(), dep)
@@ -144,35 +170,63 @@ func resolveCreates(r Registry, from chan *DeployablePair, to chan *DeployablePa
 
 // XXX now that everything is DeployablePairs, this can probably be simplified
 
-func maybeResolveRetains(r Registry, from chan *DeployablePair, to chan *DeployablePair, errs chan *DiffResolution) {
+func maybeResolveRetains(r Registry, keys TrustedKeys, from chan *DeployablePair, to chan *DeployablePair, errs chan *DiffResolution) {
 	for dp := range from {
-		da := maybeResolveSingle(r, dp.Post)
+		da := maybeResolveSingle(r, keys, dp.Post)
+		if err := checkRunningDigest(dp.Prior, da); err != nil {
+			Log.Warn.Printf("Deployment %q: %s", dp.ID(), err.Error)
+			errs <- err
+			continue
+		}
 		to <- &DeployablePair{ExecutorData: dp.ExecutorData, name: dp.name, Prior: da, Post: da}
 	}
 	close(to)
 }
 
-func maybeResolveDeletes(r Registry, from chan *DeployablePair, to chan *DeployablePair, errs chan *DiffResolution) {
+// checkRunningDigest reports an error if the artifact running for a deployment
+// is not the one now known for its SourceID: if the image was overwritten, the
+// running deployment is no longer what its SourceID says it is. Artifacts
+// without digests can't be compared, and are assumed to match.
+func checkRunningDigest(running, intended *Deployable) *DiffResolution {
+	if running == nil || running.BuildArtifact == nil || intended == nil || intended.BuildArtifact == nil {
+		return nil
+	}
+	was, is := running.BuildArtifact.Digest(), intended.BuildArtifact.Digest()
+	if was == "" || is == "" || was == is {
+		return nil
+	}
+	return &DiffResolution{
+		DeploymentID: intended.ID(),
+		Desc:         StableDiff,
+		Error: WrapResolveError(&DigestMismatchError{
+			SourceID: intended.SourceID,
+			Running:  running.BuildArtifact.Name,
+			Known:    intended.BuildArtifact.Name,
+		}),
+	}
+}
+
+func maybeResolveDeletes(r Registry, keys TrustedKeys, from chan *DeployablePair, to chan *DeployablePair, errs chan *DiffResolution) {
 	for dp := range from {
-		da := maybeResolveSingle(r, dp.Prior)
+		da := maybeResolveSingle(r, keys, dp.Prior)
 		to <- &DeployablePair{ExecutorData: dp.ExecutorData, name: dp.name, Prior: da, Post: nil}
 	}
 	close(to)
 }
 
-func maybeResolveSingle(r Registry, dep *Deployable) *Deployable {
+func maybeResolveSingle(r Registry, keys TrustedKeys, dep *Deployable) *Deployable {
 	Log.Vomit.Printf("Attempting to resolve optional artifact: %#v (stable or deletes don't need images)", dep)
-	da, err := resolveName(r, dep)
+	da, err := resolveName(r, keys, dep)
 	if err != nil {
 		Log.Debug.Printf("Error resolving stopped or stable deployment (proceeding anyway): %#v: %#v", dep, err)
 	}
 	return da
 }
 
-func resolvePairs(r Registry, from chan *DeployablePair, to chan *DeployablePair, errs chan *DiffResolution) {
+func resolvePairs(r Registry, keys TrustedKeys, from chan *DeployablePair, to chan *DeployablePair, errs chan *DiffResolution) {
 	for depPair := range from {
 		Log.Vomit.Printf("Pair of deployments processed, needs artifact: %#v", depPair)
-		d, err := resolvePair(r, depPair)
+		d, err := resolvePair(r, keys, depPair)
 		if err != nil {
 			Log.Info.Printf("Unable to modify deployment %q: %s", depPair.Post, err)
 			Log.Debug.Printf("Failed modify deployment %q: % #v", depPair.ID(), depPair.Post)
@@ -189,8 +243,8 @@ func resolvePairs(r Registry, from chan *DeployablePair, to chan *DeployablePair
 	close(to)
 }
 
-func resolveName(r Registry, d *Deployable) (*Deployable, *DiffResolution) {
-	art, err := GuardImage(r, d.Deployment)
+func resolveName(r Registry, keys TrustedKeys, d *Deployable) (*Deployable, *DiffResolution) {
+	art, err := GuardImage(r, d.Deployment, keys)
 	if err != nil {
 		return d, &DiffResolution{
 			DeploymentID: d.ID(),
@@ -201,9 +255,9 @@ func resolveName(r Registry, d *Deployable) (*Deployable, *DiffResolution) {
 	return d, nil
 }
 
-func resolvePair(r Registry, depPair *DeployablePair) (*DeployablePair, *DiffResolution) {
-	prior, _ := resolveName(r, depPair.Prior)
-	post, err := resolveName(r, depPair.Post)
+func resolvePair(r Registry, keys TrustedKeys, depPair *DeployablePair) (*DeployablePair, *DiffResolution) {
+	prior, _ := resolveName(r, keys, depPair.Prior)
+	post, err := resolveName(r, keys, depPair.Post)
 
 	return &DeployablePair{ExecutorData: depPair.ExecutorData, name: depPair.name, Prior: prior, Post: post}, err
 }
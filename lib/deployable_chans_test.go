@@ -78,7 +78,7 @@ func (nrs *NameResolveTestSuite) TearDownTest() {
 }
 
 func (nrs *NameResolveTestSuite) TestResolveNameGood() {
-	da, err := resolveName(nrs.reg, nrs.makeTestDep())
+	da, err := resolveName(nrs.reg, nil, nrs.makeTestDep())
 	nrs.NotNil(da)
 	nrs.Nil(err)
 }
@@ -86,7 +86,7 @@ func (nrs *NameResolveTestSuite) TestResolveNameGood() {
 func (nrs *NameResolveTestSuite) TestResolveNameBad() {
 	nrs.reg.FeedArtifact(nil, fmt.Errorf("badness"))
 
-	da, err := resolveName(nrs.reg, nrs.makeTestDep())
+	da, err := resolveName(nrs.reg, nil, nrs.makeTestDep())
 	nrs.Nil(da.BuildArtifact)
 	nrs.Error(err.Error)
 }
@@ -95,13 +95,13 @@ func (nrs *NameResolveTestSuite) TestResolveNameSkipped() {
 	noInstances := nrs.makeTestDep()
 	noInstances.DeployConfig.NumInstances = 0
 
-	da, err := resolveName(nrs.reg, noInstances)
+	da, err := resolveName(nrs.reg, nil, noInstances)
 	nrs.Nil(da.BuildArtifact)
 	nrs.Nil(err)
 }
 
 func (nrs *NameResolveTestSuite) TestResolveNameStartChannel() {
-	nrs.depChans.ResolveNames(nrs.reg, nrs.diffChans, nrs.errChan)
+	nrs.depChans.ResolveNames(nrs.reg, nil, nrs.diffChans, nrs.errChan)
 	nrs.diffChans.Start <- nrs.makeTestDepPair(nil, nrs.makeTestDep())
 
 	select {
@@ -117,7 +117,7 @@ func (nrs *NameResolveTestSuite) TestResolveNameStartChannel() {
 }
 
 func (nrs *NameResolveTestSuite) TestResolveNameUpdateChannel() {
-	nrs.depChans.ResolveNames(nrs.reg, nrs.diffChans, nrs.errChan)
+	nrs.depChans.ResolveNames(nrs.reg, nil, nrs.diffChans, nrs.errChan)
 	nrs.diffChans.Update <- &DeployablePair{
 		Prior: nrs.makeTestDep(),
 		Post:  nrs.makeTestDep(),
@@ -136,7 +136,7 @@ func (nrs *NameResolveTestSuite) TestResolveNameUpdateChannel() {
 
 func (nrs *NameResolveTestSuite) TestResolveNameStartChannelUnresolved() {
 	nrs.reg.FeedArtifact(nil, fmt.Errorf("not found"))
-	nrs.depChans.ResolveNames(nrs.reg, nrs.diffChans, nrs.errChan)
+	nrs.depChans.ResolveNames(nrs.reg, nil, nrs.diffChans, nrs.errChan)
 	nrs.diffChans.Start <- nrs.makeTestDepPair(nil, nrs.makeTestDep())
 
 	select {
@@ -149,9 +149,50 @@ func (nrs *NameResolveTestSuite) TestResolveNameStartChannelUnresolved() {
 	}
 }
 
+func (nrs *NameResolveTestSuite) makeDigestedTestDep(digest string) *Deployable {
+	dep := nrs.makeTestDep()
+	dep.BuildArtifact = &BuildArtifact{Type: "docker", Name: "docker.example.com/gh/offset@" + digest}
+	return dep
+}
+
+func (nrs *NameResolveTestSuite) TestResolveNameStableDigestMatches() {
+	digest := "sha256:0123456789012345678901234567890123456789012345678901234567890123"
+	nrs.reg.FeedArtifact(nrs.makeDigestedTestDep(digest).BuildArtifact, nil)
+	nrs.depChans.ResolveNames(nrs.reg, nil, nrs.diffChans, nrs.errChan)
+	nrs.diffChans.Stable <- nrs.makeTestDepPair(nrs.makeDigestedTestDep(digest), nrs.makeTestDep())
+
+	select {
+	case stable := <-nrs.depChans.Stable:
+		nrs.NotNil(stable)
+	case err := <-nrs.errChan:
+		nrs.Fail("Unexpected error: %v", err)
+	case <-time.After(time.Second / 2):
+		nrs.Fail("Timeout waiting for depChans to resolve")
+	}
+}
+
+func (nrs *NameResolveTestSuite) TestResolveNameStableDigestMismatch() {
+	running := "sha256:0123456789012345678901234567890123456789012345678901234567890123"
+	known := "sha256:abcdefabcdefabcdefabcdefabcdefabcdefabcdefabcdefabcdefabcdefabcd"
+	nrs.reg.FeedArtifact(nrs.makeDigestedTestDep(known).BuildArtifact, nil)
+	nrs.depChans.ResolveNames(nrs.reg, nil, nrs.diffChans, nrs.errChan)
+	nrs.diffChans.Stable <- nrs.makeTestDepPair(nrs.makeDigestedTestDep(running), nrs.makeTestDep())
+
+	select {
+	case <-nrs.depChans.Stable:
+		nrs.Fail("Shouldn't report a deployment running the wrong image as stable")
+	case err := <-nrs.errChan:
+		nrs.IsType(&DigestMismatchError{}, err.Error.error)
+		nrs.Contains(err.Error.Error(), running)
+		nrs.Contains(err.Error.Error(), known)
+	case <-time.After(time.Second / 2):
+		nrs.Fail("Timeout waiting for depChans to resolve")
+	}
+}
+
 func (nrs *NameResolveTestSuite) TestResolveNameStopChannelUnresolved() {
 	nrs.reg.FeedArtifact(nil, fmt.Errorf("not found"))
-	nrs.depChans.ResolveNames(nrs.reg, nrs.diffChans, nrs.errChan)
+	nrs.depChans.ResolveNames(nrs.reg, nil, nrs.diffChans, nrs.errChan)
 	nrs.diffChans.Stop <- nrs.makeTestDepPair(nrs.makeTestDep(), nil)
 
 	select {
@@ -173,7 +214,7 @@ func TestNameResolver(t *testing.T) {
 	reg := NewDummyRegistry()
 
 	errChan := make(chan error)
-	depChans.ResolveNames(reg, &diffChans, errChan)
+	depChans.ResolveNames(reg, nil, &diffChans, errChan)
 
 	reg.FeedArtifact(makeBuildArtifact(), nil)
 	reg.FeedArtifact(nil, fmt.Errorf("something wrong"))
@@ -10,6 +10,19 @@ type (
 		RectifyModifies(<-chan *DeployablePair, chan<- DiffResolution)
 	}
 
+	// RectificationClient abstracts the raw interactions with a cluster
+	// scheduler, e.g. Singularity.
+	RectificationClient interface {
+		// Deploy creates a new deploy on a particular request.
+		Deploy(d Deployable, reqID string) error
+		// PostRequest creates or updates a request.
+		PostRequest(d Deployable, reqID string) error
+		// Scale changes the number of instances of a request.
+		Scale(cluster, reqID string, instanceCount int, message string) error
+		// DeleteRequest deletes a request.
+		DeleteRequest(cluster, reqID, message string) error
+	}
+
 	// DummyDeployer is a noop deployer.
 	DummyDeployer struct {
 		deps DeployStates
@@ -88,7 +88,7 @@ func (vs Volumes) Clone() Volumes {
 }
 
 func (d *Deployment) String() string {
-	return fmt.Sprintf("%s @ %s %s", d.SourceID, d.Cluster, d.DeployConfig.String())
+	return fmt.Sprintf("%s @ %v %s", d.SourceID, d.Cluster, d.DeployConfig.String())
 }
 
 // ID returns the DeployID of this deployment.
@@ -157,8 +157,9 @@ func (d *stateDiffer) diff(existing DeployStates) {
 			name:         id,
 			ExecutorData: intendDS.ExecutorData,
 			Prior: &Deployable{
-				Deployment: &intendDS.Deployment,
-				Status:     intendDS.Status,
+				Deployment:    &intendDS.Deployment,
+				Status:        intendDS.Status,
+				BuildArtifact: intendDS.Artifact,
 			},
 			Post: &Deployable{
 				Deployment: &existingDS.Deployment,
@@ -20,6 +20,25 @@ func TestDeploymentDiffAnalysis(t *testing.T) {
 		"Deployment.Cluster.BaseURL",
 		"Deployment.Cluster.Env",
 		"Deployment.Cluster.AllowedAdvisories",
+		"Deployment.Cluster.RequireSignedArtifacts",
+		"Deployment.Cluster.AdvisoryPolicies",
+		"Deployment.Cluster.EnableOrphanDeletion",
+		"Deployment.Cluster.OrphanGracePeriod",
+		"Deployment.Cluster.Startup",
+		"Deployment.Cluster.Startup.CheckReadyURIPath",
+		"Deployment.Cluster.Startup.CheckReadyURITimeout",
+		"Deployment.Cluster.Startup.Timeout",
+		"Deployment.Cluster.Startup.CheckReadyInterval",
+		"Deployment.Cluster.Startup.CheckReadyRetries",
+		"Deployment.Cluster.Startup.CheckReadyPortIndex",
+		"Deployment.Cluster.Startup.SkipCheckReady",
+		"Deployment.Cluster.ClientPolicy",
+		"Deployment.Cluster.ClientPolicy.Timeout",
+		"Deployment.Cluster.ClientPolicy.MaxRetries",
+		"Deployment.Cluster.ClientPolicy.InitialBackoff",
+		"Deployment.Cluster.ClientPolicy.MaxBackoff",
+		"Deployment.Cluster.ClientPolicy.BreakerThreshold",
+		"Deployment.Cluster.ClientPolicy.BreakerCooldown",
 
 		// SourceID.Location is incorporated into the value of ID(),
 		// is is compared directly - Repo and Dir are compared implicitly thereby
@@ -12,6 +12,9 @@ type DeployState struct {
 	Status          DeployStatus
 	ExecutorMessage string
 	ExecutorData    interface{}
+	// Artifact is the artifact the cluster reports the deployment is running,
+	// if it reports one.
+	Artifact *BuildArtifact
 }
 
 // DeployStatus represents the status of a deployment in an external cluster.
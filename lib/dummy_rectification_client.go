@@ -1,6 +1,7 @@
 package sous
 
 import (
+	"fmt"
 	"log"
 )
 
@@ -12,11 +13,24 @@ type (
 		Created  []Deployable
 		Deployed []Deployable
 		Deleted  []dummyDelete
+		Scaled   []dummyScale
+		Runs     []dummyRun
 	}
 
 	dummyDelete struct {
 		Cluster, Reqid, Message string
 	}
+
+	dummyRun struct {
+		Cluster, Reqid, RunID string
+		Args                  []string
+	}
+
+	dummyScale struct {
+		Cluster, Reqid string
+		Instances      int
+		Message        string
+	}
 )
 
 // NewDummyRectificationClient builds a new DummyRectificationClient
@@ -63,3 +77,30 @@ func (drc *DummyRectificationClient) DeleteRequest(
 	drc.Deleted = append(drc.Deleted, dummyDelete{cluster, reqid, message})
 	return nil
 }
+
+// Scale (cluster url, request id, instance count, message)
+func (drc *DummyRectificationClient) Scale(
+	cluster, reqid string, count int, message string) error {
+	drc.logf("Scaling application %s %s %d %s", cluster, reqid, count, message)
+	drc.Scaled = append(drc.Scaled, dummyScale{cluster, reqid, count, message})
+	return nil
+}
+
+// Run implements TaskRunner on DummyRectificationClient.
+func (drc *DummyRectificationClient) Run(cluster, reqid string, args []string) (string, error) {
+	runID := fmt.Sprintf("run-%d", len(drc.Runs)+1)
+	drc.logf("Running application %s %s %v as %s", cluster, reqid, args, runID)
+	drc.Runs = append(drc.Runs, dummyRun{cluster, reqid, runID, args})
+	return runID, nil
+}
+
+// RunTask implements TaskRunner on DummyRectificationClient.
+func (drc *DummyRectificationClient) RunTask(cluster, reqid, runID string) (string, error) {
+	return reqid + "-" + runID, nil
+}
+
+// TaskStatus implements TaskRunner on DummyRectificationClient. All dummy
+// tasks finish successfully straight away.
+func (drc *DummyRectificationClient) TaskStatus(cluster, taskID string) (TaskStatus, error) {
+	return TaskStatus{TaskID: taskID, State: "TASK_FINISHED", Done: true}, nil
+}
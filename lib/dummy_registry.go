@@ -47,6 +47,12 @@ func (dc *DummyRegistry) GetArtifact(sid SourceID) (*BuildArtifact, error) {
 	}
 }
 
+// GetFlavoredArtifact implements Registry.GetFlavoredArtifact. It is fed
+// by FeedArtifact, like GetArtifact.
+func (dc *DummyRegistry) GetFlavoredArtifact(sid SourceID, flavor string) (*BuildArtifact, error) {
+	return dc.GetArtifact(sid)
+}
+
 // FeedSourceID accepts a SourceID and associated error.
 func (dc *DummyRegistry) FeedSourceID(sid SourceID, e error) {
 	dc.sids <- sourceIDReturn{sid, e}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/opentable/sous/util/restful"
 	"github.com/samsalisbury/semv"
 )
 
@@ -14,8 +15,8 @@ func TestHTTPNameInserter(t *testing.T) {
 
 	reqd := false
 	h := func(rw http.ResponseWriter, r *http.Request) {
-		if meth := r.Method; strings.ToUpper(meth) != "PUT" {
-			t.Errorf("Method should be PUT was: %s", meth)
+		if meth := r.Method; strings.ToUpper(meth) != "POST" {
+			t.Errorf("Method should be POST was: %s", meth)
 		}
 		if path := r.URL.Path; path != "/artifact" {
 			t.Errorf("Path should be '/artifact' but was: %s", path)
@@ -33,10 +34,11 @@ func TestHTTPNameInserter(t *testing.T) {
 
 	srv := httptest.NewServer(http.HandlerFunc(h))
 
-	hni, err := NewHTTPNameInserter(srv.URL)
+	cl, err := restful.NewClient(srv.URL, SilentLogSet())
 	if err != nil {
 		t.Error(err)
 	}
+	hni := NewHTTPNameInserter(cl)
 	err = hni.Insert(
 		SourceID{Location: SourceLocation{Repo: "a-repo", Dir: "offset"}, Version: semv.MustParse("5.5.5")},
 		"dockerthin.com/repo/latest",
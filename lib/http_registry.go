@@ -0,0 +1,100 @@
+package sous
+
+import "github.com/opentable/sous/util/restful"
+
+type (
+	// An HTTPRegistry is a Registry which consults the name cache of a Sous
+	// server first, and falls back to a local Registry when the server
+	// doesn't know an artifact, or can't be reached. Using the server's cache
+	// means clients agree with the server about which image is built from
+	// which source, and don't have to harvest the Docker registry themselves.
+	HTTPRegistry struct {
+		restful.HTTPClient
+		local Registry
+	}
+
+	artifactWrapper struct {
+		SourceID SourceID
+		Artifact *BuildArtifact
+	}
+
+	artifactListWrapper struct {
+		SourceIDs []SourceID
+	}
+)
+
+// NewHTTPRegistry creates a new HTTPRegistry using client, which falls back to
+// local.
+func NewHTTPRegistry(client restful.HTTPClient, local Registry) *HTTPRegistry {
+	return &HTTPRegistry{HTTPClient: client, local: local}
+}
+
+// GetArtifact implements Registry.GetArtifact on HTTPRegistry.
+func (hr *HTTPRegistry) GetArtifact(sid SourceID) (*BuildArtifact, error) {
+	params := map[string]string{}
+	qv := sid.QueryValues()
+	for k := range qv {
+		params[k] = qv.Get(k)
+	}
+	aw := artifactWrapper{}
+	_, err := hr.Retrieve("./artifact", params, &aw, nil)
+	if err == nil && aw.Artifact != nil {
+		return aw.Artifact, nil
+	}
+	Log.Debug.Printf("Server has no artifact for %v (%v): asking local registry", sid, err)
+	return hr.local.GetArtifact(sid)
+}
+
+// GetFlavoredArtifact implements Registry.GetFlavoredArtifact on
+// HTTPRegistry.
+func (hr *HTTPRegistry) GetFlavoredArtifact(sid SourceID, flavor string) (*BuildArtifact, error) {
+	if flavor == "" {
+		return hr.GetArtifact(sid)
+	}
+	params := map[string]string{"flavor": flavor}
+	qv := sid.QueryValues()
+	for k := range qv {
+		params[k] = qv.Get(k)
+	}
+	aw := artifactWrapper{}
+	_, err := hr.Retrieve("./artifact", params, &aw, nil)
+	if err == nil && aw.Artifact != nil {
+		return aw.Artifact, nil
+	}
+	Log.Debug.Printf("Server has no artifact for %v flavor %q (%v): asking local registry", sid, flavor, err)
+	return hr.local.GetFlavoredArtifact(sid, flavor)
+}
+
+// GetSourceID implements Registry.GetSourceID on HTTPRegistry.
+func (hr *HTTPRegistry) GetSourceID(a *BuildArtifact) (SourceID, error) {
+	aw := artifactWrapper{}
+	_, err := hr.Retrieve("./artifact", map[string]string{"name": a.Name}, &aw, nil)
+	if err == nil {
+		return aw.SourceID, nil
+	}
+	Log.Debug.Printf("Server has no source ID for %q (%v): asking local registry", a.Name, err)
+	return hr.local.GetSourceID(a)
+}
+
+// ImageLabels implements ImageLabeller on HTTPRegistry. The server does not
+// serve image labels, so they always come from the local registry.
+func (hr *HTTPRegistry) ImageLabels(imageName string) (map[string]string, error) {
+	return hr.local.ImageLabels(imageName)
+}
+
+// ListSourceIDs implements Registry.ListSourceIDs on HTTPRegistry.
+func (hr *HTTPRegistry) ListSourceIDs() ([]SourceID, error) {
+	alw := artifactListWrapper{}
+	_, err := hr.Retrieve("./artifacts", nil, &alw, nil)
+	if err == nil {
+		return alw.SourceIDs, nil
+	}
+	Log.Debug.Printf("Listing source IDs from server: %v: asking local registry", err)
+	return hr.local.ListSourceIDs()
+}
+
+// Warmup implements Registry.Warmup on HTTPRegistry, by warming up the local
+// registry.
+func (hr *HTTPRegistry) Warmup(r string) error {
+	return hr.local.Warmup(r)
+}
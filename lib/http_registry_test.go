@@ -0,0 +1,59 @@
+package sous
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentable/sous/util/restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPRegistry(t *testing.T) {
+	known := MustNewSourceID("github.com/example/app", "", "1.2.3")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		q := rq.URL.Query()
+		switch {
+		case rq.URL.Path == "/artifacts":
+			json.NewEncoder(w).Encode(artifactListWrapper{SourceIDs: []SourceID{known}})
+		case q.Get("name") == "docker.example.com/app:1.2.3", q.Get("version") == "1.2.3":
+			json.NewEncoder(w).Encode(artifactWrapper{
+				SourceID: known,
+				Artifact: &BuildArtifact{Name: "docker.example.com/app@sha256:abc", Type: "docker"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	cl, err := restful.NewClient(srv.URL, restful.PlaceholderLogger())
+	require.NoError(t, err)
+	local := NewDummyRegistry()
+	hr := NewHTTPRegistry(cl, local)
+
+	ba, err := hr.GetArtifact(known)
+	require.NoError(t, err)
+	assert.Equal(t, "docker.example.com/app@sha256:abc", ba.Name)
+
+	sid, err := hr.GetSourceID(&BuildArtifact{Name: "docker.example.com/app:1.2.3"})
+	require.NoError(t, err)
+	assert.Equal(t, known, sid)
+
+	sids, err := hr.ListSourceIDs()
+	require.NoError(t, err)
+	assert.Equal(t, []SourceID{known}, sids)
+
+	// Artifacts the server doesn't know come from the local registry.
+	unknown := MustNewSourceID("github.com/example/other", "", "2.0.0")
+	local.FeedArtifact(&BuildArtifact{Name: "local/other:2.0.0", Type: "docker"}, nil)
+	ba, err = hr.GetArtifact(unknown)
+	require.NoError(t, err)
+	assert.Equal(t, "local/other:2.0.0", ba.Name)
+
+	local.FeedSourceID(unknown, nil)
+	sid, err = hr.GetSourceID(&BuildArtifact{Name: "local/other:2.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, unknown, sid)
+}
@@ -2,6 +2,7 @@ package sous
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/opentable/sous/util/restful"
 	"github.com/pkg/errors"
@@ -20,6 +21,14 @@ type (
 	gdmWrapper struct {
 		Deployments []*Deployment
 	}
+
+	manifestsWrapper struct {
+		Manifests map[ManifestID]*Manifest
+	}
+
+	metadataWrapper struct {
+		Clusters map[string]Metadata
+	}
 )
 
 func wrapDeployments(source Deployments) gdmWrapper {
@@ -96,6 +105,13 @@ func (hsm *HTTPStateManager) WriteState(s *State, u User) error {
 		}
 	}
 
+	if hsm.onlyChangesManifests(s) {
+		if mids, ok := hsm.onlyChangesMetadata(s); ok {
+			return hsm.patchMetadata(s, mids)
+		}
+		return hsm.patchManifests(s)
+	}
+
 	wds, err := s.Deployments()
 	if err != nil {
 		return err
@@ -127,6 +143,89 @@ func (hsm *HTTPStateManager) putDeployments(new Deployments) error {
 	return errors.Wrapf(hsm.gdmState.Update(nil, &wNew, hsm.User.HTTPHeaders()), "putting GDM")
 }
 
+// onlyChangesManifests returns true if s has the same manifests as were last
+// read, although they may differ in content.
+func (hsm *HTTPStateManager) onlyChangesManifests(s *State) bool {
+	if hsm.cached == nil || s.Manifests.Len() != hsm.cached.Manifests.Len() {
+		return false
+	}
+	for mid := range s.Manifests.Snapshot() {
+		if _, there := hsm.cached.Manifests.Get(mid); !there {
+			return false
+		}
+	}
+	return true
+}
+
+// patchManifests sends only the changes made to the manifests since they were
+// read, so that they don't collide with other changes made since. The changes
+// to every manifest are sent in one patch, so that they're applied together or
+// not at all; if nothing has changed, no patch is sent.
+func (hsm *HTTPStateManager) patchManifests(s *State) error {
+	old := manifestsWrapper{Manifests: hsm.cached.Manifests.Snapshot()}
+	changed := manifestsWrapper{Manifests: s.Manifests.Snapshot()}
+	if err := hsm.Patch("./manifests", nil, old, changed, hsm.User.HTTPHeaders()); err != nil {
+		return errors.Wrapf(err, "patching manifests")
+	}
+	hsm.cached = s.Clone()
+	return nil
+}
+
+// onlyChangesMetadata returns the manifests whose deployment metadata s
+// changes, and true if nothing else about them has changed since they were
+// read. It must only be called if onlyChangesManifests(s) is true.
+func (hsm *HTTPStateManager) onlyChangesMetadata(s *State) ([]ManifestID, bool) {
+	var mids []ManifestID
+	for mid, m := range s.Manifests.Snapshot() {
+		old, _ := hsm.cached.Manifests.Get(mid)
+		if m.Equal(old) {
+			continue
+		}
+		if !withoutMetadata(m).Equal(withoutMetadata(old)) {
+			return nil, false
+		}
+		mids = append(mids, mid)
+	}
+	sort.Slice(mids, func(i, j int) bool { return mids[i].String() < mids[j].String() })
+	return mids, true
+}
+
+// patchMetadata sends only the changes made to the deployment metadata of
+// each of mids since they were read, one manifest at a time.
+func (hsm *HTTPStateManager) patchMetadata(s *State, mids []ManifestID) error {
+	for _, mid := range mids {
+		old, _ := hsm.cached.Manifests.Get(mid)
+		m, _ := s.Manifests.Get(mid)
+		query := map[string]string{
+			"repo":   mid.Source.Repo,
+			"offset": mid.Source.Dir,
+			"flavor": mid.Flavor,
+		}
+		if err := hsm.Patch("./metadata", query, wrapMetadata(old), wrapMetadata(m), hsm.User.HTTPHeaders()); err != nil {
+			return errors.Wrapf(err, "patching metadata of %q", mid)
+		}
+		hsm.cached.Manifests.Set(mid, m.Clone())
+	}
+	return nil
+}
+
+func wrapMetadata(m *Manifest) metadataWrapper {
+	data := metadataWrapper{Clusters: map[string]Metadata{}}
+	for cluster, spec := range m.Deployments {
+		data.Clusters[cluster] = spec.Metadata
+	}
+	return data
+}
+
+func withoutMetadata(m *Manifest) *Manifest {
+	c := m.Clone()
+	for cluster, spec := range c.Deployments {
+		spec.Metadata = nil
+		c.Deployments[cluster] = spec
+	}
+	return c
+}
+
 // EmptyReceiver implements Comparable on Manifest
 func (m *Manifest) EmptyReceiver() restful.Comparable {
 	return &Manifest{}
@@ -0,0 +1,203 @@
+package sous
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentable/sous/util/restful"
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testHTTPStateManagerServer(t *testing.T, requests *[]*http.Request, bodies *[]interface{}) *httptest.Server {
+	defs := Defs{Clusters: Clusters{"test-cluster": &Cluster{Name: "test-cluster"}}}
+	manifest := func(repo string) *Manifest {
+		return &Manifest{
+			Source: SourceLocation{Repo: repo},
+			Owners: []string{"owner"},
+			Kind:   ManifestKindService,
+			Deployments: DeploySpecs{
+				"test-cluster": {
+					Version: semv.MustParse("1.0.0"),
+					DeployConfig: DeployConfig{
+						Resources:    Resources{"cpus": "1", "memory": "256", "ports": "1"},
+						Metadata:     Metadata{"team": "test"},
+						NumInstances: 1,
+					},
+				},
+			},
+		}
+	}
+	state := &State{Defs: defs, Manifests: NewManifests(
+		manifest("github.com/opentable/test"),
+		manifest("github.com/opentable/other"),
+	)}
+	ds, err := state.Deployments()
+	require.NoError(t, err)
+
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch {
+		default:
+			var body interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			*requests = append(*requests, r)
+			*bodies = append(*bodies, body)
+		case r.Method == "GET" && r.URL.Path == "/defs":
+			json.NewEncoder(rw).Encode(defs)
+		case r.Method == "GET" && r.URL.Path == "/gdm":
+			json.NewEncoder(rw).Encode(wrapDeployments(ds))
+		}
+	}))
+}
+
+func TestHTTPStateManager_PatchesChangedMetadata(t *testing.T) {
+	var requests []*http.Request
+	var bodies []interface{}
+	srv := testHTTPStateManagerServer(t, &requests, &bodies)
+	defer srv.Close()
+
+	cl, err := restful.NewClient(srv.URL, SilentLogSet())
+	require.NoError(t, err)
+	hsm := NewHTTPStateManager(cl)
+
+	state, err := hsm.ReadState()
+	require.NoError(t, err)
+	mid := ManifestID{Source: SourceLocation{Repo: "github.com/opentable/test"}}
+	m, ok := state.Manifests.Get(mid)
+	require.True(t, ok)
+	spec := m.Deployments["test-cluster"]
+	spec.Metadata = Metadata{"team": "test", "owner": "someone"}
+	m.Deployments["test-cluster"] = spec
+	state.Manifests.Set(mid, m)
+
+	require.NoError(t, hsm.WriteState(state, User{}))
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "PATCH", requests[0].Method)
+	assert.Equal(t, "/metadata", requests[0].URL.Path)
+	assert.Equal(t, "github.com/opentable/test", requests[0].URL.Query().Get("repo"))
+	assert.Equal(t, restful.MergePatchMediaType, requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, map[string]interface{}{
+		"Clusters": map[string]interface{}{
+			"test-cluster": map[string]interface{}{"owner": "someone"},
+		},
+	}, bodies[0])
+}
+
+func TestHTTPStateManager_PatchesChangedManifests(t *testing.T) {
+	var requests []*http.Request
+	var bodies []interface{}
+	srv := testHTTPStateManagerServer(t, &requests, &bodies)
+	defer srv.Close()
+
+	cl, err := restful.NewClient(srv.URL, SilentLogSet())
+	require.NoError(t, err)
+	hsm := NewHTTPStateManager(cl)
+
+	state, err := hsm.ReadState()
+	require.NoError(t, err)
+	mid := ManifestID{Source: SourceLocation{Repo: "github.com/opentable/test"}}
+	m, ok := state.Manifests.Get(mid)
+	require.True(t, ok)
+	spec := m.Deployments["test-cluster"]
+	spec.Metadata = Metadata{"team": "test", "owner": "someone"}
+	spec.NumInstances = 2
+	m.Deployments["test-cluster"] = spec
+	state.Manifests.Set(mid, m)
+
+	require.NoError(t, hsm.WriteState(state, User{}))
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "PATCH", requests[0].Method)
+	assert.Equal(t, "/manifests", requests[0].URL.Path)
+	assert.Equal(t, restful.MergePatchMediaType, requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, map[string]interface{}{
+		"Manifests": map[string]interface{}{
+			"github.com/opentable/test": map[string]interface{}{
+				"Deployments": map[string]interface{}{
+					"test-cluster": map[string]interface{}{
+						"Metadata":     map[string]interface{}{"owner": "someone"},
+						"NumInstances": float64(2),
+					},
+				},
+			},
+		},
+	}, bodies[0])
+}
+
+func TestHTTPStateManager_PatchesManifestsTogether(t *testing.T) {
+	var requests []*http.Request
+	var bodies []interface{}
+	srv := testHTTPStateManagerServer(t, &requests, &bodies)
+	defer srv.Close()
+
+	cl, err := restful.NewClient(srv.URL, SilentLogSet())
+	require.NoError(t, err)
+	hsm := NewHTTPStateManager(cl)
+
+	state, err := hsm.ReadState()
+	require.NoError(t, err)
+	for mid, m := range state.Manifests.Snapshot() {
+		m.Owners = []string{"someone"}
+		state.Manifests.Set(mid, m)
+	}
+
+	require.NoError(t, hsm.WriteState(state, User{}))
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "PATCH", requests[0].Method)
+	assert.Equal(t, "/manifests", requests[0].URL.Path)
+	owners := map[string]interface{}{"Owners": []interface{}{"someone"}}
+	assert.Equal(t, map[string]interface{}{
+		"Manifests": map[string]interface{}{
+			"github.com/opentable/test":  owners,
+			"github.com/opentable/other": owners,
+		},
+	}, bodies[0])
+}
+
+func TestHTTPStateManager_DoesNotPatchUnchangedManifests(t *testing.T) {
+	var requests []*http.Request
+	var bodies []interface{}
+	srv := testHTTPStateManagerServer(t, &requests, &bodies)
+	defer srv.Close()
+
+	cl, err := restful.NewClient(srv.URL, SilentLogSet())
+	require.NoError(t, err)
+	hsm := NewHTTPStateManager(cl)
+
+	state, err := hsm.ReadState()
+	require.NoError(t, err)
+
+	require.NoError(t, hsm.WriteState(state, User{}))
+
+	assert.Empty(t, requests)
+}
+
+func TestHTTPStateManager_PutsGDMWhenManifestsAdded(t *testing.T) {
+	var requests []*http.Request
+	var bodies []interface{}
+	srv := testHTTPStateManagerServer(t, &requests, &bodies)
+	defer srv.Close()
+
+	cl, err := restful.NewClient(srv.URL, SilentLogSet())
+	require.NoError(t, err)
+	hsm := NewHTTPStateManager(cl)
+
+	state, err := hsm.ReadState()
+	require.NoError(t, err)
+	m, ok := state.Manifests.Get(ManifestID{Source: SourceLocation{Repo: "github.com/opentable/test"}})
+	require.True(t, ok)
+	added := m.Clone()
+	added.Source.Repo = "github.com/opentable/added"
+	state.Manifests.Add(added)
+
+	require.NoError(t, hsm.WriteState(state, User{}))
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "PUT", requests[0].Method)
+	assert.Equal(t, "/gdm", requests[0].URL.Path)
+}
@@ -134,6 +134,7 @@ func (m *Manifest) Validate() []Flaw {
 
 	for cluster, d := range m.Deployments {
 		df := d.Validate()
+		df = append(df, m.validateSchedule(cluster)...)
 		for _, f := range df {
 			f.AddContext("cluster", cluster)
 		}
@@ -42,6 +42,7 @@ func (ms Manifests) Deployments(defs Defs) (Deployments, error) {
 			return ds, errors.Errorf("cluster %q is nil, check defs.yaml", d.ClusterName)
 		}
 		d.Cluster = cluster
+		d.Startup = d.Startup.Inherit(cluster.Startup)
 	}
 	return ds, nil
 }
@@ -101,6 +102,11 @@ func (ds Deployments) PutbackManifests(defs Defs, olds Manifests) (Manifests, er
 				}
 			}
 		}
+		var oldStartup Startup
+		if was {
+			oldStartup = old.Deployments[d.ClusterName].Startup
+		}
+		spec.Startup = spec.Startup.elideDefaults(d.Cluster.Startup, oldStartup)
 		m.Deployments[d.ClusterName] = spec
 		m.Kind = d.Kind
 
@@ -0,0 +1,155 @@
+package sous
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// NetworkMode is the container networking mode of a deployment: one of
+	// "bridge", "host" or "none". The empty value means "bridge".
+	NetworkMode string
+
+	// A PortMapping names a port a container listens on.
+	PortMapping struct {
+		// Name identifies the port, e.g. "http" or "admin".
+		Name string
+		// ContainerPort is the fixed port number inside the container. The
+		// host port is allocated by the scheduler.
+		ContainerPort int
+		// Protocol is "tcp" or "udp". The empty value means "tcp".
+		Protocol string `yaml:",omitempty"`
+	}
+
+	// PortMappings is a list of PortMapping.
+	PortMappings []PortMapping
+)
+
+const (
+	// NetworkBridge gives containers their own network, with ports mapped
+	// from the host.
+	NetworkBridge NetworkMode = "bridge"
+	// NetworkHost runs containers in the host's network.
+	NetworkHost NetworkMode = "host"
+	// NetworkNone gives containers no network.
+	NetworkNone NetworkMode = "none"
+)
+
+// Normalized returns nm, with the empty value replaced by its default.
+func (nm NetworkMode) Normalized() NetworkMode {
+	if nm == "" {
+		return NetworkBridge
+	}
+	return nm
+}
+
+// Equal returns true if nm and o are the same mode, after normalization.
+func (nm NetworkMode) Equal(o NetworkMode) bool {
+	return nm.Normalized() == o.Normalized()
+}
+
+// Validate returns flaws with this NetworkMode.
+func (nm NetworkMode) Validate() []Flaw {
+	switch nm.Normalized() {
+	default:
+		return []Flaw{FatalFlaw("network mode %q not valid; expected bridge, host or none", nm)}
+	case NetworkBridge, NetworkHost, NetworkNone:
+		return nil
+	}
+}
+
+// NormalizedProtocol returns the protocol of pm, with the empty value
+// replaced by its default.
+func (pm PortMapping) NormalizedProtocol() string {
+	if pm.Protocol == "" {
+		return "tcp"
+	}
+	return strings.ToLower(pm.Protocol)
+}
+
+// Equal returns true if pm and o map the same port.
+func (pm PortMapping) Equal(o PortMapping) bool {
+	return pm.Name == o.Name && pm.ContainerPort == o.ContainerPort &&
+		pm.NormalizedProtocol() == o.NormalizedProtocol()
+}
+
+func (pm PortMapping) String() string {
+	return fmt.Sprintf("%s:%d/%s", pm.Name, pm.ContainerPort, pm.NormalizedProtocol())
+}
+
+// Equal returns true if pms and o map the same ports in the same order. The
+// order matters, since it determines which host port each is mapped from.
+func (pms PortMappings) Equal(o PortMappings) bool {
+	if len(pms) != len(o) {
+		return false
+	}
+	for i := range pms {
+		if !pms[i].Equal(o[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of pms.
+func (pms PortMappings) Clone() PortMappings {
+	if pms == nil {
+		return nil
+	}
+	c := make(PortMappings, len(pms))
+	copy(c, pms)
+	return c
+}
+
+// Names returns the names of the ports, in order.
+func (pms PortMappings) Names() []string {
+	names := make([]string, len(pms))
+	for i, pm := range pms {
+		names[i] = pm.Name
+	}
+	return names
+}
+
+// Validate returns flaws with these port mappings.
+func (pms PortMappings) Validate() []Flaw {
+	var flaws []Flaw
+	names := map[string]struct{}{}
+	for _, pm := range pms {
+		if pm.Name == "" || strings.ContainsAny(pm.Name, ", ") {
+			flaws = append(flaws, FatalFlaw("port name %q not valid", pm.Name))
+		}
+		if _, dup := names[pm.Name]; dup {
+			flaws = append(flaws, FatalFlaw("port %q mapped more than once", pm.Name))
+		}
+		names[pm.Name] = struct{}{}
+		if pm.ContainerPort < 1 || pm.ContainerPort > 65535 {
+			flaws = append(flaws, FatalFlaw("port %q has invalid container port %d", pm.Name, pm.ContainerPort))
+		}
+		if p := pm.NormalizedProtocol(); p != "tcp" && p != "udp" {
+			flaws = append(flaws, FatalFlaw("port %q has invalid protocol %q", pm.Name, pm.Protocol))
+		}
+	}
+	return flaws
+}
+
+// validateNetwork checks the network mode and ports of dc against each other
+// and against its resources.
+func (dc *DeployConfig) validateNetwork() []Flaw {
+	flaws := dc.NetworkMode.Validate()
+	flaws = append(flaws, dc.Ports.Validate()...)
+	if len(dc.Ports) == 0 {
+		return flaws
+	}
+	if mode := dc.NetworkMode.Normalized(); mode != NetworkBridge {
+		flaws = append(flaws, FatalFlaw("ports cannot be mapped with network mode %q", mode))
+		return flaws
+	}
+	// Each mapped port takes one of the host ports allocated to the container.
+	if dc.Resources != nil && dc.Resources.Ports() < int32(len(dc.Ports)) {
+		flaws = append(flaws, NewFlaw(
+			fmt.Sprintf("%d ports mapped, but only %d ports resourced", len(dc.Ports), dc.Resources.Ports()),
+			func() error { dc.Resources["ports"] = fmt.Sprint(len(dc.Ports)); return nil },
+		))
+	}
+	return flaws
+}
@@ -0,0 +1,66 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortMappingsValidate(t *testing.T) {
+	good := []PortMappings{
+		nil,
+		{{Name: "http", ContainerPort: 8080}},
+		{{Name: "http", ContainerPort: 80, Protocol: "TCP"}, {Name: "stats", ContainerPort: 8125, Protocol: "udp"}},
+	}
+	for _, pms := range good {
+		assert.Empty(t, pms.Validate(), "%v", pms)
+	}
+
+	bad := []PortMappings{
+		{{ContainerPort: 8080}},
+		{{Name: "a,b", ContainerPort: 8080}},
+		{{Name: "http", ContainerPort: 0}},
+		{{Name: "http", ContainerPort: 70000}},
+		{{Name: "http", ContainerPort: 8080, Protocol: "sctp"}},
+		{{Name: "http", ContainerPort: 8080}, {Name: "http", ContainerPort: 8081}},
+	}
+	for _, pms := range bad {
+		assert.NotEmpty(t, pms.Validate(), "%v", pms)
+	}
+}
+
+func TestDeployConfigValidateNetwork(t *testing.T) {
+	dc := DeployConfig{
+		Resources: Resources{"cpus": "0.1", "memory": "100", "ports": "1"},
+		Ports: PortMappings{
+			{Name: "http", ContainerPort: 8080},
+			{Name: "admin", ContainerPort: 8081},
+		},
+	}
+	flaws := dc.Validate()
+	if assert.Len(t, flaws, 1) {
+		assert.NoError(t, flaws[0].Repair())
+		assert.EqualValues(t, 2, dc.Resources.Ports())
+	}
+
+	dc.NetworkMode = NetworkHost
+	assert.Len(t, dc.Validate(), 1)
+
+	dc.NetworkMode = "overlay"
+	assert.NotEmpty(t, dc.Validate())
+}
+
+func TestDeployConfigDiffNetwork(t *testing.T) {
+	dc := DeployConfig{Ports: PortMappings{{Name: "http", ContainerPort: 8080}}}
+	other := dc.Clone()
+	other.NetworkMode = NetworkBridge
+	other.Ports[0].Protocol = "tcp"
+	same, _ := dc.Diff(other)
+	assert.True(t, same)
+
+	other.Ports[0].ContainerPort = 9090
+	same, diffs := dc.Diff(other)
+	assert.False(t, same)
+	assert.Len(t, diffs, 1)
+	assert.Equal(t, 8080, dc.Ports[0].ContainerPort, "Clone shares ports")
+}
@@ -0,0 +1,42 @@
+package sous
+
+import "strings"
+
+// identifies returns true if owner names u, either by email address (in any
+// case) or by name.
+func identifies(owner string, u User) bool {
+	owner = strings.TrimSpace(owner)
+	if owner == "" {
+		return false
+	}
+	if u.Email != "" && strings.EqualFold(owner, u.Email) {
+		return true
+	}
+	return u.Name != "" && owner == u.Name
+}
+
+// OwnedBy returns true if u is one of the Owners of m.
+func (m *Manifest) OwnedBy(u User) bool {
+	for _, o := range m.Owners {
+		if identifies(o, u) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin returns true if u is one of the Admins in d.
+func (d Defs) IsAdmin(u User) bool {
+	for _, a := range d.Admins {
+		if identifies(a, u) {
+			return true
+		}
+	}
+	return false
+}
+
+// MayChange returns true if u may change or remove m: that is, if u owns m or
+// is an admin.
+func (d Defs) MayChange(u User, m *Manifest) bool {
+	return d.IsAdmin(u) || m.OwnedBy(u)
+}
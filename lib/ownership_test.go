@@ -0,0 +1,24 @@
+package sous
+
+import "testing"
+
+func TestDefs_MayChange(t *testing.T) {
+	defs := Defs{Admins: []string{"admin@example.com"}}
+	m := &Manifest{Owners: []string{"Owner@Example.com", "Some Team"}}
+
+	cases := []struct {
+		user User
+		may  bool
+	}{
+		{User{Email: "owner@example.com"}, true},
+		{User{Name: "Some Team"}, true},
+		{User{Name: "Other", Email: "ADMIN@example.com"}, true},
+		{User{Name: "Other", Email: "other@example.com"}, false},
+		{User{}, false},
+	}
+	for _, c := range cases {
+		if got := defs.MayChange(c.user, m); got != c.may {
+			t.Errorf("MayChange(%v) = %t, want %t", c.user, got, c.may)
+		}
+	}
+}
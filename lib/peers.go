@@ -0,0 +1,353 @@
+package sous
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// A Peer is a Sous server, as the servers describe one another.
+	Peer struct {
+		// ClusterName is the cluster the server is responsible for.
+		ClusterName string
+		// URL is where the server can be reached.
+		URL string
+		// Version is the version of Sous the server is running.
+		Version string
+		// Generation identifies a run of the server: it is when it started.
+		// It is zero for servers which have not yet been heard from.
+		Generation int64
+		// Heartbeat counts the times the server has announced itself during
+		// this Generation.
+		Heartbeat uint64
+		// Status is whether the server is known to be running.
+		Status PeerStatus
+		// Ready is whether the server could do its work when it last
+		// announced itself, as its /ready endpoint reports.
+		Ready bool
+		// NotReady describes why the server was not ready, if it was not.
+		NotReady string
+	}
+
+	// PeerStatus is whether a Peer is known to be running.
+	PeerStatus string
+
+	// Peers is the membership list of the Sous servers known to this one. It
+	// is kept up to date by periodically announcing this server to all the
+	// others, and merging in the servers each of them knows. Servers which
+	// have not been heard from for a while are marked as failed, and
+	// eventually forgotten.
+	Peers struct {
+		self  Peer
+		known map[string]*knownPeer
+		// file is where the known peers are recorded, if not "".
+		file                   string
+		failAfter, forgetAfter time.Duration
+		newClient              func(url string) (PeerClient, error)
+		now                    func() time.Time
+		log                    *LogSet
+		// notReady returns why this server is not ready, or nil if it is.
+		notReady func() error
+		sync.Mutex
+	}
+
+	// A PeerClient sends announcements to another server.
+	PeerClient interface {
+		Post(urlPath string, qParms map[string]string, qBody, rzBody interface{}, headers map[string]string) error
+	}
+
+	// PeerList is the list of servers exchanged in announcements.
+	PeerList struct {
+		Servers []Peer
+	}
+
+	// knownPeer is a Peer, and when this server last heard that it was
+	// running.
+	knownPeer struct {
+		Peer
+		Heard time.Time
+	}
+)
+
+const (
+	// PeerUnknown is the status of a server which has not been heard from,
+	// such as one listed in the configuration.
+	PeerUnknown = PeerStatus("unknown")
+	// PeerAlive is the status of a server which has been heard from recently.
+	PeerAlive = PeerStatus("alive")
+	// PeerFailed is the status of a server which has not been heard from
+	// recently.
+	PeerFailed = PeerStatus("failed")
+)
+
+// NewPeers creates a Peers which records the servers it knows in file, if it
+// is not "", and starts out knowing those recorded there already. Servers are
+// failed once they have not been heard from for failAfter, and forgotten after
+// forgetAfter. newClient creates the clients used to announce to each server.
+func NewPeers(file string, failAfter, forgetAfter time.Duration, newClient func(url string) (PeerClient, error), log *LogSet) *Peers {
+	ps := &Peers{
+		known:       map[string]*knownPeer{},
+		file:        file,
+		failAfter:   failAfter,
+		forgetAfter: forgetAfter,
+		newClient:   newClient,
+		now:         time.Now,
+		log:         log,
+	}
+	if err := ps.load(); err != nil {
+		log.Warn.Printf("Could not load known servers: %v", err)
+	}
+	return ps
+}
+
+// newer returns true if p describes a later run or heartbeat than o.
+func (p Peer) newer(o Peer) bool {
+	if p.Generation != o.Generation {
+		return p.Generation > o.Generation
+	}
+	return p.Heartbeat > o.Heartbeat
+}
+
+// CheckReadiness makes notReady the check of this server's readiness, which is
+// made before each announcement so that the others know whether it can do its
+// work. notReady returns why the server is not ready, or nil if it is. Until
+// a check is set, this server announces itself as ready.
+func (ps *Peers) CheckReadiness(notReady func() error) {
+	ps.Lock()
+	defer ps.Unlock()
+	ps.notReady = notReady
+}
+
+// Seed adds a server which has not been heard from, such as one from the
+// configuration. If a different URL is already known for the cluster, the
+// seed replaces it.
+func (ps *Peers) Seed(clusterName, url string) {
+	ps.Lock()
+	defer ps.Unlock()
+	if clusterName == "" || url == "" || clusterName == ps.self.ClusterName {
+		return
+	}
+	if kp, ok := ps.known[clusterName]; ok && kp.URL == url {
+		return
+	}
+	ps.known[clusterName] = &knownPeer{Peer: Peer{ClusterName: clusterName, URL: url}}
+}
+
+// Merge adds the servers in list which are new to this server, and updates
+// those it knows of which list describes more recently.
+func (ps *Peers) Merge(list []Peer) {
+	ps.Lock()
+	defer ps.Unlock()
+	now := ps.now()
+	for _, p := range list {
+		if p.ClusterName == "" || p.URL == "" || ps.isSelf(p) {
+			continue
+		}
+		kp, ok := ps.known[p.ClusterName]
+		if ok && !p.newer(kp.Peer) {
+			continue
+		}
+		heard := now
+		if p.Generation == 0 {
+			if ok {
+				// Nothing is newer than what's known.
+				continue
+			}
+			heard = time.Time{}
+		}
+		p.Status = ""
+		ps.known[p.ClusterName] = &knownPeer{Peer: p, Heard: heard}
+	}
+}
+
+func (ps *Peers) isSelf(p Peer) bool {
+	if ps.self.URL == "" {
+		return false
+	}
+	return p.ClusterName == ps.self.ClusterName || p.URL == ps.self.URL
+}
+
+// List returns every server known, including this one if it announces
+// itself, ordered by cluster name. Servers which have been failed for long
+// enough are forgotten.
+func (ps *Peers) List() []Peer {
+	ps.Lock()
+	defer ps.Unlock()
+	return ps.list()
+}
+
+func (ps *Peers) list() []Peer {
+	now := ps.now()
+	list := []Peer{}
+	if ps.self.URL != "" {
+		self := ps.self
+		self.Status = PeerAlive
+		list = append(list, self)
+	}
+	for name, kp := range ps.known {
+		p := kp.Peer
+		switch since := now.Sub(kp.Heard); {
+		case kp.Heard.IsZero():
+			p.Status = PeerUnknown
+		case since < ps.failAfter:
+			p.Status = PeerAlive
+		case since < ps.forgetAfter:
+			p.Status = PeerFailed
+		default:
+			ps.log.Info.Printf("Forgetting server for %s at %s: not heard from since %s", name, kp.URL, kp.Heard)
+			delete(ps.known, name)
+			continue
+		}
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ClusterName < list[j].ClusterName })
+	return list
+}
+
+// Announce sends this server's list of servers, including itself, to every
+// other server it knows, and merges in the lists they reply with. The servers
+// known afterwards are recorded.
+func (ps *Peers) Announce() {
+	ps.Lock()
+	notReady := ps.notReady
+	ps.Unlock()
+	var readyErr error
+	if notReady != nil {
+		readyErr = notReady()
+	}
+
+	ps.Lock()
+	ps.self.Heartbeat++
+	ps.self.Ready = readyErr == nil
+	ps.self.NotReady = ""
+	if readyErr != nil {
+		ps.self.NotReady = readyErr.Error()
+	}
+	list := ps.list()
+	ps.Unlock()
+
+	wg := sync.WaitGroup{}
+	for _, p := range list {
+		if ps.isSelf(p) {
+			continue
+		}
+		wg.Add(1)
+		go func(p Peer) {
+			defer wg.Done()
+			reply, err := ps.announceTo(p.URL, list)
+			if err != nil {
+				ps.log.Debug.Printf("Announcing to server for %s at %s: %v", p.ClusterName, p.URL, err)
+				return
+			}
+			ps.Merge(reply.Servers)
+		}(p)
+	}
+	wg.Wait()
+
+	if err := ps.save(); err != nil {
+		ps.log.Warn.Printf("Could not record known servers: %v", err)
+	}
+}
+
+func (ps *Peers) announceTo(url string, list []Peer) (*PeerList, error) {
+	cl, err := ps.newClient(url)
+	if err != nil {
+		return nil, err
+	}
+	reply := &PeerList{}
+	if err := cl.Post("./servers", nil, PeerList{Servers: list}, reply, nil); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Kickoff makes self this server's description of itself, and starts
+// announcing it every interval, until the returned channel is closed. This
+// server is only announced if self has a URL; otherwise it only learns of
+// the others.
+func (ps *Peers) Kickoff(self Peer, interval time.Duration) chan struct{} {
+	ps.Lock()
+	self.Generation = ps.now().UnixNano()
+	self.Heartbeat = 0
+	ps.self = self
+	if kp, ok := ps.known[self.ClusterName]; ok && self.URL != "" && kp.URL == self.URL {
+		delete(ps.known, self.ClusterName)
+	}
+	ps.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			ps.Announce()
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return done
+}
+
+func (ps *Peers) load() error {
+	if ps.file == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(ps.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	kps := []*knownPeer{}
+	if err := json.Unmarshal(b, &kps); err != nil {
+		return errors.Wrapf(err, "parsing %s", ps.file)
+	}
+	ps.Lock()
+	defer ps.Unlock()
+	for _, kp := range kps {
+		if kp != nil && kp.ClusterName != "" && kp.URL != "" {
+			kp.Status = ""
+			ps.known[kp.ClusterName] = kp
+		}
+	}
+	return nil
+}
+
+// save records the servers known in the file, by writing a new file and
+// moving it into place, so that a partly written file is never read.
+func (ps *Peers) save() error {
+	if ps.file == "" {
+		return nil
+	}
+	ps.Lock()
+	kps := []knownPeer{}
+	for _, kp := range ps.known {
+		kps = append(kps, *kp)
+	}
+	ps.Unlock()
+	sort.Slice(kps, func(i, j int) bool { return kps[i].ClusterName < kps[j].ClusterName })
+
+	b, err := json.MarshalIndent(kps, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ps.file), 0755); err != nil {
+		return err
+	}
+	tmp := ps.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ps.file)
+}
@@ -0,0 +1,170 @@
+package sous
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPeerClient struct {
+	url   string
+	reply []Peer
+	err   error
+	got   *[]string
+}
+
+var testPeerClientLock sync.Mutex
+
+func (c *testPeerClient) Post(urlPath string, qParms map[string]string, qBody, rzBody interface{}, headers map[string]string) error {
+	testPeerClientLock.Lock()
+	*c.got = append(*c.got, c.url)
+	testPeerClientLock.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	rzBody.(*PeerList).Servers = c.reply
+	return nil
+}
+
+func newTestPeers(file string, now *time.Time, replies map[string][]Peer) (*Peers, *[]string) {
+	got := &[]string{}
+	newClient := func(url string) (PeerClient, error) {
+		reply, ok := replies[url]
+		if !ok {
+			return &testPeerClient{url: url, got: got, err: fmt.Errorf("no server at %s", url)}, nil
+		}
+		return &testPeerClient{url: url, got: got, reply: reply}, nil
+	}
+	ps := NewPeers(file, time.Minute, time.Hour, newClient, SilentLogSet())
+	ps.now = func() time.Time { return *now }
+	return ps, got
+}
+
+func peerStatuses(list []Peer) map[string]PeerStatus {
+	ss := map[string]PeerStatus{}
+	for _, p := range list {
+		ss[p.ClusterName] = p.Status
+	}
+	return ss
+}
+
+func TestPeers_MergeAndFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	ps, _ := newTestPeers("", &now, nil)
+	ps.Seed("left", "https://left.sous.com")
+
+	assert.Equal(map[string]PeerStatus{"left": PeerUnknown}, peerStatuses(ps.List()))
+
+	ps.Merge([]Peer{
+		{ClusterName: "left", URL: "https://left.sous.com", Generation: 2, Heartbeat: 5},
+		{ClusterName: "right", URL: "https://right.sous.com", Generation: 1, Heartbeat: 1},
+	})
+	assert.Equal(map[string]PeerStatus{"left": PeerAlive, "right": PeerAlive}, peerStatuses(ps.List()))
+
+	// An older heartbeat for left doesn't count as hearing from it.
+	now = now.Add(50 * time.Second)
+	ps.Merge([]Peer{
+		{ClusterName: "left", URL: "https://left.sous.com", Generation: 2, Heartbeat: 4},
+		{ClusterName: "right", URL: "https://right.sous.com", Generation: 1, Heartbeat: 2},
+	})
+	now = now.Add(20 * time.Second)
+	assert.Equal(map[string]PeerStatus{"left": PeerFailed, "right": PeerAlive}, peerStatuses(ps.List()))
+
+	now = now.Add(time.Hour)
+	assert.Len(ps.List(), 0)
+}
+
+func TestPeers_Announce(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "sous-peers")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "peers.json")
+
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	replies := map[string][]Peer{
+		"https://left.sous.com": {
+			{ClusterName: "left", URL: "https://left.sous.com", Generation: 7, Heartbeat: 3},
+			{ClusterName: "right", URL: "https://right.sous.com", Generation: 0},
+			{ClusterName: "self", URL: "https://self.sous.com", Generation: 9, Heartbeat: 1},
+		},
+	}
+	ps, got := newTestPeers(file, &now, replies)
+	ps.Seed("left", "https://left.sous.com")
+	ps.Lock()
+	ps.self = Peer{ClusterName: "self", URL: "https://self.sous.com", Generation: 10}
+	ps.Unlock()
+
+	ps.Announce()
+
+	assert.Equal([]string{"https://left.sous.com"}, *got)
+	assert.Equal(map[string]PeerStatus{
+		"left":  PeerAlive,
+		"right": PeerUnknown,
+		"self":  PeerAlive,
+	}, peerStatuses(ps.List()))
+
+	*got = nil
+	ps.Announce()
+	assert.Len(*got, 2)
+
+	// The known servers are recorded, and known again after a restart.
+	restarted, _ := newTestPeers(file, &now, nil)
+	assert.Equal(map[string]PeerStatus{
+		"left":  PeerAlive,
+		"right": PeerUnknown,
+	}, peerStatuses(restarted.List()))
+}
+
+func TestPeers_AnnounceReadiness(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	replies := map[string][]Peer{
+		"https://left.sous.com": {
+			{ClusterName: "left", URL: "https://left.sous.com", Generation: 7, Heartbeat: 3, NotReady: "no resolve has completed yet"},
+		},
+	}
+	ps, _ := newTestPeers("", &now, replies)
+	ps.Seed("left", "https://left.sous.com")
+	ps.Lock()
+	ps.self = Peer{ClusterName: "self", URL: "https://self.sous.com", Generation: 10}
+	ps.Unlock()
+
+	ready := func(list []Peer) map[string]string {
+		r := map[string]string{}
+		for _, p := range list {
+			if p.Ready {
+				r[p.ClusterName] = "ready"
+			} else {
+				r[p.ClusterName] = p.NotReady
+			}
+		}
+		return r
+	}
+
+	ps.Announce()
+	assert.Equal(map[string]string{
+		"left": "no resolve has completed yet",
+		"self": "ready",
+	}, ready(ps.List()))
+
+	ps.CheckReadiness(func() error { return fmt.Errorf("gdm: unreadable") })
+	ps.Announce()
+	assert.Equal("gdm: unreadable", ready(ps.List())["self"])
+
+	ps.CheckReadiness(func() error { return nil })
+	ps.Announce()
+	assert.Equal("ready", ready(ps.List())["self"])
+}
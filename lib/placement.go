@@ -0,0 +1,183 @@
+package sous
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Placement describes where the tasks of a deployment run, and how they are
+// reached through the scheduler's load balancer.
+type Placement struct {
+	// RackSensitive spreads instances evenly across racks.
+	RackSensitive bool `yaml:",omitempty"`
+	// SlavePlacement is the scheduler's policy for placing several instances
+	// on one slave, one of SlavePlacementPolicies. If empty, the scheduler's
+	// default policy applies.
+	SlavePlacement string `yaml:",omitempty"`
+	// RequiredAttributes are slave attributes which must all match for a
+	// task to be placed on that slave, e.g. {"instance-type": "large"}.
+	RequiredAttributes map[string]string `yaml:",omitempty"`
+	// LoadBalancerGroups are the load balancer groups the deployment is
+	// registered with. If empty, the deployment is not load balanced.
+	LoadBalancerGroups []string `yaml:",omitempty"`
+	// ServiceBasePath is the path prefix the load balancer routes to the
+	// deployment, e.g. "/my-service". Required if LoadBalancerGroups is set.
+	ServiceBasePath string `yaml:",omitempty"`
+}
+
+// SlavePlacementPolicies are the valid values of Placement.SlavePlacement.
+var SlavePlacementPolicies = []string{
+	"SEPARATE",
+	"OPTIMISTIC",
+	"GREEDY",
+	"SEPARATE_BY_DEPLOY",
+	"SEPARATE_BY_REQUEST",
+	"SPREAD_ALL_SLAVES",
+}
+
+// LoadBalanced returns true if the deployment is registered with a load
+// balancer.
+func (p Placement) LoadBalanced() bool {
+	return len(p.LoadBalancerGroups) != 0
+}
+
+// IsZero returns true if p is the default placement.
+func (p Placement) IsZero() bool {
+	return p.Equal(Placement{})
+}
+
+// Equal returns true if p and o describe the same placement. The order of
+// load balancer groups is not significant.
+func (p Placement) Equal(o Placement) bool {
+	return len(p.diff(o)) == 0
+}
+
+// Clone returns a deep copy of p.
+func (p Placement) Clone() Placement {
+	c := p
+	if p.RequiredAttributes != nil {
+		c.RequiredAttributes = make(map[string]string, len(p.RequiredAttributes))
+		for k, v := range p.RequiredAttributes {
+			c.RequiredAttributes[k] = v
+		}
+	}
+	if p.LoadBalancerGroups != nil {
+		c.LoadBalancerGroups = append([]string{}, p.LoadBalancerGroups...)
+	}
+	return c
+}
+
+func (p Placement) String() string {
+	return fmt.Sprintf("rack-sensitive:%t slave-placement:%q attributes:%v lb-groups:%v base-path:%q",
+		p.RackSensitive, p.SlavePlacement, p.RequiredAttributes, p.LoadBalancerGroups, p.ServiceBasePath)
+}
+
+func (p Placement) sortedGroups() []string {
+	gs := append([]string{}, p.LoadBalancerGroups...)
+	sort.Strings(gs)
+	return gs
+}
+
+func (p Placement) diff(o Placement) []string {
+	diffs := []string{}
+	if p.RackSensitive != o.RackSensitive {
+		diffs = append(diffs, fmt.Sprintf("rack sensitive; this: %t; other: %t", p.RackSensitive, o.RackSensitive))
+	}
+	if p.SlavePlacement != o.SlavePlacement {
+		diffs = append(diffs, fmt.Sprintf("slave placement; this: %q; other: %q", p.SlavePlacement, o.SlavePlacement))
+	}
+	if !attributesEqual(p.RequiredAttributes, o.RequiredAttributes) {
+		diffs = append(diffs, fmt.Sprintf("required attributes; this: %v; other: %v", p.RequiredAttributes, o.RequiredAttributes))
+	}
+	if strings.Join(p.sortedGroups(), ",") != strings.Join(o.sortedGroups(), ",") {
+		diffs = append(diffs, fmt.Sprintf("load balancer groups; this: %v; other: %v", p.LoadBalancerGroups, o.LoadBalancerGroups))
+	}
+	if p.ServiceBasePath != o.ServiceBasePath {
+		diffs = append(diffs, fmt.Sprintf("service base path; this: %q; other: %q", p.ServiceBasePath, o.ServiceBasePath))
+	}
+	return diffs
+}
+
+func attributesEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if ov, ok := b[k]; !ok || ov != v {
+			return false
+		}
+	}
+	return true
+}
+
+// validatePlacement checks the placement of dc, and that a load balanced
+// deployment has a port for the load balancer to route to.
+func (dc *DeployConfig) validatePlacement() []Flaw {
+	var flaws []Flaw
+	p := dc.Placement
+	if p.SlavePlacement != "" && !validSlavePlacement(p.SlavePlacement) {
+		flaws = append(flaws, FatalFlaw("slave placement %q must be one of %v", p.SlavePlacement, SlavePlacementPolicies))
+	}
+	for k, v := range p.RequiredAttributes {
+		if k == "" || v == "" {
+			flaws = append(flaws, FatalFlaw("required attribute %q=%q must have a name and a value", k, v))
+		}
+	}
+	groups := map[string]struct{}{}
+	for _, g := range p.LoadBalancerGroups {
+		if g == "" {
+			flaws = append(flaws, FatalFlaw("empty load balancer group"))
+		}
+		if _, dup := groups[g]; dup {
+			flaws = append(flaws, NewFlaw(
+				fmt.Sprintf("load balancer group %q listed more than once", g),
+				func() error {
+					dc.Placement.LoadBalancerGroups = dedupeStrings(dc.Placement.LoadBalancerGroups)
+					return nil
+				},
+			))
+		}
+		groups[g] = struct{}{}
+	}
+	if !p.LoadBalanced() {
+		if p.ServiceBasePath != "" {
+			flaws = append(flaws, FatalFlaw("service base path %q requires at least one load balancer group", p.ServiceBasePath))
+		}
+		return flaws
+	}
+	if !strings.HasPrefix(p.ServiceBasePath, "/") {
+		flaws = append(flaws, FatalFlaw("load balanced deployments require a service base path starting with /, got %q", p.ServiceBasePath))
+	}
+	if dc.NetworkMode.Normalized() == NetworkNone {
+		flaws = append(flaws, FatalFlaw("load balanced deployments cannot have network mode %q", NetworkNone))
+	}
+	// The load balancer routes to the first port allocated to each task.
+	if dc.Resources != nil && dc.Resources.Ports() < 1 {
+		flaws = append(flaws, NewFlaw("load balanced deployments require at least one port",
+			func() error { dc.Resources["ports"] = "1"; return nil }))
+	}
+	return flaws
+}
+
+func validSlavePlacement(policy string) bool {
+	for _, p := range SlavePlacementPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+func dedupeStrings(ss []string) []string {
+	seen := map[string]struct{}{}
+	out := ss[:0]
+	for _, s := range ss {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	return out
+}
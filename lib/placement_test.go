@@ -0,0 +1,76 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlacementEqual(t *testing.T) {
+	p := Placement{
+		RackSensitive:      true,
+		RequiredAttributes: map[string]string{"instance-type": "large"},
+		LoadBalancerGroups: []string{"internal", "external"},
+		ServiceBasePath:    "/app",
+	}
+	c := p.Clone()
+	assert.True(t, p.Equal(c))
+
+	c.LoadBalancerGroups = []string{"external", "internal"}
+	assert.True(t, p.Equal(c), "group order should not matter")
+
+	c.RequiredAttributes["instance-type"] = "small"
+	assert.False(t, p.Equal(c))
+	assert.Equal(t, "large", p.RequiredAttributes["instance-type"], "Clone shares attributes")
+
+	c = p.Clone()
+	c.SlavePlacement = "SEPARATE"
+	assert.False(t, p.Equal(c))
+
+	assert.True(t, Placement{}.IsZero())
+	assert.False(t, p.IsZero())
+}
+
+func TestDeployConfigValidatePlacement(t *testing.T) {
+	newConfig := func(p Placement) *DeployConfig {
+		return &DeployConfig{
+			Resources: Resources{"cpus": "0.1", "memory": "100", "ports": "1"},
+			Placement: p,
+		}
+	}
+
+	assert.Empty(t, newConfig(Placement{}).Validate())
+	assert.Empty(t, newConfig(Placement{
+		RackSensitive:      true,
+		SlavePlacement:     "SPREAD_ALL_SLAVES",
+		RequiredAttributes: map[string]string{"rack": "a"},
+		LoadBalancerGroups: []string{"internal"},
+		ServiceBasePath:    "/app",
+	}).Validate())
+
+	bad := []Placement{
+		{RequiredAttributes: map[string]string{"rack": ""}},
+		{SlavePlacement: "spread"},
+		{ServiceBasePath: "/app"},
+		{LoadBalancerGroups: []string{"internal"}},
+		{LoadBalancerGroups: []string{"internal"}, ServiceBasePath: "app"},
+		{LoadBalancerGroups: []string{""}, ServiceBasePath: "/app"},
+	}
+	for _, p := range bad {
+		flaws := newConfig(p).Validate()
+		if assert.Len(t, flaws, 1, "%v", p) {
+			assert.Error(t, flaws[0].Repair())
+		}
+	}
+
+	dc := newConfig(Placement{LoadBalancerGroups: []string{"a", "a"}, ServiceBasePath: "/app"})
+	dc.Resources["ports"] = "0"
+	flaws := dc.Validate()
+	if assert.Len(t, flaws, 2) {
+		for _, f := range flaws {
+			assert.NoError(t, f.Repair())
+		}
+	}
+	assert.Empty(t, dc.Validate())
+	assert.Equal(t, []string{"a"}, dc.Placement.LoadBalancerGroups)
+}
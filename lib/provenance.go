@@ -0,0 +1,119 @@
+package sous
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Provenance records how, where and by whom a build artifact was produced.
+	// It is applied to images as labels, and stored alongside the artifact in
+	// the registry as a JSON attestation.
+	Provenance struct {
+		// SourceID is the source code that was built.
+		SourceID SourceID
+		// Builder is the Sous user who performed the build.
+		Builder User
+		// Machine is the host the build was performed on.
+		Machine Machine
+		// SousVersion is the version of Sous that performed the build.
+		SousVersion string
+		// Buildpack names the buildpack selected to perform the build.
+		Buildpack string
+		// BaseImages are the images the built image is based on, as named by
+		// the FROM instructions of its Dockerfile.
+		BaseImages []BaseImage `json:",omitempty"`
+		// Advisories are the advisories that applied to the build.
+		Advisories []string `json:",omitempty"`
+		// BuildStarted and BuildFinished record the duration of the build itself.
+		BuildStarted, BuildFinished time.Time
+	}
+
+	// A BaseImage is an image named in a FROM instruction, along with the
+	// digest it resolved to at build time.
+	BaseImage struct {
+		Name string
+		// Digest is just the digest, e.g. "sha256:abc...", or "" if it is
+		// not known.
+		Digest string
+	}
+)
+
+// ProvenanceQualityKind is the Quality.Kind used to record a Provenance
+// attestation against a BuildArtifact.
+const ProvenanceQualityKind = "provenance"
+
+// NewProvenance starts a Provenance for a build performed in bc.
+func NewProvenance(bc *BuildContext, bp Buildpack, started time.Time) *Provenance {
+	advs := make([]string, len(bc.Advisories))
+	copy(advs, bc.Advisories)
+	return &Provenance{
+		SourceID:     bc.Version(),
+		Builder:      bc.SousUser,
+		Machine:      bc.Machine,
+		SousVersion:  bc.SousVersion.String(),
+		Buildpack:    buildpackName(bp),
+		Advisories:   advs,
+		BuildStarted: started.UTC(),
+	}
+}
+
+func buildpackName(bp Buildpack) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", bp), "*")
+}
+
+// Attestation returns the JSON representation of this Provenance.
+func (p *Provenance) Attestation() (string, error) {
+	b, err := json.Marshal(p)
+	return string(b), errors.Wrap(err, "provenance attestation")
+}
+
+// Quality returns a Quality recording this Provenance.
+func (p *Provenance) Quality() (Quality, error) {
+	att, err := p.Attestation()
+	return Quality{Name: att, Kind: ProvenanceQualityKind}, err
+}
+
+// BaseImageNames returns the digest qualified names of the base images, or
+// just their name where the digest is unknown.
+func (p *Provenance) BaseImageNames() []string {
+	names := make([]string, 0, len(p.BaseImages))
+	for _, bi := range p.BaseImages {
+		if bi.Digest == "" {
+			names = append(names, bi.Name)
+			continue
+		}
+		names = append(names, bi.Name+"@"+bi.Digest)
+	}
+	return names
+}
+
+// NoProvenanceError is returned when a BuildArtifact carries no provenance
+// attestation.
+type NoProvenanceError struct {
+	Name string
+}
+
+func (e NoProvenanceError) Error() string {
+	return fmt.Sprintf("no provenance recorded for %q", e.Name)
+}
+
+// Provenance extracts the Provenance attestation recorded on this
+// BuildArtifact.
+func (ba *BuildArtifact) Provenance() (*Provenance, error) {
+	for _, q := range ba.Qualities {
+		if q.Kind != ProvenanceQualityKind {
+			continue
+		}
+		p := &Provenance{}
+		if err := json.Unmarshal([]byte(q.Name), p); err != nil {
+			return nil, errors.Wrapf(err, "parsing provenance of %q", ba.Name)
+		}
+		return p, nil
+	}
+	return nil, NoProvenanceError{Name: ba.Name}
+}
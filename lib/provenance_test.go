@@ -0,0 +1,65 @@
+package sous
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type provenanceTestPack struct{}
+
+func (*provenanceTestPack) Detect(*BuildContext) (*DetectResult, error) { return nil, nil }
+
+func (*provenanceTestPack) Build(*BuildContext, *DetectResult) (*BuildResult, error) {
+	return nil, nil
+}
+
+func TestProvenanceRoundTrip(t *testing.T) {
+	bc := &BuildContext{
+		Source: SourceContext{
+			RemoteURL:  "github.com/opentable/test",
+			OffsetDir:  "sub",
+			Revision:   "abcd",
+			NearestTag: Tag{Name: "2.3.7", Revision: "abcd"},
+		},
+		Machine:     Machine{Host: "build", FullHost: "build.example.com"},
+		SousUser:    User{Name: "Judson", Email: "judson@example.com"},
+		SousVersion: semv.MustParse("0.5.0"),
+		Advisories:  []string{"dirty workspace"},
+	}
+	started := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	p := NewProvenance(bc, &provenanceTestPack{}, started)
+	p.BuildFinished = started.Add(time.Minute)
+	p.BaseImages = []BaseImage{{Name: "golang:1.7", Digest: "sha256:abc"}}
+
+	assert.Equal(t, "sous.provenanceTestPack", p.Buildpack)
+	assert.Equal(t, "0.5.0", p.SousVersion)
+	assert.Equal(t, []string{"golang:1.7@sha256:abc"}, p.BaseImageNames())
+
+	q, err := p.Quality()
+	require.NoError(t, err)
+	assert.Equal(t, ProvenanceQualityKind, q.Kind)
+
+	art := &BuildArtifact{
+		Name:      "docker.example.com/test:2.3.7",
+		Qualities: []Quality{{Name: "dirty workspace", Kind: "advisory"}, q},
+	}
+	got, err := art.Provenance()
+	require.NoError(t, err)
+	assert.Equal(t, p.SourceID.String(), got.SourceID.String())
+	assert.Equal(t, p.Builder, got.Builder)
+	assert.Equal(t, p.Machine, got.Machine)
+	assert.Equal(t, p.BaseImages, got.BaseImages)
+	assert.Equal(t, p.Advisories, got.Advisories)
+	assert.True(t, p.BuildFinished.Equal(got.BuildFinished))
+}
+
+func TestNoProvenance(t *testing.T) {
+	art := &BuildArtifact{Name: "docker.example.com/test:2.3.7"}
+	_, err := art.Provenance()
+	assert.IsType(t, NoProvenanceError{}, err)
+}
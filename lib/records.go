@@ -0,0 +1,239 @@
+package sous
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Records are the forms in which query commands write SourceIDs, Deployments,
+// DeployStates and artifacts. Their JSON and YAML are a stable interface for
+// tools, so fields may be added to them, but never renamed or removed.
+type (
+	// A SourceIDRecord is a SourceID as written by query commands.
+	SourceIDRecord struct {
+		Repo    string `json:"repo" yaml:"repo"`
+		Offset  string `json:"offset" yaml:"offset"`
+		Version string `json:"version" yaml:"version"`
+	}
+
+	// A DeploymentRecord is a Deployment as written by query commands.
+	DeploymentRecord struct {
+		Cluster      string            `json:"cluster" yaml:"cluster"`
+		SourceID     SourceIDRecord    `json:"sourceID" yaml:"sourceID"`
+		Flavor       string            `json:"flavor" yaml:"flavor"`
+		Kind         string            `json:"kind" yaml:"kind"`
+		NumInstances int               `json:"numInstances" yaml:"numInstances"`
+		Owners       []string          `json:"owners" yaml:"owners"`
+		Resources    map[string]string `json:"resources" yaml:"resources"`
+		Env          map[string]string `json:"env" yaml:"env"`
+		Metadata     map[string]string `json:"metadata" yaml:"metadata"`
+	}
+
+	// A DeployStateRecord is a DeployState as written by query commands.
+	DeployStateRecord struct {
+		DeploymentRecord `yaml:",inline"`
+		Status           string `json:"status" yaml:"status"`
+		ExecutorMessage  string `json:"executorMessage,omitempty" yaml:"executorMessage,omitempty"`
+		// Image is the name of the image the cluster reports running, if any.
+		Image string `json:"image,omitempty" yaml:"image,omitempty"`
+	}
+
+	// An ArtifactRecord is an artifact known to a Registry, as written by
+	// query commands.
+	ArtifactRecord struct {
+		SourceID SourceIDRecord `json:"sourceID" yaml:"sourceID"`
+		Name     string         `json:"name" yaml:"name"`
+		Type     string         `json:"type" yaml:"type"`
+	}
+
+	// DeploymentRecords can be written as a table, with a row per deployment.
+	DeploymentRecords []DeploymentRecord
+	// DeployStateRecords can be written as a table, with a row per deployment.
+	DeployStateRecords []DeployStateRecord
+	// ArtifactRecords can be written as a table, with a row per artifact.
+	ArtifactRecords []ArtifactRecord
+)
+
+// Record returns the record of this SourceID.
+func (sid SourceID) Record() SourceIDRecord {
+	return SourceIDRecord{
+		Repo:    sid.Location.Repo,
+		Offset:  sid.Location.Dir,
+		Version: sid.Version.String(),
+	}
+}
+
+// Record returns the record of this Deployment.
+func (d *Deployment) Record() DeploymentRecord {
+	return DeploymentRecord{
+		Cluster:      d.ClusterName,
+		SourceID:     d.SourceID.Record(),
+		Flavor:       d.Flavor,
+		Kind:         string(d.Kind),
+		NumInstances: d.NumInstances,
+		Owners:       d.Owners.Slice(),
+		Resources:    copyStringMap(d.DeployConfig.Resources),
+		Env:          copyStringMap(d.DeployConfig.Env),
+		Metadata:     copyStringMap(d.DeployConfig.Metadata),
+	}
+}
+
+// Record returns the record of this DeployState.
+func (ds *DeployState) Record() DeployStateRecord {
+	r := DeployStateRecord{
+		DeploymentRecord: ds.Deployment.Record(),
+		Status:           ds.Status.String(),
+		ExecutorMessage:  ds.ExecutorMessage,
+	}
+	if ds.Artifact != nil {
+		r.Image = ds.Artifact.Name
+	}
+	return r
+}
+
+// Records returns the records of these Deployments, ordered by cluster, then
+// source location and flavor.
+func (ds Deployments) Records() DeploymentRecords {
+	rs := DeploymentRecords{}
+	for _, d := range ds.Snapshot() {
+		rs = append(rs, d.Record())
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].less(rs[j]) })
+	return rs
+}
+
+// Records returns the records of these DeployStates, ordered by cluster, then
+// source location and flavor.
+func (ds DeployStates) Records() DeployStateRecords {
+	rs := DeployStateRecords{}
+	for _, d := range ds.Snapshot() {
+		rs = append(rs, d.Record())
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].less(rs[j].DeploymentRecord) })
+	return rs
+}
+
+// Records returns the records of the artifacts in the registry, ordered by
+// source ID.
+func (rd *RegistryDumper) Records() (ArtifactRecords, error) {
+	es, err := rd.Entries()
+	if err != nil {
+		return nil, err
+	}
+	rs := ArtifactRecords{}
+	for _, e := range es {
+		r := ArtifactRecord{SourceID: e.SourceID.Record()}
+		if e.BuildArtifact != nil {
+			r.Name, r.Type = e.Name, e.Type
+		}
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		a, b := rs[i].SourceID, rs[j].SourceID
+		if a.Repo != b.Repo {
+			return a.Repo < b.Repo
+		}
+		if a.Offset != b.Offset {
+			return a.Offset < b.Offset
+		}
+		return a.Version < b.Version
+	})
+	return rs, nil
+}
+
+func (r DeploymentRecord) less(o DeploymentRecord) bool {
+	if r.Cluster != o.Cluster {
+		return r.Cluster < o.Cluster
+	}
+	if r.SourceID.Repo != o.SourceID.Repo {
+		return r.SourceID.Repo < o.SourceID.Repo
+	}
+	if r.SourceID.Offset != o.SourceID.Offset {
+		return r.SourceID.Offset < o.SourceID.Offset
+	}
+	return r.Flavor < o.Flavor
+}
+
+var deploymentRecordHeaders = []string{
+	"Cluster", "Repo", "Version", "Offset", "Flavor", "NumInstances", "Owner", "Resources", "Env",
+}
+
+func (r DeploymentRecord) cells() []string {
+	owner := "<?>"
+	if len(r.Owners) != 0 {
+		owner = r.Owners[0]
+	}
+	return []string{
+		r.Cluster,
+		r.SourceID.Repo,
+		r.SourceID.Version,
+		r.SourceID.Offset,
+		r.Flavor,
+		strconv.Itoa(r.NumInstances),
+		owner,
+		joinStringMap(r.Resources),
+		joinStringMap(r.Env),
+	}
+}
+
+// TableHeaders implements cmdr.Table on DeploymentRecords.
+func (rs DeploymentRecords) TableHeaders() []string {
+	return deploymentRecordHeaders
+}
+
+// TableRows implements cmdr.Table on DeploymentRecords.
+func (rs DeploymentRecords) TableRows() [][]string {
+	rows := make([][]string, len(rs))
+	for i, r := range rs {
+		rows[i] = r.cells()
+	}
+	return rows
+}
+
+// TableHeaders implements cmdr.Table on DeployStateRecords.
+func (rs DeployStateRecords) TableHeaders() []string {
+	return append(append([]string{}, deploymentRecordHeaders...), "Status", "Image")
+}
+
+// TableRows implements cmdr.Table on DeployStateRecords.
+func (rs DeployStateRecords) TableRows() [][]string {
+	rows := make([][]string, len(rs))
+	for i, r := range rs {
+		rows[i] = append(r.DeploymentRecord.cells(), r.Status, r.Image)
+	}
+	return rows
+}
+
+// TableHeaders implements cmdr.Table on ArtifactRecords.
+func (rs ArtifactRecords) TableHeaders() []string {
+	return []string{"Repo", "Offset", "Version", "Name", "Type"}
+}
+
+// TableRows implements cmdr.Table on ArtifactRecords.
+func (rs ArtifactRecords) TableRows() [][]string {
+	rows := make([][]string, len(rs))
+	for i, r := range rs {
+		rows[i] = []string{r.SourceID.Repo, r.SourceID.Offset, r.SourceID.Version, r.Name, r.Type}
+	}
+	return rows
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// joinStringMap returns the entries of m as "key: value", in order of key.
+func joinStringMap(m map[string]string) string {
+	es := make([]string, 0, len(m))
+	for k, v := range m {
+		es = append(es, fmt.Sprintf("%s: %s", k, v))
+	}
+	sort.Strings(es)
+	return strings.Join(es, ", ")
+}
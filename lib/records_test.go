@@ -0,0 +1,69 @@
+package sous
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployStateRecords(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ds := NewDeployStates()
+	for _, cluster := range []string{"west", "east"} {
+		ds.Add(&DeployState{
+			Deployment: Deployment{
+				ClusterName: cluster,
+				SourceID:    MustNewSourceID("github.com/opentable/example", "api", "1.2.3"),
+				Kind:        ManifestKindService,
+				Owners:      NewOwnerSet("sam", "judson"),
+				DeployConfig: DeployConfig{
+					NumInstances: 2,
+					Env:          Env{"B": "2", "A": "1"},
+				},
+			},
+			Status:   DeployStatusActive,
+			Artifact: &BuildArtifact{Name: "docker.example.com/example@sha256:abc", Type: "docker"},
+		})
+	}
+
+	rs := ds.Records()
+	require.Len(rs, 2)
+	assert.Equal("east", rs[0].Cluster)
+	assert.Equal("west", rs[1].Cluster)
+
+	b, err := json.Marshal(rs[0])
+	require.NoError(err)
+	assert.JSONEq(`{
+		"cluster": "east",
+		"sourceID": {"repo": "github.com/opentable/example", "offset": "api", "version": "1.2.3"},
+		"flavor": "",
+		"kind": "http-service",
+		"numInstances": 2,
+		"owners": ["judson", "sam"],
+		"resources": {},
+		"env": {"A": "1", "B": "2"},
+		"metadata": {},
+		"status": "DeployStatusActive",
+		"image": "docker.example.com/example@sha256:abc"
+	}`, string(b))
+
+	headers := rs.TableHeaders()
+	row := rs.TableRows()[0]
+	require.Len(row, len(headers))
+	cell := func(header string) string {
+		for i, h := range headers {
+			if h == header {
+				return row[i]
+			}
+		}
+		t.Fatalf("no column %q", header)
+		return ""
+	}
+	assert.Equal("judson", cell("Owner"))
+	assert.Equal("A: 1, B: 2", cell("Env"))
+	assert.Equal("DeployStatusActive", cell("Status"))
+}
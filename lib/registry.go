@@ -1,6 +1,10 @@
 package sous
 
-import "github.com/opentable/sous/util/spies"
+import (
+	"fmt"
+
+	"github.com/opentable/sous/util/spies"
+)
 
 type (
 	// ImageLabeller can get the image labels for a given imageName
@@ -15,6 +19,10 @@ type (
 		// GetArtifact gets the build artifact address for a source ID.
 		// It does not guarantee that that artifact exists.
 		GetArtifact(SourceID) (*BuildArtifact, error)
+		// GetFlavoredArtifact gets the build artifact built from a source ID
+		// specifically for deployments of a flavor. If there is none, it
+		// returns an ArtifactNotFoundError.
+		GetFlavoredArtifact(sid SourceID, flavor string) (*BuildArtifact, error)
 		// GetSourceID gets the source ID associated with the
 		// artifact, regardless of the existence of the artifact.
 		GetSourceID(*BuildArtifact) (SourceID, error)
@@ -34,10 +42,31 @@ type (
 	Inserter interface {
 		// Insert pairs a SourceID with an imagename, and tags the pairing with Qualities
 		// The etag can be (usually will be) the empty string
+		// An image built for a flavor is inserted with a FlavorQualityKind
+		// quality naming it.
 		Insert(sid SourceID, in, etag string, qs []Quality) error
 	}
+
+	// An ArtifactNotFoundError reports that a Registry knows of no artifact
+	// built from a SourceID, for a flavor if Flavor is not empty.
+	ArtifactNotFoundError struct {
+		SourceID SourceID
+		Flavor   string
+	}
 )
 
+// FlavorQualityKind is the Quality.Kind used, when an artifact is inserted, to
+// name the flavor it was built for. Registries keep the flavor apart from the
+// artifact's other qualities.
+const FlavorQualityKind = "flavor"
+
+func (e *ArtifactNotFoundError) Error() string {
+	if e.Flavor == "" {
+		return fmt.Sprintf("no artifact known for %v", e.SourceID)
+	}
+	return fmt.Sprintf("no artifact known for %v flavor %q", e.SourceID, e.Flavor)
+}
+
 type (
 	// An InserterSpy is a spy implementation of the Inserter interface
 	InserterSpy struct {
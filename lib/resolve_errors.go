@@ -38,10 +38,30 @@ type (
 	FailedStatusError struct{} // XXX maybe handy to have the root Singularity non-SUCCEEDED status?
 
 	// An UnacceptableAdvisory reports that there is an advisory on an image
-	// which hasn't been whitelisted on the target cluster
+	// which the advisory policy of the target cluster does not allow.
 	UnacceptableAdvisory struct {
 		Quality
 		*SourceID
+		// Reason explains why the advisory is not allowed.
+		Reason string
+	}
+
+	// An UntrustedArtifactError reports that an image is not signed by a
+	// trusted key, and the target cluster requires signed artifacts.
+	UntrustedArtifactError struct {
+		SourceID SourceID
+		Cluster  string
+		Reason   string
+	}
+
+	// A DigestMismatchError reports that the image a deployment is running
+	// is not the one now known for its SourceID, typically because the image
+	// was retagged or overwritten after it was deployed.
+	DigestMismatchError struct {
+		SourceID SourceID
+		// Running and Known are the digested names of the image running, and
+		// of the image now known for SourceID.
+		Running, Known string
 	}
 
 	// CreateError is returned when there's an error trying to create a deployment
@@ -130,6 +150,14 @@ func IsTransientResolveError(err error) bool {
 		// intervention: either the image needs to be rebuilt clean, or the cluster
 		// reconfigured to accept the advisory.
 		return false
+	case *UntrustedArtifactError:
+		// UntrustedArtifactError requires that the image be rebuilt and signed
+		// with a trusted key, or that the key be added to the trusted keys.
+		return false
+	case *DigestMismatchError:
+		// DigestMismatchError requires an operator to decide which image should
+		// be running, and to redeploy or rebuild accordingly.
+		return false
 	case *MissingImageNameError:
 		// MissingImageNameError isn't transient: it requires that an appropriate
 		// image be built with the desired name and the server needs to be able to
@@ -158,7 +186,18 @@ func (e *MissingImageNameError) Error() string {
 }
 
 func (e *UnacceptableAdvisory) Error() string {
-	return fmt.Sprintf("Advisory unacceptable on image: %s for %v", e.Quality.Name, e.SourceID)
+	if e.Reason == "" {
+		return fmt.Sprintf("Advisory unacceptable on image: %s for %v", e.Quality.Name, e.SourceID)
+	}
+	return fmt.Sprintf("Advisory unacceptable on image: %s for %v: %s", e.Quality.Name, e.SourceID, e.Reason)
+}
+
+func (e *UntrustedArtifactError) Error() string {
+	return fmt.Sprintf("Artifact for %v is not trusted by cluster %q: %s", e.SourceID, e.Cluster, e.Reason)
+}
+
+func (e *DigestMismatchError) Error() string {
+	return fmt.Sprintf("Running image %s for %v, but the image now known for it is %s", e.Running, e.SourceID, e.Known)
 }
 
 func (e *FailedStatusError) Error() string {
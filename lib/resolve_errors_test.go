@@ -13,6 +13,7 @@ func TestIsTransientResolveError(t *testing.T) {
 
 	assert.False(IsTransientResolveError(fmt.Errorf("Hi!")))
 	assert.False(IsTransientResolveError(&UnacceptableAdvisory{}))
+	assert.False(IsTransientResolveError(&UntrustedArtifactError{}))
 	assert.False(IsTransientResolveError(errors.Wrap(&MissingImageNameError{}, "wrapped")))
 	assert.True(IsTransientResolveError(&CreateError{}))
 	assert.True(IsTransientResolveError(errors.Wrap(&CreateError{}, "even if wrapped")))
@@ -1,6 +1,10 @@
 package sous
 
-import "sync"
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
 
 type (
 	// Resolver is responsible for resolving intended and actual deployment
@@ -8,6 +12,12 @@ type (
 	Resolver struct {
 		Deployer Deployer
 		Registry Registry
+		// TrustedKeys are used to verify artifacts deployed to clusters which
+		// require signed artifacts. Typically set from Defs.TrustedKeys.
+		TrustedKeys TrustedKeys
+		// Tombstones, if not nil, are the tombstones changed by the Deployer
+		// during rectification, which are saved once it is complete.
+		Tombstones *Tombstones
 		*ResolveFilter
 	}
 
@@ -67,6 +77,7 @@ func (r *Resolver) reportStable(stable <-chan *DeployablePair, results chan<- Di
 // those differences.
 func (r *Resolver) Begin(intended Deployments, clusters Clusters) *ResolveRecorder {
 	intended = intended.Filter(r.FilterDeployment)
+	keys := r.TrustedKeys
 
 	return NewResolveRecorder(intended, func(recorder *ResolveRecorder) {
 		recorder.performGuaranteedPhase("filtering clusters", func() {
@@ -78,7 +89,21 @@ func (r *Resolver) Begin(intended Deployments, clusters Clusters) *ResolveRecord
 		recorder.performPhase("getting running deployments", func() error {
 			var err error
 			actual, err = r.Deployer.RunningDeployments(r.Registry, clusters)
-			return err
+			unreachable, ok := errors.Cause(err).(*UnreachableClustersError)
+			if !ok {
+				return err
+			}
+			// Without the deployments running in a cluster, every intended
+			// deployment there would be created anew, so they are skipped
+			// until the cluster is back.
+			recorder.markUnreachable(unreachable)
+			reachable := func(cluster string) bool {
+				_, down := unreachable.Clusters[cluster]
+				return !down
+			}
+			intended = intended.Filter(func(d *Deployment) bool { return reachable(d.ClusterName) })
+			actual = actual.Filter(func(ds *DeployState) bool { return reachable(ds.ClusterName) })
+			return nil
 		})
 
 		recorder.performGuaranteedPhase("filtering running deployments", func() {
@@ -107,12 +132,14 @@ func (r *Resolver) Begin(intended Deployments, clusters Clusters) *ResolveRecord
 				wg.Done()
 			}()
 			// TODO: ResolveNames should take rs.Log instead of errs.
-			namer.ResolveNames(r.Registry, diffs, errs)
+			namer.ResolveNames(r.Registry, keys, diffs, errs)
 		})
 
 		recorder.performGuaranteedPhase("rectification", func() {
 			r.rectify(namer, recorder.Log)
 		})
 		wg.Wait()
+
+		recorder.performPhase("recording tombstones", r.Tombstones.Save)
 	})
 }
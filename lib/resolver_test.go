@@ -3,6 +3,7 @@ package sous
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -17,7 +18,7 @@ func TestGuardImageMissing(t *testing.T) {
 	missing := Deployment{ClusterName: `x`, SourceID: svOne, DeployConfig: config, Cluster: clusterX}
 
 	dr.FeedArtifact(nil, fmt.Errorf("dummy error"))
-	_, err := GuardImage(dr, &missing)
+	_, err := GuardImage(dr, &missing, nil)
 	assert.Error(err)
 }
 
@@ -32,7 +33,7 @@ func TestGuardImageRejected(t *testing.T) {
 
 	dr.FeedArtifact(&BuildArtifact{"ot-docker/one", "docker", []Quality{{"ephemeral_tag", "advisory"}}}, nil)
 
-	_, err := GuardImage(dr, &rejected)
+	_, err := GuardImage(dr, &rejected, nil)
 	assert.Error(err)
 
 }
@@ -47,10 +48,29 @@ func TestAllowUndeployedUglies(t *testing.T) {
 
 	dr.FeedArtifact(nil, fmt.Errorf("dummy error"))
 
-	_, err := GuardImage(dr, &borken)
+	_, err := GuardImage(dr, &borken, nil)
 	assert.NoError(err)
 }
 
+func TestGuardImageWithoutCluster(t *testing.T) {
+	assert := assert.New(t)
+
+	svOne := MustParseSourceID(`github.com/ot/one,1.3.5`)
+	dr := NewDummyRegistry()
+	config := DeployConfig{NumInstances: 1}
+	noCluster := Deployment{ClusterName: `x`, SourceID: svOne, DeployConfig: config}
+
+	// Nothing to check, so no cluster is needed.
+	dr.FeedArtifact(&BuildArtifact{"ot-docker/one", "docker", []Quality{{"", "advisory"}}}, nil)
+	art, err := GuardImage(dr, &noCluster, nil)
+	assert.NoError(err)
+	assert.NotNil(art)
+
+	dr.FeedArtifact(&BuildArtifact{"ot-docker/one", "docker", []Quality{{"ephemeral_tag", "advisory"}}}, nil)
+	_, err = GuardImage(dr, &noCluster, nil)
+	assert.Error(err)
+}
+
 func TestAllowsWhitelistedAdvisories(t *testing.T) {
 	assert := assert.New(t)
 
@@ -61,7 +81,126 @@ func TestAllowsWhitelistedAdvisories(t *testing.T) {
 
 	dr.FeedArtifact(&BuildArtifact{"ot-docker/one", "docker", []Quality{{"ephemeral_tag", "advisory"}}}, nil)
 
-	art, err := GuardImage(dr, &intoCI)
+	art, err := GuardImage(dr, &intoCI, nil)
 	assert.NoError(err)
 	assert.NotNil(art)
 }
+
+func TestGuardImagePrefersFlavoredArtifact(t *testing.T) {
+	sid := MustParseSourceID(`github.com/ot/one,1.3.5`)
+	dr := NewDummyRegistry()
+	dep := Deployment{ClusterName: `x`, Cluster: &Cluster{Name: "x"}, Flavor: "worker",
+		SourceID: sid, DeployConfig: DeployConfig{NumInstances: 1}}
+
+	dr.FeedArtifact(&BuildArtifact{"ot-docker/one-worker", "docker", nil}, nil)
+	art, err := GuardImage(dr, &dep, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ot-docker/one-worker", art.Name)
+
+	dr.FeedArtifact(nil, &ArtifactNotFoundError{SourceID: sid, Flavor: "worker"})
+	dr.FeedArtifact(&BuildArtifact{"ot-docker/one", "docker", nil}, nil)
+	art, err = GuardImage(dr, &dep, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ot-docker/one", art.Name)
+
+	// Only a missing flavored artifact falls back to the unflavored one.
+	dr.FeedArtifact(nil, fmt.Errorf("registry unreachable"))
+	dr.FeedArtifact(&BuildArtifact{"ot-docker/one", "docker", nil}, nil)
+	_, err = GuardImage(dr, &dep, nil)
+	assert.Error(t, err)
+}
+
+// unreachableDeployer reports that some clusters are unreachable, and records
+// the deployments it is asked to create.
+type unreachableDeployer struct {
+	DummyDeployer
+	unreachable map[string]error
+	created     []DeploymentID
+}
+
+func (ud *unreachableDeployer) RunningDeployments(reg Registry, from Clusters) (DeployStates, error) {
+	return NewDeployStates(), &UnreachableClustersError{Clusters: ud.unreachable}
+}
+
+func (ud *unreachableDeployer) RectifyCreates(cc <-chan *DeployablePair, results chan<- DiffResolution) {
+	for p := range cc {
+		ud.created = append(ud.created, p.ID())
+		results <- DiffResolution{DeploymentID: p.ID(), Desc: CreateDiff}
+	}
+}
+
+func TestResolveSkipsUnreachableClusters(t *testing.T) {
+	assert := assert.New(t)
+
+	sid := MustParseSourceID(`github.com/ot/one,1.3.5`)
+	clusters := Clusters{
+		"up":   &Cluster{Name: "up", BaseURL: "http://up.example.com"},
+		"down": &Cluster{Name: "down", BaseURL: "http://down.example.com"},
+	}
+	intended := NewDeployments()
+	for name, c := range clusters {
+		intended.Add(&Deployment{
+			ClusterName:  name,
+			Cluster:      c,
+			SourceID:     sid,
+			DeployConfig: DeployConfig{NumInstances: 1},
+		})
+	}
+	deployer := &unreachableDeployer{unreachable: map[string]error{"down": fmt.Errorf("503 Service Unavailable")}}
+	r := NewResolver(deployer, NewDummyRegistry(), &ResolveFilter{})
+
+	recorder := r.Begin(intended, clusters)
+	assert.NoError(recorder.Wait())
+
+	status := recorder.CurrentStatus()
+	assert.Equal("finished", status.Phase)
+	assert.Equal(map[string]string{"down": "503 Service Unavailable"}, status.UnreachableClusters)
+	if assert.Len(deployer.created, 1) {
+		assert.Equal("up", deployer.created[0].Cluster)
+	}
+}
+
+// tombstoningDeployer tombstones a deployment whenever it lists the running
+// deployments.
+type tombstoningDeployer struct {
+	DummyDeployer
+	ts *Tombstones
+	sm *DummyStateManager
+	// writesDuringRectify is the number of state writes seen while
+	// rectifying.
+	writesDuringRectify int
+}
+
+func (td *tombstoningDeployer) RunningDeployments(reg Registry, from Clusters) (DeployStates, error) {
+	for name, c := range from {
+		td.ts.Mark(&Deployment{ClusterName: name, Cluster: c}, name+"-req", time.Now())
+	}
+	return NewDeployStates(), nil
+}
+
+func (td *tombstoningDeployer) RectifyCreates(cc <-chan *DeployablePair, results chan<- DiffResolution) {
+	td.writesDuringRectify = td.sm.WriteCount
+	for p := range cc {
+		results <- DiffResolution{DeploymentID: p.ID(), Desc: CreateDiff}
+	}
+}
+
+func TestResolveSavesTombstonesOnce(t *testing.T) {
+	assert := assert.New(t)
+
+	clusters := Clusters{
+		"one": &Cluster{Name: "one"},
+		"two": &Cluster{Name: "two"},
+	}
+	sm := &DummyStateManager{State: NewState()}
+	deployer := &tombstoningDeployer{ts: NewStateTombstones(sm.State, sm, User{}), sm: sm}
+	r := NewResolver(deployer, NewDummyRegistry(), &ResolveFilter{})
+	r.Tombstones = deployer.ts
+
+	recorder := r.Begin(NewDeployments(), clusters)
+	assert.NoError(recorder.Wait())
+
+	assert.Equal(0, deployer.writesDuringRectify, "tombstones aren't written during rectification")
+	assert.Equal(1, sm.WriteCount, "tombstones are written once the resolve is complete")
+	assert.Len(sm.State.Orphans, 2)
+}
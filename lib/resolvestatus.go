@@ -15,6 +15,10 @@ type (
 		Log []DiffResolution
 		// Errs collects errors during resolution
 		Errs ResolveErrors
+		// UnreachableClusters maps the names of clusters which could not be
+		// reached to the reason why. Deployments in those clusters were left
+		// alone.
+		UnreachableClusters map[string]string
 	}
 
 	// ResolveRecorder represents the status of a resolve run.
@@ -65,6 +69,8 @@ func NewResolveRecorder(intended Deployments, f func(*ResolveRecorder)) *Resolve
 			Intended: []*Deployment{},
 			Log:      []DiffResolution{},
 			Errs:     ResolveErrors{Causes: []ErrorWrapper{}},
+
+			UnreachableClusters: map[string]string{},
 		},
 		Log:      make(chan DiffResolution, 10),
 		finished: make(chan struct{}),
@@ -115,6 +121,10 @@ func (rr *ResolveRecorder) CurrentStatus() (rs ResolveStatus) {
 		copy(rs.Log, rr.status.Log)
 		rs.Errs.Causes = make([]ErrorWrapper, len(rr.status.Errs.Causes))
 		copy(rs.Errs.Causes, rr.status.Errs.Causes)
+		rs.UnreachableClusters = make(map[string]string, len(rr.status.UnreachableClusters))
+		for name, reason := range rr.status.UnreachableClusters {
+			rs.UnreachableClusters[name] = reason
+		}
 	})
 	return
 }
@@ -191,6 +201,16 @@ func (rr *ResolveRecorder) read(f func()) {
 	f()
 }
 
+// markUnreachable records the clusters in err as unreachable.
+func (rr *ResolveRecorder) markUnreachable(err *UnreachableClustersError) {
+	rr.write(func() {
+		for name, cause := range err.Clusters {
+			Log.Warn.Printf("Cluster %q is unreachable, leaving its deployments alone: %s", name, cause)
+			rr.status.UnreachableClusters[name] = cause.Error()
+		}
+	})
+}
+
 // doneWithError marks the resolution as finished with an error.
 func (rr *ResolveRecorder) doneWithError(err error) {
 	rr.write(func() {
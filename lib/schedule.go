@@ -0,0 +1,97 @@
+package sous
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Schedule describes when the tasks of a scheduled deployment run.
+// The zero value is an empty schedule.
+type Schedule struct {
+	// Cron is a standard five field cron expression,
+	// e.g. "30 2 * * 1-5" for 2:30am on weekdays.
+	Cron string `yaml:",omitempty"`
+	// TimeZone is the IANA time zone that Cron is interpreted in,
+	// e.g. "America/Los_Angeles". If empty, the scheduler's default (usually
+	// UTC) applies.
+	TimeZone string `yaml:",omitempty"`
+}
+
+var cronFieldRE = regexp.MustCompile(`^[0-9A-Za-z*?,/#-]+$`)
+
+// IsZero returns true if this schedule is empty.
+func (s Schedule) IsZero() bool {
+	return s.Cron == "" && s.TimeZone == ""
+}
+
+// Equal returns true if s == o.
+func (s Schedule) Equal(o Schedule) bool {
+	return s == o
+}
+
+func (s Schedule) String() string {
+	if s.TimeZone == "" {
+		return s.Cron
+	}
+	return fmt.Sprintf("%s (%s)", s.Cron, s.TimeZone)
+}
+
+func (s Schedule) diff(o Schedule) []string {
+	diffs := []string{}
+	if s.Cron != o.Cron {
+		diffs = append(diffs, fmt.Sprintf("schedule; this: %q; other: %q", s.Cron, o.Cron))
+	}
+	if s.TimeZone != o.TimeZone {
+		diffs = append(diffs, fmt.Sprintf("schedule time zone; this: %q; other: %q", s.TimeZone, o.TimeZone))
+	}
+	return diffs
+}
+
+// Validate returns flaws with the syntax of this schedule.
+func (s Schedule) Validate() []Flaw {
+	var flaws []Flaw
+	if s.Cron == "" {
+		if s.TimeZone != "" {
+			flaws = append(flaws, FatalFlaw("schedule has time zone %q but no cron expression", s.TimeZone))
+		}
+		return flaws
+	}
+	fields := strings.Fields(s.Cron)
+	if len(fields) != 5 {
+		flaws = append(flaws, FatalFlaw("cron expression %q has %d fields, expected 5", s.Cron, len(fields)))
+	} else {
+		for _, f := range fields {
+			if !cronFieldRE.MatchString(f) {
+				flaws = append(flaws, FatalFlaw("cron expression %q has invalid field %q", s.Cron, f))
+				break
+			}
+		}
+	}
+	if s.TimeZone != "" {
+		if _, err := time.LoadLocation(s.TimeZone); err != nil {
+			flaws = append(flaws, FatalFlaw("schedule time zone %q not valid: %s", s.TimeZone, err))
+		}
+	}
+	return flaws
+}
+
+// validateSchedule checks the schedule of the deployment to cluster against
+// the kind of m: scheduled kinds must have a schedule, and other kinds must
+// not.
+func (m *Manifest) validateSchedule(cluster string) []Flaw {
+	sched := m.Deployments[cluster].Schedule
+	switch m.Kind {
+	default:
+		if !sched.IsZero() {
+			return []Flaw{FatalFlaw("schedule %q not allowed for kind %q", sched, m.Kind)}
+		}
+		return nil
+	case ManifestKindScheduled, ScheduledJob:
+		if sched.Cron == "" {
+			return []Flaw{FatalFlaw("kind %q requires a schedule", m.Kind)}
+		}
+		return sched.Validate()
+	}
+}
@@ -0,0 +1,77 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleValidate(t *testing.T) {
+	good := []Schedule{
+		{},
+		{Cron: "*/5 * * * *"},
+		{Cron: "30 2 * * 1-5", TimeZone: "America/Los_Angeles"},
+		{Cron: "0 0 1 JAN,JUL MON"},
+	}
+	for _, s := range good {
+		assert.Empty(t, s.Validate(), "%v", s)
+	}
+
+	bad := []Schedule{
+		{TimeZone: "UTC"},
+		{Cron: "* * * *"},
+		{Cron: "* * * * * * *"},
+		{Cron: "* * * * $"},
+		{Cron: "* * * * *", TimeZone: "Nowhere/Special"},
+	}
+	for _, s := range bad {
+		assert.NotEmpty(t, s.Validate(), "%v", s)
+	}
+}
+
+func TestManifestValidateSchedule(t *testing.T) {
+	newManifest := func(kind ManifestKind, sched Schedule) *Manifest {
+		return &Manifest{
+			Kind: kind,
+			Deployments: DeploySpecs{
+				"cluster-1": DeploySpec{
+					DeployConfig: DeployConfig{
+						Resources: Resources{"cpus": "0.1", "memory": "100", "ports": "1"},
+						Schedule:  sched,
+					},
+				},
+			},
+		}
+	}
+	daily := Schedule{Cron: "0 3 * * *", TimeZone: "UTC"}
+
+	assert.Empty(t, newManifest(ManifestKindScheduled, daily).Validate())
+	assert.Empty(t, newManifest(ManifestKindService, Schedule{}).Validate())
+
+	flaws := newManifest(ManifestKindScheduled, Schedule{}).Validate()
+	if assert.Len(t, flaws, 1) {
+		assert.Error(t, flaws[0].Repair())
+	}
+
+	m := newManifest(ManifestKindService, daily)
+	flaws = m.Validate()
+	if assert.Len(t, flaws, 1) {
+		assert.Error(t, flaws[0].Repair())
+		assert.Equal(t, daily, m.Deployments["cluster-1"].Schedule, "the schedule must not be removed")
+	}
+}
+
+func TestDeployConfigDiffSchedule(t *testing.T) {
+	dc := DeployConfig{Schedule: Schedule{Cron: "0 3 * * *"}}
+	o := dc.Clone()
+	_, diffs := dc.Diff(o)
+	assert.Empty(t, diffs)
+
+	o.Schedule.TimeZone = "Europe/London"
+	_, diffs = dc.Diff(o)
+	assert.Len(t, diffs, 1)
+
+	o.Schedule.Cron = "0 4 * * *"
+	_, diffs = dc.Diff(o)
+	assert.Len(t, diffs, 2)
+}
@@ -0,0 +1,170 @@
+package sous
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opentable/sous/util/restful"
+)
+
+type (
+	// A ServerDoctor checks the health, readiness and diagnostics of every
+	// Sous server known to the server a client is configured with.
+	ServerDoctor struct {
+		restful.HTTPClient
+		User User
+		// newClient creates a client for each server examined.
+		newClient func(url string) (restful.HTTPClient, error)
+	}
+
+	// A ServerCheckupRecord is the result of examining one server, as
+	// written by `sous plumbing doctor`.
+	ServerCheckupRecord struct {
+		Cluster string `json:"cluster" yaml:"cluster"`
+		URL     string `json:"url" yaml:"url"`
+		// Status is whether the servers know this one to be running.
+		Status  string `json:"status" yaml:"status"`
+		Healthy bool   `json:"healthy" yaml:"healthy"`
+		Ready   bool   `json:"ready" yaml:"ready"`
+		Version string `json:"version" yaml:"version"`
+		// GDMRevision is the commit of the GDM the server is reading.
+		GDMRevision string `json:"gdmRevision" yaml:"gdmRevision"`
+		// LastResolved is when the server last resolved its cluster without
+		// errors, or "" if it has not.
+		LastResolved string `json:"lastResolved" yaml:"lastResolved"`
+		// Problems describes the checks which failed, and any errors
+		// examining the server.
+		Problems []string `json:"problems" yaml:"problems"`
+	}
+
+	// ServerCheckupRecords can be written as a table, with a row per server.
+	ServerCheckupRecords []ServerCheckupRecord
+
+	// copied from server - avoiding coupling to server implemention
+	readyData struct {
+		Ready  bool
+		Checks []struct {
+			Name   string
+			OK     bool
+			Detail string
+		}
+	}
+
+	// copied from server - avoiding coupling to server implemention
+	diagnosticsData struct {
+		Version      string
+		GDMRevision  string
+		LastResolved map[string]time.Time
+	}
+)
+
+// NewServerDoctor returns a ServerDoctor which lists the servers known to cl,
+// and examines each with a client from newClient.
+func NewServerDoctor(cl restful.HTTPClient, newClient func(url string) (restful.HTTPClient, error), user User) *ServerDoctor {
+	return &ServerDoctor{HTTPClient: cl, User: user, newClient: newClient}
+}
+
+// Examine examines every server known, and returns a record for each, in
+// order of cluster name.
+func (sd *ServerDoctor) Examine() (ServerCheckupRecords, error) {
+	servers := &serverListData{}
+	if _, err := sd.Retrieve("./servers", nil, servers, sd.User.HTTPHeaders()); err != nil {
+		return nil, err
+	}
+
+	rs := make(ServerCheckupRecords, len(servers.Servers))
+	wg := sync.WaitGroup{}
+	for i, s := range servers.Servers {
+		wg.Add(1)
+		go func(i int, s server) {
+			defer wg.Done()
+			rs[i] = sd.examine(s)
+		}(i, s)
+	}
+	wg.Wait()
+
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Cluster < rs[j].Cluster })
+	return rs, nil
+}
+
+func (sd *ServerDoctor) examine(s server) ServerCheckupRecord {
+	r := ServerCheckupRecord{Cluster: s.ClusterName, URL: s.URL, Status: string(s.Status), Problems: []string{}}
+	problem := func(f string, as ...interface{}) {
+		r.Problems = append(r.Problems, fmt.Sprintf(f, as...))
+	}
+
+	cl, err := sd.newClient(s.URL)
+	if err != nil {
+		problem("%v", err)
+		return r
+	}
+	headers := sd.User.HTTPHeaders()
+
+	if _, err := cl.Retrieve("./health", nil, &struct{}{}, headers); err != nil {
+		problem("health: %v", err)
+	} else {
+		r.Healthy = true
+	}
+
+	// An unready server replies with an error status, but still lists its
+	// checks.
+	ready := &readyData{}
+	_, err = cl.Retrieve("./ready", nil, ready, headers)
+	r.Ready = err == nil && ready.Ready
+	for _, c := range ready.Checks {
+		if !c.OK {
+			problem("%s: %s", c.Name, c.Detail)
+		}
+	}
+	if err != nil && len(ready.Checks) == 0 {
+		problem("ready: %v", err)
+	}
+
+	diag := &diagnosticsData{}
+	if _, err := cl.Retrieve("./diagnostics", nil, diag, headers); err != nil {
+		problem("diagnostics: %v", err)
+		return r
+	}
+	r.Version, r.GDMRevision = diag.Version, diag.GDMRevision
+	if t, ok := diag.LastResolved[s.ClusterName]; ok {
+		r.LastResolved = t.Format(time.RFC3339)
+	}
+	return r
+}
+
+// Healthy returns true if every server examined is healthy and ready.
+func (rs ServerCheckupRecords) Healthy() bool {
+	for _, r := range rs {
+		if !r.Healthy || !r.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+// TableHeaders implements cmdr.Table on ServerCheckupRecords.
+func (rs ServerCheckupRecords) TableHeaders() []string {
+	return []string{"Cluster", "URL", "Status", "Healthy", "Ready", "Version", "GDMRevision", "LastResolved", "Problems"}
+}
+
+// TableRows implements cmdr.Table on ServerCheckupRecords.
+func (rs ServerCheckupRecords) TableRows() [][]string {
+	rows := make([][]string, len(rs))
+	for i, r := range rs {
+		rows[i] = []string{
+			r.Cluster,
+			r.URL,
+			r.Status,
+			fmt.Sprint(r.Healthy),
+			fmt.Sprint(r.Ready),
+			r.Version,
+			r.GDMRevision,
+			r.LastResolved,
+			strings.Join(r.Problems, "; "),
+		}
+	}
+	return rows
+}
@@ -0,0 +1,82 @@
+package sous
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentable/sous/util/restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerDoctor_Examine(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var serversJSON []byte
+	handler := func(readyStatus int, readyJSON string) http.HandlerFunc {
+		return func(rw http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			default:
+				t.Errorf("Bad request: %#v", r)
+				rw.WriteHeader(500)
+			case "/servers":
+				rw.Write(serversJSON)
+			case "/health":
+				rw.Write([]byte(`{"Status": "ok"}`))
+			case "/ready":
+				rw.WriteHeader(readyStatus)
+				rw.Write([]byte(readyJSON))
+			case "/diagnostics":
+				rw.Write([]byte(`{
+					"Version": "0.5.0",
+					"GDMRevision": "abc123",
+					"LastResolved": {"main": "2017-03-01T12:00:00Z"}
+				}`))
+			}
+		}
+	}
+	mainSrv := httptest.NewServer(handler(200, `{"Ready": true, "Checks": [{"Name": "gdm", "OK": true}]}`))
+	defer mainSrv.Close()
+	otherSrv := httptest.NewServer(handler(503, `{"Ready": false, "Checks": [
+		{"Name": "gdm", "OK": true},
+		{"Name": "cluster other", "OK": false, "Detail": "connection refused"}
+	]}`))
+	defer otherSrv.Close()
+
+	serversJSON = []byte(`{"Servers": [
+		{"ClusterName": "other", "URL": "` + otherSrv.URL + `", "Status": "alive"},
+		{"ClusterName": "main", "URL": "` + mainSrv.URL + `", "Status": "alive"}
+	]}`)
+
+	newClient := func(url string) (restful.HTTPClient, error) {
+		return restful.NewClient(url, SilentLogSet())
+	}
+	cl, err := newClient(mainSrv.URL)
+	require.NoError(err)
+
+	rs, err := NewServerDoctor(cl, newClient, User{}).Examine()
+	require.NoError(err)
+	require.Len(rs, 2)
+
+	assert.Equal(ServerCheckupRecord{
+		Cluster:      "main",
+		URL:          mainSrv.URL,
+		Status:       "alive",
+		Healthy:      true,
+		Ready:        true,
+		Version:      "0.5.0",
+		GDMRevision:  "abc123",
+		LastResolved: "2017-03-01T12:00:00Z",
+		Problems:     []string{},
+	}, rs[0])
+
+	assert.Equal("other", rs[1].Cluster)
+	assert.True(rs[1].Healthy)
+	assert.False(rs[1].Ready)
+	assert.Equal("", rs[1].LastResolved)
+	assert.Equal([]string{"cluster other: connection refused"}, rs[1].Problems)
+
+	assert.False(rs.Healthy())
+}
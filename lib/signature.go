@@ -0,0 +1,225 @@
+package sous
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// TrustedKeys is a collection of TrustedKey.
+	TrustedKeys []TrustedKey
+
+	// A TrustedKey is a public key whose signatures on build artifacts are
+	// trusted for deployment.
+	TrustedKey struct {
+		// ID names the key. Signatures record the ID of the key that made them.
+		ID string
+		// PublicKey is the PEM encoded PKIX ECDSA public key.
+		PublicKey string
+	}
+
+	// An ArtifactSignature is a signature over the digest of a built image,
+	// made by the key named by KeyID.
+	ArtifactSignature struct {
+		KeyID string
+		// Digest is the digest of the image that was signed, e.g. "sha256:...".
+		Digest string
+		// Signature is the base64 encoded ASN.1 ECDSA signature.
+		Signature string
+	}
+
+	ecdsaSignature struct {
+		R, S *big.Int
+	}
+
+	// An ArtifactSigner signs build artifacts with a private key.
+	ArtifactSigner struct {
+		KeyID string
+		key   *ecdsa.PrivateKey
+	}
+)
+
+// SignatureQualityKind is the Quality.Kind used to record an
+// ArtifactSignature against a BuildArtifact.
+const SignatureQualityKind = "signature"
+
+// NewArtifactSigner returns an ArtifactSigner using the PEM encoded ECDSA
+// private key in keyPEM, recording its signatures as made by keyID.
+func NewArtifactSigner(keyID string, keyPEM []byte) (*ArtifactSigner, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.Errorf("signing key %q is not PEM encoded", keyID)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing signing key %q", keyID)
+	}
+	return &ArtifactSigner{KeyID: keyID, key: key}, nil
+}
+
+// signedPayload is the hash of the content signed for the image with digest
+// built from sid.
+func signedPayload(sid SourceID, digest string) []byte {
+	h := sha256.Sum256([]byte(sid.String() + "@" + digest))
+	return h[:]
+}
+
+// Sign signs the image with digest built from sid.
+func (s *ArtifactSigner) Sign(sid SourceID, digest string) (*ArtifactSignature, error) {
+	r, ss, err := ecdsa.Sign(rand.Reader, s.key, signedPayload(sid, digest))
+	if err != nil {
+		return nil, errors.Wrapf(err, "signing %s", sid)
+	}
+	sig, err := asn1Signature(r, ss)
+	if err != nil {
+		return nil, err
+	}
+	return &ArtifactSignature{
+		KeyID:     s.KeyID,
+		Digest:    digest,
+		Signature: base64.StdEncoding.EncodeToString(sig),
+	}, nil
+}
+
+// Quality returns a Quality recording this signature.
+func (sig *ArtifactSignature) Quality() (Quality, error) {
+	b, err := json.Marshal(sig)
+	return Quality{Name: string(b), Kind: SignatureQualityKind}, errors.Wrap(err, "signature quality")
+}
+
+// Signatures returns the signatures recorded on this BuildArtifact.
+// Malformed signature qualities are ignored.
+func (ba *BuildArtifact) Signatures() []ArtifactSignature {
+	var sigs []ArtifactSignature
+	for _, q := range ba.Qualities {
+		if q.Kind != SignatureQualityKind {
+			continue
+		}
+		sig := ArtifactSignature{}
+		if err := json.Unmarshal([]byte(q.Name), &sig); err != nil {
+			Log.Debug.Printf("Ignoring malformed signature on %q: %v", ba.Name, err)
+			continue
+		}
+		sigs = append(sigs, sig)
+	}
+	return sigs
+}
+
+// Digest returns the digest part of this artifact's name, or the empty string
+// if it is not named by digest.
+func (ba *BuildArtifact) Digest() string {
+	if i := strings.Index(ba.Name, "@"); i >= 0 {
+		return ba.Name[i+1:]
+	}
+	return ""
+}
+
+// Get returns the key with the given ID, and true, or false if there is no
+// such key.
+func (tks TrustedKeys) Get(id string) (TrustedKey, bool) {
+	for _, tk := range tks {
+		if tk.ID == id {
+			return tk, true
+		}
+	}
+	return TrustedKey{}, false
+}
+
+// Clone returns a deep copy of this TrustedKeys.
+func (tks TrustedKeys) Clone() TrustedKeys {
+	if tks == nil {
+		return nil
+	}
+	c := make(TrustedKeys, len(tks))
+	copy(c, tks)
+	return c
+}
+
+// Verify checks that art, built from sid, carries at least one valid
+// signature by a trusted key over its digest.
+func (tks TrustedKeys) Verify(sid SourceID, art *BuildArtifact) error {
+	sigs := art.Signatures()
+	if len(sigs) == 0 {
+		return errors.New("artifact is not signed")
+	}
+	var problems []string
+	for _, sig := range sigs {
+		err := tks.verifySignature(sid, art, sig)
+		if err == nil {
+			return nil
+		}
+		problems = append(problems, err.Error())
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+func (tks TrustedKeys) verifySignature(sid SourceID, art *BuildArtifact, sig ArtifactSignature) error {
+	// An artifact named by a tag could be anything the tag is later pushed
+	// to, so there's no telling what was signed.
+	d := art.Digest()
+	if d == "" {
+		return errors.Errorf("artifact %s is not named by digest, so its signature can't be checked", art.Name)
+	}
+	if d != sig.Digest {
+		return errors.Errorf("signature by %q is for digest %s, not %s", sig.KeyID, sig.Digest, d)
+	}
+	tk, ok := tks.Get(sig.KeyID)
+	if !ok {
+		return errors.Errorf("signed by untrusted key %q", sig.KeyID)
+	}
+	pub, err := tk.publicKey()
+	if err != nil {
+		return err
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		return errors.Wrapf(err, "decoding signature by %q", sig.KeyID)
+	}
+	r, s, err := parseASN1Signature(raw)
+	if err != nil {
+		return errors.Wrapf(err, "parsing signature by %q", sig.KeyID)
+	}
+	if !ecdsa.Verify(pub, signedPayload(sid, sig.Digest), r, s) {
+		return errors.Errorf("signature by %q does not match", sig.KeyID)
+	}
+	return nil
+}
+
+func (tk TrustedKey) publicKey() (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(tk.PublicKey))
+	if block == nil {
+		return nil, errors.Errorf("trusted key %q is not PEM encoded", tk.ID)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing trusted key %q", tk.ID)
+	}
+	ecpub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("trusted key %q is a %T, not an ECDSA key", tk.ID, pub)
+	}
+	return ecpub, nil
+}
+
+func asn1Signature(r, s *big.Int) ([]byte, error) {
+	b, err := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	return b, errors.Wrap(err, "encoding signature")
+}
+
+func parseASN1Signature(b []byte) (*big.Int, *big.Int, error) {
+	sig := ecdsaSignature{}
+	if _, err := asn1.Unmarshal(b, &sig); err != nil {
+		return nil, nil, err
+	}
+	return sig.R, sig.S, nil
+}
@@ -0,0 +1,83 @@
+package sous
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSigningKey(t *testing.T, id string) (*ArtifactSigner, TrustedKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	priv, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	signer, err := NewArtifactSigner(id, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: priv}))
+	require.NoError(t, err)
+	return signer, TrustedKey{ID: id, PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))}
+}
+
+func signedArtifact(t *testing.T, signer *ArtifactSigner, sid SourceID, digest string) *BuildArtifact {
+	sig, err := signer.Sign(sid, digest)
+	require.NoError(t, err)
+	q, err := sig.Quality()
+	require.NoError(t, err)
+	return &BuildArtifact{Name: "docker.example.com/one@" + digest, Type: "docker", Qualities: []Quality{q}}
+}
+
+func TestTrustedKeysVerify(t *testing.T) {
+	sid := MustParseSourceID(`github.com/ot/one,1.3.5`)
+	signer, trusted := testSigningKey(t, "ci")
+	other, _ := testSigningKey(t, "laptop")
+	_, impostor := testSigningKey(t, "ci")
+
+	art := signedArtifact(t, signer, sid, "sha256:abcd")
+	keys := TrustedKeys{trusted}
+
+	assert.NoError(t, keys.Verify(sid, art))
+	assert.Error(t, TrustedKeys{impostor}.Verify(sid, art), "wrong key")
+	assert.Error(t, keys.Verify(MustParseSourceID(`github.com/ot/one,1.3.6`), art), "wrong source")
+	assert.Error(t, keys.Verify(sid, signedArtifact(t, other, sid, "sha256:abcd")), "untrusted key")
+	assert.Error(t, keys.Verify(sid, &BuildArtifact{Name: "docker.example.com/one:1.3.5"}), "unsigned")
+
+	art.Name = "docker.example.com/one@sha256:ef01"
+	assert.Error(t, keys.Verify(sid, art), "wrong digest")
+}
+
+func TestTrustedKeysVerifyRequiresDigest(t *testing.T) {
+	sid := MustParseSourceID(`github.com/ot/one,1.3.5`)
+	signer, trusted := testSigningKey(t, "ci")
+
+	// A tag can be pushed again after it's signed, so a signature is no
+	// good for an artifact named by tag.
+	art := signedArtifact(t, signer, sid, "sha256:abcd")
+	art.Name = "docker.example.com/one:1.3.5"
+	err := TrustedKeys{trusted}.Verify(sid, art)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not named by digest")
+}
+
+func TestGuardImageRequiresSignature(t *testing.T) {
+	sid := MustParseSourceID(`github.com/ot/one,1.3.5`)
+	signer, trusted := testSigningKey(t, "ci")
+	strict := &Cluster{Name: "prod", RequireSignedArtifacts: true}
+	dep := Deployment{ClusterName: "prod", Cluster: strict, SourceID: sid, DeployConfig: DeployConfig{NumInstances: 1}}
+
+	dr := NewDummyRegistry()
+	dr.FeedArtifact(&BuildArtifact{Name: "ot-docker/one", Type: "docker"}, nil)
+	_, err := GuardImage(dr, &dep, TrustedKeys{trusted})
+	assert.IsType(t, &UntrustedArtifactError{}, err)
+
+	dr.FeedArtifact(signedArtifact(t, signer, sid, "sha256:abcd"), nil)
+	art, err := GuardImage(dr, &dep, TrustedKeys{trusted})
+	assert.NoError(t, err)
+	assert.NotNil(t, art)
+}
@@ -0,0 +1,145 @@
+package sous
+
+import "fmt"
+
+// Clone returns a deep copy of s.
+func (s Startup) Clone() Startup {
+	return Startup{
+		CheckReadyURIPath:    cloneString(s.CheckReadyURIPath),
+		CheckReadyURITimeout: cloneInt(s.CheckReadyURITimeout),
+		Timeout:              cloneInt(s.Timeout),
+		CheckReadyInterval:   cloneInt(s.CheckReadyInterval),
+		CheckReadyRetries:    cloneInt(s.CheckReadyRetries),
+		CheckReadyPortIndex:  cloneInt(s.CheckReadyPortIndex),
+		SkipCheckReady:       cloneBool(s.SkipCheckReady),
+	}
+}
+
+// Inherit returns a copy of s, with each option that is not set in s taken
+// from defaults.
+func (s Startup) Inherit(defaults Startup) Startup {
+	c := s.Clone()
+	d := defaults.Clone()
+	if c.CheckReadyURIPath == nil {
+		c.CheckReadyURIPath = d.CheckReadyURIPath
+	}
+	if c.CheckReadyURITimeout == nil {
+		c.CheckReadyURITimeout = d.CheckReadyURITimeout
+	}
+	if c.Timeout == nil {
+		c.Timeout = d.Timeout
+	}
+	if c.CheckReadyInterval == nil {
+		c.CheckReadyInterval = d.CheckReadyInterval
+	}
+	if c.CheckReadyRetries == nil {
+		c.CheckReadyRetries = d.CheckReadyRetries
+	}
+	if c.CheckReadyPortIndex == nil {
+		c.CheckReadyPortIndex = d.CheckReadyPortIndex
+	}
+	if c.SkipCheckReady == nil {
+		c.SkipCheckReady = d.SkipCheckReady
+	}
+	return c
+}
+
+// elideDefaults returns a copy of s without the options that are the same as
+// in defaults, unless they are set in old. It undoes Inherit when writing
+// deployments back to manifests.
+func (s Startup) elideDefaults(defaults, old Startup) Startup {
+	c := s.Clone()
+	if old.CheckReadyURIPath == nil && defaults.CheckReadyURIPath != nil && c.CheckReadyURIPath != nil &&
+		*c.CheckReadyURIPath == *defaults.CheckReadyURIPath {
+		c.CheckReadyURIPath = nil
+	}
+	elideInt := func(v **int, def, old *int) {
+		if old == nil && def != nil && *v != nil && **v == *def {
+			*v = nil
+		}
+	}
+	elideInt(&c.CheckReadyURITimeout, defaults.CheckReadyURITimeout, old.CheckReadyURITimeout)
+	elideInt(&c.Timeout, defaults.Timeout, old.Timeout)
+	elideInt(&c.CheckReadyInterval, defaults.CheckReadyInterval, old.CheckReadyInterval)
+	elideInt(&c.CheckReadyRetries, defaults.CheckReadyRetries, old.CheckReadyRetries)
+	elideInt(&c.CheckReadyPortIndex, defaults.CheckReadyPortIndex, old.CheckReadyPortIndex)
+	if old.SkipCheckReady == nil && defaults.SkipCheckReady != nil && c.SkipCheckReady != nil &&
+		*c.SkipCheckReady == *defaults.SkipCheckReady {
+		c.SkipCheckReady = nil
+	}
+	return c
+}
+
+// diffChecks compares the check options of s and o. Unlike the options above,
+// an unset option is the same as its zero value, since that is what the
+// scheduler reports for options that were not set.
+func (s Startup) diffChecks(o Startup) []string {
+	diffs := []string{}
+	diffInt := func(name string, a, b *int) {
+		if intOrZero(a) != intOrZero(b) {
+			diffs = append(diffs, fmt.Sprintf("%s; this %d, other %d", name, intOrZero(a), intOrZero(b)))
+		}
+	}
+	diffInt("CheckReadyInterval", s.CheckReadyInterval, o.CheckReadyInterval)
+	diffInt("CheckReadyRetries", s.CheckReadyRetries, o.CheckReadyRetries)
+	diffInt("CheckReadyPortIndex", s.CheckReadyPortIndex, o.CheckReadyPortIndex)
+	if boolOrFalse(s.SkipCheckReady) != boolOrFalse(o.SkipCheckReady) {
+		diffs = append(diffs, fmt.Sprintf("SkipCheckReady; this %t, other %t", boolOrFalse(s.SkipCheckReady), boolOrFalse(o.SkipCheckReady)))
+	}
+	return diffs
+}
+
+// validateStartup checks that the startup options of dc are in range.
+func (dc *DeployConfig) validateStartup() []Flaw {
+	var flaws []Flaw
+	s := dc.Startup
+	nonNegative := func(name string, v *int) {
+		if v != nil && *v < 0 {
+			flaws = append(flaws, FatalFlaw("startup option %s is %d, must not be negative", name, *v))
+		}
+	}
+	nonNegative("CheckReadyURITimeout", s.CheckReadyURITimeout)
+	nonNegative("Timeout", s.Timeout)
+	nonNegative("CheckReadyInterval", s.CheckReadyInterval)
+	nonNegative("CheckReadyRetries", s.CheckReadyRetries)
+	nonNegative("CheckReadyPortIndex", s.CheckReadyPortIndex)
+	if i := s.CheckReadyPortIndex; i != nil && dc.Resources != nil && *i >= int(dc.Resources.Ports()) {
+		flaws = append(flaws, FatalFlaw("startup option CheckReadyPortIndex is %d, but only %d ports are resourced", *i, dc.Resources.Ports()))
+	}
+	return flaws
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
+
+func cloneInt(i *int) *int {
+	if i == nil {
+		return nil
+	}
+	c := *i
+	return &c
+}
+
+func cloneBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	return &c
+}
+
+func intOrZero(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func boolOrFalse(b *bool) bool {
+	return b != nil && *b
+}
@@ -0,0 +1,98 @@
+package sous
+
+import (
+	"testing"
+
+	"github.com/samsalisbury/semv"
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(i int) *int { return &i }
+
+func TestStartupInherit(t *testing.T) {
+	path := "/health"
+	skip := true
+	defaults := Startup{
+		CheckReadyURIPath:  &path,
+		CheckReadyInterval: intPtr(5),
+		CheckReadyRetries:  intPtr(3),
+		SkipCheckReady:     &skip,
+	}
+	s := Startup{CheckReadyRetries: intPtr(10)}
+
+	c := s.Inherit(defaults)
+	assert.Equal(t, "/health", *c.CheckReadyURIPath)
+	assert.Equal(t, 5, *c.CheckReadyInterval)
+	assert.Equal(t, 10, *c.CheckReadyRetries)
+	assert.True(t, *c.SkipCheckReady)
+	assert.Nil(t, c.Timeout)
+
+	*c.CheckReadyInterval = 7
+	assert.Equal(t, 5, *defaults.CheckReadyInterval, "Inherit shares defaults")
+
+	e := c.elideDefaults(defaults, Startup{CheckReadyInterval: intPtr(1)})
+	assert.Nil(t, e.CheckReadyURIPath)
+	assert.Equal(t, 7, *e.CheckReadyInterval)
+	assert.Equal(t, 10, *e.CheckReadyRetries)
+	assert.Nil(t, e.SkipCheckReady)
+}
+
+func TestStartupDiffChecks(t *testing.T) {
+	assert.True(t, Startup{}.Equal(Startup{CheckReadyPortIndex: intPtr(0)}))
+	assert.False(t, Startup{}.Equal(Startup{CheckReadyPortIndex: intPtr(1)}))
+	assert.False(t, Startup{CheckReadyInterval: intPtr(5)}.Equal(Startup{CheckReadyInterval: intPtr(6)}))
+	skip := true
+	assert.False(t, Startup{}.Equal(Startup{SkipCheckReady: &skip}))
+}
+
+func TestDeployConfigValidateStartup(t *testing.T) {
+	dc := DeployConfig{
+		Resources: Resources{"cpus": "0.1", "memory": "100", "ports": "2"},
+		Startup:   Startup{CheckReadyPortIndex: intPtr(1), CheckReadyRetries: intPtr(3)},
+	}
+	assert.Empty(t, dc.Validate())
+
+	dc.Startup.CheckReadyPortIndex = intPtr(2)
+	dc.Startup.CheckReadyInterval = intPtr(-1)
+	assert.Len(t, dc.Validate(), 2)
+}
+
+func TestState_DeploymentsInheritClusterStartup(t *testing.T) {
+	defs := makeTestDefs()
+	cluster := cluster1.Clone()
+	cluster.Startup = Startup{CheckReadyInterval: intPtr(5), CheckReadyRetries: intPtr(3)}
+	defs.Clusters["cluster-1"] = cluster
+
+	ms := NewManifests(&Manifest{
+		Source: project1,
+		Deployments: DeploySpecs{
+			"cluster-1": {
+				Version: semv.MustParse("1.0.0"),
+				DeployConfig: DeployConfig{
+					Startup: Startup{CheckReadyRetries: intPtr(10)},
+				},
+			},
+		},
+	})
+	ds, err := ms.Deployments(defs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, ok := ds.Single(func(*Deployment) bool { return true })
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, 5, *d.Startup.CheckReadyInterval)
+	assert.Equal(t, 10, *d.Startup.CheckReadyRetries)
+
+	back, err := ds.PutbackManifests(defs, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, ok := back.Any(func(*Manifest) bool { return true })
+	if assert.True(t, ok) {
+		startup := m.Deployments["cluster-1"].Startup
+		assert.Nil(t, startup.CheckReadyInterval)
+		assert.Equal(t, 10, *startup.CheckReadyRetries)
+	}
+}
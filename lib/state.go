@@ -2,6 +2,7 @@ package sous
 
 import (
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -15,6 +16,9 @@ type (
 		// Manifests contains a mapping of source code repositories to global
 		// deployment configurations for artifacts built using that source code.
 		Manifests Manifests `hy:"manifests/"`
+		// Orphans are the deployments tombstoned by Sous: running, but with no
+		// manifest, and awaiting deletion.
+		Orphans []Orphan `hy:"orphans"`
 	}
 
 	// Defs holds definitions for organisation-level objects.
@@ -30,6 +34,12 @@ type (
 		Resources FieldDefinitions
 		// Metadata contains the definitions for metadata fields
 		Metadata FieldDefinitions
+		// TrustedKeys are the keys whose signatures on build artifacts are
+		// accepted by clusters that require signed artifacts.
+		TrustedKeys TrustedKeys `yaml:",omitempty"`
+		// Admins are the users, by email address or name, who may change any
+		// manifest, whether or not they own it.
+		Admins []string `yaml:",omitempty"`
 	}
 
 	// EnvDefs is a collection of EnvDef
@@ -74,8 +84,29 @@ type (
 		// Env is the default environment for all deployments in this region.
 		Env EnvDefaults
 		// AllowedAdvisories lists the artifact advisories which are permissible in
-		// this cluster
+		// this cluster. Advisories with an entry in AdvisoryPolicies are governed
+		// by that policy instead.
 		AllowedAdvisories []string
+		// AdvisoryPolicies describe how artifact advisories are treated in this
+		// cluster.
+		AdvisoryPolicies AdvisoryPolicies `yaml:",omitempty"`
+		// RequireSignedArtifacts, if true, means that only artifacts signed by
+		// one of Defs.TrustedKeys may be deployed to this cluster.
+		RequireSignedArtifacts bool `yaml:",omitempty"`
+		// EnableOrphanDeletion, if true, means that orphaned deployments in
+		// this cluster (those with no manifest) are scaled down and tombstoned,
+		// then deleted. Otherwise they are left alone.
+		EnableOrphanDeletion bool `yaml:",omitempty"`
+		// OrphanGracePeriod is how long an orphaned deployment stays scaled to
+		// zero before its request is deleted, if EnableOrphanDeletion is set.
+		// If zero, orphans are only deleted by `sous plumbing delete -confirm`.
+		OrphanGracePeriod time.Duration `yaml:",omitempty"`
+		// Startup holds the default startup options for deployments in this
+		// cluster. Options set in a manifest take precedence.
+		Startup Startup `yaml:",omitempty"`
+		// ClientPolicy configures timeouts, retries and circuit breaking for
+		// requests to this cluster's scheduler.
+		ClientPolicy ClientPolicy `yaml:",omitempty"`
 	}
 
 	// EnvDefaults is a list of named environment variables along with their values.
@@ -99,6 +130,7 @@ func NewState() *State {
 func (s State) Clone() *State {
 	s.Manifests = s.Manifests.Clone()
 	s.Defs = s.Defs.Clone()
+	s.Orphans = append([]Orphan(nil), s.Orphans...)
 	return &s
 }
 
@@ -108,6 +140,10 @@ func (d Defs) Clone() Defs {
 	d.EnvVars = d.EnvVars.Clone()
 	d.Resources = d.Resources.Clone()
 	d.Metadata = d.Metadata.Clone()
+	d.TrustedKeys = d.TrustedKeys.Clone()
+	if d.Admins != nil {
+		d.Admins = append([]string{}, d.Admins...)
+	}
 	return d
 }
 
@@ -136,6 +172,8 @@ func (c Cluster) Clone() *Cluster {
 	allowedAdvisories := make([]string, len(c.AllowedAdvisories))
 	copy(allowedAdvisories, c.AllowedAdvisories)
 	c.AllowedAdvisories = allowedAdvisories
+	c.AdvisoryPolicies = c.AdvisoryPolicies.Clone()
+	c.Startup = c.Startup.Clone()
 	return &c
 }
 
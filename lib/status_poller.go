@@ -30,6 +30,8 @@ type (
 	server struct {
 		ClusterName string
 		URL         string
+		// Status is empty when talking to an older server.
+		Status PeerStatus
 	}
 
 	// copied from server
@@ -231,6 +233,11 @@ func (sp *StatusPoller) subPollers(clusters *serverListData, deps Deployments) (
 			Log.Vomit.Printf("%s not requested for polling", s.ClusterName)
 			continue
 		}
+		// skip servers known to have stopped announcing themselves
+		if s.Status == PeerFailed {
+			Log.Warn.Printf("Not polling %s: its server at %s has not been heard from recently", s.ClusterName, s.URL)
+			continue
+		}
 		// skip clusters that there's no current intention of deploying into
 		if _, intended := deps.Single(func(d *Deployment) bool {
 			return d.ClusterName == s.ClusterName
@@ -0,0 +1,29 @@
+package sous
+
+type (
+	// A TaskRunner starts one-off runs of deployed requests, such as those of
+	// on-demand and run-once manifests, and reports on the tasks they start.
+	TaskRunner interface {
+		// Run starts a run of the request reqID in cluster, passing args to the
+		// task's command. It returns an ID for the run.
+		Run(cluster, reqID string, args []string) (runID string, err error)
+		// RunTask returns the ID of the task started for runID, or the empty
+		// string if no task has been started yet.
+		RunTask(cluster, reqID, runID string) (taskID string, err error)
+		// TaskStatus reports the status of the task taskID.
+		TaskStatus(cluster, taskID string) (TaskStatus, error)
+	}
+
+	// TaskStatus is the status of a single task.
+	TaskStatus struct {
+		TaskID string
+		// State is the scheduler's name for the task's state, e.g. TASK_RUNNING.
+		State string
+		// Message is any message from the scheduler about the state.
+		Message string
+		// Done is true once the task has stopped running.
+		Done bool
+		// ExitCode is the exit code of a task which is Done.
+		ExitCode int
+	}
+)
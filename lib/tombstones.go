@@ -0,0 +1,186 @@
+package sous
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Tombstones records orphaned deployments, i.e. those running in a cluster
+	// but no longer described by any manifest, in clusters with
+	// EnableOrphanDeletion set. Orphans are scaled to zero when they are first
+	// tombstoned, and deleted once their cluster's OrphanGracePeriod has
+	// elapsed.
+	//
+	// Tombstones made by NewStateTombstones are kept in the State, so that
+	// restarting Sous doesn't restart the grace period of every orphan.
+	// Changes are only written to the State by Save, which the Resolver calls
+	// once each resolve is complete.
+	//
+	// Owners of orphans are not notified directly: orphans are logged as they
+	// are tombstoned, and listed with their owners by the server's /status.
+	Tombstones struct {
+		sync.RWMutex
+		orphans map[DeploymentID]Orphan
+		// changed is true if orphans has changed since it was last saved.
+		changed bool
+		sm      StateManager
+		user    User
+	}
+
+	// An Orphan is a tombstoned deployment.
+	Orphan struct {
+		DeploymentID
+		// RequestID is the ID of the scheduler request for this deployment.
+		RequestID string
+		// Owners are the owners of the deployment when it was orphaned.
+		Owners []string
+		// Tombstoned is when the orphan was scaled to zero.
+		Tombstoned time.Time
+		// DeleteAfter is when the orphan becomes eligible for deletion. The zero
+		// value means it will only be deleted by explicit confirmation.
+		DeleteAfter time.Time `json:",omitempty"`
+	}
+)
+
+// TombstoneDiff - a deployment was active that wasn't intended at all, and
+// was scaled down pending deletion.
+const TombstoneDiff = ResolutionType("tombstoned")
+
+// NewTombstones returns an empty Tombstones, kept only in memory.
+func NewTombstones() *Tombstones {
+	return &Tombstones{orphans: map[DeploymentID]Orphan{}}
+}
+
+// NewStateTombstones returns the Tombstones recorded in state. Save writes
+// changes to them back to the State managed by sm, as user.
+func NewStateTombstones(state *State, sm StateManager, user User) *Tombstones {
+	ts := NewTombstones()
+	for _, o := range state.Orphans {
+		ts.orphans[o.DeploymentID] = o
+	}
+	ts.sm, ts.user = sm, user
+	return ts
+}
+
+// Save writes the tombstones to the State, if they are kept there and have
+// changed since they were last saved. They aren't locked while the State is
+// written, so rectification isn't held up by it.
+func (ts *Tombstones) Save() error {
+	if ts == nil || ts.sm == nil {
+		return nil
+	}
+	ts.Lock()
+	if !ts.changed {
+		ts.Unlock()
+		return nil
+	}
+	orphans := ts.snapshot()
+	ts.changed = false
+	ts.Unlock()
+
+	state, err := ts.sm.ReadState()
+	if err == nil {
+		state.Orphans = orphans
+		err = ts.sm.WriteState(state, ts.user)
+	}
+	if err != nil {
+		ts.Lock()
+		ts.changed = true
+		ts.Unlock()
+		return errors.Wrapf(err, "recording tombstones")
+	}
+	return nil
+}
+
+// Mark tombstones the deployment d, with request ID reqID, at now. If d is
+// already tombstoned, the existing Orphan is returned along with false.
+func (ts *Tombstones) Mark(d *Deployment, reqID string, now time.Time) (Orphan, bool) {
+	ts.Lock()
+	defer ts.Unlock()
+	if o, ok := ts.orphans[d.ID()]; ok {
+		return o, false
+	}
+	o := Orphan{
+		DeploymentID: d.ID(),
+		RequestID:    reqID,
+		Owners:       d.Owners.Slice(),
+		Tombstoned:   now,
+	}
+	if d.Cluster != nil && d.Cluster.OrphanGracePeriod > 0 {
+		o.DeleteAfter = now.Add(d.Cluster.OrphanGracePeriod)
+	}
+	ts.orphans[o.DeploymentID] = o
+	ts.changed = true
+	return o, true
+}
+
+// Get returns the Orphan for id, and true, or false if id is not tombstoned.
+func (ts *Tombstones) Get(id DeploymentID) (Orphan, bool) {
+	ts.RLock()
+	defer ts.RUnlock()
+	o, ok := ts.orphans[id]
+	return o, ok
+}
+
+// Remove forgets the tombstone for id, if there is one.
+func (ts *Tombstones) Remove(id DeploymentID) {
+	ts.Lock()
+	defer ts.Unlock()
+	if _, ok := ts.orphans[id]; !ok {
+		return
+	}
+	delete(ts.orphans, id)
+	ts.changed = true
+}
+
+// Prune forgets the tombstones in cluster of deployments that are not
+// running, because they were deleted by other means. Tombstones in other
+// clusters are kept, so that a cluster which can't be reached doesn't lose
+// its tombstones.
+func (ts *Tombstones) Prune(cluster string, running map[DeploymentID]struct{}) {
+	ts.Lock()
+	defer ts.Unlock()
+	for id := range ts.orphans {
+		if id.Cluster != cluster {
+			continue
+		}
+		if _, ok := running[id]; !ok {
+			delete(ts.orphans, id)
+			ts.changed = true
+		}
+	}
+}
+
+// Snapshot returns all the current orphans, oldest first.
+func (ts *Tombstones) Snapshot() []Orphan {
+	ts.RLock()
+	defer ts.RUnlock()
+	return ts.snapshot()
+}
+
+func (ts *Tombstones) snapshot() []Orphan {
+	os := make([]Orphan, 0, len(ts.orphans))
+	for _, o := range ts.orphans {
+		os = append(os, o)
+	}
+	sort.Slice(os, func(i, j int) bool {
+		a, b := os[i], os[j]
+		if !a.Tombstoned.Equal(b.Tombstoned) {
+			return a.Tombstoned.Before(b.Tombstoned)
+		}
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		return a.ManifestID.String() < b.ManifestID.String()
+	})
+	return os
+}
+
+// Expired returns true if o is eligible for deletion at now.
+func (o Orphan) Expired(now time.Time) bool {
+	return !o.DeleteAfter.IsZero() && !now.Before(o.DeleteAfter)
+}
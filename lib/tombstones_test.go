@@ -0,0 +1,111 @@
+package sous
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTombstones(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	ts := NewTombstones()
+
+	forever := &Deployment{
+		ClusterName: "forever",
+		Cluster:     &Cluster{Name: "forever"},
+		Owners:      NewOwnerSet("b@example.com", "a@example.com"),
+	}
+	hourly := &Deployment{
+		ClusterName: "hourly",
+		Cluster:     &Cluster{Name: "hourly", OrphanGracePeriod: time.Hour},
+	}
+
+	o, isNew := ts.Mark(forever, "forever-req", now)
+	assert.True(isNew)
+	assert.Equal([]string{"a@example.com", "b@example.com"}, o.Owners)
+	assert.True(o.DeleteAfter.IsZero())
+	assert.False(o.Expired(now.Add(24 * 365 * time.Hour)))
+
+	o, isNew = ts.Mark(hourly, "hourly-req", now.Add(time.Minute))
+	assert.True(isNew)
+	assert.False(o.Expired(now.Add(time.Hour)))
+	assert.True(o.Expired(now.Add(time.Hour + time.Minute)))
+
+	o, isNew = ts.Mark(forever, "forever-req", now.Add(time.Hour))
+	assert.False(isNew)
+	assert.Equal(now, o.Tombstoned)
+
+	snap := ts.Snapshot()
+	if assert.Len(snap, 2) {
+		assert.Equal("forever-req", snap[0].RequestID)
+		assert.Equal("hourly-req", snap[1].RequestID)
+	}
+
+	ts.Prune("forever", map[DeploymentID]struct{}{})
+	_, ok := ts.Get(forever.ID())
+	assert.False(ok, "not running, so no longer an orphan")
+	_, ok = ts.Get(hourly.ID())
+	assert.True(ok, "other clusters' tombstones are kept")
+	ts.Remove(hourly.ID())
+	assert.Len(ts.Snapshot(), 0)
+}
+
+func TestStateTombstones(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	sm := &DummyStateManager{State: NewState()}
+	ts := NewStateTombstones(sm.State, sm, User{})
+
+	d := &Deployment{ClusterName: "hourly", Cluster: &Cluster{Name: "hourly", OrphanGracePeriod: time.Hour}}
+	other := &Deployment{ClusterName: "forever", Cluster: &Cluster{Name: "forever"}}
+	ts.Mark(d, "hourly-req", now)
+	ts.Mark(other, "forever-req", now)
+	assert.Len(sm.State.Orphans, 0, "changes are only written by Save")
+	assert.NoError(ts.Save())
+	assert.Equal(1, sm.WriteCount, "all the changes are written at once")
+	if assert.Len(sm.State.Orphans, 2) {
+		assert.Equal("forever-req", sm.State.Orphans[0].RequestID)
+		assert.Equal("hourly-req", sm.State.Orphans[1].RequestID)
+	}
+
+	// A restarted Sous keeps the grace period already begun.
+	again := NewStateTombstones(sm.State, sm, User{})
+	o, isNew := again.Mark(d, "hourly-req", now.Add(time.Minute))
+	assert.False(isNew)
+	assert.Equal(now.Add(time.Hour), o.DeleteAfter)
+
+	writes := sm.WriteCount
+	again.Prune("elsewhere", map[DeploymentID]struct{}{})
+	assert.NoError(again.Save())
+	assert.Equal(writes, sm.WriteCount, "nothing changed, so nothing is written")
+	again.Remove(d.ID())
+	again.Remove(other.ID())
+	assert.NoError(again.Save())
+	assert.Len(sm.State.Orphans, 0)
+}
+
+type failingStateManager struct{ DummyStateManager }
+
+func (sm *failingStateManager) WriteState(*State, User) error {
+	return errors.New("push rejected")
+}
+
+func TestStateTombstones_SaveFails(t *testing.T) {
+	assert := assert.New(t)
+
+	sm := &failingStateManager{DummyStateManager{State: NewState()}}
+	ts := NewStateTombstones(sm.State, sm, User{})
+	ts.Mark(&Deployment{ClusterName: "c", Cluster: &Cluster{Name: "c"}}, "req", time.Now())
+
+	assert.Error(ts.Save())
+	sm.State.Orphans = nil
+	ok := &DummyStateManager{State: sm.State}
+	ts.sm = ok
+	assert.NoError(ts.Save())
+	assert.Len(ok.State.Orphans, 1, "tombstones which failed to save are saved next time")
+}
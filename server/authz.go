@@ -0,0 +1,83 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
+)
+
+type (
+	// An Authorizer decides which manifests the user making a request may
+	// change. Only users the server has authenticated are restricted: when
+	// authentication is not configured, the user is whoever the client
+	// claims to be, and ownership cannot be checked.
+	Authorizer struct {
+		User          ClientUser
+		authenticated bool
+		log           *sous.LogSet
+	}
+
+	// ForbiddenError is returned when a user tries to change a manifest they
+	// may not.
+	ForbiddenError struct {
+		User       sous.User
+		ManifestID sous.ManifestID
+	}
+)
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("%s may not change manifest %q: only its owners and admins may", e.User, e.ManifestID)
+}
+
+func newAuthorizer(req *http.Request, user ClientUser, log *sous.LogSet) *Authorizer {
+	_, authenticated := restful.PrincipalFrom(req)
+	return &Authorizer{User: user, authenticated: authenticated, log: log}
+}
+
+// MayChange returns a *ForbiddenError if the user may not change the manifest
+// mid from before to after. before is nil for a new manifest, and after is nil
+// when it is being removed. Owners of the current manifest may change it, and
+// new manifests may be added by the owners they list; admins may do anything.
+func (a *Authorizer) MayChange(defs sous.Defs, mid sous.ManifestID, before, after *sous.Manifest) error {
+	if a == nil || !a.authenticated {
+		return nil
+	}
+	user := sous.User(a.User)
+	current := before
+	if current == nil {
+		current = after
+	}
+	if current == nil || defs.MayChange(user, current) {
+		return nil
+	}
+	a.log.Warn.Printf("Denied %s changing manifest %q", user, mid)
+	return &ForbiddenError{User: user, ManifestID: mid}
+}
+
+// MayChangeAll returns a *ForbiddenError for the first manifest changed from
+// before to after that the user may not change.
+func (a *Authorizer) MayChangeAll(defs sous.Defs, before, after sous.Manifests) error {
+	olds, news := before.Snapshot(), after.Snapshot()
+	for mid, old := range olds {
+		m, kept := news[mid]
+		if kept {
+			if changed, _ := old.Diff(m); !changed {
+				continue
+			}
+		}
+		if err := a.MayChange(defs, mid, old, m); err != nil {
+			return err
+		}
+	}
+	for mid, m := range news {
+		if _, existed := olds[mid]; existed {
+			continue
+		}
+		if err := a.MayChange(defs, mid, nil, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
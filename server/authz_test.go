@@ -0,0 +1,90 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func authorizerFor(email string) *Authorizer {
+	return &Authorizer{User: ClientUser{Email: email}, authenticated: true, log: &sous.Log}
+}
+
+func TestAuthorizer_MayChange(t *testing.T) {
+	defs := sous.Defs{Admins: []string{"admin@example.com"}}
+	mid := sous.ManifestID{Source: sous.SourceLocation{Repo: "gh"}}
+	owned := &sous.Manifest{Owners: []string{"owner@example.com"}}
+	stolen := &sous.Manifest{Owners: []string{"thief@example.com"}}
+
+	assert.NoError(t, authorizerFor("owner@example.com").MayChange(defs, mid, owned, stolen))
+	assert.NoError(t, authorizerFor("admin@example.com").MayChange(defs, mid, owned, nil))
+	assert.NoError(t, authorizerFor("thief@example.com").MayChange(defs, mid, nil, stolen))
+
+	err := authorizerFor("thief@example.com").MayChange(defs, mid, owned, stolen)
+	assert.IsType(t, &ForbiddenError{}, err)
+
+	// Without authentication, nothing is checked.
+	unauthenticated := &Authorizer{User: ClientUser{Email: "thief@example.com"}}
+	assert.NoError(t, unauthenticated.MayChange(defs, mid, owned, stolen))
+}
+
+func TestAuthorizer_MayChangeAll(t *testing.T) {
+	defs := sous.Defs{}
+	mine := &sous.Manifest{Source: sous.SourceLocation{Repo: "mine"}, Owners: []string{"me@example.com"}}
+	theirs := &sous.Manifest{Source: sous.SourceLocation{Repo: "theirs"}, Owners: []string{"them@example.com"}}
+	before := sous.NewManifests(mine, theirs)
+	a := authorizerFor("me@example.com")
+
+	changed := mine.Clone()
+	changed.Kind = sous.ManifestKindScheduled
+	assert.NoError(t, a.MayChangeAll(defs, before, sous.NewManifests(changed, theirs)))
+
+	assert.Error(t, a.MayChangeAll(defs, before, sous.NewManifests(mine)))
+
+	changed = theirs.Clone()
+	changed.Kind = sous.ManifestKindScheduled
+	assert.Error(t, a.MayChangeAll(defs, before, sous.NewManifests(mine, changed)))
+}
+
+func TestHandlesManifestPut_Forbidden(t *testing.T) {
+	q, err := url.ParseQuery("repo=gh")
+	require.NoError(t, err)
+	state := sous.NewState()
+	state.Manifests.Add(&sous.Manifest{
+		Source: sous.SourceLocation{Repo: "gh"},
+		Owners: []string{"owner@example.com"},
+		Kind:   sous.ManifestKindService,
+	})
+	writer := graph.StateWriter{StateWriter: &sous.DummyStateManager{State: state}}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, json.NewEncoder(buf).Encode(&sous.Manifest{
+		Source: sous.SourceLocation{Repo: "gh"},
+		Owners: []string{"thief@example.com"},
+		Kind:   sous.ManifestKindService,
+	}))
+	req, err := http.NewRequest("PUT", "", buf)
+	require.NoError(t, err)
+
+	th := &PUTManifestHandler{
+		Request:     req,
+		StateWriter: writer,
+		State:       state,
+		QueryValues: &restful.QueryValues{Values: q},
+		LogSet:      &sous.Log,
+		Authorizer:  authorizerFor("thief@example.com"),
+	}
+	_, status := th.Exchange()
+	assert.Equal(t, http.StatusForbidden, status)
+
+	m, _ := state.Manifests.Get(sous.ManifestID{Source: sous.SourceLocation{Repo: "gh"}})
+	assert.Equal(t, []string{"owner@example.com"}, m.Owners)
+}
@@ -17,6 +17,7 @@ type (
 func AddsPerRequest(g restful.Injector) {
 	g.Add(liveGDM)
 	g.Add(getUser)
+	g.Add(newAuthorizer)
 }
 
 func liveGDM(sr graph.StateReader) (*LiveGDM, error) {
@@ -11,18 +11,130 @@ import (
 )
 
 type (
+	// ArtifactResource describes the artifacts built from each source ID.
 	ArtifactResource struct{}
 
-	PUTArtifactHandler struct {
+	// POSTArtifactHandler records an artifact built by a client, along with
+	// its qualities, such as its signatures, in the server's name cache.
+	POSTArtifactHandler struct {
 		*http.Request
 		*restful.QueryValues
 		sous.Inserter
 	}
+
+	// GETArtifactHandler looks up an artifact in the server's registry,
+	// either by source ID (repo, offset and version) or by image name.
+	GETArtifactHandler struct {
+		*restful.QueryValues
+		sous.Registry
+	}
+
+	// ArtifactListResource lists the source IDs known to the server's
+	// registry.
+	ArtifactListResource struct{}
+
+	// GETArtifactListHandler handles GET exchanges for the artifact list.
+	GETArtifactListHandler struct {
+		sous.Registry
+	}
+
+	artifactWrapper struct {
+		SourceID sous.SourceID
+		Artifact *sous.BuildArtifact
+	}
+
+	artifactListWrapper struct {
+		SourceIDs []sous.SourceID
+	}
 )
 
-func (ar *ArtifactResource) Put() *PUTArtifactHandler { return &PUTArtifactHandler{} }
+// Post implements Postable on ArtifactResource. Artifacts are recorded by
+// POST, rather than PUT, because recording an artifact adds its qualities to
+// any already known, rather than replacing them.
+func (ar *ArtifactResource) Post() restful.Exchanger { return &POSTArtifactHandler{} }
+
+// Get implements Getable on ArtifactResource.
+func (ar *ArtifactResource) Get() restful.Exchanger { return &GETArtifactHandler{} }
+
+// Get implements Getable on ArtifactListResource.
+func (alr *ArtifactListResource) Get() restful.Exchanger { return &GETArtifactListHandler{} }
+
+// sourceIDParameters are the query parameters naming a source ID, read by
+// sourceIDFromValues.
+var sourceIDParameters = []restful.QueryParameter{
+	{Name: "repo", Description: "the source repository", Required: true},
+	{Name: "offset", Description: "the directory within the repository"},
+	{Name: "version", Description: "the semantic version of the source"},
+}
+
+// QueryParameters implements restful.QueryParameterizer on GETArtifactHandler.
+func (*GETArtifactHandler) QueryParameters() []restful.QueryParameter {
+	ps := []restful.QueryParameter{}
+	for _, p := range sourceIDParameters {
+		// repo is only required when the artifact is not named.
+		p.Required = false
+		ps = append(ps, p)
+	}
+	return append(ps,
+		restful.QueryParameter{Name: "flavor", Description: "the flavor the artifact was built for, with a source ID"},
+		restful.QueryParameter{Name: "name", Description: "the image name, instead of a source ID"})
+}
+
+// QueryParameters implements restful.QueryParameterizer on POSTArtifactHandler.
+func (*POSTArtifactHandler) QueryParameters() []restful.QueryParameter { return sourceIDParameters }
+
+// RequestType implements restful.RequestTyper on POSTArtifactHandler.
+func (*POSTArtifactHandler) RequestType() interface{} { return sous.BuildArtifact{} }
+
+// ResponseType implements restful.ResponseTyper on GETArtifactHandler.
+func (*GETArtifactHandler) ResponseType() interface{} { return artifactWrapper{} }
+
+// ResponseType implements restful.ResponseTyper on GETArtifactListHandler.
+func (*GETArtifactListHandler) ResponseType() interface{} { return artifactListWrapper{} }
+
+// Exchange implements restful.Exchanger on GETArtifactHandler.
+func (gah *GETArtifactHandler) Exchange() (interface{}, int) {
+	if name := gah.QueryValues.Get("name"); name != "" {
+		sid, err := gah.Registry.GetSourceID(sous.NewBuildArtifact(name, nil))
+		if err != nil {
+			return err.Error(), http.StatusNotFound
+		}
+		ba, err := gah.Registry.GetArtifact(sid)
+		if err != nil {
+			ba = sous.NewBuildArtifact(name, nil)
+		}
+		return artifactWrapper{SourceID: sid, Artifact: ba}, http.StatusOK
+	}
+
+	sid, err := sourceIDFromValues(gah.QueryValues)
+	if err != nil {
+		return err.Error(), http.StatusBadRequest
+	}
+	flavor, err := gah.QueryValues.Single("flavor", "")
+	if err != nil {
+		return err.Error(), http.StatusBadRequest
+	}
+	ba, err := gah.Registry.GetFlavoredArtifact(sid, flavor)
+	if err != nil {
+		return err.Error(), http.StatusNotFound
+	}
+	return artifactWrapper{SourceID: sid, Artifact: ba}, http.StatusOK
+}
+
+// Exchange implements restful.Exchanger on GETArtifactListHandler.
+func (galh *GETArtifactListHandler) Exchange() (interface{}, int) {
+	sids, err := galh.Registry.ListSourceIDs()
+	if err != nil {
+		return err.Error(), http.StatusInternalServerError
+	}
+	if sids == nil {
+		sids = []sous.SourceID{}
+	}
+	return artifactListWrapper{SourceIDs: sids}, http.StatusOK
+}
 
-func (pah *PUTArtifactHandler) Exchange() (interface{}, int) {
+// Exchange implements restful.Exchanger on POSTArtifactHandler.
+func (pah *POSTArtifactHandler) Exchange() (interface{}, int) {
 	ba := sous.BuildArtifact{}
 	dec := json.NewDecoder(pah.Request.Body)
 	err := dec.Decode(&ba)
@@ -40,7 +152,7 @@ func (pah *PUTArtifactHandler) Exchange() (interface{}, int) {
 		return err, http.StatusNotAcceptable
 	}
 
-	return "", http.StatusOK
+	return nil, http.StatusNoContent
 }
 
 func sourceIDFromValues(qv *restful.QueryValues) (sous.SourceID, error) {
@@ -2,13 +2,25 @@ package server
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
+	"github.com/opentable/sous/ext/docker"
 	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/docker_registry"
 	"github.com/opentable/sous/util/restful"
+	"github.com/opentable/sous/util/shell"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type artifactTestInserter struct {
@@ -19,12 +31,12 @@ func (ati *artifactTestInserter) Insert(sid sous.SourceID, in, etag string, qs [
 	return ati.insFunc(sid, in, etag, qs)
 }
 
-func TestPUTArtifact(t *testing.T) {
+func TestPOSTArtifact(t *testing.T) {
 	art := sous.NewBuildArtifact("test.reg.com/repo/test", sous.Strpairs{})
 	buf := &bytes.Buffer{}
 	enc := json.NewEncoder(buf)
 	enc.Encode(art)
-	req, err := http.NewRequest("PUT", "", buf)
+	req, err := http.NewRequest("POST", "", buf)
 	if err != nil {
 		t.Fatal("error building request", err)
 	}
@@ -37,7 +49,7 @@ func TestPUTArtifact(t *testing.T) {
 	var inSid sous.SourceID
 	var inName string
 
-	pah := &PUTArtifactHandler{
+	pah := &POSTArtifactHandler{
 		Request:     req,
 		QueryValues: &restful.QueryValues{q},
 		Inserter: &artifactTestInserter{
@@ -50,8 +62,8 @@ func TestPUTArtifact(t *testing.T) {
 	}
 
 	_, status := pah.Exchange()
-	if status != 200 {
-		t.Errorf("status should be 200, was %d", status)
+	if status != 204 {
+		t.Errorf("status should be 204, was %d", status)
 	}
 	if inSid.Location.Repo != "github.com/opentable/test" {
 		t.Errorf("inserted SID repo was %s, should be github.com/opentable/test", inSid.Location.Repo)
@@ -60,3 +72,132 @@ func TestPUTArtifact(t *testing.T) {
 		t.Errorf("inserted artifact name was %s, should be test.reg.com/repo/test", inName)
 	}
 }
+
+func TestGETArtifact(t *testing.T) {
+	sid := sous.MustNewSourceID("github.com/opentable/test", "", "1.2.3")
+	reg := sous.NewDummyRegistry()
+
+	q, _ := url.ParseQuery("repo=github.com/opentable/test&version=1.2.3")
+	reg.FeedArtifact(sous.NewBuildArtifact("test.reg.com/repo/test@sha256:abc", nil), nil)
+	data, status := (&GETArtifactHandler{QueryValues: &restful.QueryValues{Values: q}, Registry: reg}).Exchange()
+	if status != http.StatusOK {
+		t.Fatalf("status should be 200, was %d", status)
+	}
+	if name := data.(artifactWrapper).Artifact.Name; name != "test.reg.com/repo/test@sha256:abc" {
+		t.Errorf("artifact name was %s", name)
+	}
+
+	q, _ = url.ParseQuery("name=test.reg.com/repo/test:1.2.3")
+	reg.FeedSourceID(sid, nil)
+	data, status = (&GETArtifactHandler{QueryValues: &restful.QueryValues{Values: q}, Registry: reg}).Exchange()
+	if status != http.StatusOK {
+		t.Fatalf("status should be 200, was %d", status)
+	}
+	if got := data.(artifactWrapper).SourceID; !got.Equal(sid) {
+		t.Errorf("source ID was %v, should be %v", got, sid)
+	}
+
+	q, _ = url.ParseQuery("name=test.reg.com/repo/unknown:1.2.3")
+	reg.FeedSourceID(sous.SourceID{}, errors.New("no such image"))
+	_, status = (&GETArtifactHandler{QueryValues: &restful.QueryValues{Values: q}, Registry: reg}).Exchange()
+	if status != http.StatusNotFound {
+		t.Errorf("status should be 404, was %d", status)
+	}
+
+	q, _ = url.ParseQuery("repo=github.com/opentable/test")
+	_, status = (&GETArtifactHandler{QueryValues: &restful.QueryValues{Values: q}, Registry: reg}).Exchange()
+	if status != http.StatusBadRequest {
+		t.Errorf("status should be 400, was %d", status)
+	}
+}
+
+func TestGETArtifactList(t *testing.T) {
+	sid := sous.MustNewSourceID("github.com/opentable/test", "", "1.2.3")
+	reg := sous.NewDummyRegistry()
+	reg.FeedSourceIDList([]sous.SourceID{sid}, nil)
+	data, status := (&GETArtifactListHandler{Registry: reg}).Exchange()
+	if status != http.StatusOK {
+		t.Fatalf("status should be 200, was %d", status)
+	}
+	if sids := data.(artifactListWrapper).SourceIDs; len(sids) != 1 || !sids[0].Equal(sid) {
+		t.Errorf("listed %v", sids)
+	}
+}
+
+func testArtifactSigner(t *testing.T, id string) (*sous.ArtifactSigner, sous.TrustedKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	priv, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	signer, err := sous.NewArtifactSigner(id, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: priv}))
+	require.NoError(t, err)
+	return signer, sous.TrustedKey{ID: id, PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))}
+}
+
+// A client built and signed image must be deployable by the server to a
+// cluster which requires signed artifacts.
+func TestSignedBuildPassesServerGuardImage(t *testing.T) {
+	const digest = "sha256:012345678901234567890123456789AB012345678901234567890123456789AB"
+
+	dc := docker_registry.NewDummyClient()
+	dc.AddMetadata(`test:`, docker_registry.Metadata{CanonicalName: "test@" + digest})
+	db, err := docker.GetDatabase(&docker.DBConfig{Driver: "sqlite3_sous", Connection: docker.InMemoryConnection("signed_build")})
+	require.NoError(t, err)
+	nc, err := docker.NewNameCache("docker.example.com", dc, db)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "POST", r.Method)
+		_, status := (&POSTArtifactHandler{
+			Request:     r,
+			QueryValues: &restful.QueryValues{Values: r.URL.Query()},
+			Inserter:    nc,
+		}).Exchange()
+		rw.WriteHeader(status)
+	}))
+	defer srv.Close()
+	cl, err := restful.NewClient(srv.URL, sous.SilentLogSet())
+	require.NoError(t, err)
+
+	srcSh, err := shell.NewTestShell("/src", map[string]string{})
+	require.NoError(t, err)
+	srcSh.CmdsF = func(name string, args []interface{}) *shell.DummyResult {
+		if name == "docker" && args[0] == "inspect" {
+			return &shell.DummyResult{SO: []byte("docker.example.com/test@" + digest + "\n")}
+		}
+		return nil
+	}
+	scratchSh, err := shell.NewTestShell("/tmp", map[string]string{"/tmp/__exists__": ""})
+	require.NoError(t, err)
+
+	b, err := docker.NewBuilder(sous.NewHTTPNameInserter(cl), "docker.example.com", srcSh, scratchSh)
+	require.NoError(t, err)
+	signer, trusted := testArtifactSigner(t, "ci")
+	b.Signer = signer
+
+	bc := &sous.BuildContext{Source: sous.SourceContext{
+		RemoteURL:  "github.com/opentable/test",
+		Revision:   "abcd",
+		NearestTag: sous.Tag{Name: "1.2.3", Revision: "abcd"},
+	}}
+	br := &sous.BuildResult{ImageID: "identifier"}
+	require.NoError(t, b.ApplyMetadata(br, bc))
+	require.NoError(t, b.Register(br, bc))
+
+	strict := &sous.Cluster{Name: "prod", RequireSignedArtifacts: true}
+	dep := &sous.Deployment{
+		ClusterName:  "prod",
+		Cluster:      strict,
+		SourceID:     bc.Version(),
+		DeployConfig: sous.DeployConfig{NumInstances: 1},
+	}
+	art, err := sous.GuardImage(nc, dep, sous.TrustedKeys{trusted})
+	require.NoError(t, err)
+	assert.Equal(t, "docker.example.com/test@"+digest, art.Name)
+
+	_, err = sous.GuardImage(nc, dep, sous.TrustedKeys{})
+	assert.IsType(t, &sous.UntrustedArtifactError{}, err)
+}
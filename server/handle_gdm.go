@@ -27,6 +27,7 @@ type (
 		GDM          *LiveGDM
 		StateManager *graph.StateManager
 		User         ClientUser
+		Authorizer   *Authorizer
 	}
 
 	gdmWrapper struct {
@@ -55,6 +56,12 @@ func (h *GETGDMHandler) Exchange() (interface{}, int) {
 	return data, http.StatusOK
 }
 
+// ResponseType implements restful.ResponseTyper on GETGDMHandler.
+func (*GETGDMHandler) ResponseType() interface{} { return gdmWrapper{} }
+
+// RequestType implements restful.RequestTyper on PUTGDMHandler.
+func (*PUTGDMHandler) RequestType() interface{} { return gdmWrapper{} }
+
 // Put implements Putable on GDMResource
 func (gr *GDMResource) Put() restful.Exchanger { return &PUTGDMHandler{} }
 
@@ -73,11 +80,15 @@ func (h *PUTGDMHandler) Exchange() (interface{}, int) {
 		return "Error loading state from storage", http.StatusInternalServerError
 	}
 
-	state.Manifests, err = deps.PutbackManifests(state.Defs, state.Manifests)
+	manifests, err := deps.PutbackManifests(state.Defs, state.Manifests)
 	if err != nil {
 		h.Warn.Printf("%#v", err)
 		return "Error getting state", http.StatusConflict
 	}
+	if err := h.Authorizer.MayChangeAll(state.Defs, state.Manifests, manifests); err != nil {
+		return err.Error(), http.StatusForbidden
+	}
+	state.Manifests = manifests
 
 	flaws := state.Validate()
 	if len(flaws) > 0 {
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/ext/singularity"
+	"github.com/opentable/sous/graph"
+	sous "github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// HealthResource dispatches /health, which reports that the server
+	// process is up.
+	HealthResource struct{}
+
+	// HealthHandler handles GET for /health.
+	HealthHandler struct{}
+
+	// ReadyResource dispatches /ready, which reports whether the server can
+	// do its work.
+	ReadyResource struct{}
+
+	// ReadyHandler handles GET for /ready.
+	ReadyHandler struct {
+		StateManager *graph.StateManager
+		AutoResolver *sous.AutoResolver
+	}
+
+	// DiagnosticsResource dispatches /diagnostics, which describes the
+	// server for operators.
+	DiagnosticsResource struct{}
+
+	// DiagnosticsHandler handles GET for /diagnostics.
+	DiagnosticsHandler struct {
+		Config       *config.Config
+		StateManager *graph.StateManager
+		AutoResolver *sous.AutoResolver
+		Version      graph.Version
+	}
+
+	healthData struct {
+		Status string
+	}
+
+	readyData struct {
+		Ready  bool
+		Checks []readyCheck
+	}
+
+	// readyCheck is the result of checking one thing the server needs.
+	readyCheck struct {
+		Name   string
+		OK     bool
+		Detail string `json:",omitempty"`
+	}
+
+	diagnosticsData struct {
+		Version string
+		// GDMRevision is the commit of the GDM repository, if it is one.
+		GDMRevision string `json:",omitempty"`
+		// LastResolved is when each cluster was last resolved without errors.
+		LastResolved map[string]time.Time
+		// DeploymentCache reports how effective the cache of deployments read
+		// from Singularity has been, if the deployer has one.
+		DeploymentCache *deploymentCacheData `json:",omitempty"`
+		// Config is the server's configuration, with its secrets redacted.
+		Config config.Config
+	}
+
+	deploymentCacheData struct {
+		singularity.DeploymentCacheStats
+		HitRate float64
+	}
+
+	deploymentCacher interface {
+		DeploymentCacheStats() singularity.DeploymentCacheStats
+	}
+
+	pinger interface {
+		Ping() error
+	}
+
+	revisioner interface {
+		Revision() (string, error)
+	}
+)
+
+// Get implements Getable on HealthResource.
+func (*HealthResource) Get() restful.Exchanger { return &HealthHandler{} }
+
+// Get implements Getable on ReadyResource.
+func (*ReadyResource) Get() restful.Exchanger { return &ReadyHandler{} }
+
+// Get implements Getable on DiagnosticsResource.
+func (*DiagnosticsResource) Get() restful.Exchanger { return &DiagnosticsHandler{} }
+
+// ResponseType implements restful.ResponseTyper on HealthHandler.
+func (*HealthHandler) ResponseType() interface{} { return healthData{} }
+
+// ResponseType implements restful.ResponseTyper on ReadyHandler.
+func (*ReadyHandler) ResponseType() interface{} { return readyData{} }
+
+// ResponseType implements restful.ResponseTyper on DiagnosticsHandler.
+func (*DiagnosticsHandler) ResponseType() interface{} { return diagnosticsData{} }
+
+// Exchange implements restful.Exchanger on HealthHandler.
+func (*HealthHandler) Exchange() (interface{}, int) {
+	return healthData{Status: "ok"}, http.StatusOK
+}
+
+// resolveSlack is how much longer than twice its UpdateTime an AutoResolver
+// may go without completing a resolve before the server stops being ready.
+const resolveSlack = 10 * time.Minute
+
+// Exchange implements restful.Exchanger on ReadyHandler. The server is ready
+// once it can read the GDM, reach its name cache, and has recently reached
+// each of the clusters it resolves.
+func (h *ReadyHandler) Exchange() (interface{}, int) {
+	data := checkReady(h.StateManager, h.AutoResolver)
+	if !data.Ready {
+		return data, http.StatusServiceUnavailable
+	}
+	return data, http.StatusOK
+}
+
+// NotReady returns why a server which reads the GDM from sm and resolves with
+// ar is not ready, as /ready reports it, or nil if it is ready.
+func NotReady(sm *graph.StateManager, ar *sous.AutoResolver) error {
+	data := checkReady(sm, ar)
+	if data.Ready {
+		return nil
+	}
+	var failed []string
+	for _, c := range data.Checks {
+		if !c.OK {
+			failed = append(failed, c.Name+": "+c.Detail)
+		}
+	}
+	return errors.New(strings.Join(failed, "; "))
+}
+
+func checkReady(sm *graph.StateManager, ar *sous.AutoResolver) readyData {
+	data := readyData{Ready: true}
+	check := func(name string, err error) {
+		c := readyCheck{Name: name, OK: err == nil}
+		if err != nil {
+			c.Detail = err.Error()
+			data.Ready = false
+		}
+		data.Checks = append(data.Checks, c)
+	}
+
+	state, err := sm.ReadState()
+	if err == nil {
+		_, err = state.Deployments()
+	}
+	check("gdm", err)
+
+	if p, ok := resolverRegistry(ar).(pinger); ok {
+		check("namecache", p.Ping())
+	}
+
+	if state != nil {
+		maxAge := 2*ar.UpdateTime + resolveSlack
+		stable, staleErr := ar.StableStatus(maxAge, time.Now())
+		for _, name := range resolvedClusterNames(ar, state.Defs.Clusters) {
+			switch {
+			case staleErr != nil:
+				check("cluster "+name, staleErr)
+			case stable.UnreachableClusters[name] != "":
+				check("cluster "+name, readyError(stable.UnreachableClusters[name]))
+			default:
+				check("cluster "+name, nil)
+			}
+		}
+	}
+	return data
+}
+
+type readyError string
+
+func (e readyError) Error() string { return string(e) }
+
+func resolverRegistry(ar *sous.AutoResolver) sous.Registry {
+	if ar == nil || ar.Resolver == nil {
+		return nil
+	}
+	return ar.Resolver.Registry
+}
+
+// resolvedClusterNames returns the names of the clusters ar resolves, in
+// order.
+func resolvedClusterNames(ar *sous.AutoResolver, clusters sous.Clusters) []string {
+	if r := ar.Resolver; r != nil && r.ResolveFilter != nil {
+		clusters = r.FilteredClusters(clusters)
+	}
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Exchange implements restful.Exchanger on DiagnosticsHandler.
+func (h *DiagnosticsHandler) Exchange() (interface{}, int) {
+	data := diagnosticsData{
+		Version:      h.Version.String(),
+		LastResolved: h.AutoResolver.LastResolved(),
+	}
+	if h.Config != nil {
+		data.Config = h.Config.Redacted()
+	}
+	if h.AutoResolver.Resolver != nil {
+		if dc, ok := h.AutoResolver.Resolver.Deployer.(deploymentCacher); ok {
+			stats := dc.DeploymentCacheStats()
+			data.DeploymentCache = &deploymentCacheData{
+				DeploymentCacheStats: stats,
+				HitRate:              stats.HitRate(),
+			}
+		}
+	}
+	if r, ok := h.StateManager.StateManager.(revisioner); ok {
+		rev, err := r.Revision()
+		if err != nil {
+			return err.Error(), http.StatusInternalServerError
+		}
+		data.GDMRevision = rev
+	}
+	return data, http.StatusOK
+}
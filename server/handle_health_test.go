@@ -0,0 +1,97 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/opentable/sous/config"
+	"github.com/opentable/sous/ext/singularity"
+	"github.com/opentable/sous/graph"
+	sous "github.com/opentable/sous/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleHealth(t *testing.T) {
+	data, status := (&HealthHandler{}).Exchange()
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", data.(healthData).Status)
+}
+
+func TestHandleReady_NotYetResolved(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	state := sous.NewState()
+	state.Defs.Clusters = sous.Clusters{"cluster-1": &sous.Cluster{Name: "cluster-1"}}
+	h := &ReadyHandler{
+		StateManager: &graph.StateManager{StateManager: &sous.DummyStateManager{State: state}},
+		AutoResolver: &sous.AutoResolver{},
+	}
+
+	data, status := h.Exchange()
+	assert.Equal(http.StatusServiceUnavailable, status)
+
+	ready := data.(readyData)
+	assert.False(ready.Ready)
+	require.Len(ready.Checks, 2)
+	assert.Equal(readyCheck{Name: "gdm", OK: true}, ready.Checks[0])
+	assert.Equal("cluster cluster-1", ready.Checks[1].Name)
+	assert.False(ready.Checks[1].OK)
+
+	err := NotReady(h.StateManager, h.AutoResolver)
+	if assert.Error(err) {
+		assert.Equal("cluster cluster-1: no resolve has completed yet", err.Error())
+	}
+}
+
+func TestHandleDiagnostics(t *testing.T) {
+	assert := assert.New(t)
+
+	cfg := config.DefaultConfig()
+	cfg.Auth.Token = "s3cret"
+	h := &DiagnosticsHandler{
+		Config:       &cfg,
+		StateManager: &graph.StateManager{StateManager: &sous.DummyStateManager{State: sous.NewState()}},
+		AutoResolver: &sous.AutoResolver{},
+	}
+
+	data, status := h.Exchange()
+	assert.Equal(http.StatusOK, status)
+
+	diag := data.(diagnosticsData)
+	assert.Equal(config.Redaction, diag.Config.Auth.Token)
+	assert.Equal("s3cret", cfg.Auth.Token)
+	assert.Empty(diag.LastResolved)
+	assert.Nil(diag.DeploymentCache)
+}
+
+type cachingDeployer struct {
+	sous.Deployer
+	stats singularity.DeploymentCacheStats
+}
+
+func (d cachingDeployer) DeploymentCacheStats() singularity.DeploymentCacheStats {
+	return d.stats
+}
+
+func TestHandleDiagnostics_DeploymentCache(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	stats := singularity.DeploymentCacheStats{Hits: 3, Misses: 1, Entries: 3}
+	h := &DiagnosticsHandler{
+		StateManager: &graph.StateManager{StateManager: &sous.DummyStateManager{State: sous.NewState()}},
+		AutoResolver: &sous.AutoResolver{
+			Resolver: &sous.Resolver{Deployer: cachingDeployer{stats: stats}},
+		},
+	}
+
+	data, status := h.Exchange()
+	assert.Equal(http.StatusOK, status)
+
+	diag := data.(diagnosticsData)
+	require.NotNil(diag.DeploymentCache)
+	assert.Equal(stats, diag.DeploymentCache.DeploymentCacheStats)
+	assert.Equal(0.75, diag.DeploymentCache.HitRate)
+}
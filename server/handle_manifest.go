@@ -30,6 +30,7 @@ type (
 		*restful.QueryValues
 		User        ClientUser
 		StateWriter graph.StateWriter
+		Authorizer  *Authorizer
 	}
 
 	// DELETEManifestHandler handles DELETE exchanges for manifests
@@ -37,6 +38,7 @@ type (
 		*sous.State
 		*restful.QueryValues
 		StateWriter graph.StateWriter
+		Authorizer  *Authorizer
 	}
 )
 
@@ -46,9 +48,36 @@ func (mr *ManifestResource) Get() restful.Exchanger { return &GETManifestHandler
 // Put implements Putable for ManifestResource
 func (mr *ManifestResource) Put() restful.Exchanger { return &PUTManifestHandler{} }
 
+// Patch implements Patchable for ManifestResource. Patches are applied to the
+// manifest by the router, and the patched manifest handled as if it were PUT.
+func (mr *ManifestResource) Patch() restful.Exchanger { return &PUTManifestHandler{} }
+
 // Delete implements Deleteable for ManifestResource
 func (mr *ManifestResource) Delete() restful.Exchanger { return &DELETEManifestHandler{} }
 
+// manifestIDParameters are the query parameters naming a manifest, read by
+// manifestIDFromValues.
+var manifestIDParameters = []restful.QueryParameter{
+	{Name: "repo", Description: "the source repository", Required: true},
+	{Name: "offset", Description: "the directory within the repository"},
+	{Name: "flavor", Description: "the flavor of the manifest"},
+}
+
+// QueryParameters implements restful.QueryParameterizer on GETManifestHandler.
+func (*GETManifestHandler) QueryParameters() []restful.QueryParameter { return manifestIDParameters }
+
+// QueryParameters implements restful.QueryParameterizer on PUTManifestHandler.
+func (*PUTManifestHandler) QueryParameters() []restful.QueryParameter { return manifestIDParameters }
+
+// QueryParameters implements restful.QueryParameterizer on DELETEManifestHandler.
+func (*DELETEManifestHandler) QueryParameters() []restful.QueryParameter { return manifestIDParameters }
+
+// ResponseType implements restful.ResponseTyper on GETManifestHandler.
+func (*GETManifestHandler) ResponseType() interface{} { return sous.Manifest{} }
+
+// ResponseType implements restful.ResponseTyper on PUTManifestHandler.
+func (*PUTManifestHandler) ResponseType() interface{} { return sous.Manifest{} }
+
 // Exchange implements restful.Exchanger
 func (gmh *GETManifestHandler) Exchange() (interface{}, int) {
 	mid, err := manifestIDFromValues(gmh.QueryValues)
@@ -68,10 +97,13 @@ func (dmh *DELETEManifestHandler) Exchange() (interface{}, int) {
 	if err != nil {
 		return err, http.StatusNotFound
 	}
-	_, there := dmh.State.Manifests.Get(mid)
+	m, there := dmh.State.Manifests.Get(mid)
 	if !there {
 		return nil, http.StatusNotFound
 	}
+	if err := dmh.Authorizer.MayChange(dmh.State.Defs, mid, m, nil); err != nil {
+		return err.Error(), http.StatusForbidden
+	}
 	dmh.State.Manifests.Remove(mid)
 
 	return nil, http.StatusNoContent
@@ -93,6 +125,13 @@ func (pmh *PUTManifestHandler) Exchange() (interface{}, int) {
 		pmh.Vomit.Print(spew.Sdump(flaws))
 		return "Invalid manifest", http.StatusBadRequest
 	}
+	var existing *sous.Manifest
+	if old, there := pmh.State.Manifests.Get(mid); there {
+		existing = old
+	}
+	if err := pmh.Authorizer.MayChange(pmh.State.Defs, mid, existing, m); err != nil {
+		return err.Error(), http.StatusForbidden
+	}
 	pmh.State.Manifests.Set(mid, m)
 	if err := pmh.StateWriter.WriteState(pmh.State, sous.User(pmh.User)); err != nil {
 		return errors.Wrapf(err, "state recording collision - retry"), http.StatusConflict
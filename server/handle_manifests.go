@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// ManifestsResource describes the resource for all the manifests at once,
+	// so that changes to several manifests can be made in one request.
+	ManifestsResource struct{}
+
+	// GETManifestsHandler handles GET exchanges for all manifests
+	GETManifestsHandler struct {
+		*sous.State
+	}
+
+	// PATCHManifestsHandler handles PATCH exchanges for all manifests. The
+	// patch is applied by the router, and every manifest changed by it is
+	// written in a single update to the state.
+	PATCHManifestsHandler struct {
+		*sous.State
+		*sous.LogSet
+		*http.Request
+		User        ClientUser
+		StateWriter graph.StateWriter
+		Authorizer  *Authorizer
+	}
+
+	manifestsWrapper struct {
+		Manifests map[sous.ManifestID]*sous.Manifest
+	}
+)
+
+// Get implements Getable for ManifestsResource
+func (mr *ManifestsResource) Get() restful.Exchanger { return &GETManifestsHandler{} }
+
+// Patch implements Patchable for ManifestsResource
+func (mr *ManifestsResource) Patch() restful.Exchanger { return &PATCHManifestsHandler{} }
+
+// ResponseType implements restful.ResponseTyper on GETManifestsHandler.
+func (*GETManifestsHandler) ResponseType() interface{} { return manifestsWrapper{} }
+
+// ResponseType implements restful.ResponseTyper on PATCHManifestsHandler.
+func (*PATCHManifestsHandler) ResponseType() interface{} { return manifestsWrapper{} }
+
+// Exchange implements restful.Exchanger
+func (gmh *GETManifestsHandler) Exchange() (interface{}, int) {
+	return manifestsWrapper{Manifests: gmh.State.Manifests.Snapshot()}, http.StatusOK
+}
+
+// Exchange implements restful.Exchanger
+func (pmh *PATCHManifestsHandler) Exchange() (interface{}, int) {
+	data := manifestsWrapper{}
+	if err := json.NewDecoder(pmh.Request.Body).Decode(&data); err != nil {
+		return fmt.Sprintf("Error parsing manifests: %v", err), http.StatusBadRequest
+	}
+
+	manifests := sous.NewManifests()
+	for mid, m := range data.Manifests {
+		if m.ID() != mid {
+			return fmt.Sprintf("Manifest %q stored as %q", m.ID(), mid), http.StatusBadRequest
+		}
+		if flaws := m.Validate(); len(flaws) > 0 {
+			pmh.Vomit.Print(spew.Sdump(flaws))
+			return fmt.Sprintf("Invalid manifest %q", mid), http.StatusBadRequest
+		}
+		manifests.Set(mid, m)
+	}
+
+	if err := pmh.Authorizer.MayChangeAll(pmh.State.Defs, pmh.State.Manifests, manifests); err != nil {
+		return err.Error(), http.StatusForbidden
+	}
+	pmh.State.Manifests = manifests
+	if err := pmh.StateWriter.WriteState(pmh.State, sous.User(pmh.User)); err != nil {
+		return errors.Wrapf(err, "state recording collision - retry"), http.StatusConflict
+	}
+	return data, http.StatusOK
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testManifestsState() *sous.State {
+	state := sous.NewState()
+	for _, repo := range []string{"gh1", "gh2"} {
+		state.Manifests.Add(&sous.Manifest{
+			Source: sous.SourceLocation{Repo: repo},
+			Kind:   sous.ManifestKindService,
+			Deployments: sous.DeploySpecs{
+				"ci": sous.DeploySpec{
+					DeployConfig: sous.DeployConfig{
+						Resources: sous.Resources{"cpus": "0.1", "memory": "100", "ports": "1"},
+					},
+				},
+			},
+		})
+	}
+	return state
+}
+
+func TestHandlesManifestsGet(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	th := &GETManifestsHandler{State: testManifestsState()}
+	data, status := th.Exchange()
+	assert.Equal(200, status)
+	require.IsType(manifestsWrapper{}, data)
+	assert.Len(data.(manifestsWrapper).Manifests, 2)
+}
+
+func TestHandlesManifestsPatch(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	state := testManifestsState()
+	stored := &sous.DummyStateManager{State: sous.NewState()}
+	writer := graph.StateWriter{StateWriter: stored}
+
+	data := manifestsWrapper{Manifests: state.Manifests.Clone().Snapshot()}
+	gh1 := sous.ManifestID{Source: sous.SourceLocation{Repo: "gh1"}}
+	gh2 := sous.ManifestID{Source: sous.SourceLocation{Repo: "gh2"}}
+	data.Manifests[gh1].Owners = []string{"sam"}
+	delete(data.Manifests, gh2)
+
+	buf := &bytes.Buffer{}
+	require.NoError(json.NewEncoder(buf).Encode(data))
+	req, err := http.NewRequest("PATCH", "", buf)
+	require.NoError(err)
+
+	th := &PATCHManifestsHandler{
+		Request:     req,
+		StateWriter: writer,
+		State:       state,
+		LogSet:      &sous.Log,
+	}
+	_, status := th.Exchange()
+	assert.Equal(200, status)
+
+	written, err := stored.ReadState()
+	require.NoError(err)
+	require.Equal(1, written.Manifests.Len())
+	changed, found := written.Manifests.Get(gh1)
+	require.True(found)
+	assert.Equal([]string{"sam"}, changed.Owners)
+	_, found = written.Manifests.Get(gh2)
+	assert.False(found, "a manifest missing from the patched manifests should be removed")
+}
+
+func TestHandlesManifestsPatchInvalid(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	state := testManifestsState()
+	stored := &sous.DummyStateManager{State: sous.NewState()}
+	writer := graph.StateWriter{StateWriter: stored}
+
+	data := manifestsWrapper{Manifests: state.Manifests.Clone().Snapshot()}
+	gh1 := sous.ManifestID{Source: sous.SourceLocation{Repo: "gh1"}}
+	data.Manifests[gh1].Owners = []string{"sam"}
+	data.Manifests[sous.ManifestID{Source: sous.SourceLocation{Repo: "gh2"}}].Kind = ""
+
+	buf := &bytes.Buffer{}
+	require.NoError(json.NewEncoder(buf).Encode(data))
+	req, err := http.NewRequest("PATCH", "", buf)
+	require.NoError(err)
+
+	th := &PATCHManifestsHandler{
+		Request:     req,
+		StateWriter: writer,
+		State:       state,
+		LogSet:      &sous.Log,
+	}
+	_, status := th.Exchange()
+	assert.Equal(400, status)
+
+	assert.Equal(0, stored.State.Manifests.Len(), "no manifest should be written if any is invalid")
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
+	"github.com/pkg/errors"
+)
+
+type (
+	// MetadataResource describes the deployment metadata of a manifest, per
+	// cluster, so that it can be patched without sending the whole manifest.
+	MetadataResource struct{}
+
+	// GETMetadataHandler handles GET exchanges for metadata
+	GETMetadataHandler struct {
+		*sous.State
+		*restful.QueryValues
+	}
+
+	// PATCHMetadataHandler handles PATCH exchanges for metadata. The patch is
+	// applied by the router, and the patched metadata written to the manifest.
+	PATCHMetadataHandler struct {
+		*sous.State
+		*http.Request
+		*restful.QueryValues
+		User        ClientUser
+		StateWriter graph.StateWriter
+		Authorizer  *Authorizer
+	}
+
+	metadataWrapper struct {
+		Clusters map[string]sous.Metadata
+	}
+)
+
+// Get implements Getable for MetadataResource
+func (mr *MetadataResource) Get() restful.Exchanger { return &GETMetadataHandler{} }
+
+// Patch implements Patchable for MetadataResource
+func (mr *MetadataResource) Patch() restful.Exchanger { return &PATCHMetadataHandler{} }
+
+// QueryParameters implements restful.QueryParameterizer on GETMetadataHandler.
+func (*GETMetadataHandler) QueryParameters() []restful.QueryParameter { return manifestIDParameters }
+
+// QueryParameters implements restful.QueryParameterizer on PATCHMetadataHandler.
+func (*PATCHMetadataHandler) QueryParameters() []restful.QueryParameter {
+	return manifestIDParameters
+}
+
+// ResponseType implements restful.ResponseTyper on GETMetadataHandler.
+func (*GETMetadataHandler) ResponseType() interface{} { return metadataWrapper{} }
+
+// ResponseType implements restful.ResponseTyper on PATCHMetadataHandler.
+func (*PATCHMetadataHandler) ResponseType() interface{} { return metadataWrapper{} }
+
+// Exchange implements restful.Exchanger
+func (gmh *GETMetadataHandler) Exchange() (interface{}, int) {
+	mid, err := manifestIDFromValues(gmh.QueryValues)
+	if err != nil {
+		return err, http.StatusNotFound
+	}
+	m, there := gmh.State.Manifests.Get(mid)
+	if !there {
+		return nil, http.StatusNotFound
+	}
+	data := metadataWrapper{Clusters: map[string]sous.Metadata{}}
+	for cluster, spec := range m.Deployments {
+		data.Clusters[cluster] = spec.Metadata
+	}
+	return data, http.StatusOK
+}
+
+// Exchange implements restful.Exchanger
+func (pmh *PATCHMetadataHandler) Exchange() (interface{}, int) {
+	mid, err := manifestIDFromValues(pmh.QueryValues)
+	if err != nil {
+		return err, http.StatusNotFound
+	}
+	existing, there := pmh.State.Manifests.Get(mid)
+	if !there {
+		return nil, http.StatusNotFound
+	}
+
+	data := metadataWrapper{}
+	if err := json.NewDecoder(pmh.Request.Body).Decode(&data); err != nil {
+		return fmt.Sprintf("Error parsing metadata: %v", err), http.StatusBadRequest
+	}
+	for cluster := range data.Clusters {
+		if _, deployed := existing.Deployments[cluster]; !deployed {
+			return fmt.Sprintf("%q is not deployed to cluster %q", mid, cluster), http.StatusBadRequest
+		}
+	}
+
+	m := existing.Clone()
+	for cluster, spec := range m.Deployments {
+		spec.Metadata = data.Clusters[cluster]
+		m.Deployments[cluster] = spec
+	}
+	if err := pmh.Authorizer.MayChange(pmh.State.Defs, mid, existing, m); err != nil {
+		return err.Error(), http.StatusForbidden
+	}
+	pmh.State.Manifests.Set(mid, m)
+	if err := pmh.StateWriter.WriteState(pmh.State, sous.User(pmh.User)); err != nil {
+		return errors.Wrapf(err, "state recording collision - retry"), http.StatusConflict
+	}
+	return data, http.StatusOK
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMetadataState() *sous.State {
+	state := sous.NewState()
+	spec := func(md sous.Metadata) sous.DeploySpec {
+		return sous.DeploySpec{
+			DeployConfig: sous.DeployConfig{
+				Resources: sous.Resources{"cpus": "0.1", "memory": "100", "ports": "1"},
+				Metadata:  md,
+			},
+		}
+	}
+	state.Manifests.Add(&sous.Manifest{
+		Source: sous.SourceLocation{Repo: "gh"},
+		Kind:   sous.ManifestKindService,
+		Deployments: sous.DeploySpecs{
+			"ci":   spec(sous.Metadata{"team": "one"}),
+			"prod": spec(sous.Metadata{"team": "two"}),
+		},
+	})
+	return state
+}
+
+func TestHandlesMetadataGet(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	q, err := url.ParseQuery("repo=gh")
+	require.NoError(err)
+
+	th := &GETMetadataHandler{
+		State:       testMetadataState(),
+		QueryValues: &restful.QueryValues{q},
+	}
+	data, status := th.Exchange()
+	assert.Equal(200, status)
+	assert.Equal(metadataWrapper{Clusters: map[string]sous.Metadata{
+		"ci":   {"team": "one"},
+		"prod": {"team": "two"},
+	}}, data)
+}
+
+func TestHandlesMetadataGetNotKnown(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	q, err := url.ParseQuery("repo=unknown")
+	require.NoError(err)
+
+	th := &GETMetadataHandler{
+		State:       testMetadataState(),
+		QueryValues: &restful.QueryValues{q},
+	}
+	_, status := th.Exchange()
+	assert.Equal(404, status)
+}
+
+func patchMetadataHandler(t *testing.T, state *sous.State, query string, data metadataWrapper) *PATCHMetadataHandler {
+	q, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	buf := &bytes.Buffer{}
+	require.NoError(t, json.NewEncoder(buf).Encode(data))
+	req, err := http.NewRequest("PATCH", "", buf)
+	require.NoError(t, err)
+
+	return &PATCHMetadataHandler{
+		Request:     req,
+		StateWriter: graph.StateWriter{StateWriter: &sous.DummyStateManager{State: state}},
+		State:       state,
+		QueryValues: &restful.QueryValues{q},
+	}
+}
+
+func TestHandlesMetadataPatch(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	state := testMetadataState()
+	th := patchMetadataHandler(t, state, "repo=gh", metadataWrapper{Clusters: map[string]sous.Metadata{
+		"ci": {"team": "one", "owner": "sam"},
+	}})
+	_, status := th.Exchange()
+	assert.Equal(200, status)
+
+	m, found := state.Manifests.Get(sous.ManifestID{Source: sous.SourceLocation{Repo: "gh"}})
+	require.True(found)
+	assert.Equal(sous.Metadata{"team": "one", "owner": "sam"}, m.Deployments["ci"].Metadata)
+	assert.Empty(m.Deployments["prod"].Metadata, "metadata of clusters missing from the patched metadata should be removed")
+}
+
+func TestHandlesMetadataPatchUnknownCluster(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	state := testMetadataState()
+	th := patchMetadataHandler(t, state, "repo=gh", metadataWrapper{Clusters: map[string]sous.Metadata{
+		"ci":    {"team": "one"},
+		"prod":  {"team": "two"},
+		"other": {"team": "three"},
+	}})
+	_, status := th.Exchange()
+	assert.Equal(400, status)
+
+	m, found := state.Manifests.Get(sous.ManifestID{Source: sous.SourceLocation{Repo: "gh"}})
+	require.True(found)
+	assert.Len(m.Deployments, 2)
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/util/restful"
+)
+
+type (
+	// OpenAPIResource dispatches /openapi.json, the OpenAPI description of
+	// the Sous server's API.
+	OpenAPIResource struct{}
+
+	// OpenAPIHandler handles GET for /openapi.json.
+	OpenAPIHandler struct {
+		Version graph.Version
+	}
+)
+
+// Get implements Getable on OpenAPIResource.
+func (*OpenAPIResource) Get() restful.Exchanger { return &OpenAPIHandler{} }
+
+// Exchange implements restful.Exchanger on OpenAPIHandler.
+func (h *OpenAPIHandler) Exchange() (interface{}, int) {
+	return SousRouteMap.OpenAPI(restful.OpenAPIInfo{Title: "Sous", Version: h.Version.String()}), http.StatusOK
+}
+
+// ResponseType implements restful.ResponseTyper on OpenAPIHandler.
+func (*OpenAPIHandler) ResponseType() interface{} { return restful.OpenAPI{} }
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
+)
+
+type (
+	// RegistryEventsResource receives notifications from a Docker registry.
+	// Configure it as a notification endpoint of the registry, e.g. at
+	// https://sous.example.com/registry-events
+	RegistryEventsResource struct{}
+
+	// POSTRegistryEventsHandler records the Sous images pushed to the registry
+	// in the name cache, so that they can be deployed without a harvest.
+	POSTRegistryEventsHandler struct {
+		*http.Request
+		*sous.LogSet
+		NameCache *docker.NameCache
+	}
+
+	registryEventsResult struct {
+		// Recorded are the source IDs of the images recorded.
+		Recorded []string
+		// Skipped counts the events which were not pushes of Sous images.
+		Skipped int
+		// Errors describe the pushes which could not be recorded.
+		Errors []string
+	}
+)
+
+// Post implements Postable on RegistryEventsResource.
+func (rer *RegistryEventsResource) Post() restful.Exchanger { return &POSTRegistryEventsHandler{} }
+
+// RequestType implements restful.RequestTyper on POSTRegistryEventsHandler.
+func (*POSTRegistryEventsHandler) RequestType() interface{} { return docker.RegistryEvents{} }
+
+// ResponseType implements restful.ResponseTyper on POSTRegistryEventsHandler.
+func (*POSTRegistryEventsHandler) ResponseType() interface{} { return registryEventsResult{} }
+
+// Exchange implements restful.Exchanger on POSTRegistryEventsHandler. The
+// registry resends notifications until they succeed, so failures to record
+// individual images are reported in the body, but not in the status, lest
+// one bad image block every later notification.
+func (h *POSTRegistryEventsHandler) Exchange() (interface{}, int) {
+	evs := docker.RegistryEvents{}
+	if err := json.NewDecoder(h.Request.Body).Decode(&evs); err != nil {
+		return err.Error(), http.StatusBadRequest
+	}
+
+	res := registryEventsResult{Recorded: []string{}, Errors: []string{}}
+	for _, ev := range evs.Events {
+		if !ev.IsManifestPush() {
+			res.Skipped++
+			continue
+		}
+		sid, err := h.NameCache.RecordPush(ev)
+		if _, notSous := err.(docker.NotSousImage); notSous {
+			h.Debug.Print(err)
+			res.Skipped++
+			continue
+		}
+		if err != nil {
+			h.Warn.Printf("Registry event %s: %v", ev.ID, err)
+			res.Errors = append(res.Errors, err.Error())
+			continue
+		}
+		res.Recorded = append(res.Recorded, sid.String())
+	}
+	return res, http.StatusOK
+}
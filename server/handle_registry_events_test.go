@@ -0,0 +1,60 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/opentable/sous/ext/docker"
+	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/docker_registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPOSTRegistryEvents(t *testing.T) {
+	dc := docker_registry.NewDummyClient()
+	db, err := docker.GetDatabase(&docker.DBConfig{Driver: "sqlite3_sous", Connection: docker.InMemoryConnection("registry_events")})
+	require.NoError(t, err)
+	nc, err := docker.NewNameCache("docker.example.com", dc, db)
+	require.NoError(t, err)
+	sid := sous.MustNewSourceID("github.com/example/app", "", "1.2.3")
+	dc.FeedMetadata(docker_registry.Metadata{
+		Registry:      "docker.example.com",
+		Labels:        docker.Labels(sid),
+		CanonicalName: "example/app@sha256:012345678901234567890123456789AB012345678901234567890123456789AB",
+	})
+
+	body := `{"events": [
+		{"id": "1", "action": "push", "target": {
+			"mediaType": "application/octet-stream",
+			"digest": "sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210",
+			"repository": "example/app"}},
+		{"id": "2", "action": "push", "target": {
+			"mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+			"digest": "sha256:012345678901234567890123456789AB012345678901234567890123456789AB",
+			"repository": "example/app",
+			"tag": "1.2.3"}}
+	]}`
+	req, err := http.NewRequest("POST", "/registry-events", bytes.NewBufferString(body))
+	require.NoError(t, err)
+
+	h := &POSTRegistryEventsHandler{Request: req, LogSet: &sous.Log, NameCache: nc}
+	data, status := h.Exchange()
+	assert.Equal(t, http.StatusOK, status)
+	res := data.(registryEventsResult)
+	assert.Equal(t, []string{sid.String()}, res.Recorded)
+	assert.Equal(t, 1, res.Skipped)
+	assert.Empty(t, res.Errors)
+
+	ba, err := nc.GetArtifact(sid)
+	require.NoError(t, err)
+	assert.Equal(t, "docker.example.com/example/app@sha256:012345678901234567890123456789AB012345678901234567890123456789AB", ba.Name)
+}
+
+func TestPOSTRegistryEvents_BadBody(t *testing.T) {
+	req, err := http.NewRequest("POST", "/registry-events", bytes.NewBufferString("not json"))
+	require.NoError(t, err)
+	_, status := (&POSTRegistryEventsHandler{Request: req, LogSet: &sous.Log}).Exchange()
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"net/http"
 
-	"github.com/opentable/sous/config"
 	sous "github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/restful"
 )
@@ -15,19 +14,33 @@ type (
 
 	// ServerListHandler handles GET for /servers
 	ServerListHandler struct {
-		Config *config.Config
+		Peers *sous.Peers
 	}
 
 	// ServerListUpdater handles PUT for /servers
 	ServerListUpdater struct {
 		*http.Request
-		Config *config.Config
-		Log    *sous.LogSet
+		Peers *sous.Peers
+		Log   *sous.LogSet
+	}
+
+	// ServerListAnnouncer handles POST for /servers, by which the other Sous
+	// servers announce themselves.
+	ServerListAnnouncer struct {
+		*http.Request
+		Peers *sous.Peers
+		Log   *sous.LogSet
 	}
 
 	server struct {
 		ClusterName string
 		URL         string
+		Version     string          `json:",omitempty"`
+		Generation  int64           `json:",omitempty"`
+		Heartbeat   uint64          `json:",omitempty"`
+		Status      sous.PeerStatus `json:",omitempty"`
+		Ready       bool
+		NotReady    string `json:",omitempty"`
 	}
 
 	serverListData struct {
@@ -37,18 +50,32 @@ type (
 
 // Get implements Getable on ServerListResource
 func (slr *ServerListResource) Get() restful.Exchanger { return &ServerListHandler{} }
+
+// Put implements Putable on ServerListResource
 func (slr *ServerListResource) Put() restful.Exchanger { return &ServerListUpdater{} }
 
+// Post implements Postable on ServerListResource
+func (slr *ServerListResource) Post() restful.Exchanger { return &ServerListAnnouncer{} }
+
+// ResponseType implements restful.ResponseTyper on ServerListHandler
+func (*ServerListHandler) ResponseType() interface{} { return serverListData{} }
+
+// ResponseType implements restful.ResponseTyper on ServerListUpdater
+func (*ServerListUpdater) ResponseType() interface{} { return serverListData{} }
+
+// RequestType implements restful.RequestTyper on ServerListAnnouncer
+func (*ServerListAnnouncer) RequestType() interface{} { return serverListData{} }
+
+// ResponseType implements restful.ResponseTyper on ServerListAnnouncer
+func (*ServerListAnnouncer) ResponseType() interface{} { return serverListData{} }
+
 // Exchange implements restful.Exchanger on ServerListHandler
 func (slh *ServerListHandler) Exchange() (interface{}, int) {
-	data := serverListData{Servers: []server{}}
-	for name, url := range slh.Config.SiblingURLs {
-		data.Servers = append(data.Servers, server{ClusterName: name, URL: url})
-	}
-	return data, 200
+	return newServerListData(slh.Peers.List()), 200
 }
 
-// Exchange implements restful.Exchanger on ServerListUpdater
+// Exchange implements restful.Exchanger on ServerListUpdater. The servers
+// put are added to those known, as though they had been configured.
 func (slh *ServerListUpdater) Exchange() (interface{}, int) {
 	dec := json.NewDecoder(slh.Request.Body)
 	data := serverListData{Servers: []server{}}
@@ -56,13 +83,38 @@ func (slh *ServerListUpdater) Exchange() (interface{}, int) {
 
 	slh.Log.Vomit.Printf("Updating server list to: %#v", data)
 
-	if slh.Config.SiblingURLs == nil {
-		slh.Config.SiblingURLs = make(map[string]string)
-	}
-
 	for _, server := range data.Servers {
-		slh.Config.SiblingURLs[server.ClusterName] = server.URL
+		slh.Peers.Seed(server.ClusterName, server.URL)
 	}
 
 	return data, 200
 }
+
+// Exchange implements restful.Exchanger on ServerListAnnouncer. The servers
+// announced are merged into those known, and all of those are returned, so
+// that the announcing server learns of them.
+func (sla *ServerListAnnouncer) Exchange() (interface{}, int) {
+	dec := json.NewDecoder(sla.Request.Body)
+	data := serverListData{}
+	if err := dec.Decode(&data); err != nil {
+		return err.Error(), http.StatusBadRequest
+	}
+
+	sla.Log.Vomit.Printf("Server list announced: %#v", data)
+
+	peers := make([]sous.Peer, len(data.Servers))
+	for i, s := range data.Servers {
+		peers[i] = sous.Peer(s)
+	}
+	sla.Peers.Merge(peers)
+
+	return newServerListData(sla.Peers.List()), 200
+}
+
+func newServerListData(peers []sous.Peer) serverListData {
+	data := serverListData{Servers: make([]server, len(peers))}
+	for i, p := range peers {
+		data.Servers[i] = server(p)
+	}
+	return data
+}
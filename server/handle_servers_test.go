@@ -1,20 +1,29 @@
 package server
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http"
 	"testing"
+	"time"
 
-	"github.com/opentable/sous/config"
+	sous "github.com/opentable/sous/lib"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func testPeers() *sous.Peers {
+	noClient := func(string) (sous.PeerClient, error) { return nil, nil }
+	ps := sous.NewPeers("", time.Minute, time.Hour, noClient, sous.SilentLogSet())
+	ps.Seed("left", "https://left.sous.com")
+	ps.Seed("right", "https://right.sous.com")
+	return ps
+}
+
 func TestHandleServerList_Get(t *testing.T) {
 	assert := assert.New(t)
 
-	h := &ServerListHandler{
-		Config: &config.Config{
-			SiblingURLs: map[string]string{"left": "https://left.sous.com", "right": "https://right.sous.com"},
-		},
-	}
+	h := &ServerListHandler{Peers: testPeers()}
 
 	rez, stat := h.Exchange()
 	assert.Equal(stat, 200)
@@ -33,4 +42,35 @@ func TestHandleServerList_Get(t *testing.T) {
 	// newer test
 	assert.Equal(list.Servers[0].ClusterName, "left")
 	assert.Equal(list.Servers[1].ClusterName, "right")
+
+	assert.Equal(sous.PeerUnknown, list.Servers[0].Status)
+}
+
+func TestHandleServerList_Post(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	body, err := json.Marshal(serverListData{Servers: []server{
+		{ClusterName: "left", URL: "https://left.sous.com", Version: "0.5.0", Generation: 1, Heartbeat: 1},
+		{ClusterName: "up", URL: "https://up.sous.com", Generation: 3, Heartbeat: 2},
+	}})
+	require.NoError(err)
+	req, err := http.NewRequest("POST", "/servers", bytes.NewBuffer(body))
+	require.NoError(err)
+
+	h := &ServerListAnnouncer{Request: req, Peers: testPeers(), Log: sous.SilentLogSet()}
+
+	rez, stat := h.Exchange()
+	assert.Equal(stat, 200)
+
+	list, yup := rez.(serverListData)
+	require.True(yup)
+	require.Len(list.Servers, 3)
+
+	assert.Equal("left", list.Servers[0].ClusterName)
+	assert.Equal("0.5.0", list.Servers[0].Version)
+	assert.Equal(sous.PeerAlive, list.Servers[0].Status)
+	assert.Equal(sous.PeerUnknown, list.Servers[1].Status)
+	assert.Equal("up", list.Servers[2].ClusterName)
+	assert.Equal(sous.PeerAlive, list.Servers[2].Status)
 }
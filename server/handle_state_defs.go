@@ -17,6 +17,9 @@ type (
 // handle GET requests.)
 func (sdr *StateDefResource) Get() restful.Exchanger { return &StateDefGetHandler{} }
 
+// ResponseType implements restful.ResponseTyper on StateDefGetHandler.
+func (*StateDefGetHandler) ResponseType() interface{} { return sous.Defs{} }
+
 // Exchange implements restful.Exchanger on StateDefGetHandler.
 func (sdg *StateDefGetHandler) Exchange() (interface{}, int) {
 	return sdg.State.Defs, 200
@@ -15,18 +15,24 @@ type (
 	// StatusHandler handles requests for status.
 	StatusHandler struct {
 		AutoResolver *sous.AutoResolver
+		Tombstones   *sous.Tombstones
 		*sous.ResolveFilter
 	}
 
 	statusData struct {
 		Deployments           []*sous.Deployment
 		Completed, InProgress *sous.ResolveStatus
+		// Orphans are running deployments with no manifest, awaiting deletion.
+		Orphans []sous.Orphan
 	}
 )
 
 // Get implements Getable on StatusResource.
 func (*StatusResource) Get() restful.Exchanger { return &StatusHandler{} }
 
+// ResponseType implements restful.ResponseTyper on StatusHandler.
+func (*StatusHandler) ResponseType() interface{} { return statusData{} }
+
 // Exchange implements the Handler interface.
 func (h *StatusHandler) Exchange() (interface{}, int) {
 	status := statusData{}
@@ -34,5 +40,8 @@ func (h *StatusHandler) Exchange() (interface{}, int) {
 		status.Deployments = append(status.Deployments, d)
 	}
 	status.Completed, status.InProgress = h.AutoResolver.Statuses()
+	if h.Tombstones != nil {
+		status.Orphans = h.Tombstones.Snapshot()
+	}
 	return status, http.StatusOK
 }
@@ -2,6 +2,7 @@ package server
 
 import (
 	"testing"
+	"time"
 
 	"github.com/opentable/sous/lib"
 	"github.com/stretchr/testify/assert"
@@ -19,3 +20,21 @@ func TestHandlesStatusGet(t *testing.T) {
 	assert.Equal(status, 200)
 	assert.Len(data.(statusData).Deployments, 0)
 }
+
+func TestHandlesStatusGetOrphans(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := sous.NewTombstones()
+	ts.Mark(&sous.Deployment{ClusterName: "cluster-1"}, "reqid", time.Now())
+	th := &StatusHandler{
+		AutoResolver: &sous.AutoResolver{
+			GDM: sous.NewDeployments(),
+		},
+		Tombstones: ts,
+	}
+	data, status := th.Exchange()
+	assert.Equal(status, 200)
+	if assert.Len(data.(statusData).Orphans, 1) {
+		assert.Equal("reqid", data.(statusData).Orphans[0].RequestID)
+	}
+}
@@ -39,3 +39,40 @@ func TestSousRoutes(t *testing.T) {
 		"status",
 	)
 }
+
+func TestSousRouteMap_OpenAPI(t *testing.T) {
+	doc := SousRouteMap.OpenAPI(restful.OpenAPIInfo{Title: "Sous", Version: "0.0.0"})
+
+	for _, path := range []string{"/gdm", "/defs", "/manifest", "/manifests", "/metadata", "/status", "/servers", "/openapi.json"} {
+		get, ok := doc.Paths[path]["get"]
+		if !ok {
+			t.Errorf("No GET operation described for %s", path)
+			continue
+		}
+		if _, ok := get.Responses["200"].Content["application/json"]; !ok {
+			t.Errorf("No response type described for GET %s", path)
+		}
+	}
+
+	for _, name := range []string{"gdmWrapper", "statusData", "Manifest", "manifestsWrapper", "metadataWrapper", "Defs", "serverListData"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("No schema for %s", name)
+		}
+	}
+
+	params := doc.Paths["/manifest"]["put"].Parameters
+	if len(params) != 3 || params[0].Name != "repo" || !params[0].Required {
+		t.Errorf("Manifest query parameters described as %#v", params)
+	}
+
+	for _, path := range []string{"/manifest", "/manifests", "/metadata"} {
+		patch, ok := doc.Paths[path]["patch"]
+		if !ok {
+			t.Errorf("No PATCH operation described for %s", path)
+			continue
+		}
+		if _, ok := patch.RequestBody.Content[restful.MergePatchMediaType]; !ok {
+			t.Errorf("PATCH %s request described as %#v", path, patch.RequestBody)
+		}
+	}
+}
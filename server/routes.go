@@ -8,8 +8,16 @@ var (
 		{"gdm", "/gdm", &GDMResource{}},
 		{"defs", "/defs", &StateDefResource{}},
 		{"manifest", "/manifest", &ManifestResource{}},
+		{"manifests", "/manifests", &ManifestsResource{}},
+		{"metadata", "/metadata", &MetadataResource{}},
 		{"artifact", "/artifact", &ArtifactResource{}},
+		{"artifacts", "/artifacts", &ArtifactListResource{}},
+		{"registry-events", "/registry-events", &RegistryEventsResource{}},
 		{"status", "/status", &StatusResource{}},
 		{"servers", "/servers", &ServerListResource{}},
+		{"health", "/health", &HealthResource{}},
+		{"ready", "/ready", &ReadyResource{}},
+		{"diagnostics", "/diagnostics", &DiagnosticsResource{}},
+		{"openapi", "/openapi.json", &OpenAPIResource{}},
 	}
 )
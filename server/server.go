@@ -6,6 +6,7 @@ import (
 
 	"github.com/opentable/sous/config"
 	"github.com/opentable/sous/graph"
+	"github.com/opentable/sous/lib"
 	"github.com/opentable/sous/util/restful"
 )
 
@@ -14,6 +15,8 @@ import (
 type fixedPoints struct {
 	*config.Config
 	*graph.StateManager
+	*sous.Tombstones
+	*sous.Peers
 }
 
 type logSet interface {
@@ -23,24 +26,52 @@ type logSet interface {
 }
 
 // Handler builds the http.Handler for the Sous server httprouter.
+// If the configuration enables authentication, only authenticated requests
+// are handled.
 func Handler(mainGraph *graph.SousGraph, ls logSet) http.Handler {
-	mainGraph.Inject(&fixedPoints{})
+	fp := &fixedPoints{}
+	mainGraph.Inject(fp)
 	gf := func() restful.Injector {
 		g := mainGraph.Clone()
 		AddsPerRequest(g)
 
 		return g
 	}
-	return SousRouteMap.BuildRouter(gf, ls)
+	h := SousRouteMap.BuildRouter(gf, ls)
+	if fp.Config == nil {
+		return h
+	}
+	if auth := fp.Config.Auth.Authenticator(); auth != nil {
+		// Load balancers must be able to check health and readiness.
+		return restful.RequireAuthentication(h, auth, ls, "/health", "/ready")
+	}
+	return h
 }
 
 // Run starts a server up.
 func Run(mainGraph *graph.SousGraph, laddr string, ls logSet) error {
-	s := &http.Server{
+	return listenAndServe(mainGraph, &http.Server{
 		Addr:    laddr,
 		Handler: Handler(mainGraph, ls),
+	})
+}
+
+// listenAndServe serves s over HTTPS if the configuration has a server
+// certificate, and over HTTP otherwise.
+func listenAndServe(mainGraph *graph.SousGraph, s *http.Server) error {
+	fp := &fixedPoints{}
+	if err := mainGraph.Inject(fp); err != nil {
+		return err
 	}
-	return s.ListenAndServe()
+	tc, err := fp.Config.Auth.ServerTLS()
+	if err != nil {
+		return err
+	}
+	if tc == nil {
+		return s.ListenAndServe()
+	}
+	s.TLSConfig = tc
+	return s.ListenAndServeTLS("", "")
 }
 
 func profilingHandler(mainGraph *graph.SousGraph, ls logSet) http.Handler {
@@ -57,9 +88,8 @@ func profilingHandler(mainGraph *graph.SousGraph, ls logSet) http.Handler {
 
 // RunWithProfiling mixes in the pprof handlers so that we can return profiles
 func RunWithProfiling(mainGraph *graph.SousGraph, laddr string, ls logSet) error {
-	s := &http.Server{
+	return listenAndServe(mainGraph, &http.Server{
 		Addr:    laddr,
 		Handler: profilingHandler(mainGraph, ls),
-	}
-	return s.ListenAndServe()
+	})
 }
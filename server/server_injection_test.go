@@ -43,7 +43,7 @@ func TestServerListHandlerInjection(t *testing.T) {
 	serverListGet, ok := slh.(*ServerListHandler)
 	require.True(ok)
 
-	assert.NotNil(serverListGet.Config)
+	assert.NotNil(serverListGet.Peers)
 }
 
 func TestStatusHandlerInjection(t *testing.T) {
@@ -4,13 +4,19 @@ import (
 	"net/http"
 
 	"github.com/opentable/sous/lib"
+	"github.com/opentable/sous/util/restful"
 )
 
 // ClientUser is the sous.User configured in the calling client.
 type ClientUser sous.User
 
-// getUser parses a ClientUser from the headers of a HTTP request.
+// getUser returns the ClientUser the server authenticated for a HTTP
+// request. If the request was not authenticated, the user is parsed from its
+// headers.
 func getUser(req *http.Request) ClientUser {
+	if p, ok := restful.PrincipalFrom(req); ok {
+		return ClientUser{Name: p.Name, Email: p.Email}
+	}
 	// Maybe we want to check this user isn't empty, eventually.
 	return ClientUser{
 		Name:  req.Header.Get("Sous-User-Name"),
@@ -157,3 +157,66 @@ func TestWriteState(t *testing.T) {
 		t.Errorf("Server's version of changed state was %q; want %q", actualVersion, expectedVersion)
 	}
 }
+
+func TestWriteMetadata(t *testing.T) {
+	m := buildManifest("test-cluster", "github.com/opentable/metadata", "1.2.3")
+	spec := m.Deployments["test-cluster"]
+	spec.Metadata = sous.Metadata{"team": "sous"}
+	m.Deployments["test-cluster"] = spec
+
+	state := &sous.State{}
+	state.Defs.Clusters = sous.Clusters{"test-cluster": &sous.Cluster{Name: "test-cluster"}}
+	state.Manifests = sous.NewManifests(m)
+	sm := sous.DummyStateManager{State: state}
+
+	di := psyringe.New()
+	di.Add(sous.NewLogSet(os.Stderr, os.Stderr, os.Stderr))
+	graph.AddInternals(di)
+	di.Add(
+		func() graph.StateReader { return graph.StateReader{StateReader: &sm} },
+		func() graph.StateWriter { return graph.StateWriter{StateWriter: &sm} },
+		func() *graph.StateManager { return &graph.StateManager{StateManager: &sm} },
+	)
+	di.Add(&config.Verbosity{})
+
+	gf := func() restful.Injector {
+		cdi := di.Clone()
+		server.AddsPerRequest(cdi)
+		return cdi
+	}
+
+	testServer := httptest.NewServer(server.SousRouteMap.BuildRouter(gf, sous.Log))
+	defer testServer.Close()
+
+	cl, err := restful.NewClient(testServer.URL, sous.Log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hsm := sous.NewHTTPStateManager(cl)
+
+	local, err := hsm.ReadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lm, there := local.Manifests.Get(m.ID())
+	if !there {
+		t.Fatalf("Manifest %q not in local manifests!", m.ID())
+	}
+	spec = lm.Deployments["test-cluster"]
+	spec.Metadata = sous.Metadata{"team": "sous", "pager": "sous-oncall"}
+	lm.Deployments["test-cluster"] = spec
+	local.Manifests.Set(lm.ID(), lm)
+
+	if err := hsm.WriteState(local, sous.User{Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to write state: %+v", err)
+	}
+
+	written, _ := sm.State.Manifests.Get(m.ID())
+	expected := sous.Metadata{"team": "sous", "pager": "sous-oncall"}
+	if actual := written.Deployments["test-cluster"].Metadata; !actual.Equal(expected) {
+		t.Errorf("Server's metadata was %v; want %v", actual, expected)
+	}
+	if sm.WriteCount != 1 {
+		t.Errorf("Server wrote state %d times; want 1", sm.WriteCount)
+	}
+}
@@ -121,3 +121,18 @@ func (e *cliErr) UnderlyingError() error {
 func (e *cliErr) prefix(prefix string) string {
 	return fmt.Sprintf("%s error: %s", prefix, e.Error())
 }
+
+// ExitErr signifies failure with a specific exit code, for example that of a
+// process run on the user's behalf.
+type ExitErr struct {
+	*cliErr
+	Code int
+}
+
+// ExitErrorf returns an ExitErr which exits with code.
+func ExitErrorf(code int, format string, v ...interface{}) ExitErr {
+	return ExitErr{newError(format, v...), code}
+}
+
+// ExitCode returns the exit code of this ExitErr.
+func (e ExitErr) ExitCode() int { return e.Code }
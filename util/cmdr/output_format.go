@@ -0,0 +1,214 @@
+package cmdr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/opentable/sous/util/yaml"
+)
+
+type (
+	// OutputFormat is the format in which a command writes its results. It
+	// implements flag.Value, so that it can be set by a -format flag.
+	OutputFormat struct {
+		// Kind is one of FormatTable, FormatJSON, FormatYAML or
+		// FormatTemplate. If it is empty, Tables are written as tables, and
+		// everything else as YAML.
+		Kind string
+		// Columns are the names of the table columns to write, in order. Every
+		// column is written if Columns is empty.
+		Columns []string
+		// Template is executed for each result when Kind is FormatTemplate.
+		Template *template.Template
+		// source is the flag value this format was parsed from.
+		source string
+	}
+
+	// A Table is a result which can be written as a table.
+	Table interface {
+		// TableHeaders returns the names of the table's columns.
+		TableHeaders() []string
+		// TableRows returns the rows of the table, each with a cell for each
+		// column.
+		TableRows() [][]string
+	}
+)
+
+const (
+	// FormatTable writes Tables as tab-aligned columns.
+	FormatTable = "table"
+	// FormatJSON writes results as indented JSON.
+	FormatJSON = "json"
+	// FormatYAML writes results as YAML.
+	FormatYAML = "yaml"
+	// FormatTemplate executes a Go template for each result.
+	FormatTemplate = "template"
+)
+
+// OutputFormatHelp describes the values of a -format flag.
+const OutputFormatHelp = `output format: table, table=COLUMN,COLUMN..., json, yaml, or a Go template like '{{.Cluster}}'`
+
+// ParseOutputFormat parses the value of a -format flag: "table", optionally
+// followed by "=" and a comma-separated list of columns; "json"; "yaml"; or a
+// Go template, which is executed for each result.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	f := OutputFormat{source: s}
+	switch {
+	case s == "":
+	case s == FormatJSON, s == FormatYAML, s == FormatTable:
+		f.Kind = s
+	case strings.HasPrefix(s, FormatTable+"="):
+		f.Kind = FormatTable
+		for _, c := range strings.Split(strings.TrimPrefix(s, FormatTable+"="), ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				f.Columns = append(f.Columns, c)
+			}
+		}
+	case strings.Contains(s, "{{"):
+		t, err := template.New("format").Parse(s)
+		if err != nil {
+			return f, fmt.Errorf("parsing format template: %s", err)
+		}
+		f.Kind = FormatTemplate
+		f.Template = t
+	default:
+		return f, fmt.Errorf("unknown format %q: want table, json, yaml or a Go template", s)
+	}
+	return f, nil
+}
+
+// Set implements flag.Value on OutputFormat.
+func (f *OutputFormat) Set(s string) error {
+	parsed, err := ParseOutputFormat(s)
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// String implements flag.Value on OutputFormat.
+func (f *OutputFormat) String() string {
+	return f.source
+}
+
+// WriteFormatted writes v to this Output in format f. Only values implementing
+// Table can be written as tables. Templates are executed once for each element
+// of v if it is a slice, and once for v otherwise.
+func (o *Output) WriteFormatted(f OutputFormat, v interface{}) error {
+	kind := f.Kind
+	if kind == "" {
+		kind = FormatYAML
+		if _, ok := v.(Table); ok {
+			kind = FormatTable
+		}
+	}
+	switch kind {
+	default:
+		return fmt.Errorf("unknown format %q", kind)
+	case FormatJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		o.Write(append(b, '\n'))
+	case FormatYAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		o.Write(b)
+	case FormatTemplate:
+		return o.writeTemplate(f.Template, v)
+	case FormatTable:
+		t, ok := v.(Table)
+		if !ok {
+			return fmt.Errorf("%T cannot be written as a table", v)
+		}
+		return o.writeTable(t, f.Columns)
+	}
+	return nil
+}
+
+func (o *Output) writeTemplate(t *template.Template, v interface{}) error {
+	if t == nil {
+		return fmt.Errorf("no format template")
+	}
+	items := []interface{}{v}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		items = make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+	}
+	for _, item := range items {
+		buf := &bytes.Buffer{}
+		if err := t.Execute(buf, item); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		o.Write(buf.Bytes())
+	}
+	return nil
+}
+
+// writeTable writes the named columns of t, or all of them if there are no
+// columns named. Column names are matched without regard to case.
+func (o *Output) writeTable(t Table, columns []string) error {
+	headers := t.TableHeaders()
+	indexes := make([]int, len(headers))
+	for i := range headers {
+		indexes[i] = i
+	}
+	if len(columns) != 0 {
+		indexes = indexes[:0]
+		for _, c := range columns {
+			i := columnIndex(headers, c)
+			if i < 0 {
+				return fmt.Errorf("no column %q: want one of %s", c, strings.Join(headers, ", "))
+			}
+			indexes = append(indexes, i)
+		}
+	}
+
+	w := &tabwriter.Writer{}
+	w.Init(o, 2, 4, 2, ' ', 0)
+	writeRow := func(cells []string) {
+		selected := make([]string, len(indexes))
+		for i, c := range indexes {
+			if c < len(cells) {
+				selected[i] = cells[c]
+			}
+		}
+		fmt.Fprintln(w, strings.Join(selected, "\t"))
+	}
+	writeRow(headers)
+	for _, row := range t.TableRows() {
+		writeRow(row)
+	}
+	return w.Flush()
+}
+
+func columnIndex(headers []string, column string) int {
+	for i, h := range headers {
+		if strings.EqualFold(h, column) {
+			return i
+		}
+	}
+	return -1
+}
+
+// SuccessFormatted returns a SuccessResult whose data is v written in format f,
+// or an error result if v cannot be written in that format.
+func SuccessFormatted(f OutputFormat, v interface{}) Result {
+	buf := &bytes.Buffer{}
+	if err := NewOutput(buf).WriteFormatted(f, v); err != nil {
+		return UsageErrorf("unable to write output: %s", err)
+	}
+	return SuccessData(buf.Bytes())
+}
@@ -0,0 +1,86 @@
+package cmdr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type testRecord struct {
+	Name  string `json:"name" yaml:"name"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+type testTable []testRecord
+
+func (tt testTable) TableHeaders() []string { return []string{"Name", "Count"} }
+
+func (tt testTable) TableRows() [][]string {
+	rows := [][]string{}
+	for _, r := range tt {
+		rows = append(rows, []string{r.Name, strings.Repeat("*", r.Count)})
+	}
+	return rows
+}
+
+var testRecords = testTable{{Name: "one", Count: 1}, {Name: "three", Count: 3}}
+
+func formatted(t *testing.T, flag string, v interface{}) string {
+	f := OutputFormat{}
+	if err := f.Set(flag); err != nil {
+		t.Fatalf("-format %q: %s", flag, err)
+	}
+	buf := &bytes.Buffer{}
+	if err := NewOutput(buf).WriteFormatted(f, v); err != nil {
+		t.Fatalf("-format %q: %s", flag, err)
+	}
+	return buf.String()
+}
+
+func TestWriteFormatted(t *testing.T) {
+	tbl := map[string]string{
+		"":                     "Name   Count\none    *\nthree  ***\n",
+		"table":                "Name   Count\none    *\nthree  ***\n",
+		"table=count":          "Count\n*\n***\n",
+		"table=COUNT,name":     "Count  Name\n*      one\n***    three\n",
+		"json":                 "[\n  {\n    \"name\": \"one\",\n    \"count\": 1\n  },\n  {\n    \"name\": \"three\",\n    \"count\": 3\n  }\n]\n",
+		"{{.Name}}={{.Count}}": "one=1\nthree=3\n",
+	}
+	for flag, expected := range tbl {
+		if actual := formatted(t, flag, testRecords); actual != expected {
+			t.Errorf("-format %q: got:\n%s\nwant:\n%s", flag, actual, expected)
+		}
+	}
+
+	if actual := formatted(t, "yaml", testRecords); !strings.Contains(actual, "name: three") {
+		t.Errorf("-format yaml: got:\n%s", actual)
+	}
+	// Values which aren't tables are written as YAML by default.
+	if actual := formatted(t, "", testRecord{Name: "one"}); !strings.Contains(actual, "name: one") {
+		t.Errorf("default format of a non-table: got:\n%s", actual)
+	}
+	if actual := formatted(t, "{{.Name}}", testRecord{Name: "one"}); actual != "one\n" {
+		t.Errorf("template of a single value: got %q", actual)
+	}
+}
+
+func TestWriteFormattedErrors(t *testing.T) {
+	f := OutputFormat{}
+	for _, flag := range []string{"xml", "{{.Name"} {
+		if err := f.Set(flag); err == nil {
+			t.Errorf("-format %q: no error", flag)
+		}
+	}
+
+	for flag, v := range map[string]interface{}{
+		"table=Nope": testRecords,
+		"table":      testRecord{},
+	} {
+		if err := f.Set(flag); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewOutput(&bytes.Buffer{}).WriteFormatted(f, v); err == nil {
+			t.Errorf("-format %q of %T: no error", flag, v)
+		}
+	}
+}
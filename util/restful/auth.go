@@ -0,0 +1,131 @@
+package restful
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// A Principal is the authenticated identity of a client.
+	Principal struct {
+		Name, Email string
+	}
+
+	// An Authenticator identifies the client making a request.
+	Authenticator interface {
+		// Authenticate returns the Principal which made rq. It returns
+		// ErrNoCredentials if rq carries no credentials this Authenticator
+		// recognises, and some other error if they are not valid.
+		Authenticate(rq *http.Request) (Principal, error)
+	}
+
+	// BearerTokens authenticates requests with an "Authorization: Bearer"
+	// header, by mapping static tokens to the Principals they identify.
+	BearerTokens map[string]Principal
+
+	// ClientCerts authenticates requests made over TLS with a verified
+	// client certificate. The certificate's first email address, and its
+	// common name, identify the Principal.
+	ClientCerts struct{}
+
+	// Authenticators tries each of its Authenticators in turn, and uses
+	// the first which recognises the credentials on a request.
+	Authenticators []Authenticator
+
+	// authenticatingHandler rejects requests which its Authenticator cannot
+	// authenticate.
+	authenticatingHandler struct {
+		handler http.Handler
+		auth    Authenticator
+		public  map[string]bool
+		logSet
+	}
+
+	principalKey struct{}
+)
+
+// ErrNoCredentials is returned by Authenticators for requests which carry no
+// credentials they recognise.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authenticate implements Authenticator on BearerTokens.
+func (bt BearerTokens) Authenticate(rq *http.Request) (Principal, error) {
+	header := rq.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return Principal{}, ErrNoCredentials
+	}
+	given := []byte(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+	for token, p := range bt {
+		if subtle.ConstantTimeCompare(given, []byte(token)) == 1 {
+			return p, nil
+		}
+	}
+	return Principal{}, errors.New("unknown bearer token")
+}
+
+// Authenticate implements Authenticator on ClientCerts. It relies on the
+// server's tls.Config to verify client certificates.
+func (ClientCerts) Authenticate(rq *http.Request) (Principal, error) {
+	if rq.TLS == nil || len(rq.TLS.VerifiedChains) == 0 || len(rq.TLS.VerifiedChains[0]) == 0 {
+		return Principal{}, ErrNoCredentials
+	}
+	cert := rq.TLS.VerifiedChains[0][0]
+	p := Principal{Name: cert.Subject.CommonName}
+	if len(cert.EmailAddresses) > 0 {
+		p.Email = cert.EmailAddresses[0]
+	}
+	if p.Name == "" && p.Email == "" {
+		return Principal{}, errors.Errorf("client certificate %s names nobody", cert.SerialNumber)
+	}
+	return p, nil
+}
+
+// Authenticate implements Authenticator on Authenticators.
+func (as Authenticators) Authenticate(rq *http.Request) (Principal, error) {
+	for _, a := range as {
+		p, err := a.Authenticate(rq)
+		if err != ErrNoCredentials {
+			return p, err
+		}
+	}
+	return Principal{}, ErrNoCredentials
+}
+
+// RequireAuthentication wraps h so that only requests authenticated by auth
+// reach it; others are refused with 401 Unauthorized. OPTIONS requests are
+// passed through, so that CORS preflight requests still work. The Principal
+// of each request is available to h from PrincipalFrom. Requests for the
+// public paths, such as health checks, are passed through unauthenticated.
+func RequireAuthentication(h http.Handler, auth Authenticator, ls logSet, public ...string) http.Handler {
+	ah := &authenticatingHandler{handler: h, auth: auth, public: map[string]bool{}, logSet: ls}
+	for _, p := range public {
+		ah.public[p] = true
+	}
+	return ah
+}
+
+func (ah *authenticatingHandler) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	if rq.Method == "OPTIONS" || ah.public[rq.URL.Path] {
+		ah.handler.ServeHTTP(w, rq)
+		return
+	}
+	p, err := ah.auth.Authenticate(rq)
+	if err != nil {
+		ah.Warnf("Refused unauthenticated %s %s from %s: %v", rq.Method, rq.URL, rq.RemoteAddr, err)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="sous"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	ah.handler.ServeHTTP(w, rq.WithContext(context.WithValue(rq.Context(), principalKey{}, p)))
+}
+
+// PrincipalFrom returns the Principal which RequireAuthentication
+// authenticated for rq, and true; or false if rq was not authenticated.
+func PrincipalFrom(rq *http.Request) (Principal, bool) {
+	p, ok := rq.Context().Value(principalKey{}).(Principal)
+	return p, ok
+}
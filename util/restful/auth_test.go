@@ -0,0 +1,58 @@
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAuthentication(t *testing.T) {
+	var seen Principal
+	h := RequireAuthentication(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		seen, _ = PrincipalFrom(rq)
+	}), Authenticators{
+		ClientCerts{},
+		BearerTokens{"s3cret": {Name: "Test User", Email: "test@example.com"}},
+	}, PlaceholderLogger(), "/health")
+
+	serveAt := func(method, path, auth string) int {
+		rq := httptest.NewRequest(method, path, nil)
+		if auth != "" {
+			rq.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, rq)
+		return w.Code
+	}
+	serve := func(method, auth string) int {
+		return serveAt(method, "/manifest", auth)
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", "Bearer wrong"))
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", "Basic s3cret"))
+	assert.Equal(t, http.StatusOK, serve("OPTIONS", ""))
+	assert.Equal(t, http.StatusOK, serveAt("GET", "/health", ""))
+	assert.Equal(t, http.StatusUnauthorized, serveAt("GET", "/healthy", ""))
+
+	assert.Equal(t, http.StatusOK, serve("PUT", "Bearer s3cret"))
+	assert.Equal(t, Principal{Name: "Test User", Email: "test@example.com"}, seen)
+}
+
+func TestLiveHTTPClient_UseBearerToken(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		auth = rq.Header.Get("Authorization")
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	cl, err := NewClient(srv.URL, PlaceholderLogger())
+	assert.NoError(t, err)
+	cl.UseBearerToken("s3cret")
+	_, err = cl.Retrieve("/manifest", nil, &map[string]interface{}{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer s3cret", auth)
+}
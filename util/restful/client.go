@@ -2,6 +2,7 @@ package restful
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -20,6 +21,7 @@ type (
 		serverURL *url.URL
 		http.Client
 		logSet
+		bearerToken string
 	}
 
 	resourceState struct {
@@ -35,11 +37,17 @@ type (
 		Create(urlPath string, qParms map[string]string, rqBody interface{}, headers map[string]string) error
 		Retrieve(urlPath string, qParms map[string]string, rzBody interface{}, headers map[string]string) (Updater, error)
 		Delete(urlPath string, qParms map[string]string, from *resourceState, headers map[string]string) error
+		Post(urlPath string, qParms map[string]string, qBody, rzBody interface{}, headers map[string]string) error
+		Patch(urlPath string, qParms map[string]string, base, changed interface{}, headers map[string]string) error
 	}
 
 	// An Updater captures the state of a retrieved resource so that it can be updated later.
+	// Update replaces the resource, and fails if it has changed since it was
+	// retrieved; Patch sends only the changes, which are merged into the
+	// resource as it is.
 	Updater interface {
 		Update(params map[string]string, body Comparable, headers map[string]string) error
+		Patch(params map[string]string, body Comparable, headers map[string]string) error
 	}
 
 	// DummyHTTPClient doesn't really make HTTP requests.
@@ -69,6 +77,10 @@ func (rs *resourceState) Update(qParms map[string]string, qBody Comparable, head
 	return rs.client.update(rs.path, qParms, rs, qBody, headers)
 }
 
+func (rs *resourceState) Patch(qParms map[string]string, qBody Comparable, headers map[string]string) error {
+	return rs.client.patch(rs.path, qParms, mergePatch(rs.resourceJSON, encodeJSON(qBody)), headers)
+}
+
 func (re retryableError) Error() string {
 	return string(re)
 }
@@ -99,6 +111,20 @@ func NewClient(serverURL string, ls logSet) (*LiveHTTPClient, error) {
 	return client, errors.Wrapf(err, "new Sous REST client")
 }
 
+// UseBearerToken makes the client authenticate every request it sends with
+// token, in an "Authorization: Bearer" header.
+func (client *LiveHTTPClient) UseBearerToken(token string) {
+	client.bearerToken = token
+}
+
+// UseTLSConfig makes the client use tc for HTTPS connections, e.g. to
+// present a client certificate, or to trust a private CA.
+func (client *LiveHTTPClient) UseTLSConfig(tc *tls.Config) {
+	if t, ok := client.Client.Transport.(*http.Transport); ok {
+		t.TLSClientConfig = tc
+	}
+}
+
 // ****
 
 // Retrieve makes a GET request on urlPath, after transforming qParms into ?&=
@@ -130,6 +156,19 @@ func (client *LiveHTTPClient) Create(urlPath string, qParms map[string]string, q
 	}(), "Create %s", urlPath)
 }
 
+// Post sends qBody to the server at urlPath/qParms, and decodes the response
+// into rzBody. Unlike Create and Update, it is not conditional: it is for
+// resources which process what is posted, rather than store it.
+func (client *LiveHTTPClient) Post(urlPath string, qParms map[string]string, qBody, rzBody interface{}, headers map[string]string) error {
+	return errors.Wrapf(func() error {
+		url, err := client.buildURL(urlPath, qParms)
+		rq, err := client.buildRequest("POST", url, headers, nil, qBody, err)
+		rz, err := client.sendRequest(rq, err)
+		_, err = client.getBody(rz, rzBody, err)
+		return err
+	}(), "Post %s", urlPath)
+}
+
 // Delete removes a resource from the server, granted that we know the resource that we're removing.
 // It functions similarly to Update, but issues DELETE requests.
 func (client *LiveHTTPClient) Delete(urlPath string, qParms map[string]string, from *resourceState, headers map[string]string) error {
@@ -155,6 +194,32 @@ func (client *LiveHTTPClient) update(urlPath string, qParms map[string]string, f
 	}(), "Update %s", urlPath)
 }
 
+// Patch sends the changes from base to changed to the server at urlPath/qParms
+// as a JSON merge patch. Fields of the resource which were not changed are
+// left as the server has them, so Patch isn't conditional on the resource
+// being unchanged since base was retrieved, unless headers includes If-Match.
+func (client *LiveHTTPClient) Patch(urlPath string, qParms map[string]string, base, changed interface{}, headers map[string]string) error {
+	return client.patch(urlPath, qParms, mergePatch(encodeJSON(base), encodeJSON(changed)), headers)
+}
+
+func (client *LiveHTTPClient) patch(urlPath string, qParms map[string]string, patch jsonMap, headers map[string]string) error {
+	return errors.Wrapf(func() error {
+		if len(patch) == 0 {
+			return nil
+		}
+		url, err := client.buildURL(urlPath, qParms)
+		rq, err := client.buildRequest("PATCH", url, addMergePatchType(headers), nil, patch, err)
+		rz, err := client.sendRequest(rq, err)
+		_, err = client.getBody(rz, nil, err)
+		return err
+	}(), "Patch %s", urlPath)
+}
+
+// Post implements Post on DummyHTTPClient - it does nothing and returns nil
+func (*DummyHTTPClient) Post(urlPath string, qParms map[string]string, qBody, rzBody interface{}, headers map[string]string) error {
+	return nil
+}
+
 // Create implements HTTPClient on DummyHTTPClient - it does nothing and returns nil
 func (*DummyHTTPClient) Create(urlPath string, qParms map[string]string, rqBody interface{}, headers map[string]string) error {
 	return nil
@@ -170,6 +235,11 @@ func (*DummyHTTPClient) update(urlPath string, qParms map[string]string, from *r
 	return nil
 }
 
+// Patch implements HTTPClient on DummyHTTPClient - it does nothing and returns nil
+func (*DummyHTTPClient) Patch(urlPath string, qParms map[string]string, base, changed interface{}, headers map[string]string) error {
+	return nil
+}
+
 // Delete implements HTTPClient on DummyHTTPClient - it does nothing and returns nil
 func (*DummyHTTPClient) Delete(urlPath string, qParms map[string]string, from *resourceState, headers map[string]string) error {
 	return nil
@@ -185,6 +255,14 @@ func addNoMatchStar(headers map[string]string) map[string]string {
 	return headers
 }
 
+func addMergePatchType(headers map[string]string) map[string]string {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headers["Content-Type"] = MergePatchMediaType
+	return headers
+}
+
 func addIfMatch(headers map[string]string, etag string) map[string]string {
 	if headers == nil {
 		headers = map[string]string{}
@@ -235,6 +313,10 @@ func (client *LiveHTTPClient) buildRequest(method, url string, headers map[strin
 		rq.Header.Add("Sous-User-Email", user.Email)
 	*/
 
+	if err == nil && client.bearerToken != "" {
+		rq.Header.Set("Authorization", "Bearer "+client.bearerToken)
+	}
+
 	if headers != nil {
 		for k, v := range headers {
 			rq.Header.Add(k, v)
@@ -52,6 +52,69 @@ func TestPutbackJSON(t *testing.T) {
 	assert.Equal(t, "w", dig(mapped, "d", "y", 0, "q"))
 }
 
+func TestMergePatch(t *testing.T) {
+	origB := `{
+		"a": 7,
+		"b": "b",
+		"c": [1,2,3],
+		"d": {
+			"z": 1,
+			"y": [{"q":"q", "z": "o"}],
+			"x": "x",
+			"w": "w"
+		}
+	}`
+
+	baseB := bytes.NewBufferString(`{
+		"b": "b",
+		"c": [1,2,3],
+		"d": {
+			"y": [{"q":"q"}],
+			"x": "x",
+			"w": "w"
+		}
+	}`)
+
+	updatedB := bytes.NewBufferString(`{
+		"b": "y",
+		"c": [1,2,3],
+		"d": {
+			"y": [{"q":"w"}],
+			"x": "x",
+			"v": "v"
+		}
+	}`)
+
+	patch := mergePatch(baseB, updatedB)
+	assert.Equal(t, jsonMap{
+		"b": "y",
+		"d": map[string]interface{}{
+			"y": []interface{}{map[string]interface{}{"q": "w"}},
+			"w": nil,
+			"v": "v",
+		},
+	}, patch)
+
+	var orig interface{}
+	assert.NoError(t, json.Unmarshal([]byte(origB), &orig))
+	// The patch passes through JSON on its way to the server.
+	var sent interface{}
+	assert.NoError(t, json.NewDecoder(encodeJSON(patch)).Decode(&sent))
+
+	patched := applyMergePatch(orig, sent)
+	assert.Equal(t, 7.0, dig(patched, "a")) //unknown to the client
+	assert.Equal(t, 1.0, dig(patched, "d", "z"))
+	assert.Equal(t, "y", dig(patched, "b"))
+	assert.Equal(t, "w", dig(patched, "d", "y", 0, "q"))
+	assert.Equal(t, "v", dig(patched, "d", "v"))
+	assert.NotContains(t, patched.(map[string]interface{})["d"], "w")
+}
+
+func TestMergePatchUnchanged(t *testing.T) {
+	doc := `{"a": 1, "b": {"c": [1, 2]}}`
+	assert.Empty(t, mergePatch(bytes.NewBufferString(doc), bytes.NewBufferString(doc)))
+}
+
 func dig(m interface{}, index ...interface{}) interface{} {
 	var res interface{}
 	has := true
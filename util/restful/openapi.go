@@ -0,0 +1,338 @@
+package restful
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type (
+	// A ResponseTyper declares the type of the body of its successful
+	// responses, so that it can be described by OpenAPI. ResponseType
+	// returns a zero value of that type.
+	ResponseTyper interface {
+		ResponseType() interface{}
+	}
+
+	// A RequestTyper declares the type of the body it expects in requests.
+	// Exchangers handling PUT which don't declare one expect the same type as
+	// they respond with.
+	RequestTyper interface {
+		RequestType() interface{}
+	}
+
+	// A QueryParameterizer declares the query parameters it reads.
+	QueryParameterizer interface {
+		QueryParameters() []QueryParameter
+	}
+
+	// A QueryParameter is a query parameter read by an Exchanger.
+	QueryParameter struct {
+		Name, Description string
+		Required          bool
+	}
+
+	// OpenAPI is an OpenAPI 3.0 document describing the API served from a
+	// RouteMap.
+	OpenAPI struct {
+		OpenAPI    string                                  `json:"openapi"`
+		Info       OpenAPIInfo                             `json:"info"`
+		Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+		Components OpenAPIComponents                       `json:"components"`
+	}
+
+	// OpenAPIInfo describes the API as a whole.
+	OpenAPIInfo struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+
+	// OpenAPIComponents holds the schemas which operations refer to.
+	OpenAPIComponents struct {
+		Schemas map[string]*OpenAPISchema `json:"schemas"`
+	}
+
+	// An OpenAPIOperation describes a method on a path.
+	OpenAPIOperation struct {
+		OperationID string                      `json:"operationId"`
+		Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+		RequestBody *OpenAPIBody                `json:"requestBody,omitempty"`
+		Responses   map[string]*OpenAPIResponse `json:"responses"`
+	}
+
+	// An OpenAPIParameter describes a query parameter of an operation.
+	OpenAPIParameter struct {
+		Name        string         `json:"name"`
+		In          string         `json:"in"`
+		Description string         `json:"description,omitempty"`
+		Required    bool           `json:"required,omitempty"`
+		Schema      *OpenAPISchema `json:"schema"`
+	}
+
+	// An OpenAPIBody describes the body of a request.
+	OpenAPIBody struct {
+		Required bool                        `json:"required"`
+		Content  map[string]OpenAPIMediaType `json:"content"`
+	}
+
+	// An OpenAPIResponse describes a response to an operation.
+	OpenAPIResponse struct {
+		Description string                      `json:"description"`
+		Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+	}
+
+	// An OpenAPIMediaType gives the schema of a body.
+	OpenAPIMediaType struct {
+		Schema *OpenAPISchema `json:"schema"`
+	}
+
+	// An OpenAPISchema describes a JSON value. Named struct types are
+	// described once, as components, and referred to by Ref.
+	OpenAPISchema struct {
+		Ref                  string                    `json:"$ref,omitempty"`
+		Type                 string                    `json:"type,omitempty"`
+		Format               string                    `json:"format,omitempty"`
+		Items                *OpenAPISchema            `json:"items,omitempty"`
+		Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+		AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	}
+
+	// schemaBuilder collects the component schemas of an OpenAPI.
+	schemaBuilder struct {
+		schemas map[string]*OpenAPISchema
+		names   map[reflect.Type]string
+	}
+)
+
+const (
+	jsonMediaType = "application/json"
+	// MergePatchMediaType is the media type of JSON merge patches, which
+	// PATCH requests send.
+	MergePatchMediaType = "application/merge-patch+json"
+)
+
+// OpenAPI describes the API served from this RouteMap. Each operation is
+// described by the Exchanger its resource builds, so Exchangers should
+// implement ResponseTyper, and RequestTyper and QueryParameterizer where they
+// apply.
+func (rm *RouteMap) OpenAPI(info OpenAPIInfo) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI:    "3.0.0",
+		Info:       info,
+		Paths:      map[string]map[string]*OpenAPIOperation{},
+		Components: OpenAPIComponents{Schemas: map[string]*OpenAPISchema{}},
+	}
+	sb := &schemaBuilder{schemas: doc.Components.Schemas, names: map[reflect.Type]string{}}
+
+	for _, e := range *rm {
+		ops := map[string]*OpenAPIOperation{}
+		if r, ok := e.Resource.(Getable); ok {
+			ops["get"] = sb.operation("GET", e.Name, r.Get())
+		}
+		if r, ok := e.Resource.(Putable); ok {
+			ops["put"] = sb.operation("PUT", e.Name, r.Put())
+		}
+		if r, ok := e.Resource.(Deleteable); ok {
+			ops["delete"] = sb.operation("DELETE", e.Name, r.Delete())
+		}
+		if r, ok := e.Resource.(Postable); ok {
+			ops["post"] = sb.operation("POST", e.Name, r.Post())
+		}
+		if r, ok := e.Resource.(Patchable); ok {
+			ops["patch"] = sb.operation("PATCH", e.Name, r.Patch())
+		}
+		if len(ops) > 0 {
+			doc.Paths[e.Path] = ops
+		}
+	}
+	return doc
+}
+
+func (sb *schemaBuilder) operation(method, name string, ex Exchanger) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		OperationID: operationID(method, name),
+		Responses:   map[string]*OpenAPIResponse{},
+	}
+
+	if qp, ok := ex.(QueryParameterizer); ok {
+		for _, p := range qp.QueryParameters() {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name:        p.Name,
+				In:          "query",
+				Description: p.Description,
+				Required:    p.Required,
+				Schema:      &OpenAPISchema{Type: "string"},
+			})
+		}
+	}
+
+	var rq, rz interface{}
+	if rt, ok := ex.(ResponseTyper); ok {
+		rz = rt.ResponseType()
+	}
+	if rt, ok := ex.(RequestTyper); ok {
+		rq = rt.RequestType()
+	} else if method == "PUT" || method == "PATCH" {
+		rq = rz
+	}
+	if rq != nil {
+		op.RequestBody = &OpenAPIBody{Required: true, Content: sb.content(rq)}
+		if method == "PATCH" {
+			op.RequestBody.Content = map[string]OpenAPIMediaType{MergePatchMediaType: op.RequestBody.Content[jsonMediaType]}
+		}
+	}
+
+	switch {
+	case rz != nil:
+		op.Responses["200"] = &OpenAPIResponse{Description: http.StatusText(http.StatusOK), Content: sb.content(rz)}
+	case method == "GET":
+		op.Responses["200"] = &OpenAPIResponse{Description: http.StatusText(http.StatusOK)}
+	default:
+		op.Responses["204"] = &OpenAPIResponse{Description: http.StatusText(http.StatusNoContent)}
+	}
+	return op
+}
+
+// operationID returns an ID like "getRegistryEvents" for GET of the route
+// named "registry-events".
+func operationID(method, name string) string {
+	id := strings.ToLower(method)
+	for _, w := range strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' }) {
+		id += strings.ToUpper(w[:1]) + w[1:]
+	}
+	return id
+}
+
+func (sb *schemaBuilder) content(v interface{}) map[string]OpenAPIMediaType {
+	return map[string]OpenAPIMediaType{jsonMediaType: {Schema: sb.schema(reflect.TypeOf(v))}}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
+
+// schema describes how encoding/json encodes values of type t.
+func (sb *schemaBuilder) schema(t reflect.Type) *OpenAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case implements(t, jsonMarshalerType):
+		// There's no knowing what a Marshaler writes, but Stringers
+		// usually write their strings.
+		if implements(t, stringerType) {
+			return &OpenAPISchema{Type: "string"}
+		}
+		return &OpenAPISchema{}
+	case implements(t, textMarshalerType):
+		return &OpenAPISchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	default:
+		// Interfaces may hold anything.
+		return &OpenAPISchema{}
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: sb.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: sb.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return sb.object(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + sb.component(t)}
+	}
+}
+
+// component returns the name of the component describing the named struct
+// type t, describing it first if need be.
+func (sb *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := sb.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := sb.schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	sb.names[t] = name
+	// Reserve the name before describing the fields, which may refer back
+	// to t.
+	sb.schemas[name] = &OpenAPISchema{}
+	*sb.schemas[name] = *sb.object(t)
+	return name
+}
+
+func (sb *schemaBuilder) object(t reflect.Type) *OpenAPISchema {
+	s := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	sb.addFields(s, t)
+	return s
+}
+
+// addFields adds the fields of struct type t to s as encoding/json would,
+// with the fields of embedded structs promoted, unless t has fields of the
+// same names.
+func (sb *schemaBuilder) addFields(s *OpenAPISchema, t reflect.Type) {
+	embedded := []reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct &&
+			!implements(ft, jsonMarshalerType) && !implements(ft, textMarshalerType) {
+			embedded = append(embedded, ft)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = sb.schema(f.Type)
+	}
+	for _, et := range embedded {
+		promoted := &OpenAPISchema{Properties: map[string]*OpenAPISchema{}}
+		sb.addFields(promoted, et)
+		for name, ps := range promoted.Properties {
+			if _, ok := s.Properties[name]; !ok {
+				s.Properties[name] = ps
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package restful
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	testThingResource struct{}
+	testThingGetter   struct{}
+	testThingPutter   struct{}
+	testThingDeleter  struct{}
+
+	testThing struct {
+		testEmbedded
+		Name    string
+		Size    int    `json:"size,omitempty"`
+		Secret  string `json:"-"`
+		When    time.Time
+		Tags    []string
+		Labels  map[string]string
+		Parts   []*testThing
+		Blob    []byte
+		Private string `json:"private"`
+		hidden  string
+	}
+
+	testEmbedded struct {
+		Kind    string
+		Private bool
+	}
+)
+
+func (*testThingResource) Get() Exchanger    { return &testThingGetter{} }
+func (*testThingResource) Put() Exchanger    { return &testThingPutter{} }
+func (*testThingResource) Delete() Exchanger { return &testThingDeleter{} }
+
+func (*testThingGetter) Exchange() (interface{}, int)  { return testThing{}, 200 }
+func (*testThingPutter) Exchange() (interface{}, int)  { return testThing{}, 200 }
+func (*testThingDeleter) Exchange() (interface{}, int) { return nil, 204 }
+
+func (*testThingGetter) ResponseType() interface{} { return testThing{} }
+func (*testThingPutter) ResponseType() interface{} { return &testThing{} }
+
+func (*testThingGetter) QueryParameters() []QueryParameter {
+	return []QueryParameter{{Name: "name", Description: "the thing's name", Required: true}}
+}
+
+func TestRouteMap_OpenAPI(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rm := RouteMap{
+		{"thing", "/thing", &testThingResource{}},
+		{"other-thing", "/other", &testThingResource{}},
+	}
+	doc := rm.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.2.3"})
+
+	assert.Equal("3.0.0", doc.OpenAPI)
+	assert.Equal("Test", doc.Info.Title)
+	require.Contains(doc.Paths, "/thing")
+	ops := doc.Paths["/thing"]
+	assert.Len(ops, 3)
+
+	get := ops["get"]
+	assert.Equal("getThing", get.OperationID)
+	assert.Equal("getOtherThing", doc.Paths["/other"]["get"].OperationID)
+	assert.Equal([]OpenAPIParameter{{
+		Name: "name", In: "query", Description: "the thing's name", Required: true,
+		Schema: &OpenAPISchema{Type: "string"},
+	}}, get.Parameters)
+	assert.Nil(get.RequestBody)
+	ref := &OpenAPISchema{Ref: "#/components/schemas/testThing"}
+	assert.Equal(ref, get.Responses["200"].Content["application/json"].Schema)
+
+	put := ops["put"]
+	require.NotNil(put.RequestBody)
+	assert.Equal(ref, put.RequestBody.Content["application/json"].Schema)
+
+	del := ops["delete"]
+	assert.Nil(del.RequestBody)
+	assert.Contains(del.Responses, "204")
+
+	thing := doc.Components.Schemas["testThing"]
+	require.NotNil(thing)
+	assert.Equal("object", thing.Type)
+	assert.Equal(map[string]*OpenAPISchema{
+		"Kind":    {Type: "string"},
+		"Name":    {Type: "string"},
+		"size":    {Type: "integer", Format: "int64"},
+		"When":    {Type: "string", Format: "date-time"},
+		"Tags":    {Type: "array", Items: &OpenAPISchema{Type: "string"}},
+		"Labels":  {Type: "object", AdditionalProperties: &OpenAPISchema{Type: "string"}},
+		"Parts":   {Type: "array", Items: ref},
+		"Blob":    {Type: "string", Format: "byte"},
+		"private": {Type: "string"},
+		// The embedded Private is promoted, since the outer one is named
+		// "private".
+		"Private": {Type: "boolean"},
+	}, thing.Properties)
+
+	_, err := json.Marshal(doc)
+	assert.NoError(err)
+}
@@ -68,6 +68,70 @@ func applyChanges(base, changed, target map[string]interface{}) map[string]inter
 	return target
 }
 
+// mergePatch returns a JSON merge patch (RFC 7386) which transforms base into
+// changed.
+func mergePatch(baseBuf, changedBuf io.Reader) jsonMap {
+	var base, changed jsonMap
+	if err := mapDecode(baseBuf, &base); err != nil {
+		panic(err)
+	}
+	if err := mapDecode(changedBuf, &changed); err != nil {
+		panic(err)
+	}
+	return diffChanges(base, changed)
+}
+
+// diffChanges returns the merge patch which transforms base into changed:
+// created and changed fields are set, fields changed in nested objects are
+// patched in turn, and deleted fields are set to null. Arrays can't be patched,
+// so a changed array is replaced entirely.
+func diffChanges(base, changed map[string]interface{}) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for k, v := range changed {
+		b, old := base[k]
+		switch {
+		case !old:
+			patch[k] = v //created
+		case same(b, v):
+		default:
+			bsub, bIsMap := b.(map[string]interface{})
+			vsub, vIsMap := v.(map[string]interface{})
+			if bIsMap && vIsMap {
+				patch[k] = diffChanges(bsub, vsub)
+			} else {
+				patch[k] = v //changed
+			}
+		}
+	}
+	for k := range base {
+		if _, has := changed[k]; !has {
+			patch[k] = nil //deleted
+		}
+	}
+	return patch
+}
+
+// applyMergePatch applies a JSON merge patch (RFC 7386) to target, and returns
+// the result. Objects in target are mutated.
+func applyMergePatch(target, patch interface{}) interface{} {
+	p, isMap := patch.(map[string]interface{})
+	if !isMap {
+		return patch
+	}
+	t, isMap := target.(map[string]interface{})
+	if !isMap {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = applyMergePatch(t[k], v)
+	}
+	return t
+}
+
 // same does a kind of limited deep equality over loosly typed values (e.g. map[string]interface{})
 func same(left, right interface{}) bool {
 	switch left := left.(type) {
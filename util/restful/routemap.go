@@ -51,11 +51,22 @@ type (
 	Optionsable interface {
 		Options() Exchanger
 	}
+
+	// Postable tags ResourceFamilies that respond to POST
+	Postable interface {
+		Post() Exchanger
+	}
+
+	// Patchable tags ResourceFamilies that respond to PATCH with a JSON merge
+	// patch (RFC 7386). The patch is applied to the resource as it would be
+	// returned by GET, and the Exchanger receives the patched resource as its
+	// request body, just as a PUT Exchanger would - so the Putable's Exchanger
+	// will often do.
+	Patchable interface {
+		Patch() Exchanger
+	}
 	/*
-		Postable interface {
-			Post() Exchanger
-		}
-		// also consider Headable or Patchable
+		// also consider Headable or SpecialPatch
 		// which maybe should be named "SpecializedHead" or something
 		// Note that Patchable and SpecialPatch should be separate
 		// The former means "there is data format that reasonably represents
@@ -93,6 +104,8 @@ func (rm *RouteMap) BuildRouter(grf func() Injector, ls logSet) http.Handler {
 		put, canPut := e.Resource.(Putable)
 		del, canDel := e.Resource.(Deleteable)
 		opt, canOpt := e.Resource.(Optionsable)
+		post, canPost := e.Resource.(Postable)
+		patch, canPatch := e.Resource.(Patchable)
 
 		if canGet {
 			r.Handle("GET", e.Path, mh.GetHandling(get.Get))
@@ -104,6 +117,12 @@ func (rm *RouteMap) BuildRouter(grf func() Injector, ls logSet) http.Handler {
 		if canDel {
 			r.Handle("DELETE", e.Path, mh.DeleteHandling(del.Delete))
 		}
+		if canPost {
+			r.Handle("POST", e.Path, mh.PostHandling(post.Post))
+		}
+		if canPatch {
+			r.Handle("PATCH", e.Path, mh.PatchHandling(patch.Patch))
+		}
 		if canOpt {
 			r.Handle("OPTIONS", e.Path, mh.OptionsHandling(opt.Options))
 		} else {
@@ -126,6 +145,12 @@ func defaultOptions(res Resource) func() Exchanger {
 	if _, can := res.(Deleteable); can {
 		ex.methods = append(ex.methods, "DELETE")
 	}
+	if _, can := res.(Postable); can {
+		ex.methods = append(ex.methods, "POST")
+	}
+	if _, can := res.(Patchable); can {
+		ex.methods = append(ex.methods, "PATCH")
+	}
 
 	return func() Exchanger {
 		return ex
@@ -12,6 +12,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -23,6 +24,23 @@ type (
 		graphFac      func() Injector //XXX This is a workaround for a bug in psyringe.Clone()
 		statusHandler *StatusMiddleware
 		logSet
+		// writes serializes the writes to each resource, so that the version
+		// of a resource an etag is checked against is the version the write
+		// replaces.
+		writes resourceLocks
+	}
+
+	// resourceLocks holds a lock for each resource, named by its path and
+	// query, while it is in use.
+	resourceLocks struct {
+		sync.Mutex
+		locks map[string]*resourceLock
+	}
+
+	resourceLock struct {
+		sync.Mutex
+		// users counts the requests holding or waiting for the lock.
+		users int
 	}
 
 	// ResponseWriter wraps the the http.ResponseWriter interface.
@@ -68,6 +86,8 @@ func (mh *MetaHandler) GetHandling(factory ExchangeFactory) httprouter.Handle {
 // DeleteHandling handles Delete requests.
 func (mh *MetaHandler) DeleteHandling(factory ExchangeFactory) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		defer mh.writes.lock(r)()
+
 		h := mh.injectedHandler(factory, w, r, p)
 		_, status := h.Exchange()
 		mh.renderData(status, w, r, nil)
@@ -106,6 +126,8 @@ func (mh *MetaHandler) PutHandling(factory ExchangeFactory) httprouter.Handle {
 			return
 		}
 
+		defer mh.writes.lock(r)()
+
 		gr := copyRequest(r)
 		gr.Method = "GET"
 		grez := mh.synthResponse(gr)
@@ -135,6 +157,61 @@ func (mh *MetaHandler) PutHandling(factory ExchangeFactory) httprouter.Handle {
 	}
 }
 
+// PostHandling handles POST requests.
+func (mh *MetaHandler) PostHandling(factory ExchangeFactory) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		h := mh.injectedHandler(factory, w, r, p)
+		data, status := h.Exchange()
+		mh.renderData(status, w, r, data)
+	}
+}
+
+// PatchHandling handles PATCH requests, whose bodies are JSON merge patches.
+// Unlike PUT, PATCH is not required to be conditional, since a patch only
+// changes the fields it names; but If-Match is honored if it's provided.
+// The patch is applied to the version of the resource whose etag was
+// checked: no other write to the resource is handled until it has been
+// written.
+func (mh *MetaHandler) PatchHandling(factory ExchangeFactory) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		defer mh.writes.lock(r)()
+
+		gr := copyRequest(r)
+		gr.Method = "GET"
+		grez := mh.synthResponse(gr)
+		if grez.StatusCode != http.StatusOK {
+			mh.writeHeaders(grez.StatusCode, w, r, "cannot patch a resource which cannot be retrieved")
+			return
+		}
+
+		grezEtag := grez.Header.Get("Etag")
+		if etag := r.Header.Get("If-Match"); etag != "" && etag != grezEtag {
+			mh.writeHeaders(http.StatusPreconditionFailed, w, r,
+				fmt.Sprintf("Etag mismatch: provided %q != existing %q", etag, grezEtag))
+			return
+		}
+
+		var current, patch interface{}
+		if err := json.NewDecoder(grez.Body).Decode(&current); err != nil {
+			mh.writeHeaders(http.StatusInternalServerError, w, r, fmt.Sprintf("Error parsing existing resource: %v", err))
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			mh.writeHeaders(http.StatusBadRequest, w, r, fmt.Sprintf("Error parsing JSON merge patch: %v", err))
+			return
+		}
+		if m, isMap := current.(map[string]interface{}); isMap {
+			delete(m, grezEtag) // the canary attribute
+		}
+
+		r.Body = ioutil.NopCloser(encodeJSON(applyMergePatch(current, patch)))
+		r.Header.Set("If-Match", grezEtag)
+		h := mh.injectedHandler(factory, w, r, p)
+		data, status := h.Exchange()
+		mh.renderData(status, w, r, data)
+	}
+}
+
 // InstallPanicHandler installs an panic handler into the router.
 func (mh *MetaHandler) InstallPanicHandler() {
 	g := mh.graphFac()
@@ -189,6 +266,36 @@ func (mh *MetaHandler) renderData(status int, w http.ResponseWriter, r *http.Req
 	io.Copy(w, InjectCanaryAttr(buf, etag))
 }
 
+// lock locks the resource r is for, and returns the func which unlocks it.
+// Requests with the same path and query parameters, in any order, are for
+// the same resource.
+func (rl *resourceLocks) lock(r *http.Request) func() {
+	key := r.URL.Path + "?" + r.URL.Query().Encode()
+
+	rl.Lock()
+	if rl.locks == nil {
+		rl.locks = map[string]*resourceLock{}
+	}
+	l, ok := rl.locks[key]
+	if !ok {
+		l = &resourceLock{}
+		rl.locks[key] = l
+	}
+	l.users++
+	rl.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		rl.Lock()
+		defer rl.Unlock()
+		l.users--
+		if l.users == 0 {
+			delete(rl.locks, key)
+		}
+	}
+}
+
 func emptyBody() io.ReadCloser {
 	return ioutil.NopCloser(&bytes.Buffer{})
 }
@@ -0,0 +1,44 @@
+package restful
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceLocks(t *testing.T) {
+	rl := &resourceLocks{}
+	lock := func(url string) func() {
+		return rl.lock(httptest.NewRequest("PUT", url, nil))
+	}
+
+	unlock := lock("/fields?a=1&b=2")
+
+	// Other resources are not held up.
+	lock("/fields?a=1")()
+	lock("/other?a=1&b=2")()
+
+	locked := make(chan struct{})
+	go func() {
+		lock("/fields?b=2&a=1")()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("same resource locked twice at once")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("resource not unlocked")
+	}
+
+	rl.Lock()
+	defer rl.Unlock()
+	assert.Empty(t, rl.locks, "unused locks should be dropped")
+}
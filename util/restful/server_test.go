@@ -3,10 +3,12 @@ package restful
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"github.com/julienschmidt/httprouter"
@@ -38,10 +40,25 @@ type (
 	TestData struct {
 		Data, Name, Extra string
 	}
+
+	TestFieldsResource struct {
+		sync.Mutex
+		Fields map[string]string
+	}
+
+	TestFieldsGetExchanger struct {
+		*TestFieldsResource
+	}
+
+	TestFieldsPatchExchanger struct {
+		*TestFieldsResource
+		*http.Request
+	}
 )
 
-func (tr *TestResource) Get() Exchanger { return &TestGetExchanger{TestResource: tr} }
-func (tr *TestResource) Put() Exchanger { return &TestPutExchanger{TestResource: tr} }
+func (tr *TestResource) Get() Exchanger   { return &TestGetExchanger{TestResource: tr} }
+func (tr *TestResource) Put() Exchanger   { return &TestPutExchanger{TestResource: tr} }
+func (tr *TestResource) Patch() Exchanger { return &TestPutExchanger{TestResource: tr} }
 
 func (ge *TestGetExchanger) Exchange() (interface{}, int) {
 	p := ge.Params.ByName("param")
@@ -66,9 +83,36 @@ func (ge *TestPutExchanger) Exchange() (interface{}, int) {
 	}, 200
 }
 
+func (tr *TestFieldsResource) Get() Exchanger { return &TestFieldsGetExchanger{TestFieldsResource: tr} }
+func (tr *TestFieldsResource) Patch() Exchanger {
+	return &TestFieldsPatchExchanger{TestFieldsResource: tr}
+}
+
+func (ge *TestFieldsGetExchanger) Exchange() (interface{}, int) {
+	ge.Lock()
+	defer ge.Unlock()
+	fields := map[string]string{}
+	for k, v := range ge.Fields {
+		fields[k] = v
+	}
+	return fields, 200
+}
+
+func (pe *TestFieldsPatchExchanger) Exchange() (interface{}, int) {
+	fields := map[string]string{}
+	if err := json.NewDecoder(pe.Request.Body).Decode(&fields); err != nil {
+		return err, http.StatusBadRequest
+	}
+	pe.Lock()
+	defer pe.Unlock()
+	pe.Fields = fields
+	return fields, 200
+}
+
 func testRouteMap() *RouteMap {
 	return &RouteMap{
 		{"test", "/test/:param", &TestResource{"base"}},
+		{"fields", "/fields", &TestFieldsResource{Fields: map[string]string{"base": "set"}}},
 	}
 }
 
@@ -145,6 +189,7 @@ func (t *PutConditionalsSuite) TestOptionsAllowCORS() {
 	t.Regexp("GET", methods)
 	t.Regexp("HEAD", methods)
 	t.Regexp("PUT", methods)
+	t.Regexp("PATCH", methods)
 	t.Regexp("OPTIONS", methods)
 }
 
@@ -249,6 +294,105 @@ func (t *PutConditionalsSuite) TestPutConditionalsMatchedRejected() {
 	t.Equal(res.Status, "412 Precondition Failed")
 }
 
+func (t *PutConditionalsSuite) TestPatch() {
+	req := t.testReq("PATCH", "/test/one?extra=two", map[string]interface{}{"Data": "patched"})
+	res, err := t.client.Do(req)
+	t.NoError(err)
+	t.Equal("200 OK", res.Status)
+
+	var td TestData
+	t.NoError(json.NewDecoder(res.Body).Decode(&td))
+	res.Body.Close()
+	t.Equal(TestData{"patched", "one", "two"}, td)
+}
+
+func (t *PutConditionalsSuite) TestPatchMatched() {
+	res, err := http.Get(t.server.URL + "/test/one?extra=two")
+	t.NoError(err)
+	res.Body.Close()
+	etag := res.Header.Get("Etag")
+
+	req := t.testReq("PATCH", "/test/one?extra=two", map[string]interface{}{"Data": "patched"})
+	req.Header.Add("If-Match", etag)
+	res, err = t.client.Do(req)
+	t.NoError(err)
+	t.Equal("200 OK", res.Status)
+}
+
+func (t *PutConditionalsSuite) TestPatchMatchedRejected() {
+	req := t.testReq("PATCH", "/test/one?extra=two", map[string]interface{}{"Data": "patched"})
+	req.Header.Add("If-Match", "blarglearglebarg")
+	res, err := t.client.Do(req)
+	t.NoError(err)
+	t.Equal("412 Precondition Failed", res.Status)
+}
+
+func (t *PutConditionalsSuite) TestPatchMissing() {
+	req := t.testReq("PATCH", "/test/missing", map[string]interface{}{"Data": "patched"})
+	res, err := t.client.Do(req)
+	t.NoError(err)
+	t.Equal(404, res.StatusCode)
+}
+
+func (t *PutConditionalsSuite) TestConcurrentPatches() {
+	const patches = 20
+	wg := sync.WaitGroup{}
+	wg.Add(patches)
+	for i := 0; i < patches; i++ {
+		go func(i int) {
+			defer wg.Done()
+			req := t.testReq("PATCH", "/fields", map[string]string{fmt.Sprintf("field%d", i): "set"})
+			res, err := t.client.Do(req)
+			if t.NoError(err) {
+				res.Body.Close()
+				t.Equal(200, res.StatusCode)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	res, err := http.Get(t.server.URL + "/fields")
+	t.NoError(err)
+	fields := map[string]interface{}{}
+	t.NoError(json.NewDecoder(res.Body).Decode(&fields))
+	res.Body.Close()
+	delete(fields, res.Header.Get("Etag"))
+	t.Len(fields, patches+1, "every concurrent patch should have been applied")
+}
+
+func (t *PutConditionalsSuite) TestConcurrentConditionalPatches() {
+	res, err := http.Get(t.server.URL + "/fields")
+	t.NoError(err)
+	res.Body.Close()
+	etag := res.Header.Get("Etag")
+
+	const patches = 20
+	statuses := make(chan int, patches)
+	wg := sync.WaitGroup{}
+	wg.Add(patches)
+	for i := 0; i < patches; i++ {
+		go func(i int) {
+			defer wg.Done()
+			req := t.testReq("PATCH", "/fields", map[string]string{fmt.Sprintf("field%d", i): "set"})
+			req.Header.Add("If-Match", etag)
+			res, err := t.client.Do(req)
+			if t.NoError(err) {
+				res.Body.Close()
+				statuses <- res.StatusCode
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	t.Equal(map[int]int{200: 1, 412: patches - 1}, counts,
+		"exactly one patch should apply to the version it was conditioned on")
+}
+
 func TestPutConditionals(t *testing.T) {
 	suite.Run(t, new(PutConditionalsSuite))
 }